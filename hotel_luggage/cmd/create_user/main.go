@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 
+	"hotel_luggage/configs"
 	"hotel_luggage/internal/repositories"
 	"hotel_luggage/internal/services"
 )
@@ -17,20 +19,25 @@ func main() {
 	username := flag.String("u", "", "用户名")
 	password := flag.String("p", "", "密码（明文）")
 	hotelID := flag.Int64("h", 0, "酒店ID（必填）")
+	configPath := flag.String("config", "", "配置文件路径（可选）")
 	flag.Parse()
 
 	if *username == "" || *password == "" {
 		log.Fatal("参数缺失：必须提供 -u 和 -p")
 	}
 
-	// 初始化数据库连接
-	repositories.InitDB()
+	// 加载配置并初始化数据库连接
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	repositories.InitDB(cfg.DB)
 
 	// 创建用户（自动生成 bcrypt 哈希）
 	if *hotelID <= 0 {
 		log.Fatal("参数缺失：必须提供 -h 酒店ID")
 	}
-	user, err := services.CreateUser(*username, *password, "", hotelID)
+	user, err := services.CreateUser(context.Background(), *username, *password, "", hotelID)
 	if err != nil {
 		log.Fatalf("创建用户失败: %v", err)
 	}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/services"
+	"hotel_luggage/internal/storage"
+)
+
+// 命令行工具：清理未被寄存单 / 取件历史 / 拾获物品 / 事故报告引用的照片（与后台清理任务规则相同）
+// 用法示例：
+// go run ./cmd/gc_uploads -dry-run        # 只列出会被删除的照片
+// go run ./cmd/gc_uploads                 # 删除超过 upload.gc_grace_period 仍未被引用的照片
+// go run ./cmd/gc_uploads -grace 72h      # 指定宽限期
+func main() {
+	configPath := flag.String("config", "", "配置文件路径（可选）")
+	dryRun := flag.Bool("dry-run", false, "只列出未被引用的照片，不删除")
+	grace := flag.Duration("grace", 0, "宽限期（默认使用配置 upload.gc_grace_period）")
+	flag.Parse()
+
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if *grace <= 0 {
+		*grace = cfg.Upload.GCGracePeriod.Std()
+	}
+
+	repositories.InitDB(cfg.DB)
+	repositories.InitMinIO(cfg.MinIO)
+	if !*dryRun && repositories.MinIO() == nil {
+		log.Fatal("MinIO 不可用，无法清理（可使用 -dry-run 查看报告）")
+	}
+	store := storage.New(cfg, repositories.MinIO)
+
+	report, err := services.CollectOrphanUploads(context.Background(), store, *grace, *dryRun)
+	for _, orphan := range report.Orphans {
+		state := "orphan"
+		switch {
+		case orphan.Deleted:
+			state = "deleted"
+		case orphan.Error != "":
+			state = "failed: " + orphan.Error
+		}
+		fmt.Printf("%s\t%d\t%s\t%s\n", orphan.Key, orphan.Size, orphan.CreatedAt.Format("2006-01-02 15:04:05"), state)
+	}
+	if err != nil {
+		log.Fatalf("清理失败: %v（已删除 %d 个）", err, report.Deleted)
+	}
+	if *dryRun {
+		fmt.Printf("检查 %d 个上传，仍被引用 %d 个，可删除 %d 个（%d 字节，dry-run，未删除）\n",
+			report.Scanned, report.Referenced, len(report.Orphans), report.FreedBytes)
+		return
+	}
+	fmt.Printf("检查 %d 个上传，仍被引用 %d 个，已删除 %d 个（%d 字节），失败 %d 个（可重新运行重试）\n",
+		report.Scanned, report.Referenced, report.Deleted, report.FreedBytes, report.Failed)
+}
@@ -1,30 +1,123 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
 
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/handlers"
+	"hotel_luggage/internal/notify"
 	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/services"
+	"hotel_luggage/internal/storage"
 	"hotel_luggage/router"
+	"hotel_luggage/utils"
+
+	"github.com/gin-gonic/gin"
 )
 
 // main 是程序入口：
-// 1. 先初始化数据库连接（GORM）
-// 2. 再初始化路由
-// 3. 启动 HTTP 服务
+// 1. 先加载并校验配置（配置文件 + 环境变量）
+// 2. 初始化数据库连接（GORM）、Redis、MinIO
+// 3. 再初始化路由
+// 4. 启动 HTTP 服务
+// 5. 收到 SIGTERM/SIGINT 后优雅退出：等待进行中的请求完成，再依次关闭 MinIO、Redis、数据库
 func main() {
+	configPath := flag.String("config", "", "配置文件路径（.yaml/.yml/.toml），也可通过环境变量 CONFIG_FILE 指定")
+	flag.Parse()
+
+	// 加载配置（校验失败直接退出，生产模式下禁止使用默认密钥）
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatalf("load config failed: %v", err)
+	}
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// 初始化 JWT 签名密钥
+	utils.InitJWT(cfg.JWT.Secret, cfg.JWT.Expire.Std())
 	// 初始化数据库连接（失败会直接退出）
-	repositories.InitDB()
+	repositories.InitDB(cfg.DB)
 	// 初始化 Redis（失败则自动降级）
-	repositories.InitRedis()
+	repositories.InitRedis(cfg.Redis)
 	// 初始化 MinIO（失败则自动降级到本地存储）
-	repositories.InitMinIO()
+	repositories.InitMinIO(cfg.MinIO)
+	// 启动依赖监管：Redis / MinIO 降级后在后台按退避策略重连，恢复后自动切换
+	supervisorCtx, stopSupervisor := context.WithCancel(context.Background())
+	waitSupervisor := repositories.StartSupervisor(supervisorCtx, cfg.Recovery)
+
+	// 初始化文件存储（MinIO 为主，不可用时降级到本地目录）
+	store := storage.New(cfg, repositories.MinIO)
+	// 照片字段保存对象 key，响应中换成有效期为 upload.url_expiry 的签名地址，并附带缩略图地址
+	services.InitPhotoStore(store, cfg.Upload.URLExpiry.Std(), cfg.Upload.Image.ListThumbnail())
+	// 取件验证码通过 notify.webhook_url 发送（开发环境未配置时写日志）
+	services.InitCheckoutVerification(notify.New(cfg), cfg.Checkout.OTPTTL.Std(), cfg.Checkout.OTPResendInterval.Std(), cfg.Checkout.OTPMaxAttempts)
+	// 代取码未指定过期时间时的默认有效期
+	services.InitPickupDelegates(cfg.Checkout.DelegateCodeTTL.Std())
+	// 客人自助登记代取人、凭代取码取件的失败次数限制
+	services.InitAttemptLimits(cfg.Checkout.MaxFailedAttempts, cfg.Checkout.MaxFailedAttemptsPerIP, cfg.Checkout.LockoutWindow.Std())
+	// 后台把降级写入本地的上传同步到 MinIO（与依赖监管一起停止）
+	waitReplicator := services.StartUploadReplicator(supervisorCtx, store, cfg.Upload.SyncInterval.Std())
+	// 后台删除超过宽限期仍未被寄存单引用的照片（upload.gc_interval 为 0 时不启动）
+	waitUploadGC := func() {}
+	if cfg.Upload.GCInterval > 0 {
+		waitUploadGC = services.StartUploadGC(supervisorCtx, store, cfg.Upload.GCInterval.Std(), cfg.Upload.GCGracePeriod.Std())
+	}
 
 	// 初始化 Gin 路由
-	r := router.SetupRouter()
+	r := router.SetupRouter(cfg, store)
 
-	// 启动服务，监听所有网络接口的 8080 端口
+	// 启动服务，默认监听所有网络接口的 8080 端口（server.addr / SERVER_ADDR）
 	// Docker 容器中使用 0.0.0.0 或 :8080 来监听所有接口
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("server failed: %v", err)
+	srv := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: r,
 	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("服务启动，监听 %s", cfg.Server.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Fatalf("server failed: %v", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+	stop()
+
+	// 先让 readyz 返回 503，负载均衡不再转发新请求；再等待进行中的请求（如取件）处理完
+	log.Println("收到退出信号，开始优雅退出...")
+	handlers.MarkShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  等待请求完成超时，强制关闭: %v", err)
+	}
+
+	// 先停止依赖监管（避免关闭后又被重连），再按初始化的逆序关闭依赖：MinIO → Redis → 数据库
+	stopSupervisor()
+	waitSupervisor()
+	waitReplicator()
+	waitUploadGC()
+	repositories.CloseMinIO()
+	repositories.CloseRedis()
+	repositories.CloseDB()
+	log.Println("服务已退出")
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/apidoc"
+	"hotel_luggage/internal/storage"
+	"hotel_luggage/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 命令行工具：生成 OpenAPI 文档 / 检查路由与文档是否一致
+// 用法示例：
+// go run ./cmd/openapi -check              # 路由与 RouteDocs 不一致时退出码为 1（CI 使用）
+// go run ./cmd/openapi -out openapi.json   # 输出 OpenAPI 文档（可用 openapi-generator 生成前端客户端）
+func main() {
+	check := flag.Bool("check", false, "检查实际路由与文档是否一致")
+	out := flag.String("out", "", "OpenAPI 文档输出路径（- 表示标准输出）")
+	flag.Parse()
+
+	// 只构建路由，不连接数据库/Redis/MinIO
+	gin.SetMode(gin.ReleaseMode)
+	r := router.SetupRouter(configs.Default(), storage.NewMemory(""))
+
+	if *check {
+		problems := apidoc.CheckDrift(r.Routes(), router.RouteDocs)
+		if len(problems) > 0 {
+			for _, p := range problems {
+				fmt.Fprintln(os.Stderr, p)
+			}
+			log.Fatalf("路由与接口文档不一致（共 %d 处），请同步更新 router/docs.go", len(problems))
+		}
+		fmt.Println("路由与接口文档一致")
+	}
+
+	if *out != "" {
+		spec := apidoc.Build(apidoc.Info{Title: "Hotel Luggage API", Version: "1.0.0"}, router.RouteDocs)
+		data, err := json.MarshalIndent(spec, "", "  ")
+		if err != nil {
+			log.Fatalf("生成文档失败: %v", err)
+		}
+		if *out == "-" {
+			fmt.Println(string(data))
+			return
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatalf("写入文档失败: %v", err)
+		}
+		fmt.Printf("已生成：%s\n", *out)
+	}
+}
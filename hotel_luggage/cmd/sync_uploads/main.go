@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/services"
+	"hotel_luggage/internal/storage"
+)
+
+// 命令行工具：把本地 uploads 目录中的文件一次性迁移到 MinIO，并改写行李记录中的照片地址
+// 用法示例：
+// go run ./cmd/sync_uploads                    # 迁移 upload.local_dir 下的所有文件
+// go run ./cmd/sync_uploads -dir ./old_uploads # 指定目录
+// go run ./cmd/sync_uploads -dry-run           # 只统计，不上传
+func main() {
+	configPath := flag.String("config", "", "配置文件路径（可选）")
+	dir := flag.String("dir", "", "本地上传目录（默认使用配置 upload.local_dir）")
+	dryRun := flag.Bool("dry-run", false, "只列出待迁移的文件，不上传")
+	flag.Parse()
+
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if *dir == "" {
+		*dir = cfg.Upload.LocalDir
+	}
+	ctx := context.Background()
+	local := storage.NewLocal(*dir, cfg.Upload.PublicBaseURL, cfg.SigningKey())
+
+	if *dryRun {
+		var total int64
+		count := 0
+		err := local.Walk(ctx, func(info storage.ObjectInfo) error {
+			count++
+			total += info.Size
+			fmt.Printf("%s\t%d\n", info.Key, info.Size)
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("扫描目录失败: %v", err)
+		}
+		fmt.Printf("共 %d 个文件，%d 字节（dry-run，未上传）\n", count, total)
+		return
+	}
+
+	// 初始化数据库和 MinIO（MinIO 不可用时直接退出）
+	repositories.InitDB(cfg.DB)
+	repositories.InitMinIO(cfg.MinIO)
+	if repositories.MinIO() == nil {
+		log.Fatal("MinIO 不可用，无法迁移")
+	}
+	remote := storage.NewMinIO(repositories.MinIO, cfg.MinIO.BucketName, storage.MinIOKeyPrefix, cfg.MinIO.BaseURL())
+	store := storage.NewFallback(remote, local)
+
+	count, err := services.EnqueueLocalUploads(ctx, local)
+	if err != nil {
+		log.Fatalf("登记本地文件失败: %v", err)
+	}
+	fmt.Printf("扫描到 %d 个本地文件\n", count)
+
+	result, err := services.SyncPendingUploads(ctx, store)
+	if err != nil {
+		log.Fatalf("同步失败: %v（已同步 %d 个）", err, result.Synced)
+	}
+	fmt.Printf("同步完成：成功 %d，失败 %d（可重新运行重试），本地文件丢失 %d\n", result.Synced, result.Failed, result.Missing)
+}
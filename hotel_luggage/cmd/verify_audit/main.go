@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/services"
+)
+
+// 命令行工具：按序号重新计算审计日志的哈希链，发现被修改、删除或插入的记录
+// 用法示例：
+// go run ./cmd/verify_audit               # 校验全部审计日志
+// go run ./cmd/verify_audit -batch 5000   # 每批读取 5000 条
+// 校验失败时以非 0 状态码退出，可用于定时任务告警
+func main() {
+	configPath := flag.String("config", "", "配置文件路径（可选）")
+	batch := flag.Int("batch", 1000, "每批读取的日志条数")
+	flag.Parse()
+
+	cfg, err := configs.Load(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	repositories.InitDB(cfg.DB)
+
+	report, err := services.VerifyAuditChain(*batch)
+	if err != nil {
+		log.Fatalf("校验失败: %v（已校验 %d 条）", err, report.Checked)
+	}
+	if report.BrokenAt > 0 {
+		log.Fatalf("哈希链在序号 %d 处断开: %s（此前 %d 条校验通过）", report.BrokenAt, report.Problem, report.Checked)
+	}
+	fmt.Printf("审计日志校验通过：共 %d 条，链末尾序号 %d\n", report.Checked, report.HeadSeq)
+}
@@ -1,21 +1,513 @@
 package configs
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// 运行模式
+const (
+	ModeDevelopment = "development" // 开发模式（允许使用默认配置）
+	ModeProduction  = "production"  // 生产模式（禁止使用默认密钥）
+)
+
+// Config 应用的全部配置（启动时加载一次，再显式传给各个子系统）
+// 加载顺序：默认值 -> 配置文件（YAML/TOML，可选） -> 环境变量覆盖 -> 校验
+type Config struct {
+	Server ServerConfig `yaml:"server" toml:"server"`
+	DB     DBConfig     `yaml:"db" toml:"db"`
+	Redis  RedisConfig  `yaml:"redis" toml:"redis"`
+	MinIO  MinIOConfig  `yaml:"minio" toml:"minio"`
+	JWT    JWTConfig    `yaml:"jwt" toml:"jwt"`
+	Upload UploadConfig `yaml:"upload" toml:"upload"`
+	// Recovery 降级依赖（Redis / MinIO）的后台重连策略
+	Recovery RecoveryConfig `yaml:"recovery" toml:"recovery"`
+	Checkout CheckoutConfig `yaml:"checkout" toml:"checkout"`
+	Notify   NotifyConfig   `yaml:"notify" toml:"notify"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Addr string `yaml:"addr" toml:"addr"` // 监听地址，例如 :8080
+	Mode string `yaml:"mode" toml:"mode"` // 运行模式：development / production
+	// 优雅退出等待时间：收到 SIGTERM 后最多等待进行中的请求这么久
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// 可信反向代理的 IP / CIDR：只有来自这些地址的请求才读取 X-Forwarded-For / X-Real-IP 作为客户端 IP
+	// 为空时不信任任何代理，客户端 IP 取 TCP 连接的对端地址（审计日志和按 IP 的失败次数限制都使用该 IP）
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
 
 // DBConfig 用于保存数据库连接相关配置。
 // 目前只使用 DSN（Data Source Name）字符串。
 type DBConfig struct {
-	DSN string
+	DSN string `yaml:"dsn" toml:"dsn"`
+}
+
+// RedisConfig Redis 配置（连接失败时自动降级）
+type RedisConfig struct {
+	Addr     string   `yaml:"addr" toml:"addr"`           // Redis 地址
+	Password string   `yaml:"password" toml:"password"`   // 密码（可为空）
+	DB       int      `yaml:"db" toml:"db"`               // 数据库编号
+	CacheTTL Duration `yaml:"cache_ttl" toml:"cache_ttl"` // 按取件码查询的缓存过期时间
+}
+
+// JWTConfig JWT 配置
+type JWTConfig struct {
+	Secret string   `yaml:"secret" toml:"secret"` // 签名密钥（生产环境必须修改）
+	Expire Duration `yaml:"expire" toml:"expire"` // token 有效期
+}
+
+// UploadConfig 上传配置
+type UploadConfig struct {
+	MaxSize  int64  `yaml:"max_size" toml:"max_size"`   // 单个文件最大字节数
+	LocalDir string `yaml:"local_dir" toml:"local_dir"` // 本地存储目录（MinIO 不可用时使用）
+	// 本地文件下载地址前缀（例如 https://api.example.com/uploads），为空时返回相对地址 /uploads/...
+	PublicBaseURL string `yaml:"public_base_url" toml:"public_base_url"`
+	// 降级写入本地的文件同步到 MinIO 的间隔
+	SyncInterval Duration `yaml:"sync_interval" toml:"sync_interval"`
+	// 照片访问地址的有效期（MinIO 预签名 URL / 本地签名下载地址）
+	URLExpiry Duration `yaml:"url_expiry" toml:"url_expiry"`
+	// 本地签名下载地址的 HMAC 密钥（为空时使用 jwt.secret）
+	SigningSecret string `yaml:"signing_secret" toml:"signing_secret"`
+	// 未被寄存单引用的照片清理间隔（0 表示不启动后台清理，仍可通过接口 / 命令行手动清理）
+	GCInterval Duration `yaml:"gc_interval" toml:"gc_interval"`
+	// 照片上传或被替换后多久仍未被引用才会被清理（不能短于 url_expiry）
+	GCGracePeriod Duration `yaml:"gc_grace_period" toml:"gc_grace_period"`
+	// 图片处理（自动旋转、去除 EXIF、压缩、生成缩略图）
+	Image ImageConfig `yaml:"image" toml:"image"`
+}
+
+// ImageConfig 上传图片处理配置
+type ImageConfig struct {
+	MaxDimension int               `yaml:"max_dimension" toml:"max_dimension"` // 原图长边像素上限（超过时等比缩小）
+	JPEGQuality  int               `yaml:"jpeg_quality" toml:"jpeg_quality"`   // JPEG 编码质量（1~100）
+	MaxPixels    int               `yaml:"max_pixels" toml:"max_pixels"`       // 允许解码的最大像素数（宽×高），防止超大图片耗尽内存
+	Thumbnails   []ThumbnailConfig `yaml:"thumbnails" toml:"thumbnails"`       // 缩略图规格（第一个用于列表的 thumbnail_url）
+}
+
+// ListThumbnail 列表中使用的缩略图规格名称（第一个规格，未配置时为空）
+func (c ImageConfig) ListThumbnail() string {
+	if len(c.Thumbnails) == 0 {
+		return ""
+	}
+	return c.Thumbnails[0].Name
+}
+
+// ThumbnailConfig 缩略图规格
+type ThumbnailConfig struct {
+	Name         string `yaml:"name" toml:"name"`                   // 规格名称（只能包含小写字母和数字，例如 small）
+	MaxDimension int    `yaml:"max_dimension" toml:"max_dimension"` // 长边像素上限
+}
+
+// SigningKey 返回本地下载地址的签名密钥（未单独配置时使用 JWT 密钥）
+func (c Config) SigningKey() []byte {
+	if c.Upload.SigningSecret != "" {
+		return []byte(c.Upload.SigningSecret)
+	}
+	return []byte(c.JWT.Secret)
+}
+
+// RecoveryConfig 可选依赖的自动重连配置
+// 连接失败后从 initial_backoff 开始重试，每次失败翻倍，最长 max_backoff；
+// 连接正常时每隔 check_interval 检查一次，检查失败立即标记为降级并开始重连
+type RecoveryConfig struct {
+	InitialBackoff Duration `yaml:"initial_backoff" toml:"initial_backoff"` // 首次重试间隔
+	MaxBackoff     Duration `yaml:"max_backoff" toml:"max_backoff"`         // 最大重试间隔
+	CheckInterval  Duration `yaml:"check_interval" toml:"check_interval"`   // 健康检查间隔
+}
+
+// CheckoutConfig 取件身份核验配置
+type CheckoutConfig struct {
+	OTPTTL            Duration `yaml:"otp_ttl" toml:"otp_ttl"`                         // 一次性验证码有效期
+	OTPResendInterval Duration `yaml:"otp_resend_interval" toml:"otp_resend_interval"` // 同一取件码两次发送验证码的最小间隔
+	OTPMaxAttempts    int      `yaml:"otp_max_attempts" toml:"otp_max_attempts"`       // 每个验证码最多尝试次数
+	DelegateCodeTTL   Duration `yaml:"delegate_code_ttl" toml:"delegate_code_ttl"`     // 代取码默认有效期（登记时未指定过期时间时使用）
+	// 客人自助登记代取人、凭代取码取件的失败次数限制：lockout_window 内同一取件码 / 同一 IP 失败达到上限后锁定
+	MaxFailedAttempts      int      `yaml:"max_failed_attempts" toml:"max_failed_attempts"`               // 每个取件码的失败次数上限
+	MaxFailedAttemptsPerIP int      `yaml:"max_failed_attempts_per_ip" toml:"max_failed_attempts_per_ip"` // 每个客户端 IP 的失败次数上限
+	LockoutWindow          Duration `yaml:"lockout_window" toml:"lockout_window"`                         // 失败次数计数窗口
+}
+
+// NotifyConfig 短信 / 邮件通知配置
+type NotifyConfig struct {
+	// 通知 webhook（POST JSON：channel / to / subject / body），为空时开发环境只写日志，生产环境禁用
+	WebhookURL string   `yaml:"webhook_url" toml:"webhook_url"`
+	Timeout    Duration `yaml:"timeout" toml:"timeout"` // webhook 请求超时
+}
+
+// Duration 支持在配置文件中使用 "1m"、"24h" 这类写法
+type Duration time.Duration
+
+// UnmarshalText 实现 encoding.TextUnmarshaler（YAML/TOML 均会调用）
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Std 转换为 time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// 开发用默认值：生产模式下如果仍是这些值会拒绝启动
+const (
+	defaultDSN         = "root:root@tcp(127.0.0.1:3306)/hotel_luggage?charset=utf8mb4&parseTime=True&loc=Local"
+	defaultJWTSecret   = "change-me"
+	defaultMinIOKey    = "minioadmin"
+	defaultMinIOSecret = "minioadmin"
+)
+
+// weakJWTSecrets 已知的占位密钥（示例配置、docker-compose 中出现过的值）
+var weakJWTSecrets = map[string]bool{
+	defaultJWTSecret:                       true,
+	"your-secret-key-change-in-production": true,
+}
+
+// thumbnailNamePattern 缩略图规格名称（会出现在对象 key 中）
+var thumbnailNamePattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// Default 返回开发环境默认配置
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			Mode:            ModeDevelopment,
+			ShutdownTimeout: Duration(15 * time.Second),
+		},
+		DB: DBConfig{
+			// 默认本地连接字符串，请根据本机 MySQL 用户名/密码调整
+			// 格式：用户名:密码@tcp(地址:端口)/数据库名?参数
+			DSN: defaultDSN,
+		},
+		Redis: RedisConfig{
+			Addr:     "127.0.0.1:6379",
+			CacheTTL: Duration(time.Minute),
+		},
+		MinIO: MinIOConfig{
+			Endpoint:        "localhost:9000",
+			AccessKeyID:     defaultMinIOKey,
+			SecretAccessKey: defaultMinIOSecret,
+			BucketName:      "hotel-luggage",
+		},
+		JWT: JWTConfig{
+			Secret: defaultJWTSecret,
+			Expire: Duration(24 * time.Hour),
+		},
+		Upload: UploadConfig{
+			MaxSize:       5 << 20, // 5MB
+			LocalDir:      "uploads",
+			SyncInterval:  Duration(time.Minute),
+			URLExpiry:     Duration(15 * time.Minute),
+			GCInterval:    Duration(time.Hour),
+			GCGracePeriod: Duration(24 * time.Hour),
+			Image: ImageConfig{
+				MaxDimension: 2048,
+				JPEGQuality:  85,
+				MaxPixels:    50_000_000,
+				Thumbnails: []ThumbnailConfig{
+					{Name: "small", MaxDimension: 256},
+					{Name: "medium", MaxDimension: 768},
+				},
+			},
+		},
+		Recovery: RecoveryConfig{
+			InitialBackoff: Duration(time.Second),
+			MaxBackoff:     Duration(30 * time.Second),
+			CheckInterval:  Duration(10 * time.Second),
+		},
+		Checkout: CheckoutConfig{
+			OTPTTL:                 Duration(10 * time.Minute),
+			OTPResendInterval:      Duration(time.Minute),
+			OTPMaxAttempts:         5,
+			MaxFailedAttempts:      5,
+			MaxFailedAttemptsPerIP: 20,
+			LockoutWindow:          Duration(15 * time.Minute),
+			DelegateCodeTTL:        Duration(72 * time.Hour),
+		},
+		Notify: NotifyConfig{
+			Timeout: Duration(5 * time.Second),
+		},
+	}
+}
+
+// Load 加载配置
+// 参数：
+//   - path: 配置文件路径（.yaml/.yml/.toml），为空时读取环境变量 CONFIG_FILE，仍为空则只使用默认值 + 环境变量
+//
+// 环境变量（优先级高于配置文件）：
+//
+//	APP_ENV / SERVER_ADDR / TRUSTED_PROXIES
+//	DB_DSN
+//	REDIS_ADDR / REDIS_PASSWORD / REDIS_DB / REDIS_CACHE_TTL
+//	MINIO_ENDPOINT / MINIO_ACCESS_KEY / MINIO_SECRET_KEY / MINIO_USE_SSL / MINIO_BUCKET_NAME
+//	JWT_SECRET / JWT_EXPIRE
+//	UPLOAD_MAX_SIZE / UPLOAD_LOCAL_DIR / UPLOAD_SYNC_INTERVAL / UPLOAD_URL_EXPIRY / UPLOAD_GC_INTERVAL / UPLOAD_GC_GRACE_PERIOD
+//	UPLOAD_IMAGE_MAX_DIMENSION / UPLOAD_IMAGE_JPEG_QUALITY
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// IsProduction 是否生产模式
+func (c Config) IsProduction() bool {
+	return c.Server.Mode == ModeProduction
 }
 
-// LoadDBConfig 从环境变量读取数据库配置。
-// 优先读取 DB_DSN，如果为空则使用默认本地配置（仅用于开发环境）。
-func LoadDBConfig() DBConfig {
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		// 默认本地连接字符串，请根据本机 MySQL 用户名/密码调整
-		// 格式：用户名:密码@tcp(地址:端口)/数据库名?参数
-		dsn = "root:root@tcp(127.0.0.1:3306)/hotel_luggage?charset=utf8mb4&parseTime=True&loc=Local"
+// Validate 校验配置
+// - 必填项不能为空、数值不能为负
+// - 生产模式下禁止使用默认/占位密钥
+func (c Config) Validate() error {
+	var problems []string
+	if c.Server.Mode != ModeDevelopment && c.Server.Mode != ModeProduction {
+		problems = append(problems, fmt.Sprintf("server.mode must be %q or %q", ModeDevelopment, ModeProduction))
+	}
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr is empty")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout must be positive")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if !validProxy(proxy) {
+			problems = append(problems, fmt.Sprintf("server.trusted_proxies: %q is not an IP address or CIDR", proxy))
+		}
+	}
+	if c.DB.DSN == "" {
+		problems = append(problems, "db.dsn is empty")
+	}
+	if c.Redis.CacheTTL < 0 {
+		problems = append(problems, "redis.cache_ttl cannot be negative")
+	}
+	if c.JWT.Secret == "" {
+		problems = append(problems, "jwt.secret is empty")
+	}
+	if c.JWT.Expire <= 0 {
+		problems = append(problems, "jwt.expire must be positive")
+	}
+	if c.Upload.MaxSize <= 0 {
+		problems = append(problems, "upload.max_size must be positive")
+	}
+	if c.Upload.LocalDir == "" {
+		problems = append(problems, "upload.local_dir is empty")
+	}
+	if c.Upload.SyncInterval <= 0 {
+		problems = append(problems, "upload.sync_interval must be positive")
+	}
+	if c.Upload.URLExpiry <= 0 || c.Upload.URLExpiry.Std() > 7*24*time.Hour {
+		problems = append(problems, "upload.url_expiry must be between 0 and 7 days")
+	}
+	if c.Upload.GCInterval < 0 {
+		problems = append(problems, "upload.gc_interval cannot be negative")
+	}
+	if c.Upload.GCGracePeriod < c.Upload.URLExpiry {
+		problems = append(problems, "upload.gc_grace_period cannot be less than upload.url_expiry")
+	}
+	if c.Upload.Image.MaxDimension <= 0 || c.Upload.Image.MaxPixels <= 0 {
+		problems = append(problems, "upload.image.max_dimension and upload.image.max_pixels must be positive")
+	}
+	if c.Upload.Image.JPEGQuality < 1 || c.Upload.Image.JPEGQuality > 100 {
+		problems = append(problems, "upload.image.jpeg_quality must be between 1 and 100")
+	}
+	thumbnailNames := make(map[string]bool, len(c.Upload.Image.Thumbnails))
+	for _, t := range c.Upload.Image.Thumbnails {
+		if !thumbnailNamePattern.MatchString(t.Name) || thumbnailNames[t.Name] {
+			problems = append(problems, fmt.Sprintf("upload.image.thumbnails: name %q must be unique and match [a-z0-9]+", t.Name))
+		}
+		if t.MaxDimension <= 0 {
+			problems = append(problems, fmt.Sprintf("upload.image.thumbnails: %q max_dimension must be positive", t.Name))
+		}
+		thumbnailNames[t.Name] = true
+	}
+	if c.Checkout.OTPTTL <= 0 || c.Checkout.OTPResendInterval < 0 || c.Checkout.OTPMaxAttempts <= 0 {
+		problems = append(problems, "checkout.otp_ttl and checkout.otp_max_attempts must be positive")
+	}
+	if c.Checkout.DelegateCodeTTL <= 0 {
+		problems = append(problems, "checkout.delegate_code_ttl must be positive")
+	}
+	if c.Checkout.MaxFailedAttempts <= 0 || c.Checkout.MaxFailedAttemptsPerIP <= 0 || c.Checkout.LockoutWindow <= 0 {
+		problems = append(problems, "checkout.max_failed_attempts, checkout.max_failed_attempts_per_ip and checkout.lockout_window must be positive")
+	}
+	if c.Notify.Timeout <= 0 {
+		problems = append(problems, "notify.timeout must be positive")
+	}
+	if c.Recovery.InitialBackoff <= 0 || c.Recovery.CheckInterval <= 0 {
+		problems = append(problems, "recovery.initial_backoff and recovery.check_interval must be positive")
+	}
+	if c.Recovery.MaxBackoff < c.Recovery.InitialBackoff {
+		problems = append(problems, "recovery.max_backoff cannot be less than recovery.initial_backoff")
+	}
+
+	if c.IsProduction() {
+		if weakJWTSecrets[c.JWT.Secret] || len(c.JWT.Secret) < 16 {
+			problems = append(problems, "jwt.secret must be changed to a strong secret (>= 16 chars) in production")
+		}
+		if c.Upload.SigningSecret != "" && len(c.Upload.SigningSecret) < 16 {
+			problems = append(problems, "upload.signing_secret must be at least 16 chars in production")
+		}
+		if c.DB.DSN == defaultDSN {
+			problems = append(problems, "db.dsn must not use the default development credentials in production")
+		}
+		if c.MinIO.AccessKeyID == defaultMinIOKey || c.MinIO.SecretAccessKey == defaultMinIOSecret {
+			problems = append(problems, "minio credentials must not use the default minioadmin in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validProxy 可信代理必须是 IP 地址或 CIDR
+func validProxy(proxy string) bool {
+	if strings.Contains(proxy, "/") {
+		_, _, err := net.ParseCIDR(proxy)
+		return err == nil
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+// loadFile 按扩展名解析 YAML / TOML 配置文件（只覆盖文件中出现的字段）
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("unsupported config file type: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv 使用环境变量覆盖配置（兼容原有的环境变量名）
+func applyEnv(cfg *Config) error {
+	setString := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	setString("APP_ENV", &cfg.Server.Mode)
+	setString("SERVER_ADDR", &cfg.Server.Addr)
+	setString("DB_DSN", &cfg.DB.DSN)
+	setString("REDIS_ADDR", &cfg.Redis.Addr)
+	setString("MINIO_ENDPOINT", &cfg.MinIO.Endpoint)
+	setString("MINIO_ACCESS_KEY", &cfg.MinIO.AccessKeyID)
+	setString("MINIO_SECRET_KEY", &cfg.MinIO.SecretAccessKey)
+	setString("MINIO_BUCKET_NAME", &cfg.MinIO.BucketName)
+	setString("JWT_SECRET", &cfg.JWT.Secret)
+	setString("UPLOAD_LOCAL_DIR", &cfg.Upload.LocalDir)
+	setString("UPLOAD_PUBLIC_BASE_URL", &cfg.Upload.PublicBaseURL)
+	setString("UPLOAD_SIGNING_SECRET", &cfg.Upload.SigningSecret)
+	setString("MINIO_PUBLIC_BASE_URL", &cfg.MinIO.PublicBaseURL)
+	setString("NOTIFY_WEBHOOK_URL", &cfg.Notify.WebhookURL)
+
+	// 逗号分隔，显式设置为空表示不信任任何代理
+	if v, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		cfg.Server.TrustedProxies = nil
+		for _, proxy := range strings.Split(v, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				cfg.Server.TrustedProxies = append(cfg.Server.TrustedProxies, proxy)
+			}
+		}
+	}
+
+	// 密码允许显式设置为空
+	if v, ok := os.LookupEnv("REDIS_PASSWORD"); ok {
+		cfg.Redis.Password = v
+	}
+	if v := os.Getenv("MINIO_USE_SSL"); v != "" {
+		cfg.MinIO.UseSSL = v == "true"
+	}
+	if v := os.Getenv("REDIS_DB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_DB: %w", err)
+		}
+		cfg.Redis.DB = n
+	}
+	ints := map[string]*int{
+		"UPLOAD_IMAGE_MAX_DIMENSION":          &cfg.Upload.Image.MaxDimension,
+		"UPLOAD_IMAGE_JPEG_QUALITY":           &cfg.Upload.Image.JPEGQuality,
+		"CHECKOUT_OTP_MAX_ATTEMPTS":           &cfg.Checkout.OTPMaxAttempts,
+		"CHECKOUT_MAX_FAILED_ATTEMPTS":        &cfg.Checkout.MaxFailedAttempts,
+		"CHECKOUT_MAX_FAILED_ATTEMPTS_PER_IP": &cfg.Checkout.MaxFailedAttemptsPerIP,
+	}
+	for key, dst := range ints {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*dst = n
+		}
+	}
+	if v := os.Getenv("UPLOAD_MAX_SIZE"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid UPLOAD_MAX_SIZE: %w", err)
+		}
+		cfg.Upload.MaxSize = n
+	}
+	durations := map[string]*Duration{
+		"REDIS_CACHE_TTL":              &cfg.Redis.CacheTTL,
+		"SHUTDOWN_TIMEOUT":             &cfg.Server.ShutdownTimeout,
+		"RECOVERY_INITIAL_BACKOFF":     &cfg.Recovery.InitialBackoff,
+		"RECOVERY_MAX_BACKOFF":         &cfg.Recovery.MaxBackoff,
+		"RECOVERY_CHECK_INTERVAL":      &cfg.Recovery.CheckInterval,
+		"JWT_EXPIRE":                   &cfg.JWT.Expire,
+		"UPLOAD_SYNC_INTERVAL":         &cfg.Upload.SyncInterval,
+		"UPLOAD_URL_EXPIRY":            &cfg.Upload.URLExpiry,
+		"UPLOAD_GC_INTERVAL":           &cfg.Upload.GCInterval,
+		"UPLOAD_GC_GRACE_PERIOD":       &cfg.Upload.GCGracePeriod,
+		"CHECKOUT_OTP_TTL":             &cfg.Checkout.OTPTTL,
+		"CHECKOUT_OTP_RESEND_INTERVAL": &cfg.Checkout.OTPResendInterval,
+		"DELEGATE_CODE_TTL":            &cfg.Checkout.DelegateCodeTTL,
+		"CHECKOUT_LOCKOUT_WINDOW":      &cfg.Checkout.LockoutWindow,
+		"NOTIFY_TIMEOUT":               &cfg.Notify.Timeout,
+	}
+	for key, dst := range durations {
+		if v := os.Getenv(key); v != "" {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
 	}
-	return DBConfig{DSN: dsn}
+	return nil
 }
@@ -0,0 +1,173 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// productionConfig 满足生产模式要求的配置
+func productionConfig() Config {
+	cfg := Default()
+	cfg.Server.Mode = ModeProduction
+	cfg.JWT.Secret = "0123456789abcdef-prod"
+	cfg.DB.DSN = "app:strong@tcp(db:3306)/hotel_luggage?parseTime=True"
+	cfg.MinIO.AccessKeyID = "app"
+	cfg.MinIO.SecretAccessKey = "strong-minio-secret"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    func() Config
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "development defaults", base: Default},
+		{name: "production", base: productionConfig},
+		{name: "unknown mode", base: Default, modify: func(c *Config) { c.Server.Mode = "staging" }, wantErr: "server.mode"},
+		{name: "empty dsn", base: Default, modify: func(c *Config) { c.DB.DSN = "" }, wantErr: "db.dsn is empty"},
+		{name: "trusted proxies", base: Default, modify: func(c *Config) {
+			c.Server.TrustedProxies = []string{"10.0.0.0/8", "127.0.0.1", "::1"}
+		}},
+		{name: "invalid trusted proxy", base: Default, modify: func(c *Config) {
+			c.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy.local"}
+		}, wantErr: `server.trusted_proxies: "proxy.local"`},
+		{name: "negative cache ttl", base: Default, modify: func(c *Config) { c.Redis.CacheTTL = -1 }, wantErr: "redis.cache_ttl"},
+		{name: "url expiry over 7 days", base: Default, modify: func(c *Config) {
+			c.Upload.URLExpiry = Duration(8 * 24 * time.Hour)
+			c.Upload.GCGracePeriod = Duration(30 * 24 * time.Hour)
+		}, wantErr: "upload.url_expiry"},
+		{name: "gc grace shorter than url expiry", base: Default, modify: func(c *Config) {
+			c.Upload.GCGracePeriod = Duration(time.Minute)
+		}, wantErr: "upload.gc_grace_period"},
+		{name: "jpeg quality", base: Default, modify: func(c *Config) { c.Upload.Image.JPEGQuality = 101 }, wantErr: "jpeg_quality"},
+		{name: "duplicate thumbnail", base: Default, modify: func(c *Config) {
+			c.Upload.Image.Thumbnails = []ThumbnailConfig{{Name: "small", MaxDimension: 256}, {Name: "small", MaxDimension: 512}}
+		}, wantErr: `name "small" must be unique`},
+		{name: "thumbnail name", base: Default, modify: func(c *Config) {
+			c.Upload.Image.Thumbnails = []ThumbnailConfig{{Name: "Small-1", MaxDimension: 256}}
+		}, wantErr: "match [a-z0-9]+"},
+		{name: "otp attempts", base: Default, modify: func(c *Config) { c.Checkout.OTPMaxAttempts = 0 }, wantErr: "checkout.otp_max_attempts"},
+		{name: "zero otp resend interval", base: Default, modify: func(c *Config) { c.Checkout.OTPResendInterval = 0 }},
+		{name: "lockout window", base: Default, modify: func(c *Config) { c.Checkout.LockoutWindow = 0 }, wantErr: "checkout.lockout_window"},
+		{name: "recovery backoff", base: Default, modify: func(c *Config) {
+			c.Recovery.MaxBackoff = Duration(time.Millisecond)
+		}, wantErr: "recovery.max_backoff"},
+		{name: "production default jwt secret", base: productionConfig, modify: func(c *Config) { c.JWT.Secret = defaultJWTSecret }, wantErr: "jwt.secret must be changed"},
+		{name: "production short jwt secret", base: productionConfig, modify: func(c *Config) { c.JWT.Secret = "short" }, wantErr: "jwt.secret must be changed"},
+		{name: "production short signing secret", base: productionConfig, modify: func(c *Config) { c.Upload.SigningSecret = "short" }, wantErr: "upload.signing_secret"},
+		{name: "production default dsn", base: productionConfig, modify: func(c *Config) { c.DB.DSN = defaultDSN }, wantErr: "db.dsn must not use"},
+		{name: "production default minio", base: productionConfig, modify: func(c *Config) { c.MinIO.SecretAccessKey = defaultMinIOSecret }, wantErr: "minio credentials"},
+		// 开发模式允许默认密钥
+		{name: "development default secrets", base: Default, modify: func(c *Config) { c.Upload.SigningSecret = "short" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.base()
+			if tt.modify != nil {
+				tt.modify(&cfg)
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte("server:\n  addr: \":9090\"\ncheckout:\n  otp_ttl: 5m\n  otp_max_attempts: 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tomlPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(tomlPath, []byte("[jwt]\nexpire = \"2h\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(jsonPath, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		check   func(t *testing.T, cfg Config)
+		wantErr string
+	}{
+		{name: "defaults", check: func(t *testing.T, cfg Config) {
+			if cfg.Server.Addr != ":8080" || cfg.Checkout.OTPMaxAttempts != 5 {
+				t.Fatalf("unexpected defaults: %+v", cfg.Server)
+			}
+		}},
+		{name: "yaml file", path: yamlPath, check: func(t *testing.T, cfg Config) {
+			if cfg.Server.Addr != ":9090" || cfg.Checkout.OTPTTL.Std() != 5*time.Minute || cfg.Checkout.OTPMaxAttempts != 3 {
+				t.Fatalf("file values not applied: addr=%s otp_ttl=%s attempts=%d", cfg.Server.Addr, cfg.Checkout.OTPTTL.Std(), cfg.Checkout.OTPMaxAttempts)
+			}
+			// 文件中没有的字段保持默认值
+			if cfg.Checkout.DelegateCodeTTL.Std() != 72*time.Hour {
+				t.Fatalf("delegate_code_ttl = %s, want default", cfg.Checkout.DelegateCodeTTL.Std())
+			}
+		}},
+		{name: "toml file from CONFIG_FILE", env: map[string]string{"CONFIG_FILE": tomlPath}, check: func(t *testing.T, cfg Config) {
+			if cfg.JWT.Expire.Std() != 2*time.Hour {
+				t.Fatalf("jwt.expire = %s, want 2h", cfg.JWT.Expire.Std())
+			}
+		}},
+		{name: "env overrides file", path: yamlPath, env: map[string]string{
+			"SERVER_ADDR":                  ":7070",
+			"CHECKOUT_OTP_MAX_ATTEMPTS":    "8",
+			"CHECKOUT_OTP_RESEND_INTERVAL": "30s",
+		}, check: func(t *testing.T, cfg Config) {
+			if cfg.Server.Addr != ":7070" || cfg.Checkout.OTPMaxAttempts != 8 || cfg.Checkout.OTPResendInterval.Std() != 30*time.Second {
+				t.Fatalf("env values not applied: addr=%s attempts=%d resend=%s", cfg.Server.Addr, cfg.Checkout.OTPMaxAttempts, cfg.Checkout.OTPResendInterval.Std())
+			}
+		}},
+		{name: "trusted proxies env", env: map[string]string{"TRUSTED_PROXIES": " 10.0.0.0/8, 127.0.0.1 ,"}, check: func(t *testing.T, cfg Config) {
+			if want := []string{"10.0.0.0/8", "127.0.0.1"}; strings.Join(cfg.Server.TrustedProxies, ",") != strings.Join(want, ",") {
+				t.Fatalf("trusted_proxies = %v, want %v", cfg.Server.TrustedProxies, want)
+			}
+		}},
+		{name: "invalid trusted proxies env", env: map[string]string{"TRUSTED_PROXIES": "10.0.0.0/33"}, wantErr: "server.trusted_proxies"},
+		{name: "invalid int env", env: map[string]string{"CHECKOUT_MAX_FAILED_ATTEMPTS": "many"}, wantErr: "invalid CHECKOUT_MAX_FAILED_ATTEMPTS"},
+		{name: "invalid duration env", env: map[string]string{"CHECKOUT_LOCKOUT_WINDOW": "forever"}, wantErr: "invalid CHECKOUT_LOCKOUT_WINDOW"},
+		{name: "env fails validation", env: map[string]string{"CHECKOUT_LOCKOUT_WINDOW": "0s"}, wantErr: "checkout.lockout_window"},
+		{name: "production with default secrets", env: map[string]string{"APP_ENV": ModeProduction}, wantErr: "jwt.secret must be changed"},
+		{name: "missing file", path: filepath.Join(dir, "missing.yaml"), wantErr: "read config file"},
+		{name: "unsupported file type", path: jsonPath, wantErr: "unsupported config file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 清除可能影响结果的环境变量
+			for _, key := range []string{"CONFIG_FILE", "APP_ENV", "SERVER_ADDR", "TRUSTED_PROXIES", "DB_DSN", "JWT_SECRET", "JWT_EXPIRE"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := Load(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -1,45 +1,24 @@
 package configs
 
-import "os"
-
 // MinIOConfig MinIO对象存储配置
 type MinIOConfig struct {
-	Endpoint        string // MinIO服务地址，例如：localhost:9000
-	AccessKeyID     string // Access Key
-	SecretAccessKey string // Secret Key
-	UseSSL          bool   // 是否使用HTTPS
-	BucketName      string // 存储桶名称
+	Endpoint        string `yaml:"endpoint" toml:"endpoint"`       // MinIO服务地址，例如：localhost:9000
+	AccessKeyID     string `yaml:"access_key" toml:"access_key"`   // Access Key
+	SecretAccessKey string `yaml:"secret_key" toml:"secret_key"`   // Secret Key
+	UseSSL          bool   `yaml:"use_ssl" toml:"use_ssl"`         // 是否使用HTTPS
+	BucketName      string `yaml:"bucket_name" toml:"bucket_name"` // 存储桶名称
+	// 对外访问前缀（可选，例如 CDN 地址 https://cdn.example.com/hotel-luggage），为空时使用 scheme://endpoint/bucket
+	PublicBaseURL string `yaml:"public_base_url" toml:"public_base_url"`
 }
 
-// LoadMinIOConfig 从环境变量读取MinIO配置
-func LoadMinIOConfig() MinIOConfig {
-	endpoint := os.Getenv("MINIO_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "localhost:9000" // 默认本地MinIO
-	}
-
-	accessKey := os.Getenv("MINIO_ACCESS_KEY")
-	if accessKey == "" {
-		accessKey = "minioadmin" // 默认用户名
-	}
-
-	secretKey := os.Getenv("MINIO_SECRET_KEY")
-	if secretKey == "" {
-		secretKey = "minioadmin" // 默认密码
+// BaseURL 返回对象访问前缀（未配置 public_base_url 时为 scheme://endpoint/bucket）
+func (c MinIOConfig) BaseURL() string {
+	if c.PublicBaseURL != "" {
+		return c.PublicBaseURL
 	}
-
-	bucketName := os.Getenv("MINIO_BUCKET_NAME")
-	if bucketName == "" {
-		bucketName = "hotel-luggage" // 默认桶名
-	}
-
-	useSSL := os.Getenv("MINIO_USE_SSL") == "true"
-
-	return MinIOConfig{
-		Endpoint:        endpoint,
-		AccessKeyID:     accessKey,
-		SecretAccessKey: secretKey,
-		UseSSL:          useSSL,
-		BucketName:      bucketName,
+	scheme := "http"
+	if c.UseSSL {
+		scheme = "https"
 	}
+	return scheme + "://" + c.Endpoint + "/" + c.BucketName
 }
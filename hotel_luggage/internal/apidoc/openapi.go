@@ -0,0 +1,401 @@
+package apidoc
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route 描述一个已注册接口的文档信息
+// 说明：
+// - Method/Path 必须与 router 中注册的路由完全一致（gin 风格路径，如 /api/luggage/:id）
+// - Body 传入请求结构体的零值（如 handlers.CreateLuggageRequest{}），用于反射生成 JSON Schema
+// - Query/Form 分别描述查询参数与 multipart 表单字段
+type Route struct {
+	Method  string      // HTTP 方法
+	Path    string      // gin 风格路径
+	Tag     string      // 分组（用于 OpenAPI tags）
+	Summary string      // 接口说明
+	Auth    bool        // 是否需要 JWT 认证
+	Query   []Param     // 查询参数
+	Form    []Param     // multipart/form-data 字段
+	Body    interface{} // JSON 请求体（nil 表示无请求体）
+}
+
+// Param 描述一个查询参数或表单字段
+type Param struct {
+	Name        string // 参数名
+	Type        string // 参数类型（string/integer/boolean/file）
+	Required    bool   // 是否必填
+	Description string // 说明
+}
+
+// Info OpenAPI 文档的基础信息
+type Info struct {
+	Title   string
+	Version string
+}
+
+// Build 根据路由文档生成 OpenAPI 3 文档（map 形式，可直接序列化为 JSON）
+func Build(info Info, routes []Route) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+
+	for _, route := range routes {
+		path := toOpenAPIPath(route.Path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = buildOperation(route, schemas)
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   info.Title,
+			"version": info.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+	return doc
+}
+
+// CheckDrift 对比实际注册的路由与文档，返回所有不一致项（为空表示一致）
+// - 已注册但未写文档的路由
+// - 写了文档但实际不存在的路由
+func CheckDrift(registered gin.RoutesInfo, routes []Route) []string {
+	documented := map[string]bool{}
+	for _, route := range routes {
+		documented[routeKey(route.Method, route.Path)] = true
+	}
+	actual := map[string]bool{}
+	for _, r := range registered {
+		// OPTIONS/HEAD 由框架或中间件处理，不纳入文档
+		if r.Method == "OPTIONS" || r.Method == "HEAD" {
+			continue
+		}
+		actual[routeKey(r.Method, r.Path)] = true
+	}
+
+	var problems []string
+	for key := range actual {
+		if !documented[key] {
+			problems = append(problems, "undocumented route: "+key)
+		}
+	}
+	for key := range documented {
+		if !actual[key] {
+			problems = append(problems, "documented route not registered: "+key)
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// Lookup 按方法和路径查找路由文档
+func Lookup(routes []Route, method, path string) (Route, bool) {
+	for _, route := range routes {
+		if route.Method == method && route.Path == path {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// toOpenAPIPath 把 gin 路径参数（:id、*path）转换为 OpenAPI 形式（{id}、{path}）
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParams 提取 gin 路径中的参数名
+func pathParams(path string) []string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			names = append(names, seg[1:])
+		}
+	}
+	return names
+}
+
+func buildOperation(route Route, schemas map[string]interface{}) map[string]interface{} {
+	op := map[string]interface{}{
+		"summary": route.Summary,
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "success"},
+			"400": map[string]interface{}{"description": "invalid request"},
+		},
+	}
+	if route.Tag != "" {
+		op["tags"] = []string{route.Tag}
+	}
+	if route.Auth {
+		op["security"] = []map[string][]string{{"bearerAuth": {}}}
+		op["responses"].(map[string]interface{})["401"] = map[string]interface{}{"description": "unauthorized"}
+	}
+
+	params := make([]map[string]interface{}, 0)
+	for _, name := range pathParams(route.Path) {
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, p := range route.Query {
+		params = append(params, map[string]interface{}{
+			"name":        p.Name,
+			"in":          "query",
+			"required":    p.Required,
+			"description": p.Description,
+			"schema":      map[string]interface{}{"type": paramType(p.Type)},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if route.Body != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": schemaFor(reflect.TypeOf(route.Body), schemas),
+				},
+			},
+		}
+	} else if len(route.Form) > 0 {
+		props := map[string]interface{}{}
+		var required []string
+		for _, p := range route.Form {
+			prop := map[string]interface{}{"type": paramType(p.Type), "description": p.Description}
+			if p.Type == "file" {
+				prop = map[string]interface{}{"type": "string", "format": "binary", "description": p.Description}
+			}
+			props[p.Name] = prop
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"multipart/form-data": map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+	return op
+}
+
+func paramType(t string) string {
+	if t == "" || t == "file" {
+		return "string"
+	}
+	return t
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schemaer 自定义 JSON 编解码的类型可以实现该接口，直接给出自己的 JSON Schema
+// （例如既可以传数字也可以传字符串的字段）
+type Schemaer interface {
+	OpenAPISchema() map[string]interface{}
+}
+
+var schemaerType = reflect.TypeOf((*Schemaer)(nil)).Elem()
+
+// schemaFor 通过反射生成 JSON Schema
+// - 具名结构体会注册到 components.schemas 并以 $ref 引用
+// - 字段名取 json tag，binding:"required" 视为必填
+// - 实现了 Schemaer 的类型使用其自带的 Schema
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	if t.Implements(schemaerType) {
+		return reflect.Zero(t).Interface().(Schemaer).OpenAPISchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := t.Name()
+		if _, ok := schemas[name]; !ok {
+			// 先占位，避免递归结构体死循环
+			schemas[name] = map[string]interface{}{}
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	for _, field := range jsonFields(t) {
+		props[field.name] = schemaFor(field.typ, schemas)
+		if field.required {
+			required = append(required, field.name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonField 结构体序列化为 JSON 后的一个字段
+type jsonField struct {
+	name     string
+	tagged   bool  // 名称来自 json tag
+	index    []int // 字段在（嵌入的）结构体中的位置，长度即嵌入层级
+	typ      reflect.Type
+	required bool
+}
+
+// jsonFields 按 encoding/json 的规则列出结构体序列化后的字段（按声明顺序）：
+// - 匿名嵌入且 json tag 没有指定名称的结构体（或结构体指针）展开到外层，未导出的嵌入结构体也展开
+// - 同名字段取嵌入层级最浅的；层级相同时取有 json tag 的；仍无法区分时都不输出
+func jsonFields(t reflect.Type) []jsonField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var candidates []jsonField
+	visited := map[reflect.Type]bool{}
+	for level := []embedded{{typ: t}}; len(level) > 0; {
+		var next []embedded
+		for _, e := range level {
+			if visited[e.typ] {
+				continue
+			}
+			for i := 0; i < e.typ.NumField(); i++ {
+				field := e.typ.Field(i)
+				ft := field.Type
+				if field.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if !field.IsExported() && !(field.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+				tag := field.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name := strings.Split(tag, ",")[0]
+				index := append(append([]int{}, e.index...), i)
+				if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+				candidate := jsonField{name: name, tagged: name != "", index: index, typ: field.Type,
+					required: strings.Contains(field.Tag.Get("binding"), "required")}
+				if name == "" {
+					candidate.name = field.Name
+				}
+				candidates = append(candidates, candidate)
+			}
+		}
+		// 同一层级嵌入两次的结构体都要展开（字段互相冲突而被忽略），这一层处理完后再标记
+		for _, e := range level {
+			visited[e.typ] = true
+		}
+		level = next
+	}
+
+	byName := map[string][]jsonField{}
+	for _, candidate := range candidates {
+		byName[candidate.name] = append(byName[candidate.name], candidate)
+	}
+	var fields []jsonField
+	for _, group := range byName {
+		if field, ok := dominantField(group); ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+// dominantField 在同名字段中选出最终输出的字段（候选按嵌入层级从浅到深排列）
+func dominantField(group []jsonField) (jsonField, bool) {
+	depth := len(group[0].index)
+	var shallowest []jsonField
+	for _, field := range group {
+		if len(field.index) == depth {
+			shallowest = append(shallowest, field)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	var tagged []jsonField
+	for _, field := range shallowest {
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
@@ -0,0 +1,178 @@
+package apidoc
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type docBase struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DocAudit struct {
+	Actor string `json:"actor"`
+	Note  string
+}
+
+type docConflictA struct{ Label string }
+
+type docConflictB struct{ Label string }
+
+type docTaggedA struct {
+	Code string `json:"code"`
+}
+
+type docTaggedB struct{ Code string }
+
+type docSample struct {
+	docBase
+	*DocAudit
+	docConflictA
+	docConflictB
+	docTaggedA
+	docTaggedB `json:"tagged_b"`
+	Actor      string   `json:"actor"` // 外层字段覆盖嵌入结构体的同名字段
+	Named      DocAudit `json:"named"`
+	Title      string   `json:"title" binding:"required"`
+	Tags       []string `json:"tags,omitempty"`
+	Skip       string   `json:"-"`
+	hidden     string
+}
+
+// sampleJSONKeys 按 encoding/json 实际输出的字段名（所有字段都赋值，omitempty 不影响结果）
+func sampleJSONKeys(t *testing.T, v interface{}) []string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestStructSchemaMatchesJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "embedded structs", value: docSample{
+			docBase:    docBase{ID: 1, CreatedAt: time.Now()},
+			DocAudit:   &DocAudit{Actor: "alice", Note: "n"},
+			docTaggedB: docTaggedB{Code: "B"},
+			Actor:      "bob",
+			Title:      "t",
+			Tags:       []string{"a"},
+			hidden:     "h",
+		}},
+		{name: "plain struct", value: DocAudit{Actor: "alice"}},
+		// 嵌入结构体被 json tag 命名后按普通字段输出
+		{name: "named embedded", value: struct {
+			docBase `json:"base"`
+			Title   string `json:"title"`
+		}{Title: "t"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := structSchema(reflect.TypeOf(tt.value), map[string]interface{}{})
+			props := schema["properties"].(map[string]interface{})
+			got := make([]string, 0, len(props))
+			for key := range props {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			want := sampleJSONKeys(t, tt.value)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("schema properties = %v, want json keys %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStructSchemaEmbeddedFields(t *testing.T) {
+	schemas := map[string]interface{}{}
+	schema := structSchema(reflect.TypeOf(docSample{}), schemas)
+	props := schema["properties"].(map[string]interface{})
+	tests := []struct {
+		name string
+		prop string
+		want map[string]interface{}
+	}{
+		{name: "promoted from embedded struct", prop: "id", want: map[string]interface{}{"type": "integer", "format": "int64"}},
+		{name: "promoted time", prop: "created_at", want: map[string]interface{}{"type": "string", "format": "date-time"}},
+		{name: "promoted from embedded pointer", prop: "Note", want: map[string]interface{}{"type": "string"}},
+		// 同一层级同名时取有 json tag 的
+		{name: "tagged wins at same depth", prop: "code", want: map[string]interface{}{"type": "string"}},
+		{name: "named embedded struct", prop: "tagged_b", want: map[string]interface{}{"$ref": "#/components/schemas/docTaggedB"}},
+		{name: "nested named struct", prop: "named", want: map[string]interface{}{"$ref": "#/components/schemas/DocAudit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := props[tt.prop]; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("properties[%q] = %v, want %v", tt.prop, got, tt.want)
+			}
+		})
+	}
+	// 同一层级同名且都没有 json tag 的字段都不输出
+	if _, ok := props["Label"]; ok {
+		t.Fatalf("conflicting field Label should be omitted")
+	}
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"title"}) {
+		t.Fatalf("required = %v, want [title]", got)
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	registered := gin.RoutesInfo{
+		{Method: "GET", Path: "/api/luggage/:id"},
+		{Method: "POST", Path: "/api/luggage"},
+		{Method: "OPTIONS", Path: "/api/luggage"},
+	}
+	tests := []struct {
+		name   string
+		routes []Route
+		want   []string
+	}{
+		{name: "in sync", routes: []Route{
+			{Method: "GET", Path: "/api/luggage/:id"},
+			{Method: "POST", Path: "/api/luggage"},
+		}},
+		{name: "undocumented route", routes: []Route{
+			{Method: "GET", Path: "/api/luggage/:id"},
+		}, want: []string{"undocumented route: POST /api/luggage"}},
+		{name: "documented route not registered", routes: []Route{
+			{Method: "GET", Path: "/api/luggage/:id"},
+			{Method: "POST", Path: "/api/luggage"},
+			{Method: "DELETE", Path: "/api/luggage/:id"},
+		}, want: []string{"documented route not registered: DELETE /api/luggage/:id"}},
+		// 路径参数名不同视为不同的路由
+		{name: "param name differs", routes: []Route{
+			{Method: "GET", Path: "/api/luggage/:luggage_id"},
+			{Method: "POST", Path: "/api/luggage"},
+		}, want: []string{
+			"documented route not registered: GET /api/luggage/:luggage_id",
+			"undocumented route: GET /api/luggage/:id",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckDrift(registered, tt.routes)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("CheckDrift() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// ContextMessageKey handler 写入 gin.Context 的“操作描述”键名
+// ErrorHandler 中间件会把它作为响应中的 message 字段（如 "create luggage failed"）
+const ContextMessageKey = "error_message"
+
+// Error 业务错误（领域错误）
+// 说明：
+// - Code：稳定的错误码（如 LUGGAGE_NOT_FOUND），前端应依据 Code 判断错误类型，而不是解析文字
+// - Status：对应的 HTTP 状态码（404/409/403...）
+// - Message：可以直接展示给调用方的错误描述
+// - Err：底层错误（如数据库错误），只写日志，不返回给客户端
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Err     error
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 支持 errors.Is / errors.As 继续向下匹配底层错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 按错误码比较，使 errors.Is(err, apperr.ErrLuggageNotFound) 对 Wrap/WithMessage 后的错误同样成立
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// New 创建业务错误
+func New(code string, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+// WithMessage 复制错误并替换对外描述（错误码、状态码不变）
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Code: e.Code, Status: e.Status, Message: message, Err: e.Err}
+}
+
+// Wrap 复制错误并附加底层原因
+func (e *Error) Wrap(err error) *Error {
+	return &Error{Code: e.Code, Status: e.Status, Message: e.Message, Err: err}
+}
+
+// InvalidRequest 参数校验失败（400）
+func InvalidRequest(message string) *Error {
+	return ErrInvalidRequest.WithMessage(message)
+}
+
+// Internal 内部错误（500），底层错误只写日志
+func Internal(err error) *Error {
+	return ErrInternal.Wrap(err)
+}
+
+// From 把任意错误转换为业务错误
+// - 已是 *Error：原样返回
+// - gorm.ErrRecordNotFound：统一视为 NOT_FOUND
+// - 其他错误（数据库、网络等）：视为内部错误，避免原始 SQL 错误泄露给客户端
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound.Wrap(err)
+	}
+	return Internal(err)
+}
+
+// 通用错误
+var (
+	ErrInvalidRequest = New("INVALID_REQUEST", http.StatusBadRequest, "invalid request")
+	ErrUnauthorized   = New("UNAUTHORIZED", http.StatusUnauthorized, "unauthorized")
+	ErrForbidden      = New("FORBIDDEN", http.StatusForbidden, "forbidden")
+	ErrNotFound       = New("NOT_FOUND", http.StatusNotFound, "resource not found")
+	ErrConflict       = New("CONFLICT", http.StatusConflict, "conflict")
+	ErrInternal       = New("INTERNAL_ERROR", http.StatusInternalServerError, "internal server error")
+)
+
+// 认证与用户
+var (
+	ErrInvalidToken       = New("INVALID_TOKEN", http.StatusUnauthorized, "invalid token")
+	ErrInvalidCredentials = New("INVALID_CREDENTIALS", http.StatusUnauthorized, "invalid username or password")
+	ErrUserNotFound       = New("USER_NOT_FOUND", http.StatusNotFound, "user not found")
+	ErrUsernameExists     = New("USERNAME_EXISTS", http.StatusConflict, "username already exists")
+	ErrNotStaff           = New("NOT_STAFF", http.StatusForbidden, "user is not staff")
+	ErrHotelNotAssigned   = New("HOTEL_NOT_ASSIGNED", http.StatusForbidden, "hotel_id is missing")
+	ErrAdminOnly          = New("ADMIN_ONLY", http.StatusForbidden, "admin only")
+)
+
+// 酒店
+var (
+	ErrHotelNotFound = New("HOTEL_NOT_FOUND", http.StatusNotFound, "hotel not found")
+	ErrHotelInactive = New("HOTEL_INACTIVE", http.StatusConflict, "hotel is inactive")
+)
+
+// 寄存室
+var (
+	ErrStoreroomNotFound      = New("STOREROOM_NOT_FOUND", http.StatusNotFound, "storeroom not found")
+	ErrStoreroomInactive      = New("STOREROOM_INACTIVE", http.StatusConflict, "storeroom is inactive")
+	ErrStoreroomFull          = New("STOREROOM_FULL", http.StatusConflict, "storeroom is full")
+	ErrStoreroomNotEmpty      = New("STOREROOM_NOT_EMPTY", http.StatusConflict, "storeroom has luggage, cannot delete")
+	ErrStoreroomHotelMismatch = New("STOREROOM_HOTEL_MISMATCH", http.StatusForbidden, "storeroom hotel mismatch")
+	ErrStoreroomUnsuitable    = New("STOREROOM_UNSUITABLE", http.StatusConflict, "storeroom does not support the required handling")
+	ErrNoStoreroomAvailable   = New("NO_STOREROOM_AVAILABLE", http.StatusConflict, "no storeroom can take this luggage")
+)
+
+// 寄存室内位置（区域 / 货架 / 格位）
+var (
+	ErrLocationNotFound = New("LOCATION_NOT_FOUND", http.StatusNotFound, "storeroom location not found")
+	ErrLocationInactive = New("LOCATION_INACTIVE", http.StatusConflict, "storeroom location is inactive")
+	ErrLocationFull     = New("LOCATION_FULL", http.StatusConflict, "storeroom location is full")
+	ErrLocationNotEmpty = New("LOCATION_NOT_EMPTY", http.StatusConflict, "storeroom location has luggage or sub-locations, cannot delete")
+	ErrNoFreeBin        = New("NO_FREE_BIN", http.StatusConflict, "no free bin in storeroom")
+)
+
+// 行李
+var (
+	ErrLuggageNotFound      = New("LUGGAGE_NOT_FOUND", http.StatusNotFound, "luggage not found")
+	ErrLuggageNotStored     = New("LUGGAGE_NOT_STORED", http.StatusConflict, "luggage is not in stored status")
+	ErrCodeGenerationFailed = New("CODE_GENERATION_FAILED", http.StatusInternalServerError, "failed to generate unique retrieval code")
+	ErrRetrievalCodeInvalid = New("RETRIEVAL_CODE_INVALID", http.StatusBadRequest, "retrieval code is invalid, please check for typos")
+	ErrRetrievalCodeInUse   = New("RETRIEVAL_CODE_IN_USE", http.StatusConflict, "retrieval code is in use or was used recently")
+	ErrRetrievalCodeExpired = New("RETRIEVAL_CODE_EXPIRED", http.StatusGone, "retrieval code has expired, please ask the front desk to reissue it")
+	ErrCheckinPhotoRequired = New("CHECKIN_PHOTO_REQUIRED", http.StatusBadRequest, "luggage condition photos are required at check-in")
+	ErrRevisionNotFound     = New("REVISION_NOT_FOUND", http.StatusNotFound, "luggage revision not found")
+	ErrRevertNoChanges      = New("REVERT_NO_CHANGES", http.StatusConflict, "luggage already matches this revision")
+)
+
+// 取件身份核验
+var (
+	ErrVerificationRequired = New("VERIFICATION_REQUIRED", http.StatusForbidden, "identity verification required")
+	ErrVerificationFailed   = New("VERIFICATION_FAILED", http.StatusForbidden, "identity verification failed")
+	ErrOTPRateLimited       = New("OTP_RATE_LIMITED", http.StatusTooManyRequests, "verification code requested too frequently")
+	ErrNotifierUnavailable  = New("NOTIFIER_UNAVAILABLE", http.StatusServiceUnavailable, "notification channel unavailable")
+	ErrSignatureRequired    = New("SIGNATURE_REQUIRED", http.StatusBadRequest, "guest signature required")
+	ErrTooManyAttempts      = New("TOO_MANY_ATTEMPTS", http.StatusTooManyRequests, "too many failed attempts, please try again later")
+)
+
+// 代取人
+var (
+	ErrDelegateNotFound     = New("DELEGATE_NOT_FOUND", http.StatusNotFound, "pickup delegate not found")
+	ErrDelegateLimitReached = New("DELEGATE_LIMIT_REACHED", http.StatusConflict, "too many pickup delegates for this retrieval code")
+)
+
+// 连锁酒店转寄
+var (
+	ErrTransferNotFound = New("TRANSFER_NOT_FOUND", http.StatusNotFound, "no luggage in transit for this retrieval code")
+	ErrLuggageInTransit = New("LUGGAGE_IN_TRANSIT", http.StatusConflict, "luggage is being forwarded to another hotel")
+)
+
+// 失物招领
+var (
+	ErrFoundItemNotFound    = New("FOUND_ITEM_NOT_FOUND", http.StatusNotFound, "found item not found")
+	ErrFoundItemUnavailable = New("FOUND_ITEM_UNAVAILABLE", http.StatusConflict, "found item is reserved, handed over or disposed")
+	ErrRetentionNotExpired  = New("RETENTION_NOT_EXPIRED", http.StatusConflict, "found item is still within its retention period")
+	ErrClaimNotFound        = New("CLAIM_NOT_FOUND", http.StatusNotFound, "lost item claim not found")
+	ErrClaimStatusConflict  = New("CLAIM_STATUS_CONFLICT", http.StatusConflict, "lost item claim is not in the required status")
+)
+
+// 损坏 / 事故报告
+var (
+	ErrIncidentNotFound = New("INCIDENT_NOT_FOUND", http.StatusNotFound, "incident report not found")
+	ErrIncidentClosed   = New("INCIDENT_CLOSED", http.StatusConflict, "incident report is already resolved or rejected")
+)
+
+// 上传
+var (
+	ErrFileTooLarge        = New("FILE_TOO_LARGE", http.StatusRequestEntityTooLarge, "file too large")
+	ErrUnsupportedFileType = New("UNSUPPORTED_FILE_TYPE", http.StatusUnsupportedMediaType, "unsupported file type")
+	ErrInvalidSignature    = New("INVALID_SIGNATURE", http.StatusForbidden, "invalid or expired signature")
+	ErrFileNotFound        = New("FILE_NOT_FOUND", http.StatusNotFound, "file not found")
+)
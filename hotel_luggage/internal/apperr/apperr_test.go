@@ -0,0 +1,68 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestFrom(t *testing.T) {
+	dbErr := errors.New("Error 1062: Duplicate entry 'x' for key 'PRIMARY'")
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "domain error", err: ErrLuggageNotFound, wantCode: "LUGGAGE_NOT_FOUND", wantStatus: http.StatusNotFound, wantMsg: "luggage not found"},
+		{name: "with message", err: ErrStoreroomFull.WithMessage("storeroom of this revision is full"), wantCode: "STOREROOM_FULL", wantStatus: http.StatusConflict, wantMsg: "storeroom of this revision is full"},
+		// 被 fmt.Errorf 包装后仍按原业务错误返回
+		{name: "wrapped domain error", err: fmt.Errorf("retrieve: %w", ErrVerificationFailed), wantCode: "VERIFICATION_FAILED", wantStatus: http.StatusForbidden, wantMsg: ErrVerificationFailed.Message},
+		{name: "invalid request", err: InvalidRequest("code is empty"), wantCode: "INVALID_REQUEST", wantStatus: http.StatusBadRequest, wantMsg: "code is empty"},
+		{name: "record not found", err: gorm.ErrRecordNotFound, wantCode: ErrNotFound.Code, wantStatus: http.StatusNotFound, wantMsg: ErrNotFound.Message},
+		{name: "wrapped record not found", err: fmt.Errorf("find: %w", gorm.ErrRecordNotFound), wantCode: ErrNotFound.Code, wantStatus: http.StatusNotFound, wantMsg: ErrNotFound.Message},
+		// 其他错误视为内部错误，对外描述不包含原始错误
+		{name: "database error", err: dbErr, wantCode: ErrInternal.Code, wantStatus: http.StatusInternalServerError, wantMsg: ErrInternal.Message},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Code != tt.wantCode || got.Status != tt.wantStatus || got.Message != tt.wantMsg {
+				t.Fatalf("From() = %s/%d/%q, want %s/%d/%q", got.Code, got.Status, got.Message, tt.wantCode, tt.wantStatus, tt.wantMsg)
+			}
+		})
+	}
+	if From(nil) != nil {
+		t.Fatalf("From(nil) should be nil")
+	}
+	// 底层错误保留在 Err 中，只写日志
+	if got := From(dbErr); !errors.Is(got, dbErr) {
+		t.Fatalf("From() should wrap the original error")
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "same error", err: ErrLuggageNotStored, target: ErrLuggageNotStored, want: true},
+		{name: "with message", err: ErrLuggageNotStored.WithMessage("some luggage was retrieved or moved, please retry"), target: ErrLuggageNotStored, want: true},
+		{name: "wrap", err: ErrStoreroomFull.Wrap(errors.New("capacity")), target: ErrStoreroomFull, want: true},
+		{name: "different code", err: ErrStoreroomFull, target: ErrLocationFull},
+		{name: "plain error", err: errors.New("storeroom is full"), target: ErrStoreroomFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Fatalf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
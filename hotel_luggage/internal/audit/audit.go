@@ -0,0 +1,107 @@
+// Package audit 审计日志的操作人上下文和哈希链计算
+//
+// 每条审计日志的哈希 = SHA-256(上一条的哈希 + 本条内容)，第一条的上一条哈希为空字符串；
+// 修改、删除或插入任何一条记录都会使之后所有记录的哈希对不上，由 cmd/verify_audit 校验
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hotel_luggage/internal/models"
+)
+
+// SystemUsername 后台任务、命令行工具等没有登录用户的操作使用的操作人
+const SystemUsername = "system"
+
+// AnonymousUsername 公开接口（客人自助）没有登录用户时使用的操作人
+const AnonymousUsername = "anonymous"
+
+// Actor 发起写操作的人和请求信息（由中间件写入请求的 context）
+type Actor struct {
+	Username  string
+	Role      string
+	IP        string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor 把操作人写入 context
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithUser 在 context 已有的操作人信息（IP、请求ID）上补充登录用户
+func WithUser(ctx context.Context, username, role string) context.Context {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	actor.Username, actor.Role = username, role
+	return WithActor(ctx, actor)
+}
+
+// ActorFrom 读取 context 中的操作人：没有请求信息时视为后台任务（system），有请求但未登录时为 anonymous
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok {
+		return Actor{Username: SystemUsername}
+	}
+	if actor.Username == "" {
+		actor.Username = AnonymousUsername
+	}
+	return actor
+}
+
+// hashContent 参与哈希计算的字段（时间统一为 UTC 秒级，与数据库 DATETIME 精度一致）
+type hashContent struct {
+	Seq        int64  `json:"seq"`
+	Actor      string `json:"actor"`
+	ActorRole  string `json:"actor_role"`
+	HotelID    *int64 `json:"hotel_id"`
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+	Action     string `json:"action"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	IP         string `json:"ip"`
+	RequestID  string `json:"request_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Hash 计算审计日志的链式哈希（prevHash 为上一条的哈希）
+func Hash(prevHash string, entry models.AuditLog) string {
+	content, _ := json.Marshal(hashContent{
+		Seq:        entry.Seq,
+		Actor:      entry.Actor,
+		ActorRole:  entry.ActorRole,
+		HotelID:    entry.HotelID,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Action:     entry.Action,
+		Before:     entry.Before,
+		After:      entry.After,
+		IP:         entry.IP,
+		RequestID:  entry.RequestID,
+		CreatedAt:  entry.CreatedAt.UTC().Truncate(time.Second).Format(time.RFC3339),
+	})
+	sum := sha256.Sum256(append([]byte(prevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify 校验一条审计日志：序号连续、上一条哈希一致、本条哈希与内容一致
+// prevSeq / prevHash 为上一条记录的序号和哈希（第一条为 0 和空字符串）
+func Verify(prevSeq int64, prevHash string, entry models.AuditLog) error {
+	if entry.Seq != prevSeq+1 {
+		return fmt.Errorf("seq %d: expected seq %d, entries are missing or reordered", entry.Seq, prevSeq+1)
+	}
+	if entry.PrevHash != prevHash {
+		return fmt.Errorf("seq %d: prev_hash does not match the previous entry", entry.Seq)
+	}
+	if want := Hash(prevHash, entry); entry.Hash != want {
+		return fmt.Errorf("seq %d: hash mismatch, entry content has been modified", entry.Seq)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hotel_luggage/internal/models"
+)
+
+// testChain 按写入顺序生成一条合法的哈希链
+func testChain(n int) []models.AuditLog {
+	hotelID := int64(7)
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := make([]models.AuditLog, n)
+	prevHash := ""
+	for i := range entries {
+		entry := models.AuditLog{
+			Seq:        int64(i + 1),
+			Actor:      "alice",
+			ActorRole:  "staff",
+			HotelID:    &hotelID,
+			EntityType: models.AuditEntityLuggage,
+			EntityID:   "42",
+			Action:     models.AuditUpdate,
+			Before:     `{"guest_name":"A"}`,
+			After:      `{"guest_name":"B"}`,
+			IP:         "192.0.2.1",
+			RequestID:  "req-1",
+			PrevHash:   prevHash,
+			CreatedAt:  created.Add(time.Duration(i) * time.Second),
+		}
+		entry.Hash = Hash(prevHash, entry)
+		entries[i] = entry
+		prevHash = entry.Hash
+	}
+	return entries
+}
+
+// verifyChain 依次校验，返回第一条失败的错误
+func verifyChain(entries []models.AuditLog) error {
+	var prevSeq int64
+	prevHash := ""
+	for _, entry := range entries {
+		if err := Verify(prevSeq, prevHash, entry); err != nil {
+			return err
+		}
+		prevSeq, prevHash = entry.Seq, entry.Hash
+	}
+	return nil
+}
+
+func TestHash(t *testing.T) {
+	base := testChain(1)[0]
+	otherHotel := int64(8)
+	tests := []struct {
+		name     string
+		prevHash string
+		modify   func(*models.AuditLog)
+		same     bool
+	}{
+		{name: "unchanged", same: true},
+		// 数据库只保存到秒，时区不同的同一时刻哈希相同
+		{name: "sub-second and timezone", modify: func(e *models.AuditLog) {
+			e.CreatedAt = e.CreatedAt.Add(300 * time.Millisecond).In(time.FixedZone("CST", 8*3600))
+		}, same: true},
+		// 哈希不含数据库自增ID和本条哈希本身
+		{name: "row id", modify: func(e *models.AuditLog) { e.ID = 99; e.Hash = "x" }, same: true},
+		{name: "prev hash", prevHash: "abc"},
+		{name: "seq", modify: func(e *models.AuditLog) { e.Seq = 2 }},
+		{name: "actor", modify: func(e *models.AuditLog) { e.Actor = "mallory" }},
+		{name: "hotel", modify: func(e *models.AuditLog) { e.HotelID = &otherHotel }},
+		{name: "no hotel", modify: func(e *models.AuditLog) { e.HotelID = nil }},
+		{name: "after", modify: func(e *models.AuditLog) { e.After = `{"guest_name":"C"}` }},
+		{name: "created at", modify: func(e *models.AuditLog) { e.CreatedAt = e.CreatedAt.Add(time.Second) }},
+	}
+	want := Hash("", base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := base
+			if tt.modify != nil {
+				tt.modify(&entry)
+			}
+			got := Hash(tt.prevHash, entry)
+			if (got == want) != tt.same {
+				t.Fatalf("Hash() = %s, base %s, want same = %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func([]models.AuditLog) []models.AuditLog
+		wantErr string
+	}{
+		{name: "intact", tamper: func(e []models.AuditLog) []models.AuditLog { return e }},
+		{name: "modified content", tamper: func(e []models.AuditLog) []models.AuditLog {
+			e[1].After = `{"guest_name":"X"}`
+			return e
+		}, wantErr: "seq 2: hash mismatch"},
+		{name: "modified and rehashed", tamper: func(e []models.AuditLog) []models.AuditLog {
+			e[1].After = `{"guest_name":"X"}`
+			e[1].Hash = Hash(e[1].PrevHash, e[1])
+			return e
+		}, wantErr: "seq 3: prev_hash does not match"},
+		{name: "deleted entry", tamper: func(e []models.AuditLog) []models.AuditLog {
+			return append(e[:1], e[2:]...)
+		}, wantErr: "seq 3: expected seq 2"},
+		{name: "reordered", tamper: func(e []models.AuditLog) []models.AuditLog {
+			e[1], e[2] = e[2], e[1]
+			return e
+		}, wantErr: "seq 3: expected seq 2"},
+		{name: "inserted entry", tamper: func(e []models.AuditLog) []models.AuditLog {
+			forged := e[1]
+			forged.Seq = 3
+			forged.PrevHash = e[1].Hash
+			forged.Hash = Hash(forged.PrevHash, forged)
+			return append(e[:2], append([]models.AuditLog{forged}, e[2:]...)...)
+		}, wantErr: "seq 3: expected seq 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyChain(tt.tamper(testChain(4)))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verify error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("verify error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActorFrom(t *testing.T) {
+	request := WithActor(context.Background(), Actor{IP: "192.0.2.1", RequestID: "req-1"})
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want Actor
+	}{
+		{name: "background job", ctx: context.Background(), want: Actor{Username: SystemUsername}},
+		{name: "anonymous request", ctx: request, want: Actor{Username: AnonymousUsername, IP: "192.0.2.1", RequestID: "req-1"}},
+		{name: "logged in", ctx: WithUser(request, "alice", "staff"), want: Actor{Username: "alice", Role: "staff", IP: "192.0.2.1", RequestID: "req-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ActorFrom(tt.ctx); got != tt.want {
+				t.Fatalf("ActorFrom() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
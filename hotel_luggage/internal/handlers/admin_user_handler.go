@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
 	"hotel_luggage/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -14,25 +16,18 @@ import (
 func ListUsersByHotel(c *gin.Context) {
 	hotelIDStr := c.Query("hotel_id")
 	if hotelIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is required",
-		})
+		middleware.AbortWithError(c, "hotel_id is required", apperr.ErrInvalidRequest)
 		return
 	}
 	hotelID, err := strconv.ParseInt(hotelIDStr, 10, 64)
 	if err != nil || hotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid hotel_id",
-		})
+		middleware.AbortWithError(c, "invalid hotel_id", apperr.ErrInvalidRequest)
 		return
 	}
 
 	items, err := services.ListUsersByHotel(hotelID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list users failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list users failed", err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditLogs 查询审计日志（管理员，按序号倒序）
+// GET /api/admin/audit_logs?hotel_id=1&actor=&entity_type=luggage_item&entity_id=&action=&request_id=&from=&to=&limit=200
+func ListAuditLogs(c *gin.Context) {
+	query := services.AuditLogQuery{
+		Actor:      c.Query("actor"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Action:     c.Query("action"),
+		RequestID:  c.Query("request_id"),
+	}
+	if v := c.Query("hotel_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			middleware.AbortWithError(c, "invalid hotel id", apperr.ErrInvalidRequest)
+			return
+		}
+		query.HotelID = id
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			middleware.AbortWithError(c, "invalid limit", apperr.ErrInvalidRequest)
+			return
+		}
+		query.Limit = limit
+	}
+	for name, target := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				middleware.AbortWithError(c, "invalid "+name, apperr.InvalidRequest(name+" must be RFC3339"))
+				return
+			}
+			*target = &t
+		}
+	}
+
+	entries, err := services.ListAuditLogs(query)
+	if err != nil {
+		middleware.AbortWithError(c, "list audit logs failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list audit logs success",
+		"items":   entries,
+	})
+}
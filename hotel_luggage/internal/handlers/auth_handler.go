@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
 	"hotel_luggage/internal/services"
 	"hotel_luggage/utils"
 
@@ -27,28 +29,19 @@ func Login(c *gin.Context) {
 	var req LoginRequest
 	// 解析并校验 JSON 参数
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
 	user, err := services.Login(req.Username, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "login failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "login failed", err)
 		return
 	}
 
 	token, err := utils.GenerateToken(user.Username, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "token generate failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "token generate failed", err)
 		return
 	}
 
@@ -70,19 +63,13 @@ func CreateUser(c *gin.Context) {
 	var req CreateUserRequest
 	// 解析并校验 JSON 参数
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	user, err := services.CreateUser(req.Username, req.Password, "", req.HotelID)
+	user, err := services.CreateUser(c.Request.Context(), req.Username, req.Password, "", req.HotelID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "create user failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "create user failed", err)
 		return
 	}
 
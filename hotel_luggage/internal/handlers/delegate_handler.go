@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePickupDelegateRequest 前台登记代取人请求
+type CreatePickupDelegateRequest struct {
+	Name      string     `json:"name" binding:"required"` // 代取人姓名（同事、司机、导游等）
+	Phone     string     `json:"phone"`                   // 代取人电话（可选，用于 phone_last4 核验）
+	IssueCode bool       `json:"issue_code"`              // 是否签发 6 位代取码（凭代取码取件视为已核验）
+	ExpiresAt *time.Time `json:"expires_at"`              // 授权过期时间（RFC3339，可选；签发代取码时默认 checkout.delegate_code_ttl）
+}
+
+// GuestPickupDelegateRequest 客人自助登记代取人请求
+type GuestPickupDelegateRequest struct {
+	RetrievalCode string     `json:"retrieval_code" binding:"required"` // 取件码
+	PhoneLast4    string     `json:"phone_last4" binding:"required"`    // 寄存时登记的手机号后四位
+	Name          string     `json:"name" binding:"required"`           // 代取人姓名
+	Phone         string     `json:"phone"`                             // 代取人电话（可选）
+	IssueCode     bool       `json:"issue_code"`                        // 是否签发 6 位代取码
+	ExpiresAt     *time.Time `json:"expires_at"`                        // 授权过期时间（RFC3339，可选）
+}
+
+// ListPickupDelegates 获取取件码下登记的代取人
+// GET /api/luggage/:id/delegates
+func ListPickupDelegates(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	delegates, err := services.ListPickupDelegates(hotelID, c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, "list pickup delegates failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list pickup delegates success",
+		"items":   delegates,
+	})
+}
+
+// CreatePickupDelegate 前台为取件码登记代取人
+// POST /api/luggage/:id/delegates
+func CreatePickupDelegate(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	var req CreatePickupDelegateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+
+	delegate, code, err := services.CreatePickupDelegate(c.Request.Context(), hotelID, c.Param("id"), services.CreatePickupDelegateRequest{
+		Name:      req.Name,
+		Phone:     req.Phone,
+		IssueCode: req.IssueCode,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "create pickup delegate failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, delegateResponse("create pickup delegate success", delegate, code))
+}
+
+// CreateGuestPickupDelegate 客人凭取件码和手机号后四位自助登记代取人（无需登录）
+// POST /api/guest/delegates
+func CreateGuestPickupDelegate(c *gin.Context) {
+	var req GuestPickupDelegateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+
+	delegate, code, err := services.CreateGuestPickupDelegate(c.Request.Context(), req.RetrievalCode, req.PhoneLast4, services.CreatePickupDelegateRequest{
+		Name:      req.Name,
+		Phone:     req.Phone,
+		IssueCode: req.IssueCode,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "create pickup delegate failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, delegateResponse("create pickup delegate success", delegate, code))
+}
+
+// RevokePickupDelegate 撤销代取授权
+// DELETE /api/luggage/:id/delegates/:delegate_id
+func RevokePickupDelegate(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	delegateID, err := strconv.ParseInt(c.Param("delegate_id"), 10, 64)
+	if err != nil || delegateID <= 0 {
+		middleware.AbortWithError(c, "invalid delegate id", apperr.ErrInvalidRequest)
+		return
+	}
+	if err := services.RevokePickupDelegate(c.Request.Context(), hotelID, c.Param("id"), delegateID, c.GetString("username")); err != nil {
+		middleware.AbortWithError(c, "revoke pickup delegate failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "revoke pickup delegate success",
+		"delegate_id": delegateID,
+	})
+}
+
+// delegateResponse 登记成功的响应（代取码明文只在这里返回一次）
+func delegateResponse(message string, delegate models.PickupDelegate, code string) gin.H {
+	resp := gin.H{
+		"message": message,
+		"item":    delegate,
+	}
+	if code != "" {
+		resp["delegate_code"] = code
+	}
+	return resp
+}
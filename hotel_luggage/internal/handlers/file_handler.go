@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeSignedFile 下载本地存储的文件（需要签名）
+// GET /uploads/*filepath?expires=...&sig=...
+// 签名地址由接口响应中的 photo_url / photo_urls / url 提供，过期或签名不对返回 403
+// 文件已同步到 MinIO 时直接从 MinIO 读取，旧地址仍然可用
+func ServeSignedFile(store storage.BlobStore) gin.HandlerFunc {
+	verifier, _ := store.(storage.SignatureVerifier)
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("filepath"), "/")
+		expires := c.Query("expires")
+		if verifier == nil || verifier.VerifySignature(key, expires, c.Query("sig")) != nil {
+			middleware.AbortWithError(c, "download failed", apperr.ErrInvalidSignature)
+			return
+		}
+
+		rc, info, err := store.Get(c.Request.Context(), key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+				middleware.AbortWithError(c, "download failed", apperr.ErrFileNotFound)
+				return
+			}
+			middleware.AbortWithError(c, "download failed", err)
+			return
+		}
+		defer rc.Close()
+
+		// 浏览器最多缓存到签名过期为止
+		if exp, err := strconv.ParseInt(expires, 10, 64); err == nil {
+			if maxAge := exp - time.Now().Unix(); maxAge > 0 {
+				c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+			}
+		}
+		c.Header("Content-Type", info.ContentType)
+		if rs, ok := rc.(io.ReadSeeker); ok {
+			http.ServeContent(c.Writer, c.Request, info.Key, info.LastModified, rs)
+			return
+		}
+		c.DataFromReader(http.StatusOK, info.Size, info.ContentType, rc, nil)
+	}
+}
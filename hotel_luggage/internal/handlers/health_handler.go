@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"hotel_luggage/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shuttingDown 是否正在优雅退出（退出期间 readyz 返回 503，让负载均衡摘除流量）
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown 标记服务进入退出流程（收到 SIGTERM 后由 main 调用）
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// Healthz 存活探针
+// GET /healthz
+// 只要进程能处理请求就返回 200，不检查外部依赖（依赖故障不应导致容器被重启）
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Readyz 就绪探针
+// GET /readyz
+// 检查各依赖状态：
+// - MySQL：必需依赖，实时 Ping，不可用时返回 503
+// - Redis：可选依赖，不可用时服务降级为直接查询数据库（degraded）
+// - MinIO：可选依赖，不可用时上传降级为本地存储（degraded）
+// - 正在退出时返回 503
+// Redis / MinIO 的状态来自后台依赖监管（repositories.StartSupervisor），降级期间会自动重连
+// 接口无需登录，响应中只返回各依赖的状态，具体错误只写日志（Redis / MinIO 的错误由依赖监管在状态变化时记录）
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	dbStatus := gin.H{"status": "up"}
+	if err := repositories.PingDB(ctx); err != nil {
+		log.Printf("❌ readyz: MySQL 不可用: %v", err)
+		dbStatus = gin.H{"status": "down"}
+	}
+
+	states := make(map[string]repositories.DependencyState)
+	for _, state := range repositories.DependencyStates() {
+		states[state.Name] = state
+	}
+	redisStatus, redisDegraded := dependencyStatus(states, "redis", "cache disabled, reading from database")
+	minioStatus, storageDegraded := dependencyStatus(states, "minio", "uploads stored on local disk")
+
+	ready := dbStatus["status"] == "up" && !shuttingDown.Load()
+	status := "ready"
+	if !ready {
+		status = "not_ready"
+	} else if redisDegraded || storageDegraded {
+		status = "degraded"
+	}
+
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":        status,
+		"shutting_down": shuttingDown.Load(),
+		"degraded": gin.H{
+			"redis":         redisDegraded,
+			"local_storage": storageDegraded,
+		},
+		"dependencies": gin.H{
+			"mysql": dbStatus,
+			"redis": redisStatus,
+			"minio": minioStatus,
+		},
+	})
+}
+
+// dependencyStatus 把受监管依赖的状态转换为响应结构（不含错误详情），第二个返回值表示是否处于降级模式
+func dependencyStatus(states map[string]repositories.DependencyState, name, fallback string) (gin.H, bool) {
+	state, ok := states[name]
+	if !ok {
+		return gin.H{"status": "down", "fallback": fallback}, true
+	}
+	if state.Up {
+		return gin.H{"status": "up"}, false
+	}
+	return gin.H{"status": "down", "fallback": fallback}, true
+}
@@ -2,36 +2,54 @@ package handlers
 
 import (
 	"net/http"
+	"sort"
+
+	"hotel_luggage/internal/apidoc"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Home 返回系统功能入口（给前端提供基础导航信息）
 // GET /home
-func Home(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "hotel luggage system api",
-		"endpoints": []gin.H{
-			{"name": "健康检查", "method": "GET", "path": "/ping"},
-			{"name": "登录", "method": "POST", "path": "/login"},
-			{"name": "创建用户", "method": "POST", "path": "/users"},
-			{"name": "行李寄存", "method": "POST", "path": "/storage"},
-			{"name": "取件", "method": "POST", "path": "/storage/retrieve"},
-			{"name": "寄存查询（姓名/手机号）", "method": "GET", "path": "/storage/search"},
-			{"name": "寄存查询（取件码）", "method": "GET", "path": "/storage/by-code"},
-			{"name": "寄存单列表（用户）", "method": "GET", "path": "/storage/list"},
-			{"name": "寄存单列表（客人）", "method": "GET", "path": "/storage/list/by-guest"},
-			{"name": "寄存单详情（ID）", "method": "GET", "path": "/storage/detail"},
-			{"name": "寄存单详情（取件码）", "method": "GET", "path": "/storage/detail/by-code"},
-			{"name": "寄存单详情（手机号）", "method": "GET", "path": "/storage/detail/by-phone"},
-			{"name": "取件码列表（用户）", "method": "GET", "path": "/pickup-codes"},
-			{"name": "取件码列表（手机号）", "method": "GET", "path": "/pickup-codes/by-phone"},
-			{"name": "寄存室列表", "method": "GET", "path": "/storerooms"},
-		{"name": "创建寄存室", "method": "POST", "path": "/storerooms"},
-		{"name": "删除寄存室", "method": "DELETE", "path": "/storerooms/:id"},
-		{"name": "寄存室状态更新", "method": "PUT", "path": "/storerooms/:id/status"},
-		{"name": "寄存信息修改（含寄存室迁移）", "method": "PUT", "path": "/luggage/:id"},
-		{"name": "二维码展示", "method": "GET", "path": "/qr/:code"},
-		},
-	})
+// 说明：接口清单直接读取实际注册的路由（routes），再补充文档中的说明，
+// 因此不会再出现“清单里有、实际不存在”的接口
+func Home(routes func() gin.RoutesInfo, docs []apidoc.Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		registered := routes()
+		endpoints := make([]gin.H, 0, len(registered))
+		for _, r := range registered {
+			if r.Method == http.MethodOptions || r.Method == http.MethodHead {
+				continue
+			}
+			entry := gin.H{"method": r.Method, "path": r.Path}
+			if doc, ok := apidoc.Lookup(docs, r.Method, r.Path); ok {
+				entry["name"] = doc.Summary
+				entry["auth"] = doc.Auth
+			}
+			endpoints = append(endpoints, entry)
+		}
+		sort.Slice(endpoints, func(i, j int) bool {
+			pi, pj := endpoints[i]["path"].(string), endpoints[j]["path"].(string)
+			if pi != pj {
+				return pi < pj
+			}
+			return endpoints[i]["method"].(string) < endpoints[j]["method"].(string)
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "hotel luggage system api",
+			"openapi":   "/api/openapi.json",
+			"endpoints": endpoints,
+		})
+	}
+}
+
+// OpenAPISpec 返回 OpenAPI 3 文档
+// GET /api/openapi.json
+// 文档在启动时根据路由表与请求结构体生成一次，之后直接复用
+func OpenAPISpec(docs []apidoc.Route) gin.HandlerFunc {
+	spec := apidoc.Build(apidoc.Info{Title: "Hotel Luggage API", Version: "1.0.0"}, docs)
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	}
 }
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
 	"hotel_luggage/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -29,10 +31,7 @@ type UpdateHotelRequest struct {
 func ListHotels(c *gin.Context) {
 	items, err := services.ListHotels()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list hotels failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list hotels failed", err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -45,19 +44,13 @@ func ListHotels(c *gin.Context) {
 func CreateHotel(c *gin.Context) {
 	var req CreateHotelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	hotel, err := services.CreateHotel(req.Name, req.Address, req.Phone, req.IsActive)
+	hotel, err := services.CreateHotel(c.Request.Context(), req.Name, req.Address, req.Phone, req.IsActive)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "create hotel failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "create hotel failed", err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -70,26 +63,18 @@ func CreateHotel(c *gin.Context) {
 func UpdateHotel(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid hotel id",
-		})
+		middleware.AbortWithError(c, "invalid hotel id", apperr.ErrInvalidRequest)
 		return
 	}
 
 	var req UpdateHotelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	if err := services.UpdateHotel(id, req.Name, req.Address, req.Phone, req.IsActive); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "update hotel failed",
-			"error":   err.Error(),
-		})
+	if err := services.UpdateHotel(c.Request.Context(), id, req.Name, req.Address, req.Phone, req.IsActive); err != nil {
+		middleware.AbortWithError(c, "update hotel failed", err)
 		return
 	}
 
@@ -102,17 +87,12 @@ func UpdateHotel(c *gin.Context) {
 func DeleteHotel(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid hotel id",
-		})
+		middleware.AbortWithError(c, "invalid hotel id", apperr.ErrInvalidRequest)
 		return
 	}
 
-	if err := services.DeleteHotel(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "delete hotel failed",
-			"error":   err.Error(),
-		})
+	if err := services.DeleteHotel(c.Request.Context(), id); err != nil {
+		middleware.AbortWithError(c, "delete hotel failed", err)
 		return
 	}
 
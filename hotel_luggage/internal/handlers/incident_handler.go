@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateIncidentRequest 登记行李损坏 / 事故报告请求（luggage_id 与 history_id 二选一）
+type CreateIncidentRequest struct {
+	LuggageID         int64    `json:"luggage_id"`                     // 在存的寄存单ID
+	HistoryID         int64    `json:"history_id"`                     // 已取件的取件历史ID
+	Type              string   `json:"type" binding:"required"`        // damage 损坏 / missing 缺失 / wrong_pickup 错拿 / other 其他
+	Description       string   `json:"description" binding:"required"` // 事故描述
+	CheckinPhotoURLs  []string `json:"checkin_photo_urls"`             // 寄存时的状态照片（不传时使用寄存单上的照片）
+	CheckoutPhotoURLs []string `json:"checkout_photo_urls"`            // 取件 / 发现问题时拍的照片（上传接口返回的地址）
+	StaffInvolved     []string `json:"staff_involved"`                 // 其他经手员工用户名（存放和取件操作员自动加入）
+}
+
+// UpdateIncidentRequest 修改事故报告请求（只修改传入的字段）
+type UpdateIncidentRequest struct {
+	Type              *string   `json:"type"`
+	Description       *string   `json:"description"`
+	CheckinPhotoURLs  *[]string `json:"checkin_photo_urls"`
+	CheckoutPhotoURLs *[]string `json:"checkout_photo_urls"`
+	StaffInvolved     *[]string `json:"staff_involved"` // 替换经手员工列表
+	Status            *string   `json:"status"`         // investigating 调查中 / resolved 已解决 / rejected 不成立
+	Resolution        *string   `json:"resolution"`     // 处理结果（结案时必填）
+}
+
+// CreateIncident 登记行李损坏 / 事故报告
+// POST /api/luggage/incidents
+func CreateIncident(c *gin.Context) {
+	var req CreateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	incident, err := services.CreateIncident(c.Request.Context(), services.CreateIncidentRequest{
+		HotelID:           hotelID,
+		LuggageID:         req.LuggageID,
+		HistoryID:         req.HistoryID,
+		Type:              req.Type,
+		Description:       req.Description,
+		CheckinPhotoURLs:  req.CheckinPhotoURLs,
+		CheckoutPhotoURLs: req.CheckoutPhotoURLs,
+		StaffInvolved:     req.StaffInvolved,
+		ReportedBy:        c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "create incident failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "create incident success",
+		"item":    signedIncident(c, incident),
+	})
+}
+
+// ListIncidents 查询本酒店的事故报告
+// GET /api/luggage/incidents?status=open&type=damage&luggage_id=&history_id=&code=&staff=&from=&to=
+func ListIncidents(c *gin.Context) {
+	query, ok := incidentQuery(c)
+	if !ok {
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	query.HotelID = hotelID
+
+	incidents, err := services.ListIncidents(query)
+	if err != nil {
+		middleware.AbortWithError(c, "list incidents failed", err)
+		return
+	}
+	services.SignIncidentPhotos(c.Request.Context(), incidents)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list incidents success",
+		"items":   incidents,
+	})
+}
+
+// AdminListIncidents 查询所有酒店的事故报告（管理员，hotel_id 为空表示全部酒店）
+// GET /api/admin/incidents?hotel_id=1&status=open&type=damage&staff=&from=&to=
+func AdminListIncidents(c *gin.Context) {
+	query, ok := incidentQuery(c)
+	if !ok {
+		return
+	}
+	if v := c.Query("hotel_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			middleware.AbortWithError(c, "invalid hotel id", apperr.ErrInvalidRequest)
+			return
+		}
+		query.HotelID = id
+	}
+
+	incidents, err := services.ListIncidents(query)
+	if err != nil {
+		middleware.AbortWithError(c, "list incidents failed", err)
+		return
+	}
+	services.SignIncidentPhotos(c.Request.Context(), incidents)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list incidents success",
+		"items":   incidents,
+	})
+}
+
+// GetIncident 获取事故报告详情
+// GET /api/luggage/incidents/:id
+func GetIncident(c *gin.Context) {
+	id, ok := incidentIDParam(c)
+	if !ok {
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	incident, err := services.GetIncident(hotelID, id)
+	if err != nil {
+		middleware.AbortWithError(c, "get incident failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "get incident success",
+		"item":    signedIncident(c, incident),
+	})
+}
+
+// UpdateIncident 修改事故报告（补充照片、经手员工，更新处理状态或结案）
+// PUT /api/luggage/incidents/:id
+func UpdateIncident(c *gin.Context) {
+	id, ok := incidentIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	incident, err := services.UpdateIncident(c.Request.Context(), hotelID, id, services.UpdateIncidentRequest{
+		Type:              req.Type,
+		Description:       req.Description,
+		CheckinPhotoURLs:  req.CheckinPhotoURLs,
+		CheckoutPhotoURLs: req.CheckoutPhotoURLs,
+		StaffInvolved:     req.StaffInvolved,
+		Status:            req.Status,
+		Resolution:        req.Resolution,
+		UpdatedBy:         c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "update incident failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "update incident success",
+		"item":    signedIncident(c, incident),
+	})
+}
+
+// incidentQuery 解析事故报告的查询条件（from / to 为 RFC3339 时间）
+// 失败时已写入错误，调用方直接 return 即可
+func incidentQuery(c *gin.Context) (services.IncidentQuery, bool) {
+	query := services.IncidentQuery{
+		Status:        c.Query("status"),
+		Type:          c.Query("type"),
+		RetrievalCode: c.Query("code"),
+		Staff:         c.Query("staff"),
+	}
+	for name, target := range map[string]*int64{"luggage_id": &query.LuggageID, "history_id": &query.HistoryID} {
+		if v := c.Query(name); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id <= 0 {
+				middleware.AbortWithError(c, "invalid "+name, apperr.ErrInvalidRequest)
+				return services.IncidentQuery{}, false
+			}
+			*target = id
+		}
+	}
+	for name, target := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				middleware.AbortWithError(c, "invalid "+name, apperr.InvalidRequest(name+" must be RFC3339"))
+				return services.IncidentQuery{}, false
+			}
+			*target = &t
+		}
+	}
+	return query, true
+}
+
+// incidentIDParam 解析路径中的事故报告ID
+func incidentIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid incident id", apperr.ErrInvalidRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// signedIncident 返回照片替换为签名地址的事故报告
+func signedIncident(c *gin.Context, incident models.LuggageIncident) models.LuggageIncident {
+	incidents := []models.LuggageIncident{incident}
+	services.SignIncidentPhotos(c.Request.Context(), incidents)
+	return incidents[0]
+}
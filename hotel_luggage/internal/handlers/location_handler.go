@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateStoreroomLocationRequest 创建寄存室内位置请求
+type CreateStoreroomLocationRequest struct {
+	ParentID *int64 `json:"parent_id"`               // 上级位置ID（不传表示直接挂在寄存室下）
+	Kind     string `json:"kind" binding:"required"` // zone 区域 / shelf 货架 / bin 格位
+	Name     string `json:"name" binding:"required"` // 名称（如 A区、3号架、2格）
+	Capacity int    `json:"capacity"`                // 容量（0 表示不限制）
+	IsActive *bool  `json:"is_active"`               // 是否启用（默认启用）
+}
+
+// UpdateStoreroomLocationRequest 修改寄存室内位置请求（只修改传入的字段）
+type UpdateStoreroomLocationRequest struct {
+	Name     *string `json:"name"`
+	Capacity *int    `json:"capacity"`  // 容量（0 表示不限制）
+	IsActive *bool   `json:"is_active"` // 停用后不再分配新行李
+}
+
+// ListStoreroomLocations 获取寄存室内的区域 / 货架 / 格位
+// GET /api/luggage/storerooms/:id/locations
+func ListStoreroomLocations(c *gin.Context) {
+	storeroomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || storeroomID <= 0 {
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	locations, err := services.ListStoreroomLocations(hotelID, storeroomID)
+	if err != nil {
+		middleware.AbortWithError(c, "list storeroom locations failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list storeroom locations success",
+		"items":   locations,
+	})
+}
+
+// CreateStoreroomLocation 在寄存室内创建区域 / 货架 / 格位
+// POST /api/luggage/storerooms/:id/locations
+func CreateStoreroomLocation(c *gin.Context) {
+	storeroomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || storeroomID <= 0 {
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+		return
+	}
+	var req CreateStoreroomLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	location, err := services.CreateStoreroomLocation(c.Request.Context(), hotelID, storeroomID, services.CreateStoreroomLocationRequest{
+		ParentID: req.ParentID,
+		Kind:     req.Kind,
+		Name:     req.Name,
+		Capacity: req.Capacity,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "create storeroom location failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "create storeroom location success",
+		"item":    location,
+	})
+}
+
+// UpdateStoreroomLocation 修改位置名称、容量、启用状态
+// PUT /api/luggage/locations/:id
+func UpdateStoreroomLocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid location id", apperr.ErrInvalidRequest)
+		return
+	}
+	var req UpdateStoreroomLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	location, err := services.UpdateStoreroomLocation(c.Request.Context(), hotelID, id, services.UpdateStoreroomLocationRequest{
+		Name:     req.Name,
+		Capacity: req.Capacity,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "update storeroom location failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "update storeroom location success",
+		"item":    location,
+	})
+}
+
+// DeleteStoreroomLocation 删除位置（有下级位置或在存行李时不能删）
+// DELETE /api/luggage/locations/:id
+func DeleteStoreroomLocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid location id", apperr.ErrInvalidRequest)
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteStoreroomLocation(c.Request.Context(), hotelID, id); err != nil {
+		middleware.AbortWithError(c, "delete storeroom location failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "delete storeroom location success",
+		"location_id": id,
+	})
+}
+
+// SuggestBin 推荐寄存室内的空闲格位（寄存前展示给员工，寄存时不传 bin_id 也会自动分配同一个格位）
+// GET /api/luggage/storerooms/:id/bins/suggest?quantity=1&size_class=large
+func SuggestBin(c *gin.Context) {
+	storeroomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || storeroomID <= 0 {
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+		return
+	}
+	quantity := 1
+	if q := c.Query("quantity"); q != "" {
+		quantity, err = strconv.Atoi(q)
+		if err != nil || quantity <= 0 {
+			middleware.AbortWithError(c, "invalid quantity", apperr.ErrInvalidRequest)
+			return
+		}
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	bin, err := services.SuggestBin(hotelID, storeroomID, quantity, c.Query("size_class"))
+	if err != nil {
+		middleware.AbortWithError(c, "suggest bin failed", err)
+		return
+	}
+	// 寄存室未划分格位时 item 为 null
+	c.JSON(http.StatusOK, gin.H{
+		"message": "suggest bin success",
+		"item":    bin,
+	})
+}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFoundItemRequest 登记拾获物品请求
+type CreateFoundItemRequest struct {
+	StoreroomID   int64      `json:"storeroom_id" binding:"required"`   // 保管的寄存室ID（计入寄存室容量）
+	Category      string     `json:"category"`                          // 类别（如 电子产品、证件、衣物）
+	Description   string     `json:"description" binding:"required"`    // 物品描述（用于搜索和认领匹配）
+	FoundLocation string     `json:"found_location" binding:"required"` // 拾获地点
+	FoundAt       *time.Time `json:"found_at"`                          // 拾获时间（RFC3339，可选，默认现在）
+	FoundBy       string     `json:"found_by"`                          // 拾获人（员工或客人姓名）
+	Quantity      int        `json:"quantity"`                          // 件数（默认 1）
+	SizeClass     string     `json:"size_class"`                        // 尺寸：small / medium / large / oversized
+	PhotoURL      string     `json:"photo_url"`                         // 主照片（上传接口返回的地址）
+	PhotoURLs     []string   `json:"photo_urls"`                        // 多张照片
+	Notes         string     `json:"notes"`                             // 备注
+}
+
+// UpdateFoundItemRequest 修改拾获物品请求（只修改传入的字段）
+type UpdateFoundItemRequest struct {
+	StoreroomID   *int64    `json:"storeroom_id"` // 移到其他寄存室（校验容量）
+	Category      *string   `json:"category"`
+	Description   *string   `json:"description"`
+	FoundLocation *string   `json:"found_location"`
+	FoundBy       *string   `json:"found_by"`
+	Quantity      *int      `json:"quantity"`
+	SizeClass     *string   `json:"size_class"`
+	PhotoURL      *string   `json:"photo_url"`
+	PhotoURLs     *[]string `json:"photo_urls"`
+	Notes         *string   `json:"notes"`
+}
+
+// DisposeFoundItemRequest 处置拾获物品请求
+type DisposeFoundItemRequest struct {
+	Method string `json:"method" binding:"required"` // discarded 丢弃 / donated 捐赠 / police 移交警方 / destroyed 销毁
+}
+
+// CreateLostItemClaimRequest 登记失物认领请求
+type CreateLostItemClaimRequest struct {
+	GuestName    string     `json:"guest_name" binding:"required"`  // 失主姓名
+	ContactPhone string     `json:"contact_phone"`                  // 联系电话
+	ContactEmail string     `json:"contact_email"`                  // 联系邮箱
+	Category     string     `json:"category"`                       // 类别
+	Description  string     `json:"description" binding:"required"` // 丢失物品的描述（用于匹配）
+	LostLocation string     `json:"lost_location"`                  // 可能丢失的地点
+	LostAt       *time.Time `json:"lost_at"`                        // 大约丢失时间（RFC3339，可选）
+}
+
+// VerifyLostItemClaimRequest 核验失主身份请求
+type VerifyLostItemClaimRequest struct {
+	FoundItemID    int64  `json:"found_item_id" binding:"required"`   // 认领的拾获物品ID
+	DocumentType   string `json:"document_type" binding:"required"`   // 证件类型（例如 id_card / passport）
+	DocumentLast4  string `json:"document_last4"`                     // 证件号后四位（可选，仅用于留档）
+	OwnershipProof string `json:"ownership_proof" binding:"required"` // 失主说出的、未公开的物品特征
+}
+
+// HandOverLostItemRequest 交还拾获物品请求
+type HandOverLostItemRequest struct {
+	CollectedBy string `json:"collected_by"` // 实际领取人姓名（可选，默认失主本人）
+}
+
+// CloseLostItemClaimRequest 关闭认领请求
+type CloseLostItemClaimRequest struct {
+	Reason string `json:"reason"` // 关闭原因（如 未找到、客人已找到）
+}
+
+// CreateFoundItem 登记拾获物品
+// POST /api/lost_found/items
+func CreateFoundItem(c *gin.Context) {
+	var req CreateFoundItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	item, err := services.CreateFoundItem(c.Request.Context(), services.CreateFoundItemRequest{
+		HotelID:       hotelID,
+		StoreroomID:   req.StoreroomID,
+		Category:      req.Category,
+		Description:   req.Description,
+		FoundLocation: req.FoundLocation,
+		FoundAt:       req.FoundAt,
+		FoundBy:       req.FoundBy,
+		Quantity:      req.Quantity,
+		SizeClass:     req.SizeClass,
+		PhotoURL:      req.PhotoURL,
+		PhotoURLs:     req.PhotoURLs,
+		Notes:         req.Notes,
+		CreatedBy:     c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "create found item failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "create found item success",
+		"item":    signedFoundItem(c, item),
+	})
+}
+
+// SearchFoundItems 按描述关键字搜索拾获物品
+// GET /api/lost_found/items?q=黑色 雨伞&status=held&category=&storeroom_id=&overdue=true
+func SearchFoundItems(c *gin.Context) {
+	query := services.FoundItemQuery{
+		Q:        c.Query("q"),
+		Status:   c.Query("status"),
+		Category: c.Query("category"),
+	}
+	if v := c.Query("storeroom_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+			return
+		}
+		query.StoreroomID = id
+	}
+	if v := c.Query("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			middleware.AbortWithError(c, "invalid overdue", apperr.ErrInvalidRequest)
+			return
+		}
+		query.Overdue = overdue
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	items, err := services.SearchFoundItems(hotelID, query)
+	if err != nil {
+		middleware.AbortWithError(c, "search found items failed", err)
+		return
+	}
+	services.SignFoundItemPhotos(c.Request.Context(), items)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "search found items success",
+		"items":   items,
+	})
+}
+
+// GetFoundItem 获取拾获物品详情
+// GET /api/lost_found/items/:id
+func GetFoundItem(c *gin.Context) {
+	id, ok := foundItemIDParam(c)
+	if !ok {
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	item, err := services.GetFoundItem(hotelID, id)
+	if err != nil {
+		middleware.AbortWithError(c, "get found item failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "get found item success",
+		"item":    signedFoundItem(c, item),
+	})
+}
+
+// UpdateFoundItem 修改拾获物品（描述、照片、寄存室等，保管中或等待交还时可修改）
+// PUT /api/lost_found/items/:id
+func UpdateFoundItem(c *gin.Context) {
+	id, ok := foundItemIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateFoundItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	item, err := services.UpdateFoundItem(c.Request.Context(), hotelID, id, services.UpdateFoundItemRequest{
+		StoreroomID:   req.StoreroomID,
+		Category:      req.Category,
+		Description:   req.Description,
+		FoundLocation: req.FoundLocation,
+		FoundBy:       req.FoundBy,
+		Quantity:      req.Quantity,
+		SizeClass:     req.SizeClass,
+		PhotoURL:      req.PhotoURL,
+		PhotoURLs:     req.PhotoURLs,
+		Notes:         req.Notes,
+		UpdatedBy:     c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "update found item failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "update found item success",
+		"item":    signedFoundItem(c, item),
+	})
+}
+
+// ListFoundItemHistory 获取拾获物品的修改记录
+// GET /api/lost_found/items/:id/history
+func ListFoundItemHistory(c *gin.Context) {
+	id, ok := foundItemIDParam(c)
+	if !ok {
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	records, err := services.ListFoundItemHistory(hotelID, id)
+	if err != nil {
+		middleware.AbortWithError(c, "list found item history failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list found item history success",
+		"items":   records,
+	})
+}
+
+// DisposeFoundItem 处置超过保管期限的拾获物品
+// POST /api/lost_found/items/:id/dispose
+func DisposeFoundItem(c *gin.Context) {
+	id, ok := foundItemIDParam(c)
+	if !ok {
+		return
+	}
+	var req DisposeFoundItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	item, err := services.DisposeFoundItem(c.Request.Context(), hotelID, id, req.Method, c.GetString("username"))
+	if err != nil {
+		middleware.AbortWithError(c, "dispose found item failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dispose found item success",
+		"item":    signedFoundItem(c, item),
+	})
+}
+
+// CreateLostItemClaim 登记客人报失 / 认领
+// POST /api/lost_found/claims
+func CreateLostItemClaim(c *gin.Context) {
+	var req CreateLostItemClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := services.CreateLostItemClaim(c.Request.Context(), services.CreateLostItemClaimRequest{
+		HotelID:      hotelID,
+		GuestName:    req.GuestName,
+		ContactPhone: req.ContactPhone,
+		ContactEmail: req.ContactEmail,
+		Category:     req.Category,
+		Description:  req.Description,
+		LostLocation: req.LostLocation,
+		LostAt:       req.LostAt,
+		CreatedBy:    c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "create lost item claim failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "create lost item claim success",
+		"item":    claim,
+	})
+}
+
+// ListLostItemClaims 获取本酒店的失物认领
+// GET /api/lost_found/claims?status=open
+func ListLostItemClaims(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	claims, err := services.ListLostItemClaims(hotelID, c.Query("status"))
+	if err != nil {
+		middleware.AbortWithError(c, "list lost item claims failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list lost item claims success",
+		"items":   claims,
+	})
+}
+
+// GetLostItemClaim 获取失物认领详情
+// GET /api/lost_found/claims/:id
+func GetLostItemClaim(c *gin.Context) {
+	id, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := services.GetLostItemClaim(hotelID, id)
+	if err != nil {
+		middleware.AbortWithError(c, "get lost item claim failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "get lost item claim success",
+		"item":    claim,
+	})
+}
+
+// MatchLostItemClaim 为认领查找可能匹配的拾获物品（按匹配得分排序）
+// GET /api/lost_found/claims/:id/matches
+func MatchLostItemClaim(c *gin.Context) {
+	id, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	matches, err := services.MatchLostItemClaim(hotelID, id)
+	if err != nil {
+		middleware.AbortWithError(c, "match lost item claim failed", err)
+		return
+	}
+	for i := range matches {
+		matches[i].Item = signedFoundItem(c, matches[i].Item)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "match lost item claim success",
+		"items":   matches,
+	})
+}
+
+// VerifyLostItemClaim 核验失主身份并预留拾获物品
+// POST /api/lost_found/claims/:id/verify
+func VerifyLostItemClaim(c *gin.Context) {
+	id, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	var req VerifyLostItemClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := services.VerifyLostItemClaim(c.Request.Context(), hotelID, id, services.ClaimVerification{
+		FoundItemID:    req.FoundItemID,
+		DocumentType:   req.DocumentType,
+		DocumentLast4:  req.DocumentLast4,
+		OwnershipProof: req.OwnershipProof,
+		VerifiedBy:     c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "verify lost item claim failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "verify lost item claim success",
+		"item":    claim,
+	})
+}
+
+// HandOverLostItem 把核验通过的拾获物品交还失主
+// POST /api/lost_found/claims/:id/handover
+func HandOverLostItem(c *gin.Context) {
+	id, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	// 请求体可选：不传表示失主本人领取
+	var req HandOverLostItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := services.HandOverLostItem(c.Request.Context(), hotelID, id, req.CollectedBy, c.GetString("username"))
+	if err != nil {
+		middleware.AbortWithError(c, "hand over lost item failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "hand over lost item success",
+		"item":    claim,
+	})
+}
+
+// CloseLostItemClaim 关闭认领（已核验的认领关闭后物品恢复为保管中）
+// POST /api/lost_found/claims/:id/close
+func CloseLostItemClaim(c *gin.Context) {
+	id, ok := claimIDParam(c)
+	if !ok {
+		return
+	}
+	var req CloseLostItemClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := services.CloseLostItemClaim(c.Request.Context(), hotelID, id, req.Reason, c.GetString("username"))
+	if err != nil {
+		middleware.AbortWithError(c, "close lost item claim failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "close lost item claim success",
+		"item":    claim,
+	})
+}
+
+// foundItemIDParam 解析路径中的拾获物品ID
+func foundItemIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid found item id", apperr.ErrInvalidRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// claimIDParam 解析路径中的认领ID
+func claimIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid claim id", apperr.ErrInvalidRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// signedFoundItem 返回照片替换为签名地址的拾获物品
+func signedFoundItem(c *gin.Context, item models.FoundItem) models.FoundItem {
+	items := []models.FoundItem{item}
+	services.SignFoundItemPhotos(c.Request.Context(), items)
+	return items[0]
+}
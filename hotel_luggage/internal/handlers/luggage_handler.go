@@ -1,51 +1,106 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
 	"hotel_luggage/configs"
-	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/imaging"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/services"
+	"hotel_luggage/internal/storage"
 	"hotel_luggage/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/minio/minio-go/v7"
 )
 
 // CreateLuggageRequest 行李寄存请求结构体
 type CreateLuggageRequest struct {
-	GuestName    string                     `json:"guest_name" binding:"required"` // 客人用户名
-	ContactPhone string                     `json:"contact_phone"`                 // 联系电话
-	ContactEmail string                     `json:"contact_email"`                 // 联系邮箱
-	Description  string                     `json:"description"`                   // 行李描述
-	Quantity     int                        `json:"quantity"`                      // 行李数量
-	SpecialNotes string                     `json:"special_notes"`                 // 特殊备注
-	PhotoURL     string                     `json:"photo_url"`                     // 照片URL（可选）
-	PhotoURLs    []string                   `json:"photo_urls"`                    // 多张照片URL（可选）
-	StoreroomID  int64                      `json:"storeroom_id"`                  // 寄存室ID（单件模式必填）
-	StaffName    string                     `json:"staff_name"`                    // 操作员用户名（可选，不传则用登录账号）
-	QRCodeURL    string                     `json:"qr_code_url"`                   // 二维码URL（可选）
-	Items        []CreateLuggageItemRequest `json:"items"`                         // 多件行李（可选）
+	GuestName        string                     `json:"guest_name" binding:"required"` // 客人用户名
+	ContactPhone     string                     `json:"contact_phone"`                 // 联系电话
+	ContactEmail     string                     `json:"contact_email"`                 // 联系邮箱
+	Description      string                     `json:"description"`                   // 行李描述
+	Quantity         int                        `json:"quantity"`                      // 行李数量
+	SizeClass        string                     `json:"size_class"`                    // 尺寸：small / medium / large / oversized（可选，按寄存室的尺寸权重计算容量）
+	SpecialNotes     string                     `json:"special_notes"`                 // 特殊备注
+	PhotoURL         string                     `json:"photo_url"`                     // 照片URL（可选）
+	PhotoURLs        []string                   `json:"photo_urls"`                    // 多张照片URL（可选）
+	StoreroomID      StoreroomRef               `json:"storeroom_id"`                  // 寄存室ID（单件模式必填），传 "auto" 按分配规则自动选择
+	BinID            *int64                     `json:"bin_id"`                        // 格位ID（可选，不传时自动分配寄存室内第一个可用格位）
+	Handling         []string                   `json:"handling"`                      // 特殊保管要求（可选）：fragile / valuables / refrigerated
+	ExpectedPickupAt *time.Time                 `json:"expected_pickup_at"`            // 预计取件时间（可选，自动分配时区分短时 / 长时寄存室）
+	StaffName        string                     `json:"staff_name"`                    // 操作员用户名（可选，不传则用登录账号）
+	QRCodeURL        string                     `json:"qr_code_url"`                   // 二维码URL（可选）
+	Items            []CreateLuggageItemRequest `json:"items"`                         // 多件行李（可选）
 }
 
 // CreateLuggageItemRequest 单件行李（用于多件寄存）
 type CreateLuggageItemRequest struct {
-	StoreroomID  int64    `json:"storeroom_id" binding:"required"`
-	Description  string   `json:"description"`
-	Quantity     int      `json:"quantity"`
-	SpecialNotes string   `json:"special_notes"`
-	PhotoURL     string   `json:"photo_url"`
-	PhotoURLs    []string `json:"photo_urls"`
+	StoreroomID  StoreroomRef `json:"storeroom_id"` // 寄存室ID（必填），传 "auto" 按分配规则自动选择
+	BinID        *int64       `json:"bin_id"`
+	Description  string       `json:"description"`
+	Quantity     int          `json:"quantity"`
+	SizeClass    string       `json:"size_class"`
+	Handling     []string     `json:"handling"`
+	SpecialNotes string       `json:"special_notes"`
+	PhotoURL     string       `json:"photo_url"`
+	PhotoURLs    []string     `json:"photo_urls"`
+}
+
+// StoreroomRef 寄存时指定的寄存室：寄存室ID，或字符串 "auto" 表示按酒店的分配规则自动选择
+type StoreroomRef struct {
+	ID   int64
+	Auto bool
+}
+
+// storeroomAuto 自动分配寄存室时 storeroom_id 传的值
+const storeroomAuto = "auto"
+
+// UnmarshalJSON 支持数字ID和 "auto"
+func (r *StoreroomRef) UnmarshalJSON(data []byte) error {
+	*r = StoreroomRef{}
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != storeroomAuto {
+			return fmt.Errorf("storeroom_id must be a number or %q", storeroomAuto)
+		}
+		r.Auto = true
+		return nil
+	}
+	if err := json.Unmarshal(data, &r.ID); err != nil {
+		return fmt.Errorf("storeroom_id must be a number or %q", storeroomAuto)
+	}
+	return nil
+}
+
+// OpenAPISchema 寄存室ID（整数）或 "auto"
+func (StoreroomRef) OpenAPISchema() map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer", "format": "int64"},
+			map[string]interface{}{"type": "string", "enum": []string{storeroomAuto}},
+		},
+	}
+}
+
+// empty 是否未指定寄存室
+func (r StoreroomRef) empty() bool {
+	return r.ID == 0 && !r.Auto
 }
 
 // CreateLuggage 处理行李寄存请求
@@ -53,10 +108,7 @@ func CreateLuggage(c *gin.Context) {
 	var req CreateLuggageRequest
 	// 解析并校验 JSON 参数
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 	username, _ := c.Get("username")
@@ -65,116 +117,116 @@ func CreateLuggage(c *gin.Context) {
 		req.StaffName = userNameStr
 	}
 	if req.StaffName == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+		middleware.AbortWithError(c, "missing user info", apperr.ErrUnauthorized)
 		return
 	}
 	if len(req.Items) > 0 {
-		sharedCode := ""
-		for i := 0; i < 5; i++ {
-			codeCandidate, err := utils.GenerateCode(6)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"message": "generate retrieval code failed",
-					"error":   err.Error(),
-				})
+		for _, it := range req.Items {
+			if it.StoreroomID.empty() {
+				middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest("storeroom_id is required for each item"))
 				return
 			}
-			exists, err := repositories.RetrievalCodeExists(codeCandidate)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"message": "generate retrieval code failed",
-					"error":   err.Error(),
-				})
+		}
+		// 多件行李共用一个取件码，按第一件行李所在酒店的取件码规则生成（自动分配时为当前登录账号的酒店）
+		var sharedCode string
+		var codeExpiresAt *time.Time
+		var err error
+		if first := req.Items[0].StoreroomID; first.Auto {
+			hotelID, ok := currentHotelID(c)
+			if !ok {
 				return
 			}
-			if !exists {
-				sharedCode = codeCandidate
-				break
-			}
+			sharedCode, codeExpiresAt, err = services.GenerateRetrievalCode(hotelID)
+		} else {
+			sharedCode, codeExpiresAt, err = services.NewRetrievalCodeForStoreroom(first.ID)
 		}
-		if sharedCode == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "generate retrieval code failed",
-				"error":   "failed to generate unique retrieval code",
-			})
+		if err != nil {
+			middleware.AbortWithError(c, "generate retrieval code failed", err)
 			return
 		}
 
 		items := make([]gin.H, 0, len(req.Items))
 		for _, it := range req.Items {
-			created, err := services.CreateLuggage(services.CreateLuggageRequest{
-				GuestName:     req.GuestName,
-				ContactPhone:  req.ContactPhone,
-				ContactEmail:  req.ContactEmail,
-				Description:   it.Description,
-				Quantity:      it.Quantity,
-				SpecialNotes:  it.SpecialNotes,
-				PhotoURL:      it.PhotoURL,
-				PhotoURLs:     it.PhotoURLs,
-				RetrievalCode: sharedCode,
-				StoreroomID:   it.StoreroomID,
-				StaffName:     req.StaffName,
-				QRCodeURL:     req.QRCodeURL,
+			created, err := services.CreateLuggage(c.Request.Context(), services.CreateLuggageRequest{
+				GuestName:        req.GuestName,
+				ContactPhone:     req.ContactPhone,
+				ContactEmail:     req.ContactEmail,
+				Description:      it.Description,
+				Quantity:         it.Quantity,
+				SizeClass:        it.SizeClass,
+				SpecialNotes:     it.SpecialNotes,
+				PhotoURL:         it.PhotoURL,
+				PhotoURLs:        it.PhotoURLs,
+				RetrievalCode:    sharedCode,
+				CodeExpiresAt:    codeExpiresAt,
+				StoreroomID:      it.StoreroomID.ID,
+				BinID:            it.BinID,
+				StaffName:        req.StaffName,
+				QRCodeURL:        req.QRCodeURL,
+				AutoAssign:       it.StoreroomID.Auto,
+				Handling:         it.Handling,
+				ExpectedPickupAt: req.ExpectedPickupAt,
 			})
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"message": "create luggage failed",
-					"error":   err.Error(),
-				})
+				middleware.AbortWithError(c, "create luggage failed", err)
 				return
 			}
 			items = append(items, gin.H{
 				"luggage_id":   created.ID,
 				"storeroom_id": created.StoreroomID,
-				"photo_url":    created.PhotoURL,
-				"photo_urls":   created.PhotoURLs,
+				"bin_id":       created.BinID,
+				"bin_path":     created.BinPath,
+				"photo_url":    services.SignPhotoURL(c.Request.Context(), created.PhotoURL),
+				"photo_urls":   services.SignPhotoURLs(c.Request.Context(), created.PhotoURLs),
 			})
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"message":        "create luggage success",
-			"retrieval_code": sharedCode,
-			"items":          items,
-		})
-		return
-	}
-	if req.StoreroomID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   "storeroom_id is required",
-		})
-		return
-	}
-
-	item, err := services.CreateLuggage(services.CreateLuggageRequest{
-		GuestName:    req.GuestName,
-		ContactPhone: req.ContactPhone,
-		ContactEmail: req.ContactEmail,
-		Description:  req.Description,
-		Quantity:     req.Quantity,
-		SpecialNotes: req.SpecialNotes,
-		PhotoURL:     req.PhotoURL,
-		PhotoURLs:    req.PhotoURLs,
-		StoreroomID:  req.StoreroomID,
-		StaffName:    req.StaffName,
-		QRCodeURL:    req.QRCodeURL,
+			"message":         "create luggage success",
+			"retrieval_code":  sharedCode,
+			"code_expires_at": codeExpiresAt,
+			"items":           items,
+		})
+		return
+	}
+	if req.StoreroomID.empty() {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest("storeroom_id is required"))
+		return
+	}
+
+	item, err := services.CreateLuggage(c.Request.Context(), services.CreateLuggageRequest{
+		GuestName:        req.GuestName,
+		ContactPhone:     req.ContactPhone,
+		ContactEmail:     req.ContactEmail,
+		Description:      req.Description,
+		Quantity:         req.Quantity,
+		SizeClass:        req.SizeClass,
+		SpecialNotes:     req.SpecialNotes,
+		PhotoURL:         req.PhotoURL,
+		PhotoURLs:        req.PhotoURLs,
+		StoreroomID:      req.StoreroomID.ID,
+		BinID:            req.BinID,
+		StaffName:        req.StaffName,
+		QRCodeURL:        req.QRCodeURL,
+		AutoAssign:       req.StoreroomID.Auto,
+		Handling:         req.Handling,
+		ExpectedPickupAt: req.ExpectedPickupAt,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "create luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "create luggage failed", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":        "create luggage success",
-		"luggage_id":     item.ID,
-		"retrieval_code": item.RetrievalCode,
-		"qrcode_url":     item.QRCodeURL,
-		"photo_url":      item.PhotoURL,
-		"photo_urls":     item.PhotoURLs,
+		"message":         "create luggage success",
+		"luggage_id":      item.ID,
+		"retrieval_code":  item.RetrievalCode,
+		"code_expires_at": item.CodeExpiresAt,
+		"storeroom_id":    item.StoreroomID,
+		"bin_id":          item.BinID,
+		"bin_path":        item.BinPath,
+		"qrcode_url":      item.QRCodeURL,
+		"photo_url":       services.SignPhotoURL(c.Request.Context(), item.PhotoURL),
+		"photo_urls":      services.SignPhotoURLs(c.Request.Context(), item.PhotoURLs),
 	})
 }
 
@@ -186,13 +238,11 @@ func QueryLuggageByUserInfo(c *gin.Context) {
 
 	items, err := services.FindLuggageByUserInfo(guestName, contactPhone)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "query luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "query luggage failed", err)
 		return
 	}
 
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "query luggage success",
 		"items":   items,
@@ -205,12 +255,10 @@ func QueryLuggageByCode(c *gin.Context) {
 	code := c.Query("code")
 	items, err := services.FindLuggageByCode(code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "query luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "query luggage failed", err)
 		return
 	}
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "query luggage success",
 		"items":   items,
@@ -223,13 +271,11 @@ func QueryLuggageByPhone(c *gin.Context) {
 	phone := c.Query("contact_phone")
 	items, err := services.FindLuggageByUserInfo("", phone)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "query luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "query luggage failed", err)
 		return
 	}
 
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "query luggage success",
 		"items":   items,
@@ -239,25 +285,31 @@ func QueryLuggageByPhone(c *gin.Context) {
 // RetrieveLuggage 取件接口（通过取件码）
 func RetrieveLuggage(c *gin.Context) {
 	var req struct {
-		Code        string `json:"code" binding:"required"`         // 取件码
-		RetrievedBy string `json:"retrieved_by" binding:"required"` // 操作员用户名
+		Code         string                        `json:"code" binding:"required"`         // 取件码
+		RetrievedBy  string                        `json:"retrieved_by" binding:"required"` // 操作员用户名
+		LuggageIDs   []int64                       `json:"luggage_ids"`                     // 本次取走的行李ID（可选，部分取件）
+		Verification services.CheckoutVerification `json:"verification"`                    // 取件人身份核验（按酒店策略要求）
+		Delegate     *services.DelegateCredential  `json:"delegate"`                        // 代取凭证（代取人取件时填写）
+		Signature    *services.CheckoutSignature   `json:"signature"`                       // 取件人签名（PNG 或笔迹）
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	items, err := services.RetrieveLuggage(req.Code, req.RetrievedBy)
+	result, err := services.RetrieveLuggage(c.Request.Context(), services.RetrieveLuggageRequest{
+		Code:         req.Code,
+		RetrievedBy:  req.RetrievedBy,
+		LuggageIDs:   req.LuggageIDs,
+		Verification: req.Verification,
+		Delegate:     req.Delegate,
+		Signature:    req.Signature,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "retrieve luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "retrieve luggage failed", err)
 		return
 	}
+	items, remaining := result.Retrieved, result.Remaining
 	luggageIDs := make([]int64, 0, len(items))
 	for _, item := range items {
 		luggageIDs = append(luggageIDs, item.ID)
@@ -268,126 +320,190 @@ func RetrieveLuggage(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "retrieve luggage success",
-		"retrieval_code":  req.Code,
+		"retrieval_code":  utils.NormalizeCode(req.Code),
 		"retrieved_by":    req.RetrievedBy,
 		"retrieved_count": len(items),
 		"luggage_ids":     luggageIDs,
 		"luggage_id":      singleID,
+		"remaining_count": len(remaining),
+		"collected_by":    collectedBy(result),
 	})
 }
 
+// CheckoutLuggageRequest 取件请求（请求体可省略）
+type CheckoutLuggageRequest struct {
+	LuggageIDs   []int64                       `json:"luggage_ids"`  // 本次取走的行李ID（可选，不传则取走该取件码下所有在存行李）
+	Verification services.CheckoutVerification `json:"verification"` // 取件人身份核验（酒店策略要求时必填，见 GET /api/luggage/:id/checkout）
+	Delegate     *services.DelegateCredential  `json:"delegate"`     // 代取凭证（代取人取件时填写 delegate_id 或 code）
+	Signature    *services.CheckoutSignature   `json:"signature"`    // 取件人签名（image_base64 或 strokes 二选一，酒店策略要求时必填）
+}
+
 // CheckoutLuggageByCode 通过取件码取件
 // POST /api/luggage/:id/checkout
+// 支持部分取件：传入 luggage_ids 时只取走指定行李，取件码对剩余行李继续有效
 func CheckoutLuggageByCode(c *gin.Context) {
 	code := c.Param("id")
 	username, _ := c.Get("username")
 	retrievedBy, _ := username.(string)
 	if retrievedBy == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+		middleware.AbortWithError(c, "missing user info", apperr.ErrUnauthorized)
+		return
+	}
+	var req CheckoutLuggageRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	items, err := services.RetrieveLuggage(code, retrievedBy)
+	result, err := services.RetrieveLuggage(c.Request.Context(), services.RetrieveLuggageRequest{
+		Code:         code,
+		RetrievedBy:  retrievedBy,
+		LuggageIDs:   req.LuggageIDs,
+		Verification: req.Verification,
+		Delegate:     req.Delegate,
+		Signature:    req.Signature,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "checkout failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "checkout failed", err)
 		return
 	}
+	items, remaining := result.Retrieved, result.Remaining
 	luggageIDs := make([]int64, 0, len(items))
 	for _, item := range items {
 		luggageIDs = append(luggageIDs, item.ID)
 	}
+	remainingIDs := make([]int64, 0, len(remaining))
+	for _, item := range remaining {
+		remainingIDs = append(remainingIDs, item.ID)
+	}
 	var singleID interface{} = nil
 	if len(luggageIDs) == 1 {
 		singleID = luggageIDs[0]
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"message":         "checkout success",
-		"retrieval_code":  code,
-		"retrieved_count": len(items),
-		"luggage_ids":     luggageIDs,
-		"luggage_id":      singleID,
+		"message":               "checkout success",
+		"retrieval_code":        utils.NormalizeCode(code),
+		"retrieved_count":       len(items),
+		"luggage_ids":           luggageIDs,
+		"luggage_id":            singleID,
+		"partial":               len(remaining) > 0,
+		"remaining_count":       len(remaining),
+		"remaining_luggage_ids": remainingIDs,
+		"collected_by":          collectedBy(result),
+		"signature_url":         services.SignPhotoURL(c.Request.Context(), result.Signature),
 	})
 }
 
-// GetCheckoutInfoByCode 获取当前酒店有行李在存的客人名单
-// GET /api/luggage/:id/checkout
-func GetCheckoutInfoByCode(c *gin.Context) {
+// collectedBy 取件响应中的实际取件人（代取时附带代取人ID）
+func collectedBy(result services.RetrieveLuggageResult) gin.H {
+	if result.Delegate != nil {
+		return gin.H{"type": "delegate", "name": result.Delegate.Name, "delegate_id": result.Delegate.ID}
+	}
+	name := ""
+	if len(result.Retrieved) > 0 {
+		name = result.Retrieved[0].GuestName
+	}
+	return gin.H{"type": "guest", "name": name}
+}
+
+// SendCheckoutOTPRequest 发送取件验证码请求
+type SendCheckoutOTPRequest struct {
+	Channel string `json:"channel"` // sms / email（可选，默认优先短信）
+}
+
+// SendCheckoutOTP 向寄存时登记的手机号 / 邮箱发送取件验证码
+// POST /api/luggage/:id/checkout/otp
+func SendCheckoutOTP(c *gin.Context) {
 	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+	requestedBy, _ := username.(string)
+	if requestedBy == "" {
+		middleware.AbortWithError(c, "missing user info", apperr.ErrUnauthorized)
+		return
+	}
+	var req SendCheckoutOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	user, err := repositories.GetUserByUsername(userNameStr)
+	result, err := services.SendCheckoutOTP(c.Request.Context(), c.Param("id"), req.Channel, requestedBy)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "send verification code failed", err)
 		return
 	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "send verification code success",
+		"channel":     result.Channel,
+		"destination": result.Destination,
+		"expires_in":  result.ExpiresIn,
+	})
+}
+
+// GetCheckoutInfoByCode 获取取件码下仍在寄存和已取走的行李
+// GET /api/luggage/:id/checkout
+func GetCheckoutInfoByCode(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
 
-	items, err := services.ListGuestNamesByHotelAndStatus(*user.HotelID, "stored")
+	info, err := services.GetCheckoutInfo(hotelID, c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get checkout info failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get checkout info failed", err)
 		return
 	}
+	services.SignLuggagePhotos(c.Request.Context(), info.Remaining)
+	services.SignHistoryPhotos(c.Request.Context(), info.Retrieved)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "get checkout info success",
-		"items":   items,
+		"message":         "get checkout info success",
+		"retrieval_code":  info.RetrievalCode,
+		"guest_name":      info.GuestName,
+		"contact_phone":   info.ContactPhone,
+		"remaining_count": len(info.Remaining),
+		"retrieved_count": len(info.Retrieved),
+		// 取走剩余行李需要的身份核验方式（none/phone_last4/otp/id_document）
+		"required_verification": info.RequiredVerification,
+		"high_risk":             info.HighRisk,
+		"remaining":             info.Remaining,
+		"retrieved":             info.Retrieved,
+		"require_signature":     info.RequireSignature,
+		"delegates":             info.Delegates,
+		"code_expires_at":       info.CodeExpiresAt,
 	})
 }
 
-// ListLuggageByUser 获取当前酒店所有已存放行李的客人姓名（去重）
-// GET /api/luggage/list
-func ListLuggageByUser(c *gin.Context) {
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+// ReissueRetrievalCode 为取件码下仍在寄存的行李重新生成取件码（旧取件码立即失效）
+// POST /api/luggage/:id/code
+func ReissueRetrievalCode(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
 
-	user, err := repositories.GetUserByUsername(userNameStr)
+	code, expiresAt, err := services.ReissueRetrievalCode(c.Request.Context(), hotelID, c.Param("id"), c.GetString("username"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "reissue retrieval code failed", err)
 		return
 	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "reissue retrieval code success",
+		"retrieval_code":  code,
+		"code_expires_at": expiresAt,
+		"qrcode_url":      fmt.Sprintf("/qr/%s", code),
+	})
+}
+
+// ListLuggageByUser 获取当前酒店所有已存放行李的客人姓名（去重）
+// GET /api/luggage/list
+func ListLuggageByUser(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
 
-	items, err := services.ListGuestNamesByHotelAndStatus(*user.HotelID, "stored")
+	items, err := services.ListGuestNamesByHotelAndStatus(hotelID, "stored")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list guest names failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list guest names failed", err)
 		return
 	}
 
@@ -406,13 +522,11 @@ func ListLuggageByGuest(c *gin.Context) {
 
 	items, err := services.ListLuggageByGuest(guestName, contactPhone, status)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list luggage failed", err)
 		return
 	}
 
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "list luggage success",
 		"items":   items,
@@ -422,39 +536,19 @@ func ListLuggageByGuest(c *gin.Context) {
 // ListStoredLuggageByGuestName 查询某客人正在寄存的行李
 // GET /api/luggage/list/by_guest_name?guest_name=...
 func ListStoredLuggageByGuestName(c *gin.Context) {
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
-		return
-	}
-	user, err := repositories.GetUserByUsername(userNameStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
-		return
-	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
 
 	guestName := c.Query("guest_name")
-	items, err := services.ListStoredLuggageByGuestName(*user.HotelID, guestName)
+	items, err := services.ListStoredLuggageByGuestName(hotelID, guestName)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list luggage failed", err)
 		return
 	}
 
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "list luggage success",
 		"items":   items,
@@ -469,28 +563,23 @@ func GetLuggageDetail(c *gin.Context) {
 		idStr = c.Query("id")
 	}
 	if idStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "id is required",
-		})
+		middleware.AbortWithError(c, "id is required", apperr.ErrInvalidRequest)
 		return
 	}
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid id",
-		})
+		middleware.AbortWithError(c, "invalid id", apperr.ErrInvalidRequest)
 		return
 	}
 
 	item, err := services.GetLuggageDetail(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get luggage detail failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get luggage detail failed", err)
 		return
 	}
 
+	item.PhotoURL = services.SignPhotoURL(c.Request.Context(), item.PhotoURL)
+	item.PhotoURLs = services.SignPhotoURLs(c.Request.Context(), item.PhotoURLs)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "get luggage detail success",
 		"item":    item,
@@ -503,13 +592,12 @@ func GetLuggageDetailByCode(c *gin.Context) {
 	code := c.Query("code")
 	item, err := services.GetLuggageDetailByCode(code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get luggage detail failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get luggage detail failed", err)
 		return
 	}
 
+	item.PhotoURL = services.SignPhotoURL(c.Request.Context(), item.PhotoURL)
+	item.PhotoURLs = services.SignPhotoURLs(c.Request.Context(), item.PhotoURLs)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "get luggage detail success",
 		"item":    item,
@@ -522,13 +610,11 @@ func ListLuggageDetailByPhone(c *gin.Context) {
 	phone := c.Query("contact_phone")
 	items, err := services.ListLuggageDetailByPhone(phone)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get luggage detail failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get luggage detail failed", err)
 		return
 	}
 
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "get luggage detail success",
 		"items":   items,
@@ -540,19 +626,14 @@ func ListLuggageDetailByPhone(c *gin.Context) {
 func ListPickupCodesByUser(c *gin.Context) {
 	username := c.Query("username")
 	if username == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "username is required",
-		})
+		middleware.AbortWithError(c, "username is required", apperr.ErrInvalidRequest)
 		return
 	}
 
 	status := c.Query("status")
 	items, err := services.ListPickupCodesByUser(username, status)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get pickup codes failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get pickup codes failed", err)
 		return
 	}
 
@@ -583,10 +664,7 @@ func ListPickupCodesByPhone(c *gin.Context) {
 	status := c.Query("status")
 	items, err := services.ListPickupCodesByPhone(phone, status)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get pickup codes failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get pickup codes failed", err)
 		return
 	}
 
@@ -609,61 +687,58 @@ func ListPickupCodesByPhone(c *gin.Context) {
 	})
 }
 
+// UpdateLuggageInfoRequest 修改寄存信息请求结构体（所有字段可选，只传需要修改的字段）
+type UpdateLuggageInfoRequest struct {
+	GuestName    *string   `json:"guest_name"`
+	ContactPhone *string   `json:"contact_phone"`
+	ContactEmail *string   `json:"contact_email"`
+	Description  *string   `json:"description"`
+	Quantity     *int      `json:"quantity"`
+	SizeClass    *string   `json:"size_class"` // 尺寸：small / medium / large / oversized
+	SpecialNotes *string   `json:"special_notes"`
+	PhotoURL     *string   `json:"photo_url"`
+	PhotoURLs    *[]string `json:"photo_urls"`
+	StoreroomID  *int64    `json:"storeroom_id"` // 新增：支持修改寄存室（迁移）
+	BinID        *int64    `json:"bin_id"`       // 格位ID（0 表示移出格位；迁移寄存室且不传时自动分配）
+}
+
 // UpdateLuggageInfo 修改寄存信息（包含寄存室迁移）
 // PUT /api/luggage/:id
 func UpdateLuggageInfo(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid luggage id",
-		})
+		middleware.AbortWithError(c, "invalid luggage id", apperr.ErrInvalidRequest)
 		return
 	}
 
-	var req struct {
-		GuestName    *string   `json:"guest_name"`
-		ContactPhone *string   `json:"contact_phone"`
-		ContactEmail *string   `json:"contact_email"`
-		Description  *string   `json:"description"`
-		Quantity     *int      `json:"quantity"`
-		SpecialNotes *string   `json:"special_notes"`
-		PhotoURL     *string   `json:"photo_url"`
-		PhotoURLs    *[]string `json:"photo_urls"`
-		StoreroomID  *int64    `json:"storeroom_id"` // 新增：支持修改寄存室（迁移）
-	}
+	var req UpdateLuggageInfoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
 	username, _ := c.Get("username")
 	userNameStr, _ := username.(string)
 	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+		middleware.AbortWithError(c, "missing user info", apperr.ErrUnauthorized)
 		return
 	}
 
-	if err := services.UpdateLuggageInfo(id, services.UpdateLuggageInfoRequest{
+	if err := services.UpdateLuggageInfo(c.Request.Context(), id, services.UpdateLuggageInfoRequest{
 		GuestName:    req.GuestName,
 		ContactPhone: req.ContactPhone,
 		ContactEmail: req.ContactEmail,
 		Description:  req.Description,
 		Quantity:     req.Quantity,
+		SizeClass:    req.SizeClass,
 		SpecialNotes: req.SpecialNotes,
 		PhotoURL:     req.PhotoURL,
 		PhotoURLs:    req.PhotoURLs,
 		StoreroomID:  req.StoreroomID, // 传递寄存室ID
+		BinID:        req.BinID,
 		UpdatedBy:    userNameStr,
 	}); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "update luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "update luggage failed", err)
 		return
 	}
 
@@ -677,9 +752,7 @@ func UpdateLuggageInfo(c *gin.Context) {
 func UpdateLuggageCode(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid luggage id",
-		})
+		middleware.AbortWithError(c, "invalid luggage id", apperr.ErrInvalidRequest)
 		return
 	}
 
@@ -687,18 +760,12 @@ func UpdateLuggageCode(c *gin.Context) {
 		Code string `json:"code" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	if err := services.UpdateLuggageCode(id, req.Code); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "update retrieval code failed",
-			"error":   err.Error(),
-		})
+	if err := services.UpdateLuggageCode(c.Request.Context(), id, req.Code); err != nil {
+		middleware.AbortWithError(c, "update retrieval code failed", err)
 		return
 	}
 
@@ -715,18 +782,12 @@ func BindLuggage(c *gin.Context) {
 		Username  string `json:"username" binding:"required"`   // 用户名
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	if err := services.BindLuggageToUser(req.LuggageID, req.Username); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "bind luggage failed",
-			"error":   err.Error(),
-		})
+	if err := services.BindLuggageToUser(c.Request.Context(), req.LuggageID, req.Username); err != nil {
+		middleware.AbortWithError(c, "bind luggage failed", err)
 		return
 	}
 
@@ -737,150 +798,150 @@ func BindLuggage(c *gin.Context) {
 
 // Upload 上传图片接口（multipart/form-data）
 // POST /api/upload
-func Upload(c *gin.Context) {
+// 上传大小限制来自启动配置；文件写入 BlobStore（MinIO 优先，不可用时降级到本地目录）
+// 存储是私有的：返回对象 key（写入寄存单的 photo_url / photo_urls）和短期有效的签名地址（用于预览）
+func Upload(cfg configs.UploadConfig, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		upload(c, cfg, store)
+	}
+}
+
+func upload(c *gin.Context, cfg configs.UploadConfig, store storage.BlobStore) {
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "upload failed",
-			"error":   "missing file",
-		})
+		middleware.AbortWithError(c, "upload failed", apperr.InvalidRequest("missing file"))
 		return
 	}
-	const maxSize = 5 * 1024 * 1024
+	maxSize := cfg.MaxSize
 	if file.Size > maxSize {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "upload failed",
-			"error":   "file too large",
-		})
+		middleware.AbortWithError(c, "upload failed", apperr.ErrFileTooLarge)
 		return
 	}
 
-	ext := filepath.Ext(file.Filename)
-	if ext == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "upload failed",
-			"error":   "invalid file extension",
-		})
+	fileReader, err := file.Open()
+	if err != nil {
+		middleware.AbortWithError(c, "upload failed", apperr.Internal(errors.New("cannot open file")))
 		return
 	}
-	allowed := map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
-	if !allowed[ext] {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "upload failed",
-			"error":   "unsupported file type",
-		})
+	defer fileReader.Close()
+	data, err := io.ReadAll(io.LimitReader(fileReader, maxSize+1))
+	if err != nil {
+		middleware.AbortWithError(c, "upload failed", apperr.Internal(errors.New("cannot read file")))
 		return
 	}
+	if int64(len(data)) > maxSize {
+		middleware.AbortWithError(c, "upload failed", apperr.ErrFileTooLarge)
+		return
+	}
+
+	// 按文件内容识别类型（不信任扩展名和 Content-Type），自动旋转、去除 EXIF、压缩并生成缩略图
+	processed, err := imaging.Process(data, imageOptions(cfg.Image))
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrNotImage):
+			middleware.AbortWithError(c, "upload failed", apperr.ErrUnsupportedFileType.WithMessage("only jpeg, png and webp images are allowed"))
+		case errors.Is(err, imaging.ErrTooManyPixels):
+			middleware.AbortWithError(c, "upload failed", apperr.ErrFileTooLarge.WithMessage("image dimensions too large"))
+		default:
+			middleware.AbortWithError(c, "upload failed", apperr.Internal(err))
+		}
+		return
+	}
+	contentType := processed.Image.ContentType
 
 	now := time.Now()
-	// 生成随机文件名
+	// 生成随机文件名（扩展名取决于重新编码后的格式）
 	nameBytes := make([]byte, 16)
 	if _, err := rand.Read(nameBytes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "upload failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "upload failed", err)
 		return
 	}
-	fileName := hex.EncodeToString(nameBytes) + ext
-	objectName := fmt.Sprintf("uploads/%s/%s/%s", now.Format("2006"), now.Format("01"), fileName)
+	fileName := hex.EncodeToString(nameBytes) + processed.Image.Ext
+	key := fmt.Sprintf("%s/%s/%s", now.Format("2006"), now.Format("01"), fileName)
 
-	// 优先使用MinIO上传
-	if repositories.MinIOClient != nil {
-		fileReader, err := file.Open()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "upload failed",
-				"error":   "cannot open file",
-			})
-			return
-		}
-		defer fileReader.Close()
+	// 设置30秒超时（考虑网络延迟和文件大小）
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
 
-		contentType := file.Header.Get("Content-Type")
-		if contentType == "" {
-			contentType = mime.TypeByExtension(ext)
-		}
-		if contentType == "" {
-			contentType = "application/octet-stream"
-		}
-
-		// 设置30秒超时（考虑网络延迟和文件大小）
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	info, err := store.Put(ctx, key, bytes.NewReader(processed.Image.Data), int64(len(processed.Image.Data)), contentType)
+	if err != nil {
+		middleware.AbortWithError(c, "upload failed", err)
+		return
+	}
+	services.RecordFallbackPut(store, info)
 
-		_, err = repositories.MinIOClient.PutObject(ctx, repositories.MinIOBucketName, objectName, fileReader, file.Size, minio.PutObjectOptions{
-			ContentType: contentType,
-		})
+	// 缩略图保存在原图旁边（key 见 imaging.VariantKey），写入失败时列表回退为原图，不影响上传结果
+	thumbnails := make(gin.H, len(processed.Thumbnails))
+	variantKeys := make([]string, 0, len(processed.Thumbnails))
+	totalSize := info.Size
+	for _, variant := range cfg.Image.Thumbnails {
+		thumb := processed.Thumbnails[variant.Name]
+		thumbInfo, err := store.Put(ctx, imaging.VariantKey(key, variant.Name), bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType)
 		if err != nil {
-			// MinIO上传失败，降级到本地存储
-			log.Printf("⚠️  MinIO上传失败(超时或网络错误)，降级到本地存储: %v", err)
-		} else {
-			// MinIO上传成功，返回MinIO URL
-			minioConfig := configs.LoadMinIOConfig()
-			scheme := "http"
-			if minioConfig.UseSSL {
-				scheme = "https"
-			}
-			fullURL := fmt.Sprintf("%s://%s/%s/%s", scheme, minioConfig.Endpoint, repositories.MinIOBucketName, objectName)
-
-			c.JSON(http.StatusOK, gin.H{
-				"message":       "upload success (MinIO)",
-				"url":           fullURL,
-				"object_name":   objectName,
-				"content_type":  contentType,
-				"size":          file.Size,
-				"file_name":     fileName,
-				"max_size_byte": maxSize,
-				"storage":       "minio",
-			})
-			return
+			log.Printf("⚠️  保存缩略图失败 %s (%s): %v", key, variant.Name, err)
+			continue
+		}
+		services.RecordFallbackPut(store, thumbInfo)
+		variantKeys = append(variantKeys, thumbInfo.Key)
+		totalSize += thumbInfo.Size
+		thumbURL, err := storage.SignedURLIn(ctx, store, thumbInfo.Key, thumbInfo.Store, cfg.URLExpiry.Std())
+		if err != nil {
+			log.Printf("⚠️  生成缩略图签名地址失败 %s: %v", thumbInfo.Key, err)
+			continue
 		}
+		thumbnails[variant.Name] = thumbURL
 	}
 
-	// 降级方案：本地文件存储
-	subDir := filepath.Join("uploads", now.Format("2006"), now.Format("01"))
-	if err := os.MkdirAll(subDir, 0o755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "upload failed",
-			"error":   err.Error(),
-		})
-		return
+	// 登记上传记录：超过 upload.gc_grace_period 仍未挂到寄存单上的照片会被清理任务删除
+	if err := services.RecordUpload(c.Request.Context(), info, variantKeys, totalSize, c.GetString("username")); err != nil {
+		log.Printf("⚠️  记录上传失败 %s: %v", info.Key, err)
 	}
 
-	savePath := filepath.Join(subDir, fileName)
-	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "upload failed",
-			"error":   err.Error(),
-		})
+	// 存储是私有的：返回短期有效的签名地址用于预览，寄存单中应保存 key
+	url, err := storage.SignedURLIn(ctx, store, info.Key, info.Store, cfg.URLExpiry.Std())
+	if err != nil {
+		middleware.AbortWithError(c, "upload failed", err)
 		return
 	}
 
-	relativeURL := fmt.Sprintf("/uploads/%s/%s/%s", now.Format("2006"), now.Format("01"), fileName)
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
-	}
-	fullURL := fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, relativeURL)
-	contentType := file.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = mime.TypeByExtension(ext)
-	}
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":       "upload success (Local)",
-		"url":           fullURL,
-		"relative_url":  relativeURL,
+	resp := gin.H{
+		"message":       "upload success",
+		"url":           url,
+		"key":           info.Key,
+		"expires_in":    int64(cfg.URLExpiry.Std().Seconds()),
 		"content_type":  contentType,
-		"size":          file.Size,
+		"size":          info.Size,
 		"file_name":     fileName,
 		"max_size_byte": maxSize,
-		"storage":       "local",
-	})
+		"storage":       info.Store,
+		"width":         processed.Image.Width,
+		"height":        processed.Image.Height,
+		"thumbnails":    thumbnails,
+	}
+	// 兼容旧字段：MinIO 返回 object_name，本地存储返回 relative_url
+	switch info.Store {
+	case "minio":
+		resp["message"] = "upload success (MinIO)"
+		resp["object_name"] = storage.MinIOKeyPrefix + info.Key
+	case "local":
+		resp["message"] = "upload success (Local)"
+		resp["relative_url"] = services.LocalUploadPath + info.Key
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// imageOptions 把上传配置转换为图片处理参数
+func imageOptions(cfg configs.ImageConfig) imaging.Options {
+	opts := imaging.Options{
+		MaxDimension: cfg.MaxDimension,
+		JPEGQuality:  cfg.JPEGQuality,
+		MaxPixels:    cfg.MaxPixels,
+		Thumbnails:   make([]imaging.Variant, 0, len(cfg.Thumbnails)),
+	}
+	for _, t := range cfg.Thumbnails {
+		opts.Thumbnails = append(opts.Thumbnails, imaging.Variant{Name: t.Name, MaxDimension: t.MaxDimension})
+	}
+	return opts
 }
 
 // ListHistoryByGuest 查询取件历史（按客人姓名/手机号）
@@ -891,13 +952,11 @@ func ListHistoryByGuest(c *gin.Context) {
 
 	items, err := services.ListHistoryByGuest(guestName, contactPhone)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get history failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get history failed", err)
 		return
 	}
 
+	services.SignHistoryPhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "get history success",
 		"items":   items,
@@ -909,20 +968,16 @@ func ListHistoryByGuest(c *gin.Context) {
 func ListLuggageByStoreroom(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid storeroom id",
-		})
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
 		return
 	}
 	status := c.Query("status")
 	items, err := services.ListLuggageByStoreroom(id, status)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list luggage failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list luggage failed", err)
 		return
 	}
+	services.SignLuggagePhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "list luggage success",
 		"items":   items,
@@ -932,34 +987,41 @@ func ListLuggageByStoreroom(c *gin.Context) {
 // ListStoredLogs 获取所有寄存记录（当前登录用户的酒店）
 // GET /api/luggage/logs/stored
 func ListStoredLogs(c *gin.Context) {
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
-	user, err := repositories.GetUserByUsername(userNameStr)
+	items, err := services.ListLuggageByHotelAndStatus(hotelID, "stored")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list logs failed", err)
 		return
 	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	services.SignLuggagePhotos(c.Request.Context(), items)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list logs success",
+		"items":   items,
+	})
+}
+
+// ListUpdatedLogs 获取所有寄存信息修改记录（当前登录用户的酒店，含字段级变更）
+// GET /api/luggage/logs/updated?field=storeroom_id&luggage_id=12
+func ListUpdatedLogs(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
-	items, err := services.ListLuggageByHotelAndStatus(*user.HotelID, "stored")
+	query := services.UpdateLogQuery{Field: c.Query("field")}
+	if v := c.Query("luggage_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			middleware.AbortWithError(c, "invalid luggage_id", apperr.ErrInvalidRequest)
+			return
+		}
+		query.LuggageID = id
+	}
+	items, err := services.ListLuggageUpdates(hotelID, query)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list logs failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list logs failed", err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -968,78 +1030,113 @@ func ListStoredLogs(c *gin.Context) {
 	})
 }
 
-// ListUpdatedLogs 获取所有寄存信息修改记录（当前登录用户的酒店）
-// GET /api/luggage/logs/updated
-func ListUpdatedLogs(c *gin.Context) {
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+// GetLuggageTimeline 获取单件行李的修改时间线（版本 0 为原始寄存信息，之后每条修改记录为一个版本）
+// GET /api/luggage/:id/timeline
+func GetLuggageTimeline(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid luggage id", apperr.ErrInvalidRequest)
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
-	user, err := repositories.GetUserByUsername(userNameStr)
+	timeline, err := services.GetLuggageTimeline(hotelID, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "get luggage timeline failed", err)
 		return
 	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	// 当前和原始寄存信息中的照片替换为签名地址
+	items := []models.LuggageItem{*timeline.Original}
+	if timeline.Current != nil {
+		items = append(items, *timeline.Current)
+	}
+	services.SignLuggagePhotos(c.Request.Context(), items)
+	timeline.Original = &items[0]
+	if timeline.Current != nil {
+		timeline.Current = &items[1]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "get luggage timeline success",
+		"item":    timeline,
+	})
+}
+
+// RevertLuggageRequest 回退寄存单请求体
+type RevertLuggageRequest struct {
+	Revision *int `json:"revision" binding:"required"` // 回退到的版本号（与修改时间线一致，0 为第一次修改前的寄存信息）
+}
+
+// PreviewLuggageRevert 预览把寄存单回退到历史版本的结果（管理员，不写入）
+// GET /api/admin/luggage/:id/revert?revision=0
+func PreviewLuggageRevert(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid luggage id", apperr.ErrInvalidRequest)
 		return
 	}
-	items, err := services.ListLuggageUpdatesByHotel(*user.HotelID)
+	revision, err := strconv.Atoi(c.Query("revision"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list logs failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid revision", apperr.InvalidRequest("revision is required"))
+		return
+	}
+	result, err := services.PreviewLuggageRevert(c.Request.Context(), id, revision)
+	if err != nil {
+		middleware.AbortWithError(c, "preview luggage revert failed", err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "list logs success",
-		"items":   items,
+		"message": "preview luggage revert success",
+		"item":    signLuggageRevert(c, result),
 	})
 }
 
-// ListRetrievedLogs 获取所有取出记录（当前登录用户的酒店）
-// GET /api/luggage/logs/retrieved
-func ListRetrievedLogs(c *gin.Context) {
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+// RevertLuggage 把寄存单回退到历史版本（管理员，回退写入一条新的修改记录）
+// POST /api/admin/luggage/:id/revert
+func RevertLuggage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid luggage id", apperr.ErrInvalidRequest)
 		return
 	}
-	user, err := repositories.GetUserByUsername(userNameStr)
+	var req RevertLuggageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	result, err := services.RevertLuggage(c.Request.Context(), id, *req.Revision, c.GetString("username"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "revert luggage failed", err)
 		return
 	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "revert luggage success",
+		"item":    signLuggageRevert(c, result),
+	})
+}
+
+// signLuggageRevert 把回退前后寄存单中的照片替换为签名地址
+func signLuggageRevert(c *gin.Context, result services.LuggageRevert) services.LuggageRevert {
+	items := []models.LuggageItem{result.Current, result.Reverted}
+	services.SignLuggagePhotos(c.Request.Context(), items)
+	result.Current, result.Reverted = items[0], items[1]
+	return result
+}
+
+// ListRetrievedLogs 获取所有取出记录（当前登录用户的酒店）
+// GET /api/luggage/logs/retrieved
+func ListRetrievedLogs(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
-	items, err := services.ListHistoryByHotel(*user.HotelID, "", "")
+	items, err := services.ListHistoryByHotel(hotelID, "", "")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list logs failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list logs failed", err)
 		return
 	}
+	services.SignHistoryPhotos(c.Request.Context(), items)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "list logs success",
 		"items":   items,
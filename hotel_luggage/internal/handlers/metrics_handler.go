@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 依赖状态指标（Prometheus 文本格式）
+// GET /metrics
+// 指标：
+// - hotel_luggage_dependency_up{dependency}：依赖是否可用（0 表示处于降级模式）
+// - hotel_luggage_dependency_consecutive_failures{dependency}：连续连接/检查失败次数
+// - hotel_luggage_dependency_reconnects_total{dependency}：降级后自动恢复的次数
+// - hotel_luggage_dependency_state_since_seconds{dependency}：进入当前状态的时间（Unix 秒）
+// - hotel_luggage_shutting_down：是否正在优雅退出
+// - hotel_luggage_audit_write_failures_total：写入失败的审计日志条数（大于 0 时需要排查补录）
+func Metrics(c *gin.Context) {
+	states := repositories.DependencyStates()
+
+	var b strings.Builder
+	writeMetric := func(name, help, typ string, value func(repositories.DependencyState) float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, state := range states {
+			fmt.Fprintf(&b, "%s{dependency=%q} %g\n", name, state.Name, value(state))
+		}
+	}
+	writeMetric("hotel_luggage_dependency_up", "Whether the optional dependency is available (0 = degraded).", "gauge",
+		func(s repositories.DependencyState) float64 {
+			if s.Up {
+				return 1
+			}
+			return 0
+		})
+	writeMetric("hotel_luggage_dependency_consecutive_failures", "Consecutive failed connection or health checks.", "gauge",
+		func(s repositories.DependencyState) float64 { return float64(s.ConsecutiveFailures) })
+	writeMetric("hotel_luggage_dependency_reconnects_total", "Times the dependency recovered from degraded mode.", "counter",
+		func(s repositories.DependencyState) float64 { return float64(s.Reconnects) })
+	writeMetric("hotel_luggage_dependency_state_since_seconds", "Unix time when the dependency entered its current state.", "gauge",
+		func(s repositories.DependencyState) float64 { return float64(s.Since.Unix()) })
+
+	shutting := 0
+	if shuttingDown.Load() {
+		shutting = 1
+	}
+	fmt.Fprintf(&b, "# HELP hotel_luggage_shutting_down Whether the server is draining for shutdown.\n# TYPE hotel_luggage_shutting_down gauge\nhotel_luggage_shutting_down %d\n", shutting)
+
+	fmt.Fprintf(&b, "# HELP hotel_luggage_audit_write_failures_total Audit log entries that failed to be written.\n# TYPE hotel_luggage_audit_write_failures_total counter\nhotel_luggage_audit_write_failures_total %d\n", services.AuditFailures())
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateHotelPolicyRequest 修改酒店策略请求（只修改传入的字段）
+type UpdateHotelPolicyRequest struct {
+	CheckoutVerification   *string `json:"checkout_verification"`     // 普通行李取件核验方式：none/phone_last4/otp/id_document
+	HighRiskVerification   *string `json:"high_risk_verification"`    // 高风险行李取件核验方式
+	HighRiskQuantity       *int    `json:"high_risk_quantity"`        // 件数达到该值视为高风险（0 表示不按件数判断）
+	HighRiskKeywords       *string `json:"high_risk_keywords"`        // 特殊备注包含这些关键字（逗号分隔）视为高风险
+	RequireSignature       *bool   `json:"require_signature"`         // 取件时是否必须有客人签名
+	RequireCheckinPhotos   *bool   `json:"require_checkin_photos"`    // 寄存时是否必须拍摄行李状态照片
+	CodeLength             *int    `json:"code_length"`               // 取件码长度（6-8，含校验位）
+	CodeAlphabet           *string `json:"code_alphabet"`             // 取件码字符集：numeric / crockford
+	CodeCheckDigit         *bool   `json:"code_check_digit"`          // 取件码最后一位是否为校验位
+	CodeReuseCooldownHours *int    `json:"code_reuse_cooldown_hours"` // 取件码取走后多少小时内不再分配（0 表示不限制）
+	CodeTTLHours           *int    `json:"code_ttl_hours"`            // 取件码有效期（小时，0 表示不过期）
+	AssignStrategy         *string `json:"assign_strategy"`           // 自动分配寄存室策略：balance 均匀分布 / fill 先装满一间
+	ShortTermHours         *int    `json:"short_term_hours"`          // 预计取件时间在多少小时内视为短时寄存
+	LostFoundRetentionDays *int    `json:"lost_found_retention_days"` // 拾获物品保管期限（天，1-3650）
+}
+
+// GetCurrentHotelPolicy 获取当前用户所属酒店的策略
+// GET /api/luggage/policy
+func GetCurrentHotelPolicy(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	policy, err := services.GetHotelPolicy(hotelID)
+	if err != nil {
+		middleware.AbortWithError(c, "get hotel policy failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "get hotel policy success",
+		"item":    policy,
+	})
+}
+
+// GetHotelPolicy 获取指定酒店的策略（管理员）
+// GET /api/admin/hotels/:id/policy
+func GetHotelPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid hotel id", apperr.ErrInvalidRequest)
+		return
+	}
+	policy, err := services.GetHotelPolicy(id)
+	if err != nil {
+		middleware.AbortWithError(c, "get hotel policy failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "get hotel policy success",
+		"item":    policy,
+	})
+}
+
+// UpdateHotelPolicy 修改指定酒店的策略（管理员）
+// PUT /api/admin/hotels/:id/policy
+func UpdateHotelPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid hotel id", apperr.ErrInvalidRequest)
+		return
+	}
+	var req UpdateHotelPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+
+	policy, err := services.UpdateHotelPolicy(c.Request.Context(), id, services.UpdateHotelPolicyRequest{
+		CheckoutVerification:   req.CheckoutVerification,
+		HighRiskVerification:   req.HighRiskVerification,
+		HighRiskQuantity:       req.HighRiskQuantity,
+		HighRiskKeywords:       req.HighRiskKeywords,
+		RequireSignature:       req.RequireSignature,
+		RequireCheckinPhotos:   req.RequireCheckinPhotos,
+		CodeLength:             req.CodeLength,
+		CodeAlphabet:           req.CodeAlphabet,
+		CodeCheckDigit:         req.CodeCheckDigit,
+		CodeReuseCooldownHours: req.CodeReuseCooldownHours,
+		CodeTTLHours:           req.CodeTTLHours,
+		AssignStrategy:         req.AssignStrategy,
+		ShortTermHours:         req.ShortTermHours,
+		LostFoundRetentionDays: req.LostFoundRetentionDays,
+		UpdatedBy:              c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "update hotel policy failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "update hotel policy success",
+		"item":    policy,
+	})
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
 	"hotel_luggage/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,18 +15,13 @@ import (
 func GetQRCode(c *gin.Context) {
 	code := c.Param("code")
 	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "code is required",
-		})
+		middleware.AbortWithError(c, "code is required", apperr.ErrInvalidRequest)
 		return
 	}
 
 	png, err := utils.GenerateQRCodePNG(code, 256)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "generate qr failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "generate qr failed", err)
 		return
 	}
 
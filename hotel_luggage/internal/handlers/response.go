@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// currentUser 获取当前登录用户（由 JWTAuth 写入的 username 查询数据库）
+// 失败时已写入错误，调用方直接 return 即可
+func currentUser(c *gin.Context) (models.User, bool) {
+	username, _ := c.Get("username")
+	userNameStr, _ := username.(string)
+	if userNameStr == "" {
+		middleware.AbortWithError(c, "missing user info", apperr.ErrUnauthorized)
+		return models.User{}, false
+	}
+
+	user, err := repositories.GetUserByUsername(userNameStr)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = apperr.ErrUserNotFound
+		}
+		middleware.AbortWithError(c, "get user failed", err)
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// currentHotelID 获取当前登录用户所属酒店ID
+// 失败时已写入错误，调用方直接 return 即可
+func currentHotelID(c *gin.Context) (int64, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return 0, false
+	}
+	if user.HotelID == nil || *user.HotelID <= 0 {
+		middleware.AbortWithError(c, "hotel_id is missing", apperr.ErrHotelNotAssigned)
+		return 0, false
+	}
+	return *user.HotelID, true
+}
+
+// NotFound 未匹配路由的处理函数（404，统一错误信封）
+func NotFound(c *gin.Context) {
+	middleware.AbortWithError(c, "route not found", apperr.ErrNotFound)
+}
@@ -3,8 +3,12 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
-	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +16,68 @@ import (
 
 // CreateStoreroomRequest 创建寄存室请求结构体
 type CreateStoreroomRequest struct {
-	Name     string `json:"name" binding:"required"` // 寄存室名称
-	Location string `json:"location"`                // 位置描述
-	Capacity int    `json:"capacity"`                // 容量
-	IsActive bool   `json:"is_active"`               // 是否启用
+	Name        string             `json:"name" binding:"required"` // 寄存室名称
+	Location    string             `json:"location"`                // 位置描述
+	Capacity    int                `json:"capacity"`                // 容量（单位数：每件行李占用 件数 × 尺寸权重，0 表示不限制）
+	IsActive    bool               `json:"is_active"`               // 是否启用
+	SizeWeights SizeWeightsRequest `json:"size_weights"`            // 尺寸权重（可选，默认 small 1 / medium 1 / large 2 / oversized 3）
+	Priority    *int               `json:"priority"`                // 自动分配优先级（可选，数字越小越优先，默认 0）
+	Handling    *[]string          `json:"handling"`                // 支持的特殊保管要求（可选）：fragile / valuables / refrigerated
+	PickupTerm  *string            `json:"pickup_term"`             // 适合的取件时间（可选）：any（默认）/ short / long
+}
+
+// UpdateStoreroomAssignmentRequest 修改寄存室自动分配规则请求（只修改传入的字段）
+type UpdateStoreroomAssignmentRequest struct {
+	Priority   *int      `json:"priority"`    // 数字越小越优先（如离前台越近）
+	Handling   *[]string `json:"handling"`    // 支持的特殊保管要求：fragile / valuables / refrigerated（传空数组表示都不支持）
+	PickupTerm *string   `json:"pickup_term"` // 适合的取件时间：any / short / long
+}
+
+// SizeWeightsRequest 尺寸权重（每件行李占用的容量单位数，1-100）
+type SizeWeightsRequest struct {
+	Small     *int `json:"small"`
+	Medium    *int `json:"medium"` // 未填写尺寸的行李按 medium 计算
+	Large     *int `json:"large"`
+	Oversized *int `json:"oversized"`
+}
+
+// UpdateStoreroomCapacityRequest 修改寄存室容量和尺寸权重请求（只修改传入的字段）
+type UpdateStoreroomCapacityRequest struct {
+	Capacity    *int               `json:"capacity"` // 容量（单位数，0 表示不限制）
+	SizeWeights SizeWeightsRequest `json:"size_weights"`
+}
+
+func (w SizeWeightsRequest) toService() services.SizeWeights {
+	return services.SizeWeights{Small: w.Small, Medium: w.Medium, Large: w.Large, Oversized: w.Oversized}
+}
+
+// storeroomSizeWeights 寄存室的尺寸权重（用于响应）
+func storeroomSizeWeights(room models.LuggageStoreroom) gin.H {
+	return gin.H{
+		models.SizeSmall:     room.SizeWeight(models.SizeSmall),
+		models.SizeMedium:    room.SizeWeight(models.SizeMedium),
+		models.SizeLarge:     room.SizeWeight(models.SizeLarge),
+		models.SizeOversized: room.SizeWeight(models.SizeOversized),
+	}
+}
+
+// EvacuateStoreroomRequest 批量迁移寄存室行李请求
+type EvacuateStoreroomRequest struct {
+	LuggageIDs         []int64 `json:"luggage_ids"`          // 要迁移的行李ID（不传表示全部在存行李）
+	TargetStoreroomIDs []int64 `json:"target_storeroom_ids"` // 目标寄存室ID（按顺序依次放满；不传时使用本酒店其他启用的寄存室）
+	Deactivate         bool    `json:"deactivate"`           // 迁移完成后停用源寄存室
+	DryRun             bool    `json:"dry_run"`              // 只返回迁移清单，不实际迁移
+}
+
+// storeroomHandling 寄存室支持的特殊保管要求（用于响应）
+func storeroomHandling(room models.LuggageStoreroom) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(room.Handling, ",") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
 }
 
 // UpdateStoreroomStatusRequest 更新寄存室状态请求结构体
@@ -25,56 +87,25 @@ type UpdateStoreroomStatusRequest struct {
 
 // ListStorerooms 获取寄存室列表（当前登录用户的酒店）
 func ListStorerooms(c *gin.Context) {
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
 
-	user, err := repositories.GetUserByUsername(userNameStr)
+	rooms, err := services.ListStorerooms(hotelID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
-		return
-	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
-		return
-	}
-
-	rooms, err := services.ListStorerooms(*user.HotelID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "list storerooms failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "list storerooms failed", err)
 		return
 	}
 
 	result := make([]gin.H, 0, len(rooms))
 	for _, room := range rooms {
-		storedCount, err := repositories.CountStoredByStoreroom(room.ID)
+		// stored_count / remaining_capacity 以容量单位计（件数 × 尺寸权重）
+		usage, err := services.GetStoreroomUsage(room)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "count storeroom luggage failed",
-				"error":   err.Error(),
-			})
+			middleware.AbortWithError(c, "count storeroom luggage failed", err)
 			return
 		}
-		remaining := -1
-		if room.Capacity > 0 {
-			remaining = room.Capacity - int(storedCount)
-			if remaining < 0 {
-				remaining = 0
-			}
-		}
 		result = append(result, gin.H{
 			"id":                 room.ID,
 			"hotel_id":           room.HotelID,
@@ -83,8 +114,12 @@ func ListStorerooms(c *gin.Context) {
 			"capacity":           room.Capacity,
 			"is_active":          room.IsActive,
 			"created_at":         room.CreatedAt,
-			"stored_count":       storedCount,
-			"remaining_capacity": remaining,
+			"stored_count":       usage.StoredUnits,
+			"remaining_capacity": usage.RemainingCapacity,
+			"size_weights":       storeroomSizeWeights(room),
+			"priority":           room.Priority,
+			"handling":           storeroomHandling(room),
+			"pickup_term":        room.PickupTerm,
 		})
 	}
 
@@ -98,48 +133,30 @@ func ListStorerooms(c *gin.Context) {
 func CreateStoreroom(c *gin.Context) {
 	var req CreateStoreroomRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	username, _ := c.Get("username")
-	userNameStr, _ := username.(string)
-	if userNameStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "missing user info",
-		})
-		return
-	}
-	user, err := repositories.GetUserByUsername(userNameStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "get user failed",
-			"error":   err.Error(),
-		})
-		return
-	}
-	if user.HotelID == nil || *user.HotelID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "hotel_id is missing",
-		})
+	hotelID, ok := currentHotelID(c)
+	if !ok {
 		return
 	}
 
-	room, err := services.CreateStoreroom(services.CreateStoreroomRequest{
-		HotelID:  *user.HotelID,
+	room, err := services.CreateStoreroom(c.Request.Context(), services.CreateStoreroomRequest{
+		HotelID:  hotelID,
 		Name:     req.Name,
 		Location: req.Location,
 		Capacity: req.Capacity,
 		IsActive: req.IsActive,
+		Weights:  req.SizeWeights.toService(),
+		Assignment: services.UpdateStoreroomAssignmentRequest{
+			Priority:   req.Priority,
+			Handling:   req.Handling,
+			PickupTerm: req.PickupTerm,
+		},
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "create storeroom failed",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "create storeroom failed", err)
 		return
 	}
 
@@ -153,17 +170,12 @@ func CreateStoreroom(c *gin.Context) {
 func DeleteStoreroom(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid storeroom id",
-		})
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
 		return
 	}
 
-	if err := services.DeleteStoreroom(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "delete storeroom failed",
-			"error":   err.Error(),
-		})
+	if err := services.DeleteStoreroom(c.Request.Context(), id); err != nil {
+		middleware.AbortWithError(c, "delete storeroom failed", err)
 		return
 	}
 
@@ -176,26 +188,18 @@ func DeleteStoreroom(c *gin.Context) {
 func UpdateStoreroomStatus(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid storeroom id",
-		})
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
 		return
 	}
 
 	var req UpdateStoreroomStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
 		return
 	}
 
-	if err := services.UpdateStoreroomStatus(id, req.IsActive); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "update storeroom status failed",
-			"error":   err.Error(),
-		})
+	if err := services.UpdateStoreroomStatus(c.Request.Context(), id, req.IsActive); err != nil {
+		middleware.AbortWithError(c, "update storeroom status failed", err)
 		return
 	}
 
@@ -203,3 +207,156 @@ func UpdateStoreroomStatus(c *gin.Context) {
 		"message": "update storeroom status success",
 	})
 }
+
+// UpdateStoreroomCapacity 修改寄存室容量和尺寸权重
+// PUT /api/luggage/storerooms/:id/capacity
+func UpdateStoreroomCapacity(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+		return
+	}
+	var req UpdateStoreroomCapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	room, err := services.UpdateStoreroomCapacity(c.Request.Context(), hotelID, id, services.UpdateStoreroomCapacityRequest{
+		Capacity: req.Capacity,
+		Weights:  req.SizeWeights.toService(),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "update storeroom capacity failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "update storeroom capacity success",
+		"id":           room.ID,
+		"capacity":     room.Capacity,
+		"size_weights": storeroomSizeWeights(room),
+	})
+}
+
+// UpdateStoreroomAssignment 修改寄存室的自动分配规则（优先级、支持的特殊保管要求、适合的取件时间）
+// PUT /api/luggage/storerooms/:id/assignment
+func UpdateStoreroomAssignment(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+		return
+	}
+	var req UpdateStoreroomAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	room, err := services.UpdateStoreroomAssignment(c.Request.Context(), hotelID, id, services.UpdateStoreroomAssignmentRequest{
+		Priority:   req.Priority,
+		Handling:   req.Handling,
+		PickupTerm: req.PickupTerm,
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "update storeroom assignment failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "update storeroom assignment success",
+		"id":          room.ID,
+		"priority":    room.Priority,
+		"handling":    storeroomHandling(room),
+		"pickup_term": room.PickupTerm,
+	})
+}
+
+// RecommendStorerooms 按酒店的分配规则推荐寄存室（寄存时 storeroom_id 传 "auto" 即使用第一项）
+// GET /api/luggage/storerooms/recommend?quantity=1&size_class=large&handling=fragile,valuables&expected_pickup_at=2026-01-02T15:04:05Z
+func RecommendStorerooms(c *gin.Context) {
+	quantity := 1
+	if q := c.Query("quantity"); q != "" {
+		var err error
+		quantity, err = strconv.Atoi(q)
+		if err != nil || quantity <= 0 {
+			middleware.AbortWithError(c, "invalid quantity", apperr.ErrInvalidRequest)
+			return
+		}
+	}
+	var expectedPickupAt *time.Time
+	if v := c.Query("expected_pickup_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			middleware.AbortWithError(c, "invalid expected_pickup_at", apperr.InvalidRequest("expected_pickup_at must be RFC3339"))
+			return
+		}
+		expectedPickupAt = &t
+	}
+	var handling []string
+	if v := c.Query("handling"); v != "" {
+		handling = strings.Split(v, ",")
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	recs, err := services.RecommendStorerooms(services.AssignmentRequest{
+		HotelID:          hotelID,
+		Quantity:         quantity,
+		SizeClass:        c.Query("size_class"),
+		Handling:         handling,
+		ExpectedPickupAt: expectedPickupAt,
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "recommend storerooms failed", err)
+		return
+	}
+	// 没有合适的寄存室时 items 为空数组
+	c.JSON(http.StatusOK, gin.H{
+		"message": "recommend storerooms success",
+		"items":   recs,
+	})
+}
+
+// EvacuateStoreroom 批量迁移寄存室内的行李（如寄存室装修关闭），返回迁移清单
+// POST /api/luggage/storerooms/:id/evacuate
+func EvacuateStoreroom(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		middleware.AbortWithError(c, "invalid storeroom id", apperr.ErrInvalidRequest)
+		return
+	}
+	var req EvacuateStoreroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	manifest, err := services.EvacuateStoreroom(c.Request.Context(), hotelID, id, services.EvacuateStoreroomRequest{
+		LuggageIDs: req.LuggageIDs,
+		Targets:    req.TargetStoreroomIDs,
+		Deactivate: req.Deactivate,
+		DryRun:     req.DryRun,
+		MovedBy:    c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "evacuate storeroom failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "evacuate storeroom success",
+		"manifest": manifest,
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DispatchTransferRequest 发出转寄请求
+type DispatchTransferRequest struct {
+	ToHotelID  int64  `json:"to_hotel_id" binding:"required"` // 目的酒店ID（连锁内的其他酒店）
+	Courier    string `json:"courier" binding:"required"`     // 承运方（快递公司 / 酒店班车 / 员工姓名）
+	TrackingNo string `json:"tracking_no"`                    // 运单号（可选）
+	Notes      string `json:"notes"`                          // 备注（可选）
+}
+
+// ReceiveTransferRequest 签收转寄请求
+type ReceiveTransferRequest struct {
+	StoreroomID StoreroomRef `json:"storeroom_id"` // 放入的寄存室ID（必填），传 "auto" 按优先级放入放得下的寄存室
+}
+
+// DispatchTransfer 把取件码下的在存行李转寄到连锁内的另一家酒店
+// POST /api/luggage/:id/transfer
+func DispatchTransfer(c *gin.Context) {
+	var req DispatchTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	transfers, err := services.DispatchTransfer(c.Request.Context(), hotelID, c.Param("id"), services.DispatchTransferRequest{
+		ToHotelID:    req.ToHotelID,
+		Courier:      req.Courier,
+		TrackingNo:   req.TrackingNo,
+		Notes:        req.Notes,
+		DispatchedBy: c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "dispatch transfer failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dispatch transfer success",
+		"items":   transfers,
+	})
+}
+
+// ReceiveTransfer 目的酒店签收转寄的行李并放入本酒店的寄存室（取件码不变）
+// POST /api/luggage/:id/transfer/receive
+func ReceiveTransfer(c *gin.Context) {
+	var req ReceiveTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest(err.Error()))
+		return
+	}
+	if req.StoreroomID.empty() {
+		middleware.AbortWithError(c, "invalid request", apperr.InvalidRequest("storeroom_id is required"))
+		return
+	}
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+
+	items, err := services.ReceiveTransfer(c.Request.Context(), hotelID, c.Param("id"), services.ReceiveTransferRequest{
+		StoreroomID: req.StoreroomID.ID,
+		AutoAssign:  req.StoreroomID.Auto,
+		ReceivedBy:  c.GetString("username"),
+	})
+	if err != nil {
+		middleware.AbortWithError(c, "receive transfer failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "receive transfer success",
+		"items":   items,
+	})
+}
+
+// CancelTransfer 发出酒店取消转寄，行李退回原寄存室
+// POST /api/luggage/:id/transfer/cancel
+func CancelTransfer(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	items, err := services.CancelTransfer(c.Request.Context(), hotelID, c.Param("id"), c.GetString("username"))
+	if err != nil {
+		middleware.AbortWithError(c, "cancel transfer failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "cancel transfer success",
+		"items":   items,
+	})
+}
+
+// ListTransfersByCode 获取取件码的转寄记录（本酒店发出或收到的）
+// GET /api/luggage/:id/transfers
+func ListTransfersByCode(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	transfers, err := services.ListTransfersByCode(hotelID, c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, "list transfers failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list transfers success",
+		"items":   transfers,
+	})
+}
+
+// ListTransfers 获取本酒店发出或收到的转寄记录
+// GET /api/luggage/transfers?direction=incoming&status=in_transit
+func ListTransfers(c *gin.Context) {
+	hotelID, ok := currentHotelID(c)
+	if !ok {
+		return
+	}
+	transfers, err := services.ListTransfers(hotelID, c.Query("direction"), c.Query("status"))
+	if err != nil {
+		middleware.AbortWithError(c, "list transfers failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "list transfers success",
+		"items":   transfers,
+	})
+}
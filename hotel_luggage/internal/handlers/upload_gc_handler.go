@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/services"
+	"hotel_luggage/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrphanUploadReport 未被引用的上传报告（dry-run，不删除任何文件）
+// GET /api/admin/uploads/orphans
+// 列出超过 upload.gc_grace_period 仍未挂到寄存单 / 取件历史 / 拾获物品 / 事故报告上的照片，即下一轮清理会删除的文件
+func OrphanUploadReport(cfg configs.UploadConfig, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.CollectOrphanUploads(c.Request.Context(), store, cfg.GCGracePeriod.Std(), true)
+		if err != nil {
+			middleware.AbortWithError(c, "get orphan uploads failed", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "get orphan uploads success",
+			"report":  report,
+		})
+	}
+}
+
+// CollectOrphanUploads 立即清理未被引用的上传
+// POST /api/admin/uploads/gc
+func CollectOrphanUploads(cfg configs.UploadConfig, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := services.CollectOrphanUploads(c.Request.Context(), store, cfg.GCGracePeriod.Std(), false)
+		if err != nil {
+			middleware.AbortWithError(c, "collect orphan uploads failed", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "collect orphan uploads success",
+			"report":  report,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package imaging
+
+import "encoding/binary"
+
+// jpegOrientation 读取 JPEG 中 EXIF 的 Orientation 标签（1~8），没有或解析失败时返回 1
+// 只解析 APP1 段中 IFD0 的 0x0112 标签，不依赖第三方库
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF: // 填充字节
+			i++
+			continue
+		case marker == 0xD9 || marker == 0xDA: // EOI / SOS：之后是图像数据
+			return 1
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // 无长度字段的标记
+			i += 2
+			continue
+		}
+		segLen := int(binary.BigEndian.Uint16(data[i+2:]))
+		if segLen < 2 || i+2+segLen > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+segLen]
+		if marker == 0xE1 && len(seg) >= 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + segLen
+	}
+	return 1
+}
+
+// tiffOrientation 从 TIFF 结构（EXIF 数据体）中读取 IFD0 的 Orientation
+func tiffOrientation(b []byte) int {
+	if len(b) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(b[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(b[2:]) != 42 {
+		return 1
+	}
+	offset := int(order.Uint32(b[4:]))
+	if offset < 8 || offset+2 > len(b) {
+		return 1
+	}
+	count := int(order.Uint16(b[offset:]))
+	for k := 0; k < count; k++ {
+		entry := offset + 2 + k*12
+		if entry+12 > len(b) {
+			return 1
+		}
+		if order.Uint16(b[entry:]) != 0x0112 {
+			continue
+		}
+		// 类型 SHORT，值直接存放在值字段的前 2 个字节
+		if v := int(order.Uint16(b[entry+8:])); v >= 1 && v <= 8 {
+			return v
+		}
+		return 1
+	}
+	return 1
+}
@@ -0,0 +1,128 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"strings"
+
+	_ "golang.org/x/image/webp" // 注册 WebP 解码器
+)
+
+// 图片处理错误
+var (
+	ErrNotImage      = errors.New("file is not a supported image") // 不是 JPEG / PNG / WebP
+	ErrTooManyPixels = errors.New("image dimensions too large")    // 像素数超过上限（防止解压炸弹）
+)
+
+// allowedTypes 允许上传的图片类型（按文件内容判断，不看扩展名）
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// Variant 缩略图规格
+type Variant struct {
+	Name         string // 规格名称（用于生成 key，例如 small）
+	MaxDimension int    // 长边像素上限
+}
+
+// Options 处理参数
+type Options struct {
+	MaxDimension int       // 主图长边像素上限
+	JPEGQuality  int       // JPEG 质量（1~100）
+	MaxPixels    int       // 解码前允许的最大像素数（宽×高）
+	Thumbnails   []Variant // 需要生成的缩略图
+}
+
+// Output 处理后的一张图片
+type Output struct {
+	Data        []byte
+	ContentType string // image/jpeg 或 image/png
+	Ext         string // .jpg 或 .png
+	Width       int
+	Height      int
+}
+
+// Result 处理结果
+type Result struct {
+	SourceType string            // 嗅探到的原始类型
+	Image      Output            // 主图
+	Thumbnails map[string]Output // 缩略图（按规格名称）
+}
+
+// Process 处理上传的图片：
+// 1. 按文件内容嗅探真实类型，拒绝非图片
+// 2. 检查像素数，防止超大图片耗尽内存
+// 3. 按 EXIF Orientation 自动旋转（重新编码后 EXIF / GPS 等元数据全部丢弃）
+// 4. 缩放到长边不超过 MaxDimension 并重新编码（不透明图片编码为 JPEG，带透明通道的保留为 PNG）
+// 5. 生成缩略图
+func Process(data []byte, opts Options) (Result, error) {
+	sourceType := http.DetectContentType(data)
+	if !allowedTypes[sourceType] {
+		return Result{}, ErrNotImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrNotImage
+	}
+	if opts.MaxPixels > 0 && cfg.Width*cfg.Height > opts.MaxPixels {
+		return Result{}, ErrTooManyPixels
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrNotImage
+	}
+	if sourceType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	// PNG 和带透明通道的 WebP 保留为 PNG，其余编码为 JPEG
+	asPNG := sourceType == "image/png" || !isOpaque(img)
+
+	main, err := encode(fit(img, opts.MaxDimension), asPNG, opts.JPEGQuality)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{SourceType: sourceType, Image: main, Thumbnails: make(map[string]Output, len(opts.Thumbnails))}
+	for _, v := range opts.Thumbnails {
+		thumb, err := encode(fit(img, v.MaxDimension), asPNG, opts.JPEGQuality)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Thumbnails[v.Name] = thumb
+	}
+	return result, nil
+}
+
+func encode(img image.Image, asPNG bool, quality int) (Output, error) {
+	var buf bytes.Buffer
+	b := img.Bounds()
+	if asPNG {
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := enc.Encode(&buf, img); err != nil {
+			return Output{}, fmt.Errorf("encode png: %w", err)
+		}
+		return Output{Data: buf.Bytes(), ContentType: "image/png", Ext: ".png", Width: b.Dx(), Height: b.Dy()}, nil
+	}
+	if quality <= 0 || quality > 100 {
+		quality = jpeg.DefaultQuality
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return Output{}, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return Output{Data: buf.Bytes(), ContentType: "image/jpeg", Ext: ".jpg", Width: b.Dx(), Height: b.Dy()}, nil
+}
+
+// VariantKey 返回缩略图的对象 key：2026/01/abc.jpg + small -> 2026/01/abc_small.jpg
+func VariantKey(key, variant string) string {
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_" + variant + ext
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"hotel_luggage/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler 统一错误响应中间件
+// 功能：
+// 1. 等待后续 handler 执行完毕
+// 2. 如果 handler 通过 c.Error(err) 记录了错误且尚未写响应，则统一输出错误信封
+// 3. 业务错误（apperr.Error）按其状态码返回；其他错误一律视为 500，并只写日志
+//
+// 错误信封格式：
+//
+//	{
+//	  "message": "create luggage failed", // 操作描述（handler 通过 apperr.ContextMessageKey 设置）
+//	  "code":    "STOREROOM_FULL",        // 稳定错误码
+//	  "error":   "storeroom is full"      // 错误描述
+//	}
+//
+// 使用方式：
+//
+//	r.Use(middleware.ErrorHandler())
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		appErr := apperr.From(c.Errors.Last().Err)
+		if appErr.Status >= http.StatusInternalServerError {
+			// 内部错误：记录原始错误，响应中不暴露细节
+			log.Printf("❌ %s %s: %v", c.Request.Method, c.Request.URL.Path, c.Errors.Last().Err)
+		}
+
+		message := c.GetString(apperr.ContextMessageKey)
+		if message == "" {
+			message = appErr.Message
+		}
+		c.JSON(appErr.Status, gin.H{
+			"message": message,
+			"code":    appErr.Code,
+			"error":   appErr.Message,
+		})
+	}
+}
+
+// AbortWithError 记录错误并中止请求，由 ErrorHandler 统一输出错误信封
+// message 为操作描述（如 "create luggage failed"），err 决定错误码与 HTTP 状态码
+// handler 和其他中间件都通过它返回错误
+func AbortWithError(c *gin.Context, message string, err error) {
+	c.Set(apperr.ContextMessageKey, message)
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hotel_luggage/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorHandlerEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		wantBody   map[string]string // 为空表示不输出错误信封
+	}{
+		{name: "domain error", handler: func(c *gin.Context) {
+			AbortWithError(c, "create luggage failed", apperr.ErrStoreroomFull)
+		}, wantStatus: http.StatusConflict, wantBody: map[string]string{
+			"message": "create luggage failed", "code": "STOREROOM_FULL", "error": "storeroom is full",
+		}},
+		{name: "custom message", handler: func(c *gin.Context) {
+			AbortWithError(c, "retrieve luggage failed", apperr.InvalidRequest("code is empty"))
+		}, wantStatus: http.StatusBadRequest, wantBody: map[string]string{
+			"message": "retrieve luggage failed", "code": "INVALID_REQUEST", "error": "code is empty",
+		}},
+		// 没有操作描述时 message 使用错误描述
+		{name: "no operation message", handler: func(c *gin.Context) {
+			_ = c.Error(apperr.ErrLuggageNotFound)
+		}, wantStatus: http.StatusNotFound, wantBody: map[string]string{
+			"message": "luggage not found", "code": "LUGGAGE_NOT_FOUND", "error": "luggage not found",
+		}},
+		// 原始错误不出现在响应中
+		{name: "internal error", handler: func(c *gin.Context) {
+			AbortWithError(c, "list luggage failed", errors.New("dial tcp 10.0.0.5:3306: connection refused"))
+		}, wantStatus: http.StatusInternalServerError, wantBody: map[string]string{
+			"message": "list luggage failed", "code": "INTERNAL_ERROR", "error": "internal server error",
+		}},
+		// handler 已写响应时不再覆盖
+		{name: "response already written", handler: func(c *gin.Context) {
+			_ = c.Error(apperr.ErrLuggageNotFound)
+			c.String(http.StatusOK, "ok")
+		}, wantStatus: http.StatusOK},
+		{name: "no error", handler: func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		}, wantStatus: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler())
+			r.GET("/test", tt.handler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody == nil {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q is not an error envelope: %v", w.Body.String(), err)
+			}
+			if len(body) != len(tt.wantBody) {
+				t.Fatalf("body = %v, want %v", body, tt.wantBody)
+			}
+			for key, want := range tt.wantBody {
+				if body[key] != want {
+					t.Fatalf("body[%q] = %q, want %q", key, body[key], want)
+				}
+			}
+		})
+	}
+}
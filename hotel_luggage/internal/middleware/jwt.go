@@ -1,9 +1,10 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/audit"
 	"hotel_luggage/utils"
 
 	"github.com/gin-gonic/gin"
@@ -14,7 +15,7 @@ import (
 // 1. 从 HTTP Header 中提取 Authorization: Bearer <token>
 // 2. 解析并验证 JWT token 的有效性（签名、过期时间等）
 // 3. 将解析后的用户信息（username, role）存入 gin.Context
-// 4. 如果 token 无效或缺失，返回 401 Unauthorized 并中止请求
+// 4. 如果 token 无效或缺失，中止请求并由 ErrorHandler 返回 401 Unauthorized
 //
 // 使用方式：
 //   auth := api.Group("/")
@@ -30,10 +31,8 @@ func JWTAuth() gin.HandlerFunc {
 		
 		// 2. 验证 header 格式是否为 "Bearer <token>"
 		if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"message": "missing or invalid authorization header",
-			})
-			c.Abort() // 中止请求，不再执行后续 handler
+			// 中止请求，不再执行后续 handler（由 ErrorHandler 输出 401）
+			AbortWithError(c, "missing or invalid authorization header", apperr.ErrUnauthorized)
 			return
 		}
 
@@ -44,17 +43,15 @@ func JWTAuth() gin.HandlerFunc {
 		claims, err := utils.ParseToken(tokenStr)
 		if err != nil {
 			// token 无效（签名错误、已过期、格式错误等）
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"message": "invalid token",
-				"error":   err.Error(),
-			})
-			c.Abort()
+			AbortWithError(c, "invalid token", apperr.ErrInvalidToken.Wrap(err))
 			return
 		}
 
 		// 5. 将用户信息存入 Context，供后续 handler 使用
 		c.Set("username", claims.Username) // 用户名
 		c.Set("role", claims.Role)         // 角色（staff/admin）
+		// 审计日志的操作人（IP、请求ID 已由 RequestID 中间件写入）
+		c.Request = c.Request.WithContext(audit.WithUser(c.Request.Context(), claims.Username, claims.Role))
 		
 		// 6. 继续执行后续 handler
 		c.Next()
@@ -75,10 +72,7 @@ func AdminOnly() gin.HandlerFunc {
 		
 		// 验证角色是否为 admin
 		if role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"message": "admin only",
-			})
-			c.Abort() // 非管理员，中止请求
+			AbortWithError(c, "admin only", apperr.ErrAdminOnly) // 非管理员，中止请求
 			return
 		}
 		
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"hotel_luggage/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求ID的请求 / 响应头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 接受客户端（或网关）传入的请求ID的最大长度，超长时重新生成
+const maxRequestIDLength = 64
+
+// RequestID 请求ID中间件
+// 功能：
+// 1. 沿用请求头 X-Request-ID（网关已生成时），没有或不合法时生成随机ID，并写回响应头
+// 2. 把请求ID、客户端 IP 写入请求的 context，审计日志从中读取（登录用户由 JWTAuth 补充）
+//    客户端 IP 取自 c.ClientIP()：只有 SetupRouter 中配置的可信代理转发的 X-Forwarded-For 才会被采用
+//
+// 使用方式：
+//
+//	r.Use(middleware.RequestID())
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Writer.Header().Set(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			IP:        c.ClientIP(),
+			RequestID: id,
+		}))
+		c.Next()
+	}
+}
+
+// validRequestID 请求ID只接受字母、数字、- 和 _（避免日志注入）
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成 32 位十六进制随机请求ID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
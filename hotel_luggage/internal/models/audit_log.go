@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// 审计日志的实体类型
+const (
+	AuditEntityLuggage   = "luggage_item"
+	AuditEntityStoreroom = "storeroom"
+	AuditEntityLocation  = "storeroom_location"
+	AuditEntityHotel     = "hotel"
+	AuditEntityPolicy    = "hotel_policy"
+	AuditEntityUser      = "user"
+	AuditEntityDelegate  = "pickup_delegate"
+	AuditEntityTransfer  = "luggage_transfer"
+	AuditEntityFoundItem = "found_item"
+	AuditEntityClaim     = "lost_item_claim"
+	AuditEntityIncident  = "luggage_incident"
+	AuditEntityCheckout  = "checkout_otp"
+	AuditEntityUpload    = "upload"
+)
+
+// 审计日志的操作类型
+const (
+	AuditCreate      = "create"
+	AuditUpdate      = "update"
+	AuditDelete      = "delete"
+	AuditRetrieve    = "retrieve"     // 取件
+	AuditChangeCode  = "change_code"  // 手动修改取件码
+	AuditReissueCode = "reissue_code" // 重新生成取件码
+	AuditBind        = "bind"         // 行李绑定到用户
+	AuditStatus      = "status"       // 启用 / 停用
+	AuditEvacuate    = "evacuate"     // 批量迁移寄存室
+	AuditDispatch    = "dispatch"     // 转寄发出
+	AuditReceive     = "receive"      // 转寄签收
+	AuditCancel      = "cancel"       // 取消转寄
+	AuditRevoke      = "revoke"       // 撤销代取授权
+	AuditVerify      = "verify"       // 核验失主
+	AuditHandOver    = "hand_over"    // 交还失物
+	AuditClose       = "close"        // 关闭认领
+	AuditDispose     = "dispose"      // 处置拾获物品
+	AuditSendOTP     = "send_otp"     // 发送取件验证码
+	AuditCollect     = "collect"      // 清理未被引用的照片
+	AuditSync        = "sync"         // 本地照片同步到 MinIO
+	AuditRevert      = "revert"       // 寄存单回退到历史版本
+)
+
+// AuditLog 对应 audit_logs 表（所有写操作的审计日志，按 Seq 组成哈希链，只追加不修改）
+type AuditLog struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Seq        int64     `gorm:"column:seq;not null;uniqueIndex" json:"seq"`             // 哈希链序号（从 1 开始连续）
+	Actor      string    `gorm:"column:actor;size:50;not null;index" json:"actor"`       // 操作人用户名（后台任务为 system，客人自助为 anonymous）
+	ActorRole  string    `gorm:"column:actor_role;size:20" json:"actor_role"`            // 操作人角色（staff / admin）
+	HotelID    *int64    `gorm:"column:hotel_id;index" json:"hotel_id"`                  // 数据所属酒店（用户、全局任务等可能为空）
+	EntityType string    `gorm:"column:entity_type;size:30;not null" json:"entity_type"` // 实体类型（luggage_item / storeroom / hotel / user ...）
+	EntityID   string    `gorm:"column:entity_id;size:64;not null" json:"entity_id"`     // 实体ID（照片为对象 key）
+	Action     string    `gorm:"column:action;size:30;not null" json:"action"`           // 操作类型（create / update / delete / retrieve ...）
+	Before     string    `gorm:"column:before_data;type:mediumtext" json:"before"`       // 修改前快照（JSON，新建时为空）
+	After      string    `gorm:"column:after_data;type:mediumtext" json:"after"`         // 修改后快照（JSON，删除时为空）
+	IP         string    `gorm:"column:ip;size:45" json:"ip"`                            // 客户端 IP
+	RequestID  string    `gorm:"column:request_id;size:64;index" json:"request_id"`      // 请求ID（响应头 X-Request-ID）
+	PrevHash   string    `gorm:"column:prev_hash;size:64;not null" json:"prev_hash"`     // 上一条记录的哈希（第一条为空）
+	Hash       string    `gorm:"column:hash;size:64;not null" json:"hash"`               // 本条记录的哈希（见 audit.Hash）
+	CreatedAt  time.Time `gorm:"column:created_at;not null;index" json:"created_at"`     // 记录时间（精确到秒，参与哈希计算）
+}
+
+// TableName 指定数据库表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditChain 对应 audit_chain 表（只有 ID=1 一行，保存哈希链末尾，追加日志时加行锁保证串行）
+// 末尾的序号和哈希同时用于发现直接删除最后几条日志的篡改
+type AuditChain struct {
+	ID        int64     `gorm:"column:id;primaryKey" json:"id"`
+	LastSeq   int64     `gorm:"column:last_seq;not null" json:"last_seq"`           // 最后一条日志的序号
+	LastHash  string    `gorm:"column:last_hash;size:64;not null" json:"last_hash"` // 最后一条日志的哈希
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定数据库表名
+func (AuditChain) TableName() string {
+	return "audit_chain"
+}
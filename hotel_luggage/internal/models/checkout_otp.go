@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// 一次性验证码发送渠道
+const (
+	OTPChannelSMS   = "sms"
+	OTPChannelEmail = "email"
+)
+
+// CheckoutOTP 对应 checkout_otps 表（取件一次性验证码）
+// 只保存验证码的哈希，验证成功或超过尝试次数后失效
+type CheckoutOTP struct {
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement"`               // 记录ID
+	RetrievalCode string     `gorm:"column:retrieval_code;size:8;not null;index"`      // 取件码
+	Channel       string     `gorm:"column:channel;type:enum('sms','email');not null"` // 发送渠道
+	Destination   string     `gorm:"column:destination;size:100;not null"`             // 接收手机号 / 邮箱
+	CodeHash      string     `gorm:"column:code_hash;size:64;not null"`                // 验证码哈希（SHA-256）
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`               // 已尝试次数
+	ExpiresAt     time.Time  `gorm:"column:expires_at;not null"`                       // 过期时间
+	ConsumedAt    *time.Time `gorm:"column:consumed_at"`                               // 验证成功时间
+	CreatedBy     string     `gorm:"column:created_by;size:50;not null"`               // 发送操作员用户名
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`                 // 发送时间
+}
+
+// TableName 指定数据库表名
+func (CheckoutOTP) TableName() string {
+	return "checkout_otps"
+}
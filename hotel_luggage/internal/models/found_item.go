@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 拾获物品状态
+const (
+	FoundItemHeld       = "held"        // 保管中
+	FoundItemReserved   = "reserved"    // 认领已核验，等待交还（不能再被其他认领核验或处置）
+	FoundItemHandedOver = "handed_over" // 已交还失主
+	FoundItemDisposed   = "disposed"    // 超过保管期限已处置
+)
+
+// 拾获物品的处置方式
+const (
+	DisposalDiscarded = "discarded" // 丢弃
+	DisposalDonated   = "donated"   // 捐赠
+	DisposalPolice    = "police"    // 移交警方
+	DisposalDestroyed = "destroyed" // 销毁（如证件、银行卡）
+)
+
+// IsDisposalMethod 是否为合法的处置方式
+func IsDisposalMethod(method string) bool {
+	switch method {
+	case DisposalDiscarded, DisposalDonated, DisposalPolice, DisposalDestroyed:
+		return true
+	}
+	return false
+}
+
+// FoundItem 对应 found_items 表（前台拾获的物品，与行李寄存分开管理，但同样放在寄存室内）
+type FoundItem struct {
+	ID             int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	HotelID        int64      `gorm:"column:hotel_id;not null;index" json:"hotel_id"`
+	StoreroomID    int64      `gorm:"column:storeroom_id;not null;index" json:"storeroom_id"`                                     // 保管的寄存室
+	Category       string     `gorm:"column:category;size:50" json:"category"`                                                    // 类别（如 电子产品、证件、衣物）
+	Description    string     `gorm:"column:description;type:text;not null" json:"description"`                                   // 物品描述（用于搜索和认领匹配）
+	FoundLocation  string     `gorm:"column:found_location;size:255;not null" json:"found_location"`                              // 拾获地点（如 1203 房间、大堂沙发）
+	FoundAt        time.Time  `gorm:"column:found_at;not null" json:"found_at"`                                                   // 拾获时间
+	FoundBy        string     `gorm:"column:found_by;size:100" json:"found_by"`                                                   // 拾获人（员工或客人姓名）
+	Quantity       int        `gorm:"column:quantity;not null" json:"quantity"`                                                   // 件数
+	SizeClass      string     `gorm:"column:size_class;size:10" json:"size_class,omitempty"`                                      // 尺寸（计入寄存室容量，为空按 medium 计算）
+	PhotoURL       string     `gorm:"column:photo_url;size:255" json:"photo_url"`                                                 // 照片URL
+	PhotoURLsRaw   string     `gorm:"column:photo_urls;type:text" json:"-"`                                                       // 多图JSON（数据库字段）
+	PhotoURLs      []string   `gorm:"-" json:"photo_urls,omitempty"`                                                              // 多图数组（对外）
+	ThumbnailURL   string     `gorm:"-" json:"thumbnail_url,omitempty"`                                                           // 主照片缩略图签名地址（只用于响应）
+	ThumbnailURLs  []string   `gorm:"-" json:"thumbnail_urls,omitempty"`                                                          // 多图缩略图签名地址（与 photo_urls 一一对应）
+	Notes          string     `gorm:"column:notes;type:text" json:"notes"`                                                        // 备注
+	Status         string     `gorm:"column:status;type:enum('held','reserved','handed_over','disposed');not null" json:"status"` // 状态
+	RetainUntil    time.Time  `gorm:"column:retain_until;not null" json:"retain_until"`                                           // 保管期限（拾获时间 + 酒店策略的保管天数），到期后才能处置
+	ClaimID        *int64     `gorm:"column:claim_id" json:"claim_id"`                                                            // 核验通过的认领（reserved / handed_over 时有值）
+	HandedOverTo   string     `gorm:"column:handed_over_to;size:100" json:"handed_over_to,omitempty"`                             // 实际领取人姓名
+	HandedOverBy   string     `gorm:"column:handed_over_by;size:50" json:"handed_over_by,omitempty"`                              // 交还的工作人员
+	HandedOverAt   *time.Time `gorm:"column:handed_over_at" json:"handed_over_at,omitempty"`                                      // 交还时间
+	DisposalMethod string     `gorm:"column:disposal_method;size:20" json:"disposal_method,omitempty"`                            // 处置方式
+	DisposedBy     string     `gorm:"column:disposed_by;size:50" json:"disposed_by,omitempty"`                                    // 处置的工作人员
+	DisposedAt     *time.Time `gorm:"column:disposed_at" json:"disposed_at,omitempty"`                                            // 处置时间
+	CreatedBy      string     `gorm:"column:created_by;size:50;not null" json:"created_by"`                                       // 登记的工作人员
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`                                         // 登记时间
+	UpdatedAt      time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`                                         // 最后修改时间
+}
+
+// TableName 指定数据库表名
+func (FoundItem) TableName() string {
+	return "found_items"
+}
+
+// BeforeSave 在保存前把 PhotoURLs 写入 PhotoURLsRaw
+func (item *FoundItem) BeforeSave(tx *gorm.DB) error {
+	if item.PhotoURLs != nil {
+		data, err := json.Marshal(item.PhotoURLs)
+		if err != nil {
+			return err
+		}
+		item.PhotoURLsRaw = string(data)
+	}
+	return nil
+}
+
+// AfterFind 在读取后把 PhotoURLsRaw 解析为 PhotoURLs
+func (item *FoundItem) AfterFind(tx *gorm.DB) error {
+	if item.PhotoURLsRaw == "" {
+		return nil
+	}
+	var urls []string
+	if err := json.Unmarshal([]byte(item.PhotoURLsRaw), &urls); err != nil {
+		return err
+	}
+	item.PhotoURLs = urls
+	return nil
+}
+
+// 拾获物品修改记录的操作类型
+const (
+	FoundItemActionCreated    = "created"
+	FoundItemActionUpdated    = "updated"
+	FoundItemActionReserved   = "reserved"
+	FoundItemActionReleased   = "released"
+	FoundItemActionHandedOver = "handed_over"
+	FoundItemActionDisposed   = "disposed"
+)
+
+// FoundItemUpdate 对应 found_item_updates 表（拾获物品的修改记录，与寄存单修改记录一样保存修改前后快照）
+type FoundItemUpdate struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	HotelID     int64     `gorm:"column:hotel_id;not null" json:"hotel_id"`
+	FoundItemID int64     `gorm:"column:found_item_id;not null;index" json:"found_item_id"`
+	Action      string    `gorm:"column:action;size:20;not null" json:"action"`         // created / updated / reserved / released / handed_over / disposed
+	UpdatedBy   string    `gorm:"column:updated_by;size:50;not null" json:"updated_by"` // 操作员用户名
+	OldData     string    `gorm:"column:old_data;type:text" json:"old_data"`            // 修改前快照（JSON，登记时为空）
+	NewData     string    `gorm:"column:new_data;type:text;not null" json:"new_data"`   // 修改后快照（JSON）
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoCreateTime" json:"updated_at"`   // 修改时间
+}
+
+// TableName 指定数据库表名
+func (FoundItemUpdate) TableName() string {
+	return "found_item_updates"
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// 取件身份核验方式（按强度从低到高）
+const (
+	VerificationNone       = "none"        // 不核验（仅凭取件码）
+	VerificationPhoneLast4 = "phone_last4" // 核对手机号后四位
+	VerificationOTP        = "otp"         // 发送到 ContactPhone / ContactEmail 的一次性验证码
+	VerificationIDDocument = "id_document" // 工作人员核验身份证件并确认
+)
+
+// 自动分配寄存室的策略（优先级相同的寄存室之间如何选择）
+const (
+	AssignStrategyBalance = "balance" // 优先剩余容量比例大的寄存室（均匀分布）
+	AssignStrategyFill    = "fill"    // 优先剩余容量比例小的寄存室（先装满一间再用下一间）
+)
+
+// VerificationDelegateCode 代取人出示客人授权的代取码（强度等同 otp，只用于记录，不能作为酒店策略）
+const VerificationDelegateCode = "delegate_code"
+
+// HotelPolicy 对应 hotel_policies 表（酒店级别的业务策略）
+// 没有记录的酒店使用默认策略（见 services.DefaultHotelPolicy）
+type HotelPolicy struct {
+	ID                     int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                                                                           // 记录ID
+	HotelID                int64     `gorm:"column:hotel_id;unique;not null" json:"hotel_id"`                                                                                        // 酒店ID
+	CheckoutVerification   string    `gorm:"column:checkout_verification;type:enum('none','phone_last4','otp','id_document');default:'none';not null" json:"checkout_verification"`  // 普通行李取件核验方式
+	HighRiskVerification   string    `gorm:"column:high_risk_verification;type:enum('none','phone_last4','otp','id_document');default:'otp';not null" json:"high_risk_verification"` // 高风险行李取件核验方式
+	HighRiskQuantity       int       `gorm:"column:high_risk_quantity;not null;default:0" json:"high_risk_quantity"`                                                                 // 件数达到该值视为高风险（0 表示不按件数判断）
+	HighRiskKeywords       string    `gorm:"column:high_risk_keywords;size:255" json:"high_risk_keywords"`                                                                           // 特殊备注包含这些关键字（逗号分隔）视为高风险
+	RequireSignature       bool      `gorm:"column:require_signature;not null" json:"require_signature"`                                                                             // 取件时必须有客人签名
+	RequireCheckinPhotos   bool      `gorm:"column:require_checkin_photos;not null" json:"require_checkin_photos"`                                                                   // 寄存时必须拍摄行李状态照片（发生损坏纠纷时作为依据）
+	CodeLength             int       `gorm:"column:code_length;not null" json:"code_length"`                                                                                         // 取件码总长度（含校验位）
+	CodeAlphabet           string    `gorm:"column:code_alphabet;type:enum('numeric','crockford');not null" json:"code_alphabet"`                                                    // 取件码字符集
+	CodeCheckDigit         bool      `gorm:"column:code_check_digit;not null" json:"code_check_digit"`                                                                               // 最后一位为校验位（发现输错）
+	CodeReuseCooldownHours int       `gorm:"column:code_reuse_cooldown_hours;not null" json:"code_reuse_cooldown_hours"`                                                             // 取件码取走后多少小时内不再分配（0 表示不限制）
+	CodeTTLHours           int       `gorm:"column:code_ttl_hours;not null" json:"code_ttl_hours"`                                                                                   // 取件码有效期（小时，0 表示不过期）
+	AssignStrategy         string    `gorm:"column:assign_strategy;type:enum('balance','fill');not null" json:"assign_strategy"`                                                     // 自动分配寄存室的策略
+	ShortTermHours         int       `gorm:"column:short_term_hours;not null" json:"short_term_hours"`                                                                               // 预计取件时间在多少小时内视为短时寄存
+	LostFoundRetentionDays int       `gorm:"column:lost_found_retention_days;not null" json:"lost_found_retention_days"`                                                             // 拾获物品的保管期限（天），到期后才能处置
+	UpdatedBy              string    `gorm:"column:updated_by;size:50" json:"updated_by"`                                                                                            // 最后修改人
+	CreatedAt              time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`                                                                                     // 创建时间
+	UpdatedAt              time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`                                                                                     // 更新时间
+}
+
+// TableName 指定数据库表名
+func (HotelPolicy) TableName() string {
+	return "hotel_policies"
+}
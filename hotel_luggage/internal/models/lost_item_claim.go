@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// 失物认领状态
+const (
+	ClaimOpen       = "open"        // 登记后等待匹配
+	ClaimVerified   = "verified"    // 已匹配到拾获物品并核验失主身份，等待交还
+	ClaimHandedOver = "handed_over" // 已交还
+	ClaimClosed     = "closed"      // 未找到 / 客人撤销
+)
+
+// LostItemClaim 对应 lost_item_claims 表（客人报失、认领拾获物品的记录）
+type LostItemClaim struct {
+	ID                 int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	HotelID            int64      `gorm:"column:hotel_id;not null;index" json:"hotel_id"`
+	GuestName          string     `gorm:"column:guest_name;size:100;not null" json:"guest_name"`                                    // 失主姓名
+	ContactPhone       string     `gorm:"column:contact_phone;size:20" json:"contact_phone"`                                        // 联系电话
+	ContactEmail       string     `gorm:"column:contact_email;size:100" json:"contact_email"`                                       // 联系邮箱
+	Category           string     `gorm:"column:category;size:50" json:"category"`                                                  // 类别
+	Description        string     `gorm:"column:description;type:text;not null" json:"description"`                                 // 客人对丢失物品的描述（用于匹配）
+	LostLocation       string     `gorm:"column:lost_location;size:255" json:"lost_location"`                                       // 可能丢失的地点
+	LostAt             *time.Time `gorm:"column:lost_at" json:"lost_at"`                                                            // 大约丢失时间（匹配时排除在此之前很久拾获的物品）
+	Status             string     `gorm:"column:status;type:enum('open','verified','handed_over','closed');not null" json:"status"` // 状态
+	FoundItemID        *int64     `gorm:"column:found_item_id" json:"found_item_id"`                                                // 核验通过的拾获物品
+	VerificationDetail string     `gorm:"column:verification_detail;size:255" json:"verification_detail,omitempty"`                 // 核验说明（证件类型、失主说出的物品特征，不含敏感信息）
+	VerifiedBy         string     `gorm:"column:verified_by;size:50" json:"verified_by,omitempty"`                                  // 核验的工作人员
+	VerifiedAt         *time.Time `gorm:"column:verified_at" json:"verified_at,omitempty"`                                          // 核验时间
+	CollectedBy        string     `gorm:"column:collected_by;size:100" json:"collected_by,omitempty"`                               // 实际领取人姓名
+	HandedOverBy       string     `gorm:"column:handed_over_by;size:50" json:"handed_over_by,omitempty"`                            // 交还的工作人员
+	HandedOverAt       *time.Time `gorm:"column:handed_over_at" json:"handed_over_at,omitempty"`                                    // 交还时间
+	ClosedReason       string     `gorm:"column:closed_reason;size:255" json:"closed_reason,omitempty"`                             // 关闭原因
+	ClosedBy           string     `gorm:"column:closed_by;size:50" json:"closed_by,omitempty"`                                      // 关闭的工作人员
+	ClosedAt           *time.Time `gorm:"column:closed_at" json:"closed_at,omitempty"`                                              // 关闭时间
+	CreatedBy          string     `gorm:"column:created_by;size:50;not null" json:"created_by"`                                     // 登记的工作人员
+	CreatedAt          time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`                                       // 登记时间
+	UpdatedAt          time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`                                       // 最后修改时间
+}
+
+// TableName 指定数据库表名
+func (LostItemClaim) TableName() string {
+	return "lost_item_claims"
+}
@@ -20,13 +20,24 @@ type LuggageHistory struct {
 	PhotoURL      string    `gorm:"column:photo_url;size:255" json:"photo_url"` // 照片URL
 	PhotoURLsRaw  string    `gorm:"column:photo_urls;type:text" json:"-"`       // 多图JSON（数据库字段）
 	PhotoURLs     []string  `gorm:"-" json:"photo_urls,omitempty"`              // 多图数组（对外）
+	ThumbnailURL  string    `gorm:"-" json:"thumbnail_url,omitempty"`           // 主照片缩略图签名地址（只用于响应）
+	ThumbnailURLs []string  `gorm:"-" json:"thumbnail_urls,omitempty"`          // 多图缩略图签名地址（与 photo_urls 一一对应）
 	HotelID       int64     `gorm:"column:hotel_id;not null"`              // 酒店ID
 	StoreroomID   int64     `gorm:"column:storeroom_id;not null"`          // 寄存室ID
 	RetrievalCode string    `gorm:"column:retrieval_code;size:8;not null"` // 取件码
 	QRCodeURL     string    `gorm:"column:qr_code_url;size:255"`           // 二维码URL
 	Status        string    `gorm:"column:status;size:20;not null"`        // 状态（retrieved）
+	RemainingCount int      `gorm:"column:remaining_count;not null;default:0"` // 本次取件后该取件码下仍在寄存的件数（>0 表示部分取件）
 	StoredBy      string    `gorm:"column:stored_by;size:50;not null"`     // 存放操作员用户名
 	RetrievedBy   string    `gorm:"column:retrieved_by;size:50;not null"`  // 取件操作员用户名
+	VerificationMethod string `gorm:"column:verification_method;size:20"` // 取件身份核验方式（none/phone_last4/otp/id_document）
+	VerifiedBy         string `gorm:"column:verified_by;size:50"`         // 执行核验的工作人员
+	VerificationDetail string `gorm:"column:verification_detail;size:255"` // 核验说明（例如证件类型、验证码发送渠道，不含敏感信息）
+	CollectedBy        string `gorm:"column:collected_by;size:100"`       // 实际取件人姓名（客人本人时同 GuestName）
+	CollectedByPhone   string `gorm:"column:collected_by_phone;size:20"`  // 实际取件人电话（代取时为代取人登记的电话）
+	DelegateID         *int64 `gorm:"column:delegate_id"`                 // 代取人ID（客人本人取件时为空）
+	SignatureURL       string `gorm:"column:signature_url;size:255" json:"signature_url,omitempty"` // 取件签名（保存对象 key，响应中为签名地址）
+	SignatureType      string `gorm:"column:signature_type;size:10" json:"signature_type,omitempty"` // 签名类型（png / strokes）
 	StoredAt      time.Time `gorm:"column:stored_at;not null"`             // 存放时间
 	RetrievedAt   time.Time `gorm:"column:retrieved_at;not null"`          // 取件时间
 	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`      // 记录创建时间
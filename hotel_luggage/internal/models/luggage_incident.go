@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 损坏 / 事故类型
+const (
+	IncidentDamage      = "damage"       // 行李损坏（划痕、破损、轮子或拉杆损坏等）
+	IncidentMissing     = "missing"      // 行李或包内物品缺失
+	IncidentWrongPickup = "wrong_pickup" // 错拿（交给了其他客人）
+	IncidentOther       = "other"        // 其他
+)
+
+// IsIncidentType 是否为合法的事故类型
+func IsIncidentType(t string) bool {
+	switch t {
+	case IncidentDamage, IncidentMissing, IncidentWrongPickup, IncidentOther:
+		return true
+	}
+	return false
+}
+
+// 事故处理状态
+const (
+	IncidentOpen          = "open"          // 已登记，等待处理
+	IncidentInvestigating = "investigating" // 调查中（调取照片、询问经手员工）
+	IncidentResolved      = "resolved"      // 已解决（赔偿、维修或与客人达成一致）
+	IncidentRejected      = "rejected"      // 经核实不成立（如寄存时已有损坏）
+)
+
+// IsIncidentStatus 是否为合法的事故处理状态
+func IsIncidentStatus(status string) bool {
+	switch status {
+	case IncidentOpen, IncidentInvestigating, IncidentResolved, IncidentRejected:
+		return true
+	}
+	return false
+}
+
+// IncidentClosed 事故是否已结案（结案后不能再修改）
+func IncidentClosed(status string) bool {
+	return status == IncidentResolved || status == IncidentRejected
+}
+
+// LuggageIncident 对应 luggage_incidents 表（行李损坏 / 事故报告）
+// 关联在存的寄存单（LuggageID）或已取件的取件历史（HistoryID），二者只有一个有值；
+// 客人姓名、取件码和寄存室为登记时的快照，寄存单取走后仍可按原信息查询
+type LuggageIncident struct {
+	ID                   int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	HotelID              int64      `gorm:"column:hotel_id;not null;index" json:"hotel_id"`
+	LuggageID            *int64     `gorm:"column:luggage_id;index" json:"luggage_id"`                                                    // 关联的寄存单（在存行李）
+	HistoryID            *int64     `gorm:"column:history_id;index" json:"history_id"`                                                    // 关联的取件历史（已取走的行李）
+	RetrievalCode        string     `gorm:"column:retrieval_code;size:8;not null" json:"retrieval_code"`                                  // 取件码
+	GuestName            string     `gorm:"column:guest_name;size:100;not null" json:"guest_name"`                                        // 客人姓名
+	StoreroomID          int64      `gorm:"column:storeroom_id;not null" json:"storeroom_id"`                                             // 行李所在 / 取件前所在的寄存室
+	Type                 string     `gorm:"column:type;type:enum('damage','missing','wrong_pickup','other');not null" json:"type"`        // 事故类型
+	Description          string     `gorm:"column:description;type:text;not null" json:"description"`                                     // 事故描述
+	CheckinPhotoURLsRaw  string     `gorm:"column:checkin_photo_urls;type:text" json:"-"`                                                 // 寄存时的状态照片JSON（数据库字段）
+	CheckinPhotoURLs     []string   `gorm:"-" json:"checkin_photo_urls"`                                                                  // 寄存时的状态照片（默认取寄存单照片）
+	CheckoutPhotoURLsRaw string     `gorm:"column:checkout_photo_urls;type:text" json:"-"`                                                // 取件 / 发现问题时拍的照片JSON（数据库字段）
+	CheckoutPhotoURLs    []string   `gorm:"-" json:"checkout_photo_urls"`                                                                 // 取件 / 发现问题时拍的照片
+	StaffInvolved        string     `gorm:"column:staff_involved;size:255" json:"staff_involved"`                                         // 经手员工用户名（逗号分隔，默认包含存放和取件操作员）
+	Status               string     `gorm:"column:status;type:enum('open','investigating','resolved','rejected');not null" json:"status"` // 处理状态
+	Resolution           string     `gorm:"column:resolution;type:text" json:"resolution"`                                                // 处理结果（结案时必填）
+	ResolvedBy           string     `gorm:"column:resolved_by;size:50" json:"resolved_by,omitempty"`                                      // 结案的工作人员
+	ResolvedAt           *time.Time `gorm:"column:resolved_at" json:"resolved_at,omitempty"`                                              // 结案时间
+	ReportedBy           string     `gorm:"column:reported_by;size:50;not null" json:"reported_by"`                                       // 登记的工作人员
+	CreatedAt            time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`                                           // 登记时间
+	UpdatedAt            time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`                                           // 最后修改时间
+}
+
+// TableName 指定数据库表名
+func (LuggageIncident) TableName() string {
+	return "luggage_incidents"
+}
+
+// BeforeSave 在保存前把照片数组写入对应的 JSON 字段
+func (incident *LuggageIncident) BeforeSave(tx *gorm.DB) error {
+	if incident.CheckinPhotoURLs != nil {
+		data, err := json.Marshal(incident.CheckinPhotoURLs)
+		if err != nil {
+			return err
+		}
+		incident.CheckinPhotoURLsRaw = string(data)
+	}
+	if incident.CheckoutPhotoURLs != nil {
+		data, err := json.Marshal(incident.CheckoutPhotoURLs)
+		if err != nil {
+			return err
+		}
+		incident.CheckoutPhotoURLsRaw = string(data)
+	}
+	return nil
+}
+
+// AfterFind 在读取后把 JSON 字段解析为照片数组
+func (incident *LuggageIncident) AfterFind(tx *gorm.DB) error {
+	if incident.CheckinPhotoURLsRaw != "" {
+		if err := json.Unmarshal([]byte(incident.CheckinPhotoURLsRaw), &incident.CheckinPhotoURLs); err != nil {
+			return err
+		}
+	}
+	if incident.CheckoutPhotoURLsRaw != "" {
+		if err := json.Unmarshal([]byte(incident.CheckoutPhotoURLsRaw), &incident.CheckoutPhotoURLs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,26 +10,34 @@ import (
 // LuggageItem 对应 luggage_items 表（行李寄存记录）。
 // 包含客人信息、行李信息、取件码、状态等核心字段。
 type LuggageItem struct {
-	ID            int64      `gorm:"column:id;primaryKey;autoIncrement"`                                                 // 行李ID（主键）
-	GuestName     string     `gorm:"column:guest_name;size:100;not null"`                                                // 客人姓名
-	ContactPhone  string     `gorm:"column:contact_phone;size:20"`                                                       // 联系电话
-	ContactEmail  string     `gorm:"column:contact_email;size:100"`                                                      // 联系邮箱
-	Description   string     `gorm:"column:description;type:text"`                                                       // 行李描述
-	Quantity      int        `gorm:"column:quantity;not null;default:1"`                                                 // 行李数量
-	SpecialNotes  string     `gorm:"column:special_notes;type:text"`                                                     // 特殊备注
-	PhotoURL      string     `gorm:"column:photo_url;size:255" json:"photo_url"`                                         // 照片URL
-	PhotoURLsRaw  string     `gorm:"column:photo_urls;type:text" json:"-"`                                               // 多图JSON（数据库字段）
-	PhotoURLs     []string   `gorm:"-" json:"photo_urls,omitempty"`                                                      // 多图数组（对外）
-	HotelID       int64      `gorm:"column:hotel_id;not null"`                                                           // 酒店ID
-	StoreroomID   int64      `gorm:"column:storeroom_id;not null"`                                                       // 寄存室ID（外键）
-	RetrievalCode string     `gorm:"column:retrieval_code;size:8;unique;not null"`                                       // 取回码
-	QRCodeURL     string     `gorm:"column:qr_code_url;size:255"`                                                        // 二维码URL
-	Status        string     `gorm:"column:status;type:enum('stored','retrieved','migrated');default:'stored';not null"` // 行李状态
-	StoredBy      string     `gorm:"column:stored_by;size:50;not null"`                                                  // 存放操作员用户名
-	RetrievedBy   *string    `gorm:"column:retrieved_by;size:50"`                                                        // 取回操作员用户名（可为空）
-	RetrievedAt   *time.Time `gorm:"column:retrieved_at"`                                                                // 取回时间（可为空）
-	StoredAt      time.Time  `gorm:"column:stored_at;autoCreateTime"`                                                    // 存放时间
-	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime"`                                                   // 更新时间
+	ID               int64      `gorm:"column:id;primaryKey;autoIncrement"`                                                              // 行李ID（主键）
+	GuestName        string     `gorm:"column:guest_name;size:100;not null"`                                                             // 客人姓名
+	ContactPhone     string     `gorm:"column:contact_phone;size:20"`                                                                    // 联系电话
+	ContactEmail     string     `gorm:"column:contact_email;size:100"`                                                                   // 联系邮箱
+	Description      string     `gorm:"column:description;type:text"`                                                                    // 行李描述
+	Quantity         int        `gorm:"column:quantity;not null;default:1"`                                                              // 行李数量
+	SizeClass        string     `gorm:"column:size_class;size:10" json:"size_class,omitempty"`                                           // 尺寸：small / medium / large / oversized（为空按 medium 计算容量）
+	SpecialNotes     string     `gorm:"column:special_notes;type:text"`                                                                  // 特殊备注
+	Handling         string     `gorm:"column:handling;size:100" json:"handling,omitempty"`                                              // 特殊保管要求（逗号分隔：fragile / valuables / refrigerated）
+	ExpectedPickupAt *time.Time `gorm:"column:expected_pickup_at" json:"expected_pickup_at,omitempty"`                                   // 预计取件时间（自动分配寄存室时参考）
+	PhotoURL         string     `gorm:"column:photo_url;size:255" json:"photo_url"`                                                      // 照片URL
+	PhotoURLsRaw     string     `gorm:"column:photo_urls;type:text" json:"-"`                                                            // 多图JSON（数据库字段）
+	PhotoURLs        []string   `gorm:"-" json:"photo_urls,omitempty"`                                                                   // 多图数组（对外）
+	ThumbnailURL     string     `gorm:"-" json:"thumbnail_url,omitempty"`                                                                // 主照片缩略图签名地址（只用于响应）
+	ThumbnailURLs    []string   `gorm:"-" json:"thumbnail_urls,omitempty"`                                                               // 多图缩略图签名地址（与 photo_urls 一一对应）
+	HotelID          int64      `gorm:"column:hotel_id;not null"`                                                                        // 酒店ID
+	StoreroomID      int64      `gorm:"column:storeroom_id;not null"`                                                                    // 寄存室ID（外键）
+	BinID            *int64     `gorm:"column:bin_id" json:"bin_id,omitempty"`                                                           // 格位ID（storeroom_locations，寄存室未划分格位时为空）
+	BinPath          string     `gorm:"-" json:"bin_path,omitempty"`                                                                     // 格位完整位置（如 "A区 / 3号架 / 2格"，只用于响应）
+	RetrievalCode    string     `gorm:"column:retrieval_code;size:8;unique;not null"`                                                    // 取回码
+	CodeExpiresAt    *time.Time `gorm:"column:code_expires_at" json:"code_expires_at,omitempty"`                                         // 取件码过期时间（为空表示不过期）
+	QRCodeURL        string     `gorm:"column:qr_code_url;size:255"`                                                                     // 二维码URL
+	Status           string     `gorm:"column:status;type:enum('stored','retrieved','migrated','in_transit');default:'stored';not null"` // 行李状态（in_transit 为转寄途中）
+	StoredBy         string     `gorm:"column:stored_by;size:50;not null"`                                                               // 存放操作员用户名
+	RetrievedBy      *string    `gorm:"column:retrieved_by;size:50"`                                                                     // 取回操作员用户名（可为空）
+	RetrievedAt      *time.Time `gorm:"column:retrieved_at"`                                                                             // 取回时间（可为空）
+	StoredAt         time.Time  `gorm:"column:stored_at;autoCreateTime"`                                                                 // 存放时间
+	UpdatedAt        time.Time  `gorm:"column:updated_at;autoUpdateTime"`                                                                // 更新时间
 }
 
 // TableName 指定数据库表名
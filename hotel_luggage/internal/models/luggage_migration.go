@@ -1,19 +1,57 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // LuggageUpdate 对应“行李寄存信息修改表”，记录寄存单修改前后信息
 type LuggageUpdate struct {
-	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`     // 记录ID
-	HotelID   int64     `gorm:"column:hotel_id;not null"`               // 酒店ID
-	LuggageID int64     `gorm:"column:luggage_id;not null"`             // 行李ID
-	UpdatedBy string    `gorm:"column:updated_by;size:50;not null"`      // 操作员用户名
-	OldData   string    `gorm:"column:old_data;type:text;not null"`      // 修改前快照（JSON）
-	NewData   string    `gorm:"column:new_data;type:text;not null"`      // 修改后快照（JSON）
-	UpdatedAt time.Time `gorm:"column:updated_at;autoCreateTime"`        // 修改时间
+	ID            int64         `gorm:"column:id;primaryKey;autoIncrement" json:"id"`         // 记录ID
+	HotelID       int64         `gorm:"column:hotel_id;not null" json:"hotel_id"`             // 酒店ID
+	LuggageID     int64         `gorm:"column:luggage_id;not null" json:"luggage_id"`         // 行李ID
+	UpdatedBy     string        `gorm:"column:updated_by;size:50;not null" json:"updated_by"` // 操作员用户名
+	OldData       string        `gorm:"column:old_data;type:text;not null" json:"old_data"`   // 修改前快照（JSON）
+	NewData       string        `gorm:"column:new_data;type:text;not null" json:"new_data"`   // 修改后快照（JSON）
+	ChangesRaw    string        `gorm:"column:changes;type:text" json:"-"`                    // 字段级变更（JSON，数据库字段）
+	Changes       []FieldChange `gorm:"-" json:"changes"`                                     // 字段级变更（对外）
+	ChangedFields string        `gorm:"column:changed_fields;size:500" json:"changed_fields"` // 变更的字段名（逗号分隔，用于按字段筛选）
+	Revision      int           `gorm:"-" json:"revision,omitempty"`                          // 修改后的版本号（只用于单件行李的时间线，0 为寄存时的原始版本）
+	UpdatedAt     time.Time     `gorm:"column:updated_at;autoCreateTime" json:"updated_at"`   // 修改时间
+}
+
+// FieldChange 寄存单一个字段的修改（字段名为数据库列名）
+type FieldChange struct {
+	Field    string      `json:"field"`               // 字段名（如 guest_name / storeroom_id）
+	Old      interface{} `json:"old"`                 // 修改前的值
+	New      interface{} `json:"new"`                 // 修改后的值
+	OldLabel string      `json:"old_label,omitempty"` // 修改前的可读名称（寄存室名称、格位位置）
+	NewLabel string      `json:"new_label,omitempty"` // 修改后的可读名称
 }
 
 // TableName 指定数据库表名
 func (LuggageUpdate) TableName() string {
 	return "行李寄存信息修改表"
 }
+
+// BeforeSave 在保存前把 Changes 写入 ChangesRaw
+func (record *LuggageUpdate) BeforeSave(tx *gorm.DB) error {
+	if record.Changes != nil {
+		data, err := json.Marshal(record.Changes)
+		if err != nil {
+			return err
+		}
+		record.ChangesRaw = string(data)
+	}
+	return nil
+}
+
+// AfterFind 在读取后把 ChangesRaw 解析为 Changes（旧记录没有该字段，由 service 根据快照补算）
+func (record *LuggageUpdate) AfterFind(tx *gorm.DB) error {
+	if record.ChangesRaw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(record.ChangesRaw), &record.Changes)
+}
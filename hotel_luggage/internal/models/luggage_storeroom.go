@@ -2,19 +2,101 @@ package models
 
 import "time"
 
+// 特殊保管要求（行李需要、寄存室支持，逗号分隔保存）
+const (
+	HandlingFragile      = "fragile"      // 易碎
+	HandlingValuables    = "valuables"    // 贵重物品（需要上锁 / 监控）
+	HandlingRefrigerated = "refrigerated" // 冷藏
+)
+
+// 寄存室适合的取件时间（自动分配时优先匹配）
+const (
+	PickupTermAny   = "any"   // 不区分
+	PickupTermShort = "short" // 短时寄存（靠近前台，当天取件）
+	PickupTermLong  = "long"  // 长时寄存（过夜 / 多日）
+)
+
+// 行李尺寸（容量按 件数 × 尺寸权重 计算）
+const (
+	SizeSmall     = "small"
+	SizeMedium    = "medium"
+	SizeLarge     = "large"
+	SizeOversized = "oversized"
+)
+
 // LuggageStoreroom 对应 luggage_storerooms 表（寄存室）。
 // 记录寄存室名称、容量、启用状态等信息。
+// 容量以“单位”计：每件行李占用 件数 × 尺寸权重 个单位，未填写尺寸按 medium 计算。
 type LuggageStoreroom struct {
-	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`     // 寄存室ID（主键）
-	HotelID   int64     `gorm:"column:hotel_id;not null"`               // 所属酒店ID
-	Name      string    `gorm:"column:name;size:100;not null"`          // 寄存室名称
-	Location  string    `gorm:"column:location;size:255"`               // 位置描述
-	Capacity  int       `gorm:"column:capacity;not null;default:0"`     // 容量
-	IsActive  bool      `gorm:"column:is_active;not null;default:true"` // 是否启用
-	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`       // 创建时间
+	ID              int64     `gorm:"column:id;primaryKey;autoIncrement"`     // 寄存室ID（主键）
+	HotelID         int64     `gorm:"column:hotel_id;not null"`               // 所属酒店ID
+	Name            string    `gorm:"column:name;size:100;not null"`          // 寄存室名称
+	Location        string    `gorm:"column:location;size:255"`               // 位置描述
+	Capacity        int       `gorm:"column:capacity;not null;default:0"`     // 容量（单位数，0 表示不限制）
+	WeightSmall     int       `gorm:"column:weight_small;not null"`           // 小件占用的单位数
+	WeightMedium    int       `gorm:"column:weight_medium;not null"`          // 中件占用的单位数（未填写尺寸时使用）
+	WeightLarge     int       `gorm:"column:weight_large;not null"`           // 大件占用的单位数
+	WeightOversized int       `gorm:"column:weight_oversized;not null"`       // 超大件占用的单位数
+	Priority        int       `gorm:"column:priority;not null"`               // 自动分配优先级（数字越小越优先，如离前台越近）
+	Handling        string    `gorm:"column:handling;size:100"`               // 支持的特殊保管要求（逗号分隔：fragile / valuables / refrigerated）
+	PickupTerm      string    `gorm:"column:pickup_term;size:10;not null"`    // 适合的取件时间：any / short / long
+	IsActive        bool      `gorm:"column:is_active;not null;default:true"` // 是否启用
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime"`       // 创建时间
 }
 
 // TableName 指定数据库表名
 func (LuggageStoreroom) TableName() string {
 	return "luggage_storerooms"
 }
+
+// IsHandling 判断特殊保管要求是否有效
+func IsHandling(tag string) bool {
+	switch tag {
+	case HandlingFragile, HandlingValuables, HandlingRefrigerated:
+		return true
+	}
+	return false
+}
+
+// IsPickupTerm 判断取件时间类型是否有效
+func IsPickupTerm(term string) bool {
+	switch term {
+	case PickupTermAny, PickupTermShort, PickupTermLong:
+		return true
+	}
+	return false
+}
+
+// IsSizeClass 判断尺寸是否有效（空字符串表示未填写）
+func IsSizeClass(size string) bool {
+	switch size {
+	case "", SizeSmall, SizeMedium, SizeLarge, SizeOversized:
+		return true
+	}
+	return false
+}
+
+// SizeWeight 返回尺寸对应的单位数（未填写尺寸按 medium 计算，权重未配置时按 1 计算）
+func (room LuggageStoreroom) SizeWeight(size string) int {
+	weight := room.WeightMedium
+	switch size {
+	case SizeSmall:
+		weight = room.WeightSmall
+	case SizeLarge:
+		weight = room.WeightLarge
+	case SizeOversized:
+		weight = room.WeightOversized
+	}
+	if weight <= 0 {
+		return 1
+	}
+	return weight
+}
+
+// LuggageUnits 返回一条寄存记录占用的单位数（件数 × 尺寸权重）
+func (room LuggageStoreroom) LuggageUnits(quantity int, size string) int64 {
+	if quantity <= 0 {
+		quantity = 1
+	}
+	return int64(quantity) * int64(room.SizeWeight(size))
+}
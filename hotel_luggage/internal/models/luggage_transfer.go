@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// 转寄状态
+const (
+	TransferStatusInTransit = "in_transit"
+	TransferStatusReceived  = "received"
+	TransferStatusCancelled = "cancelled"
+)
+
+// LuggageTransfer 对应 luggage_transfers 表（连锁酒店之间转寄行李的记录）。
+// 每件行李一条记录：从酒店 A 发出时行李状态变为 in_transit，酒店 B 签收后放入 B 的寄存室并恢复为 stored，
+// 取件码保持不变，客人在 B 凭原取件码取件。两家酒店都可以查询转寄记录。
+type LuggageTransfer struct {
+	ID              int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	LuggageID       int64      `gorm:"column:luggage_id;not null;index" json:"luggage_id"`
+	RetrievalCode   string     `gorm:"column:retrieval_code;size:8;not null;index" json:"retrieval_code"`                   // 转寄时的取件码
+	FromHotelID     int64      `gorm:"column:from_hotel_id;not null" json:"from_hotel_id"`                                  // 发出酒店
+	FromStoreroomID int64      `gorm:"column:from_storeroom_id;not null" json:"from_storeroom_id"`                          // 发出前所在寄存室
+	ToHotelID       int64      `gorm:"column:to_hotel_id;not null" json:"to_hotel_id"`                                      // 目的酒店
+	ToStoreroomID   *int64     `gorm:"column:to_storeroom_id" json:"to_storeroom_id"`                                       // 签收后放入的寄存室（签收前为空）
+	Courier         string     `gorm:"column:courier;size:100;not null" json:"courier"`                                     // 承运方（快递公司 / 酒店班车 / 员工姓名）
+	TrackingNo      string     `gorm:"column:tracking_no;size:100" json:"tracking_no"`                                      // 运单号
+	Notes           string     `gorm:"column:notes;type:text" json:"notes"`                                                 // 备注
+	Status          string     `gorm:"column:status;type:enum('in_transit','received','cancelled');not null" json:"status"` // 转寄状态
+	DispatchedBy    string     `gorm:"column:dispatched_by;size:50;not null" json:"dispatched_by"`                          // 发出的工作人员
+	DispatchedAt    time.Time  `gorm:"column:dispatched_at;not null" json:"dispatched_at"`                                  // 发出时间
+	ReceivedBy      string     `gorm:"column:received_by;size:50" json:"received_by,omitempty"`                             // 签收的工作人员
+	ReceivedAt      *time.Time `gorm:"column:received_at" json:"received_at,omitempty"`                                     // 签收时间
+	CancelledBy     string     `gorm:"column:cancelled_by;size:50" json:"cancelled_by,omitempty"`                           // 取消转寄的工作人员（行李退回发出酒店）
+	CancelledAt     *time.Time `gorm:"column:cancelled_at" json:"cancelled_at,omitempty"`                                   // 取消时间
+}
+
+// TableName 指定数据库表名
+func (LuggageTransfer) TableName() string {
+	return "luggage_transfers"
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// 待同步上传状态
+const (
+	PendingUploadPending = "pending" // 仅保存在本地，等待同步到 MinIO
+	PendingUploadSynced  = "synced"  // 已同步到 MinIO，行李记录中的地址已改写
+	PendingUploadFailed  = "failed"  // 无法同步（例如本地文件已丢失），需要人工处理
+)
+
+// PendingUpload 对应 pending_uploads 表（MinIO 不可用时降级写入本地的上传文件）
+// 后台同步任务会把文件推送到 MinIO，并改写 luggage_items / luggage_history / found_items / luggage_incidents 中的照片地址
+type PendingUpload struct {
+	ID          int64      `gorm:"column:id;primaryKey;autoIncrement"`                                              // 记录ID
+	ObjectKey   string     `gorm:"column:object_key;size:255;unique;not null"`                                      // 对象 key（例如 2026/01/xxx.jpg）
+	LocalURL    string     `gorm:"column:local_url;size:255;not null"`                                              // 上传时返回的本地访问地址
+	RemoteURL   string     `gorm:"column:remote_url;size:255"`                                                      // 同步后的 MinIO 访问地址
+	ContentType string     `gorm:"column:content_type;size:100"`                                                    // MIME 类型
+	Size        int64      `gorm:"column:size;not null;default:0"`                                                  // 文件大小（字节）
+	Status      string     `gorm:"column:status;type:enum('pending','synced','failed');default:'pending';not null"` // 同步状态
+	Attempts    int        `gorm:"column:attempts;not null;default:0"`                                              // 已尝试次数
+	LastError   string     `gorm:"column:last_error;type:text"`                                                     // 最近一次失败原因
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`                                                // 记录时间
+	SyncedAt    *time.Time `gorm:"column:synced_at"`                                                                // 同步完成时间
+}
+
+// TableName 指定数据库表名
+func (PendingUpload) TableName() string {
+	return "pending_uploads"
+}
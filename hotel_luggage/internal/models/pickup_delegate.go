@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 代取人登记来源
+const (
+	DelegateSourceStaff = "staff"
+	DelegateSourceGuest = "guest"
+)
+
+// 代取人状态
+const (
+	DelegateStatusActive  = "active"
+	DelegateStatusRevoked = "revoked"
+)
+
+// PickupDelegate 对应 pickup_delegates 表（客人授权的代取人）
+// 代取人挂在取件码（同一客人的一组行李）上；可选签发代取码（只保存哈希），凭代取码取件时视为已核验身份
+type PickupDelegate struct {
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                        // 记录ID
+	HotelID       int64      `gorm:"column:hotel_id;not null" json:"hotel_id"`                                            // 酒店ID
+	RetrievalCode string     `gorm:"column:retrieval_code;size:8;not null;index" json:"retrieval_code"`                   // 授权的取件码
+	Name          string     `gorm:"column:name;size:100;not null" json:"name"`                                           // 代取人姓名
+	Phone         string     `gorm:"column:phone;size:20" json:"phone"`                                                   // 代取人电话
+	CodeHash      string     `gorm:"column:code_hash;size:64" json:"-"`                                                   // 代取码哈希（SHA-256，未签发时为空）
+	HasCode       bool       `gorm:"-" json:"has_code"`                                                                   // 是否签发了代取码（只用于响应）
+	ExpiresAt     *time.Time `gorm:"column:expires_at" json:"expires_at"`                                                 // 授权过期时间（为空表示在行李取完前一直有效）
+	Status        string     `gorm:"column:status;type:enum('active','revoked');default:'active';not null" json:"status"` // 状态
+	Source        string     `gorm:"column:source;type:enum('staff','guest');default:'staff';not null" json:"source"`     // 登记来源（前台 / 客人自助）
+	CreatedBy     string     `gorm:"column:created_by;size:50" json:"created_by"`                                         // 登记的工作人员（客人自助登记时为空）
+	RevokedBy     string     `gorm:"column:revoked_by;size:50" json:"revoked_by,omitempty"`                               // 撤销的工作人员
+	RevokedAt     *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`                                       // 撤销时间
+	LastUsedAt    *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`                                   // 最近一次代取时间
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`                                  // 登记时间
+}
+
+// TableName 指定数据库表名
+func (PickupDelegate) TableName() string {
+	return "pickup_delegates"
+}
+
+// Usable 授权是否仍然有效（未撤销且未过期）
+func (d PickupDelegate) Usable(now time.Time) bool {
+	return d.Status == DelegateStatusActive && (d.ExpiresAt == nil || now.Before(*d.ExpiresAt))
+}
+
+// AfterFind 读取后标记是否签发了代取码
+func (d *PickupDelegate) AfterFind(tx *gorm.DB) error {
+	d.HasCode = d.CodeHash != ""
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 寄存室内位置的层级：区域 > 货架 > 格位
+const (
+	LocationKindZone  = "zone"
+	LocationKindShelf = "shelf"
+	LocationKindBin   = "bin"
+)
+
+// StoreroomLocation 对应 storeroom_locations 表（寄存室内的区域 / 货架 / 格位）。
+// 通过 ParentID 组成树：区域下挂货架，货架下挂格位；小寄存室也可以不分区，直接在寄存室下建货架或格位。
+// 行李只放在格位（bin）上，每一层的 Capacity 都单独限制该层（含下级）在存行李占用的单位数（按所在寄存室的尺寸权重计算）。
+type StoreroomLocation struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	HotelID     int64     `gorm:"column:hotel_id;not null" json:"hotel_id"`
+	StoreroomID int64     `gorm:"column:storeroom_id;not null" json:"storeroom_id"`
+	ParentID    *int64    `gorm:"column:parent_id" json:"parent_id"`                                // 上级位置（为空表示直接挂在寄存室下）
+	Kind        string    `gorm:"column:kind;type:enum('zone','shelf','bin');not null" json:"kind"` // zone / shelf / bin
+	Name        string    `gorm:"column:name;size:50;not null" json:"name"`                         // 名称（如 A 区、3 号架、2 格）
+	Capacity    int       `gorm:"column:capacity;not null" json:"capacity"`                         // 容量（0 表示不限制）
+	IsActive    bool      `gorm:"column:is_active;not null" json:"is_active"`                       // 是否启用（停用后不再分配，已放的行李不受影响）
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+
+	Path        string `gorm:"-" json:"path"`         // 完整位置（如 "A区 / 3号架 / 2格"，只用于响应）
+	StoredCount int64  `gorm:"-" json:"stored_count"` // 该位置（含下级）在存行李占用的单位数（只用于响应）
+}
+
+// TableName 指定数据库表名
+func (StoreroomLocation) TableName() string {
+	return "storeroom_locations"
+}
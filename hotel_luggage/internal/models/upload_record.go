@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 上传记录状态
+const (
+	UploadRecordActive  = "active"  // 对象仍在存储中
+	UploadRecordDeleted = "deleted" // 未被引用，已被清理任务删除
+)
+
+// UploadRecord 对应 upload_records 表（每次 /api/upload 成功写入一条）
+// 清理任务据此找出从未挂到寄存单上、或已被替换掉的照片，超过宽限期后从存储中删除
+type UploadRecord struct {
+	ID               int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                        // 记录ID
+	ObjectKey        string     `gorm:"column:object_key;size:255;unique;not null" json:"object_key"`                        // 原图对象 key
+	VariantKeysRaw   string     `gorm:"column:variant_keys;type:text" json:"-"`                                              // 缩略图 key（JSON，数据库字段）
+	VariantKeys      []string   `gorm:"-" json:"variant_keys,omitempty"`                                                     // 缩略图 key（对外）
+	ContentType      string     `gorm:"column:content_type;size:100" json:"content_type"`                                    // MIME 类型
+	Size             int64      `gorm:"column:size;not null;default:0" json:"size"`                                          // 原图和缩略图的总字节数
+	UploadedBy       string     `gorm:"column:uploaded_by;size:50" json:"uploaded_by"`                                       // 上传人用户名
+	Status           string     `gorm:"column:status;type:enum('active','deleted');default:'active';not null" json:"status"` // 状态
+	CreatedAt        time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`                                  // 上传时间
+	LastReferencedAt *time.Time `gorm:"column:last_referenced_at" json:"last_referenced_at"`                                 // 最近一次确认被引用（或被替换）的时间
+	DeletedAt        *time.Time `gorm:"column:deleted_at" json:"deleted_at,omitempty"`                                       // 删除时间
+}
+
+// TableName 指定数据库表名
+func (UploadRecord) TableName() string {
+	return "upload_records"
+}
+
+// BeforeSave 在保存前把 VariantKeys 写入 VariantKeysRaw
+func (r *UploadRecord) BeforeSave(tx *gorm.DB) error {
+	if r.VariantKeys != nil {
+		data, err := json.Marshal(r.VariantKeys)
+		if err != nil {
+			return err
+		}
+		r.VariantKeysRaw = string(data)
+	}
+	return nil
+}
+
+// AfterFind 在读取后把 VariantKeysRaw 解析为 VariantKeys
+func (r *UploadRecord) AfterFind(tx *gorm.DB) error {
+	if r.VariantKeysRaw == "" {
+		return nil
+	}
+	var keys []string
+	if err := json.Unmarshal([]byte(r.VariantKeysRaw), &keys); err != nil {
+		return err
+	}
+	r.VariantKeys = keys
+	return nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// 验证失败的场景
+const (
+	AttemptScopeGuestDelegate = "guest_delegate" // 客人凭取件码 + 手机号后四位自助登记代取人
+	AttemptScopeDelegateCode  = "delegate_code"  // 取件时出示代取码
+)
+
+// VerificationFailure 对应 verification_failures 表（一次验证失败）
+// 同一场景下按取件码、按客户端 IP 分别计数，一段时间内失败次数达到上限后暂时锁定
+type VerificationFailure struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"` // 记录ID
+	Scope     string    `gorm:"column:scope;size:20;not null"`      // 场景（guest_delegate / delegate_code）
+	Subject   string    `gorm:"column:subject;size:80;not null"`    // 计数对象（code:取件码 / ip:客户端IP）
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`   // 失败时间
+}
+
+// TableName 指定数据库表名
+func (VerificationFailure) TableName() string {
+	return "verification_failures"
+}
@@ -0,0 +1,17 @@
+package notify
+
+import "hotel_luggage/configs"
+
+// New 按配置创建通知渠道
+// - 配置了 notify.webhook_url：使用 webhook
+// - 开发环境未配置：写入日志
+// - 生产环境未配置：禁用（发送验证码的接口返回 503）
+func New(cfg configs.Config) Notifier {
+	if cfg.Notify.WebhookURL != "" {
+		return NewWebhook(cfg.Notify.WebhookURL, cfg.Notify.Timeout.Std())
+	}
+	if cfg.IsProduction() {
+		return DisabledNotifier{}
+	}
+	return LogNotifier{}
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ErrNotConfigured 未配置通知渠道（生产环境未设置 notify.webhook_url）
+var ErrNotConfigured = errors.New("notifier not configured")
+
+// Message 一条通知
+type Message struct {
+	Channel string `json:"channel"` // sms / email
+	To      string `json:"to"`      // 手机号或邮箱
+	Subject string `json:"subject"` // 标题（邮件使用）
+	Body    string `json:"body"`    // 正文
+}
+
+// Notifier 短信 / 邮件发送接口
+// 项目不直接对接具体的短信、邮件服务商，由 webhook 转发到实际的发送服务
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogNotifier 只把通知写入日志（开发环境使用，会在日志中输出验证码）
+type LogNotifier struct{}
+
+// Name 通知渠道名称
+func (LogNotifier) Name() string {
+	return "log"
+}
+
+// Send 写入日志
+func (LogNotifier) Send(ctx context.Context, msg Message) error {
+	log.Printf("📨 [%s] to=%s subject=%q body=%q", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// DisabledNotifier 未配置通知渠道，发送时返回 ErrNotConfigured
+type DisabledNotifier struct{}
+
+// Name 通知渠道名称
+func (DisabledNotifier) Name() string {
+	return "disabled"
+}
+
+// Send 始终返回 ErrNotConfigured
+func (DisabledNotifier) Send(ctx context.Context, msg Message) error {
+	return ErrNotConfigured
+}
+
+// WebhookNotifier 把通知以 JSON（Message）POST 到 webhook，由外部服务发送短信 / 邮件
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook 创建 webhook 通知
+func NewWebhook(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Name 通知渠道名称
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Send 发送通知（webhook 返回非 2xx 视为失败）
+func (w *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// auditChainID 哈希链末尾记录的固定ID
+const auditChainID = 1
+
+// AuditLogFilter 审计日志查询条件（为空的字段不过滤）
+type AuditLogFilter struct {
+	HotelID    int64
+	Actor      string
+	EntityType string
+	EntityID   string
+	Action     string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// AuditBatch 与业务数据在同一事务中追加的审计日志，Hash 为计算本条哈希的函数（audit.Hash）
+type AuditBatch struct {
+	Entries []models.AuditLog
+	Hash    func(prevHash string, entry models.AuditLog) string
+}
+
+// AppendAuditLog 追加一条审计日志：锁定链末尾，填写序号、上一条哈希，用 hash 计算本条哈希后写入并推进链末尾
+// 多个实例同时写入时由 audit_chain 的行锁串行化，保证链不分叉
+func AppendAuditLog(entry *models.AuditLog, hash func(prevHash string, entry models.AuditLog) string) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		batch := AuditBatch{Entries: []models.AuditLog{*entry}, Hash: hash}
+		if err := batch.append(tx); err != nil {
+			return err
+		}
+		*entry = batch.Entries[0]
+		return nil
+	})
+}
+
+// append 在事务 tx 中依次追加审计日志并推进链末尾
+// audit_chain 的行锁持有到事务结束，业务事务应在最后调用，缩短其他写入的等待时间
+func (batch AuditBatch) append(tx *gorm.DB) error {
+	if len(batch.Entries) == 0 {
+		return nil
+	}
+	// 链末尾不存在时初始化（并发初始化时只有一个成功，其余忽略）
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.AuditChain{ID: auditChainID}).Error; err != nil {
+		return err
+	}
+	var head models.AuditChain
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", auditChainID).First(&head).Error; err != nil {
+		return err
+	}
+	for i := range batch.Entries {
+		entry := &batch.Entries[i]
+		entry.Seq = head.LastSeq + 1
+		entry.PrevHash = head.LastHash
+		entry.Hash = batch.Hash(entry.PrevHash, *entry)
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		head.LastSeq, head.LastHash = entry.Seq, entry.Hash
+	}
+	return tx.Model(&models.AuditChain{}).Where("id = ?", auditChainID).Updates(map[string]interface{}{
+		"last_seq":  head.LastSeq,
+		"last_hash": head.LastHash,
+	}).Error
+}
+
+// GetAuditChain 获取哈希链末尾（还没有任何日志时返回序号 0）
+func GetAuditChain() (models.AuditChain, error) {
+	if DB == nil {
+		return models.AuditChain{}, errors.New("db not initialized")
+	}
+	var head models.AuditChain
+	err := DB.Where("id = ?", auditChainID).First(&head).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.AuditChain{ID: auditChainID}, nil
+	}
+	return head, err
+}
+
+// ListAuditLogsAfterSeq 按序号顺序获取 afterSeq 之后的审计日志（校验哈希链时分批读取）
+func ListAuditLogsAfterSeq(afterSeq int64, limit int) ([]models.AuditLog, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var entries []models.AuditLog
+	err := DB.Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// ListAuditLogs 按条件查询审计日志（按序号倒序）
+func ListAuditLogs(filter AuditLogFilter) ([]models.AuditLog, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	query := DB.Model(&models.AuditLog{})
+	if filter.HotelID > 0 {
+		query = query.Where("hotel_id = ?", filter.HotelID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	var entries []models.AuditLog
+	err := query.Order("seq DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
@@ -11,7 +11,8 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-const luggageByCodeTTL = time.Minute
+// luggageByCodeTTL 按取件码查询的缓存时间（InitRedis 时由配置覆盖）
+var luggageByCodeTTL = time.Minute
 
 func luggageByCodeKey(code string) string {
 	return "luggage:code:" + code
@@ -19,13 +20,14 @@ func luggageByCodeKey(code string) string {
 
 // GetLuggageByCodeCache 从缓存中读取行李信息
 func GetLuggageByCodeCache(code string) ([]models.LuggageItem, bool, error) {
-	if RedisClient == nil {
+	client := Redis()
+	if client == nil {
 		return nil, false, nil
 	}
 	if code == "" {
 		return nil, false, errors.New("code is empty")
 	}
-	val, err := RedisClient.Get(context.Background(), luggageByCodeKey(code)).Result()
+	val, err := client.Get(context.Background(), luggageByCodeKey(code)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, false, nil
@@ -41,7 +43,8 @@ func GetLuggageByCodeCache(code string) ([]models.LuggageItem, bool, error) {
 
 // SetLuggageByCodeCache 写入行李信息到缓存
 func SetLuggageByCodeCache(code string, items []models.LuggageItem) error {
-	if RedisClient == nil {
+	client := Redis()
+	if client == nil {
 		return nil
 	}
 	if code == "" {
@@ -51,17 +54,18 @@ func SetLuggageByCodeCache(code string, items []models.LuggageItem) error {
 	if err != nil {
 		return err
 	}
-	return RedisClient.Set(context.Background(), luggageByCodeKey(code), data, luggageByCodeTTL).Err()
+	return client.Set(context.Background(), luggageByCodeKey(code), data, luggageByCodeTTL).Err()
 }
 
 // DeleteLuggageByCodeCache 删除行李缓存
 func DeleteLuggageByCodeCache(code string) error {
-	if RedisClient == nil {
+	client := Redis()
+	if client == nil {
 		return nil
 	}
 	if code == "" {
 		return errors.New("code is empty")
 	}
-	return RedisClient.Del(context.Background(), luggageByCodeKey(code)).Err()
+	return client.Del(context.Background(), luggageByCodeKey(code)).Err()
 }
 
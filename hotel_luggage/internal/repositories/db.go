@@ -22,13 +22,13 @@ var DB *gorm.DB
 
 // InitDB 初始化数据库连接
 // 功能：
-// 1. 从环境变量读取数据库配置（DSN）
+// 1. 使用启动时加载的数据库配置（DSN）
 // 2. 使用 GORM 连接 MySQL 数据库
 // 3. 连接失败则直接退出程序（Fatalf）
 // 4. 连接成功后设置全局 DB 对象
 //
-// 环境变量配置：
-//   DB_DSN - 数据库连接字符串（Data Source Name）
+// 配置来源：
+//   configs.Load() 加载的 cfg.DB（配置文件 db.dsn 或环境变量 DB_DSN）
 //   示例：root:password@tcp(127.0.0.1:3306)/hotel_luggage?charset=utf8mb4&parseTime=True&loc=Local
 //
 // 调用时机：
@@ -39,9 +39,8 @@ var DB *gorm.DB
 //
 // 返回：
 //   *gorm.DB: GORM 数据库对象（同时也会设置到全局变量 DB）
-func InitDB() *gorm.DB {
-	// 1. 加载数据库配置（从环境变量读取 DSN）
-	cfg := configs.LoadDBConfig()
+func InitDB(cfg configs.DBConfig) *gorm.DB {
+	// 1. 数据库配置由调用方传入（见 configs.Load）
 	
 	// 2. 使用 GORM 连接 MySQL
 	// mysql.Open(cfg.DSN)：创建 MySQL 驱动
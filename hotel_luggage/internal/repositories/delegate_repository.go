@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+)
+
+// CreatePickupDelegate 登记代取人
+func CreatePickupDelegate(delegate *models.PickupDelegate) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Create(delegate).Error
+}
+
+// ListPickupDelegates 查询某取件码在 since 之后登记的代取人（取件码可能被重新分配，只看本批行李）
+func ListPickupDelegates(code string, since time.Time) ([]models.PickupDelegate, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var delegates []models.PickupDelegate
+	err := DB.Where("retrieval_code = ? AND created_at >= ?", code, since).
+		Order("id ASC").
+		Find(&delegates).Error
+	return delegates, err
+}
+
+// GetPickupDelegateByID 按ID查询代取人
+func GetPickupDelegateByID(id int64) (models.PickupDelegate, error) {
+	var delegate models.PickupDelegate
+	if DB == nil {
+		return delegate, errors.New("db not initialized")
+	}
+	err := DB.Where("id = ?", id).First(&delegate).Error
+	return delegate, err
+}
+
+// RevokePickupDelegate 撤销代取授权
+func RevokePickupDelegate(id int64, revokedBy string) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Model(&models.PickupDelegate{}).
+		Where("id = ? AND status = ?", id, models.DelegateStatusActive).
+		Updates(map[string]interface{}{
+			"status":     models.DelegateStatusRevoked,
+			"revoked_by": revokedBy,
+			"revoked_at": time.Now(),
+		}).Error
+}
+
+// TouchPickupDelegate 记录代取时间
+func TouchPickupDelegate(id int64) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Model(&models.PickupDelegate{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// PingDB 检查数据库连接是否可用
+func PingDB(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CloseDB 关闭数据库连接池（优雅退出时最后调用）
+func CloseDB() {
+	if DB == nil {
+		return
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Printf("⚠️  获取数据库连接池失败: %v", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("⚠️  关闭数据库连接失败: %v", err)
+		return
+	}
+	log.Println("✅ 数据库连接已关闭")
+}
+
+// CloseRedis 关闭 Redis 连接（需先停止 StartSupervisor，避免关闭后又被重连）
+func CloseRedis() {
+	if redisDep.shutdown() {
+		log.Println("✅ Redis 连接已关闭")
+	}
+}
+
+// CloseMinIO 释放 MinIO 客户端（minio-go 基于 HTTP，无需显式关闭，只需停止使用）
+func CloseMinIO() {
+	if minioDep.shutdown() {
+		log.Println("✅ MinIO 客户端已释放")
+	}
+}
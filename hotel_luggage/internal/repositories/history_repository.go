@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"errors"
+	"time"
 
 	"hotel_luggage/internal/models"
 )
@@ -47,3 +48,48 @@ func ListHistoryByHotel(hotelID int64, guestName, contactPhone string) ([]models
 	err := query.Order("retrieved_at DESC").Find(&items).Error
 	return items, err
 }
+
+// ListHistoryByCode 查询某取件码自 since 起的取件历史
+// 取件码在全部行李取走后可以被重新分配，since 用于排除更早使用该取件码的记录
+func ListHistoryByCode(code string, since time.Time) ([]models.LuggageHistory, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var items []models.LuggageHistory
+	err := DB.Where("retrieval_code = ? AND retrieved_at >= ?", code, since).
+		Order("retrieved_at DESC").
+		Find(&items).Error
+	return items, err
+}
+
+// GetHistoryByID 按ID获取取件历史记录
+func GetHistoryByID(id int64) (models.LuggageHistory, error) {
+	if DB == nil {
+		return models.LuggageHistory{}, errors.New("db not initialized")
+	}
+	var item models.LuggageHistory
+	err := DB.Where("id = ?", id).First(&item).Error
+	return item, err
+}
+
+// GetLatestHistoryByCode 查询某取件码最近一次取件记录
+func GetLatestHistoryByCode(code string) (models.LuggageHistory, error) {
+	if DB == nil {
+		return models.LuggageHistory{}, errors.New("db not initialized")
+	}
+	var item models.LuggageHistory
+	err := DB.Where("retrieval_code = ?", code).Order("retrieved_at DESC").First(&item).Error
+	return item, err
+}
+
+// RetrievalCodeUsedSince 判断取件码在 since 之后是否有取件记录（用于取件码复用冷却期）
+func RetrievalCodeUsedSince(code string, since time.Time) (bool, error) {
+	if DB == nil {
+		return false, errors.New("db not initialized")
+	}
+	var count int64
+	err := DB.Model(&models.LuggageHistory{}).
+		Where("retrieval_code = ? AND retrieved_at >= ?", code, since).
+		Count(&count).Error
+	return count > 0, err
+}
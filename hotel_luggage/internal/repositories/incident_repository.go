@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// IncidentFilter 事故报告查询条件（为空的字段不过滤）
+type IncidentFilter struct {
+	HotelID       int64 // 0 表示全部酒店（仅管理员）
+	Status        string
+	Type          string
+	LuggageID     int64
+	HistoryID     int64
+	RetrievalCode string
+	Staff         string     // 经手员工用户名
+	From          *time.Time // 登记时间不早于
+	To            *time.Time // 登记时间早于
+}
+
+// CreateIncident 登记事故报告
+func CreateIncident(incident *models.LuggageIncident) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Create(incident).Error
+}
+
+// GetIncidentByID 按ID获取事故报告
+func GetIncidentByID(id int64) (models.LuggageIncident, error) {
+	if DB == nil {
+		return models.LuggageIncident{}, errors.New("db not initialized")
+	}
+	var incident models.LuggageIncident
+	err := DB.Where("id = ?", id).First(&incident).Error
+	return incident, err
+}
+
+// ListIncidents 按条件查询事故报告（按登记时间倒序）
+func ListIncidents(filter IncidentFilter) ([]models.LuggageIncident, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	query := DB.Model(&models.LuggageIncident{})
+	if filter.HotelID > 0 {
+		query = query.Where("hotel_id = ?", filter.HotelID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.LuggageID > 0 {
+		query = query.Where("luggage_id = ?", filter.LuggageID)
+	}
+	if filter.HistoryID > 0 {
+		query = query.Where("history_id = ?", filter.HistoryID)
+	}
+	if filter.RetrievalCode != "" {
+		query = query.Where("retrieval_code = ?", filter.RetrievalCode)
+	}
+	if filter.Staff != "" {
+		query = query.Where("FIND_IN_SET(?, staff_involved) > 0 OR reported_by = ?", filter.Staff, filter.Staff)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	var incidents []models.LuggageIncident
+	err := query.Order("id DESC").Find(&incidents).Error
+	return incidents, err
+}
+
+// UpdateIncident 修改事故报告（只有未结案时才更新）；已结案时返回 gorm.ErrRecordNotFound
+func UpdateIncident(id int64, updates map[string]interface{}) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	result := DB.Model(&models.LuggageIncident{}).
+		Where("id = ? AND status IN ?", id, []string{models.IncidentOpen, models.IncidentInvestigating}).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
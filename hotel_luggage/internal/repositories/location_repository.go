@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"errors"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateStoreroomLocation 创建寄存室内的位置（区域 / 货架 / 格位）
+func CreateStoreroomLocation(loc *models.StoreroomLocation) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Create(loc).Error
+}
+
+// GetStoreroomLocationByID 按ID查询位置
+func GetStoreroomLocationByID(id int64) (models.StoreroomLocation, error) {
+	var loc models.StoreroomLocation
+	if DB == nil {
+		return loc, errors.New("db not initialized")
+	}
+	err := DB.Where("id = ?", id).First(&loc).Error
+	return loc, err
+}
+
+// ListStoreroomLocations 查询寄存室内的所有位置
+func ListStoreroomLocations(storeroomID int64) ([]models.StoreroomLocation, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var locs []models.StoreroomLocation
+	err := DB.Where("storeroom_id = ?", storeroomID).Order("id ASC").Find(&locs).Error
+	return locs, err
+}
+
+// UpdateStoreroomLocation 修改位置信息
+func UpdateStoreroomLocation(id int64, updates map[string]interface{}) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Model(&models.StoreroomLocation{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteStoreroomLocation 删除位置
+func DeleteStoreroomLocation(id int64) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Delete(&models.StoreroomLocation{}, id).Error
+}
+
+// SumStoredUnitsByBins 统计寄存室内每个格位的在存行李占用单位数（bin_id → 单位数）
+func SumStoredUnitsByBins(room models.LuggageStoreroom) (map[int64]int64, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	return sumStoredUnitsByBins(DB, room)
+}
+
+// sumStoredUnitsByBins 在 db（可以是事务）中按格位统计在存行李占用单位数
+func sumStoredUnitsByBins(db *gorm.DB, room models.LuggageStoreroom) (map[int64]int64, error) {
+	expr, args := storedUnitsExpr(room)
+	var rows []struct {
+		BinID int64
+		Units int64
+	}
+	err := db.Model(&models.LuggageItem{}).
+		Select("bin_id, "+expr+" AS units", args...).
+		Where("storeroom_id = ? AND status = ? AND bin_id IS NOT NULL", room.ID, "stored").
+		Group("bin_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	units := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		units[row.BinID] = row.Units
+	}
+	return units, nil
+}
+
+// checkLocationCapacity 重新统计 binIDs 所在的格位及其上级（货架、区域）的占用，任意一层超出容量时返回 ErrLocationOverCapacity
+// locations 为寄存室内的全部位置（用于查找上级）
+func checkLocationCapacity(db *gorm.DB, room models.LuggageStoreroom, locations []models.StoreroomLocation, binIDs []int64) error {
+	byID := make(map[int64]models.StoreroomLocation, len(locations))
+	for _, loc := range locations {
+		byID[loc.ID] = loc
+	}
+	// chain 返回格位及其所有上级的 ID（层级最多三层，用位置数量限制循环防止数据成环）
+	chain := func(id int64) []int64 {
+		var ids []int64
+		loc, ok := byID[id]
+		for ok && len(ids) < len(locations) {
+			ids = append(ids, loc.ID)
+			if loc.ParentID == nil {
+				break
+			}
+			loc, ok = byID[*loc.ParentID]
+		}
+		return ids
+	}
+	units, err := sumStoredUnitsByBins(db, room)
+	if err != nil {
+		return err
+	}
+	used := map[int64]int64{}
+	for binID, n := range units {
+		for _, id := range chain(binID) {
+			used[id] += n
+		}
+	}
+	for _, binID := range binIDs {
+		for _, id := range chain(binID) {
+			if loc := byID[id]; loc.Capacity > 0 && used[id] > int64(loc.Capacity) {
+				return ErrLocationOverCapacity
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// foundItemOccupying 仍占用寄存室空间的拾获物品状态
+var foundItemOccupying = []string{models.FoundItemHeld, models.FoundItemReserved}
+
+// FoundItemFilter 拾获物品查询条件（为空的字段不过滤）
+type FoundItemFilter struct {
+	Status      string
+	Category    string
+	StoreroomID int64
+	Keywords    []string   // 每个关键字都要出现在描述、类别、拾获地点或备注中
+	OverdueAt   *time.Time // 只查保管期限早于该时间、仍在保管中的物品
+}
+
+// FoundItemChange 一次拾获物品状态变更：只有当前状态属于 From 时才更新，同时写入修改记录
+type FoundItemChange struct {
+	ID      int64
+	From    []string
+	Updates map[string]interface{}
+	Record  models.FoundItemUpdate
+}
+
+// CreateFoundItem 在一个事务中登记拾获物品并写入登记记录（record 根据写入后的物品生成快照）
+func CreateFoundItem(item *models.FoundItem, record func(models.FoundItem) models.FoundItemUpdate) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		entry := record(*item)
+		return tx.Create(&entry).Error
+	})
+}
+
+// GetFoundItemByID 按ID获取拾获物品
+func GetFoundItemByID(id int64) (models.FoundItem, error) {
+	if DB == nil {
+		return models.FoundItem{}, errors.New("db not initialized")
+	}
+	var item models.FoundItem
+	err := DB.Where("id = ?", id).First(&item).Error
+	return item, err
+}
+
+// SearchFoundItems 按条件查询酒店的拾获物品（按拾获时间倒序）
+func SearchFoundItems(hotelID int64, filter FoundItemFilter) ([]models.FoundItem, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	query := DB.Where("hotel_id = ?", hotelID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.StoreroomID > 0 {
+		query = query.Where("storeroom_id = ?", filter.StoreroomID)
+	}
+	for _, keyword := range filter.Keywords {
+		pattern := "%" + keyword + "%"
+		query = query.Where("description LIKE ? OR category LIKE ? OR found_location LIKE ? OR notes LIKE ?",
+			pattern, pattern, pattern, pattern)
+	}
+	if filter.OverdueAt != nil {
+		query = query.Where("status = ? AND retain_until < ?", models.FoundItemHeld, *filter.OverdueAt)
+	}
+	var items []models.FoundItem
+	err := query.Order("found_at DESC, id DESC").Find(&items).Error
+	return items, err
+}
+
+// UpdateFoundItem 在一个事务中修改拾获物品并写入修改记录；状态已变化时返回 gorm.ErrRecordNotFound
+func UpdateFoundItem(change FoundItemChange) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return applyFoundItemChange(tx, change)
+	})
+}
+
+// applyFoundItemChange 按状态条件更新拾获物品并写入修改记录
+func applyFoundItemChange(tx *gorm.DB, change FoundItemChange) error {
+	result := tx.Model(&models.FoundItem{}).
+		Where("id = ? AND status IN ?", change.ID, change.From).
+		Updates(change.Updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return tx.Create(&change.Record).Error
+}
+
+// ListFoundItemUpdates 查询拾获物品的修改记录（按时间顺序）
+func ListFoundItemUpdates(foundItemID int64) ([]models.FoundItemUpdate, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var records []models.FoundItemUpdate
+	err := DB.Where("found_item_id = ?", foundItemID).Order("id ASC").Find(&records).Error
+	return records, err
+}
+
+// CountFoundItemsByStoreroom 统计寄存室内仍在保管（含等待交还）的拾获物品数
+func CountFoundItemsByStoreroom(storeroomID int64) (int64, error) {
+	if DB == nil {
+		return 0, errors.New("db not initialized")
+	}
+	var count int64
+	err := DB.Model(&models.FoundItem{}).
+		Where("storeroom_id = ? AND status IN ?", storeroomID, foundItemOccupying).
+		Count(&count).Error
+	return count, err
+}
+
+// sumFoundItemUnits 统计寄存室内拾获物品占用的容量单位数（与行李使用同样的尺寸权重）
+func sumFoundItemUnits(db *gorm.DB, room models.LuggageStoreroom) (int64, error) {
+	expr, args := storedUnitsExpr(room)
+	var units int64
+	err := db.Model(&models.FoundItem{}).
+		Select(expr, args...).
+		Where("storeroom_id = ? AND status IN ?", room.ID, foundItemOccupying).
+		Scan(&units).Error
+	return units, err
+}
+
+// CreateLostItemClaim 登记失物认领
+func CreateLostItemClaim(claim *models.LostItemClaim) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Create(claim).Error
+}
+
+// GetLostItemClaimByID 按ID获取失物认领
+func GetLostItemClaimByID(id int64) (models.LostItemClaim, error) {
+	if DB == nil {
+		return models.LostItemClaim{}, errors.New("db not initialized")
+	}
+	var claim models.LostItemClaim
+	err := DB.Where("id = ?", id).First(&claim).Error
+	return claim, err
+}
+
+// ListLostItemClaims 查询酒店的失物认领（status 为空表示全部，按登记时间倒序）
+func ListLostItemClaims(hotelID int64, status string) ([]models.LostItemClaim, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	query := DB.Where("hotel_id = ?", hotelID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var claims []models.LostItemClaim
+	err := query.Order("id DESC").Find(&claims).Error
+	return claims, err
+}
+
+// UpdateLostItemClaim 在一个事务中更新失物认领（只有当前状态为 from 时才更新）和关联的拾获物品（item 为空时不更新）；
+// 认领或物品状态已变化时整体回滚，返回 gorm.ErrRecordNotFound
+func UpdateLostItemClaim(id int64, from string, updates map[string]interface{}, item *FoundItemChange) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LostItemClaim{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if item == nil {
+			return nil
+		}
+		return applyFoundItemChange(tx, *item)
+	})
+}
@@ -6,8 +6,15 @@ import (
 	"hotel_luggage/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrStoreroomOverCapacity 批量迁移、回退时目标寄存室在事务内重新统计后已放不下
+var ErrStoreroomOverCapacity = errors.New("storeroom capacity exceeded")
+
+// ErrLocationOverCapacity 批量迁移时目标格位（或其所在货架、区域）在事务内重新统计后已放不下
+var ErrLocationOverCapacity = errors.New("storeroom location capacity exceeded")
+
 // CreateLuggage 创建行李寄存记录
 func CreateLuggage(item *models.LuggageItem) error {
 	if DB == nil {
@@ -26,7 +33,7 @@ func RetrievalCodeExists(code string) (bool, error) {
 	return count > 0, err
 }
 
-// CountStoredByStoreroom 统计某寄存室内“已存放”的行李数量
+// CountStoredByStoreroom 统计某寄存室内“已存放”的寄存记录数（容量请用 SumStoredUnitsByStoreroom）
 func CountStoredByStoreroom(storeroomID int64) (int64, error) {
 	if DB == nil {
 		return 0, errors.New("db not initialized")
@@ -38,6 +45,40 @@ func CountStoredByStoreroom(storeroomID int64) (int64, error) {
 	return count, err
 }
 
+// storedUnitsExpr 在存行李占用单位数的求和表达式（件数 × 寄存室的尺寸权重，未填写尺寸按 medium 计算）
+func storedUnitsExpr(room models.LuggageStoreroom) (string, []interface{}) {
+	return "COALESCE(SUM(GREATEST(quantity, 1) * CASE size_class WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? ELSE ? END), 0)",
+		[]interface{}{
+			models.SizeSmall, room.SizeWeight(models.SizeSmall),
+			models.SizeLarge, room.SizeWeight(models.SizeLarge),
+			models.SizeOversized, room.SizeWeight(models.SizeOversized),
+			room.SizeWeight(models.SizeMedium),
+		}
+}
+
+// SumStoredUnitsByStoreroom 统计某寄存室内“已存放”行李（以及仍在保管的拾获物品）占用的容量单位数
+func SumStoredUnitsByStoreroom(room models.LuggageStoreroom) (int64, error) {
+	if DB == nil {
+		return 0, errors.New("db not initialized")
+	}
+	return sumStoredUnits(DB, room)
+}
+
+// sumStoredUnits 在 db（可以是事务）上统计寄存室占用的容量单位数
+func sumStoredUnits(db *gorm.DB, room models.LuggageStoreroom) (int64, error) {
+	expr, args := storedUnitsExpr(room)
+	var units int64
+	err := db.Model(&models.LuggageItem{}).
+		Select(expr, args...).
+		Where("storeroom_id = ? AND status = ?", room.ID, "stored").
+		Scan(&units).Error
+	if err != nil {
+		return 0, err
+	}
+	found, err := sumFoundItemUnits(db, room)
+	return units + found, err
+}
+
 // FindLuggageByUserInfo 按客人姓名/电话查询寄存记录
 func FindLuggageByUserInfo(guestName, contactPhone string) ([]models.LuggageItem, error) {
 	if DB == nil {
@@ -85,51 +126,234 @@ func UpdateLuggageStoreroom(id int64, toStoreroomID int64) error {
 		Update("storeroom_id", toStoreroomID).Error
 }
 
-// UpdateLuggageRetrieved 更新行李为已取件状态
-func UpdateLuggageRetrieved(id int64, retrievedBy string) error {
+// LuggageMove 批量迁移中一件行李的目标位置
+type LuggageMove struct {
+	LuggageID   int64
+	StoreroomID int64  // 目标寄存室
+	BinID       *int64 // 目标格位（目标寄存室未划分格位时为空）
+}
+
+// MoveLuggageBatch 在一个事务中把源寄存室的行李迁移到目标位置，并写入每件行李的修改记录；
+// deactivateSource 为 true 时同时停用源寄存室。
+// 任意一件行李已不在源寄存室（被取走或被其他人迁移）时整批回滚，返回 gorm.ErrRecordNotFound；
+// 目标寄存室在事务内加行锁，迁入后重新统计占用，超出容量时整批回滚，返回 ErrStoreroomOverCapacity；
+// 目标寄存室内的位置同样加行锁，迁入后重新统计目标格位及其上级的占用，超出容量时返回 ErrLocationOverCapacity；
+// audits 在同一事务中最后写入，写入失败时迁移一起回滚
+func MoveLuggageBatch(sourceID int64, moves []LuggageMove, records []models.LuggageUpdate, deactivateSource bool, audits AuditBatch) error {
 	if DB == nil {
 		return errors.New("db not initialized")
 	}
-	return DB.Model(&models.LuggageItem{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"status":       "retrieved",
-			"retrieved_by": retrievedBy,
-			"retrieved_at": gorm.Expr("NOW()"),
-		}).Error
+	targetIDs := make([]int64, 0, len(moves))
+	seen := map[int64]bool{}
+	binIDs := map[int64][]int64{} // 目标寄存室 → 迁入的格位
+	for _, move := range moves {
+		if !seen[move.StoreroomID] {
+			seen[move.StoreroomID] = true
+			targetIDs = append(targetIDs, move.StoreroomID)
+		}
+		if move.BinID != nil {
+			binIDs[move.StoreroomID] = append(binIDs[move.StoreroomID], *move.BinID)
+		}
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// 按 ID 顺序锁定目标寄存室，同时进行的迁移在这里串行化
+		var targets []models.LuggageStoreroom
+		if len(targetIDs) > 0 {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id IN ?", targetIDs).Order("id ASC").Find(&targets).Error; err != nil {
+				return err
+			}
+		}
+		// 再按 ID 顺序锁定迁入格位的寄存室内的位置（上级货架、区域的容量也要重新校验）
+		var locations []models.StoreroomLocation
+		if len(binIDs) > 0 {
+			roomIDs := make([]int64, 0, len(binIDs))
+			for id := range binIDs {
+				roomIDs = append(roomIDs, id)
+			}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("storeroom_id IN ?", roomIDs).Order("id ASC").Find(&locations).Error; err != nil {
+				return err
+			}
+		}
+		for _, move := range moves {
+			result := tx.Model(&models.LuggageItem{}).
+				Where("id = ? AND storeroom_id = ? AND status = ?", move.LuggageID, sourceID, "stored").
+				Updates(map[string]interface{}{
+					"storeroom_id": move.StoreroomID,
+					"bin_id":       move.BinID,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		for _, room := range targets {
+			if room.Capacity <= 0 {
+				continue
+			}
+			used, err := sumStoredUnits(tx, room)
+			if err != nil {
+				return err
+			}
+			if used > int64(room.Capacity) {
+				return ErrStoreroomOverCapacity
+			}
+		}
+		for _, room := range targets {
+			if len(binIDs[room.ID]) == 0 {
+				continue
+			}
+			roomLocations := make([]models.StoreroomLocation, 0, len(locations))
+			for _, loc := range locations {
+				if loc.StoreroomID == room.ID {
+					roomLocations = append(roomLocations, loc)
+				}
+			}
+			if err := checkLocationCapacity(tx, room, roomLocations, binIDs[room.ID]); err != nil {
+				return err
+			}
+		}
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+		}
+		if deactivateSource {
+			if err := tx.Model(&models.LuggageStoreroom{}).Where("id = ?", sourceID).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		return audits.append(tx)
+	})
 }
 
-// UpdateLuggageInfo 更新寄存信息（仅更新指定字段）
-func UpdateLuggageInfo(id int64, updates map[string]interface{}) error {
+// UpdateLuggageInfoWithinCapacity 与 UpdateLuggageInfo 相同，但会在事务中锁定行李修改后所在的寄存室，
+// 更新后重新统计占用，超出容量时回滚并返回 ErrStoreroomOverCapacity（同时进行的寄存、迁移在这里串行化）
+func UpdateLuggageInfoWithinCapacity(id, storeroomID int64, updates map[string]interface{}, record models.LuggageUpdate, audits AuditBatch) error {
 	if DB == nil {
 		return errors.New("db not initialized")
 	}
 	if len(updates) == 0 {
 		return errors.New("no fields to update")
 	}
-	return DB.Model(&models.LuggageItem{}).
-		Where("id = ?", id).
-		Updates(updates).Error
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var room models.LuggageStoreroom
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", storeroomID).First(&room).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.LuggageItem{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if room.Capacity > 0 {
+			used, err := sumStoredUnits(tx, room)
+			if err != nil {
+				return err
+			}
+			if used > int64(room.Capacity) {
+				return ErrStoreroomOverCapacity
+			}
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return audits.append(tx)
+	})
 }
 
-// UpdateLuggageCode 更新取件码
-func UpdateLuggageCode(id int64, code string) error {
+// RetrieveLuggageBatch 在一个事务中完成取件：行李标记为已取件、写入取件历史、删除寄存记录（历史已保留）；
+// otpID > 0 时同一事务中标记取件验证码已使用（已被其他请求使用时返回 ErrCheckoutOTPConsumed），取件失败时验证码仍可再用；
+// audits 在取件历史写入后生成审计日志（快照带上历史记录ID），与取件一起提交；
+// 任意一件行李已不在寄存状态时整批回滚，返回 gorm.ErrRecordNotFound
+func RetrieveLuggageBatch(histories []models.LuggageHistory, retrievedBy string, otpID int64, audits func([]models.LuggageHistory) AuditBatch) error {
 	if DB == nil {
 		return errors.New("db not initialized")
 	}
-	return DB.Model(&models.LuggageItem{}).
-		Where("id = ?", id).
-		Update("retrieval_code", code).Error
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if otpID > 0 {
+			if err := consumeCheckoutOTP(tx, otpID); err != nil {
+				return err
+			}
+		}
+		ids := make([]int64, 0, len(histories))
+		for _, history := range histories {
+			result := tx.Model(&models.LuggageItem{}).
+				Where("id = ? AND status = ?", history.LuggageID, "stored").
+				Updates(map[string]interface{}{
+					"status":       "retrieved",
+					"retrieved_by": retrievedBy,
+					"retrieved_at": gorm.Expr("NOW()"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			ids = append(ids, history.LuggageID)
+		}
+		if err := tx.Create(&histories).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.LuggageItem{}, ids).Error; err != nil {
+			return err
+		}
+		return audits(histories).append(tx)
+	})
 }
 
-// BindLuggageToUser 绑定行李到用户（更新 stored_by）
-func BindLuggageToUser(id int64, username string) error {
+// UpdateLuggageInfo 在一个事务中更新寄存信息（仅更新指定字段）、写入修改记录和审计日志
+func UpdateLuggageInfo(id int64, updates map[string]interface{}, record models.LuggageUpdate, audits AuditBatch) error {
 	if DB == nil {
 		return errors.New("db not initialized")
 	}
-	return DB.Model(&models.LuggageItem{}).
-		Where("id = ?", id).
-		Update("stored_by", username).Error
+	if len(updates) == 0 {
+		return errors.New("no fields to update")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.LuggageItem{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return audits.append(tx)
+	})
+}
+
+// LuggageCodeChange 重新生成取件码时一件行李要更新的字段
+type LuggageCodeChange struct {
+	LuggageID int64
+	Updates   map[string]interface{} // retrieval_code / code_expires_at / qr_code_url
+}
+
+// ReissueLuggageCodes 在一个事务中更新取件码下所有行李的取件码，并写入修改记录和审计日志；
+// 任意一件行李已不在寄存状态（被取走或转寄）时整批回滚，返回 gorm.ErrRecordNotFound
+func ReissueLuggageCodes(changes []LuggageCodeChange, records []models.LuggageUpdate, audits AuditBatch) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			result := tx.Model(&models.LuggageItem{}).
+				Where("id = ? AND status = ?", change.LuggageID, "stored").
+				Updates(change.Updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+		}
+		return audits.append(tx)
+	})
 }
 
 // DeleteLuggageByID 删除行李记录
@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"time"
 
@@ -12,15 +11,21 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
-// MinIOClient 全局 MinIO 客户端（可为空）
+// minioDep 受监管的 MinIO 客户端（可为空）
 // 说明：
 // - MinIO 是一个兼容 Amazon S3 API 的对象存储服务
 // - 用于存储上传的行李照片，相比本地文件系统更适合分布式部署
 // - 如果 MinIO 连接失败，系统会自动降级到本地文件存储（./uploads 目录）
-// - 使用前需要判断 MinIOClient != nil
+// - 降级期间后台按指数退避重连，恢复后自动替换为新客户端（见 supervisor.go）
 //
 // 设计理念：MinIO 是可选的存储优化组件，不影响核心功能
-var MinIOClient *minio.Client
+var minioDep *supervised[minio.Client]
+
+// MinIO 返回当前可用的 MinIO 客户端（降级中返回 nil）
+// 注意：每次使用时都应重新获取，不要长期保存返回值（重连后客户端会被替换）
+func MinIO() *minio.Client {
+	return minioDep.get()
+}
 
 // MinIOBucketName MinIO 存储桶名称
 // Bucket（存储桶）相当于文件系统中的"根目录"，所有文件都存储在 bucket 中
@@ -28,14 +33,14 @@ var MinIOBucketName string
 
 // InitMinIO 初始化 MinIO 对象存储客户端（失败则自动降级到本地存储）
 // 功能：
-// 1. 从环境变量读取 MinIO 配置（服务器地址、凭证等）
+// 1. 使用调用方传入的 MinIO 配置（服务器地址、凭证等）
 // 2. 创建 MinIO 客户端并测试连接
 // 3. 检查 bucket 是否存在，不存在则创建
-// 4. 设置 bucket 为公开读取（可选，方便直接访问图片）
-// 5. 连接失败：打印警告日志，MinIOClient 设为 nil（降级到本地存储）
-// 6. 连接成功：设置全局 MinIOClient 和 MinIOBucketName
+// 4. 确保 bucket 为私有（照片通过预签名地址访问）
+// 5. 连接失败：打印警告日志，降级到本地存储，由 StartSupervisor 在后台重连
+// 6. 连接成功：客户端立即可用
 //
-// 环境变量配置：
+// 配置来源（configs.Load，环境变量可覆盖配置文件）：
 //   MINIO_ENDPOINT        - MinIO 服务器地址（如：localhost:9000 或 minio.example.com）
 //   MINIO_ACCESS_KEY      - 访问密钥（Access Key ID）
 //   MINIO_SECRET_KEY      - 私密密钥（Secret Access Key）
@@ -56,13 +61,47 @@ var MinIOBucketName string
 // 容错设计：
 //   - 权限不足时（如无 ListBucket 权限），仍尝试使用 bucket
 //   - bucket 创建失败时（可能已存在），不中断初始化
-//   - 设置 bucket 策略失败时（权限不足），不影响上传功能
+//   - 删除 bucket 公开策略失败时（权限不足），打印提示，不影响上传功能
 //   - 所有操作都有 5 秒超时，避免长时间等待
-func InitMinIO() {
-	// 1. 加载 MinIO 配置（从环境变量读取）
-	config := configs.LoadMinIOConfig()
+func InitMinIO(config configs.MinIOConfig) {
+	// 1. MinIO 配置由调用方传入（见 configs.Load），bucket 名称与连接状态无关
+	MinIOBucketName = config.BucketName
+
+	// 2. 注册受监管的依赖（后续重连使用同一份配置）
+	minioDep = newSupervised("minio",
+		func(ctx context.Context) (*minio.Client, error) {
+			return connectMinIO(ctx, config)
+		},
+		func(ctx context.Context, client *minio.Client) error {
+			_, err := client.BucketExists(ctx, config.BucketName)
+			return ignoreMinIOServerError(err)
+		},
+		nil, // minio-go 基于 HTTP，无需显式关闭
+	)
+	registerSupervised(minioDep)
 
-	// 2. 创建 MinIO 客户端
+	// 3. 首次连接（5秒超时）
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := minioDep.tryConnect(ctx); err != nil {
+		log.Printf("⚠️  MinIO初始化失败: %v (将使用本地文件存储，后台自动重连)", err)
+		return
+	}
+	log.Printf("✅ MinIO初始化成功: %s/%s", config.Endpoint, config.BucketName)
+}
+
+// ignoreMinIOServerError 忽略服务端返回的业务错误（如 AccessDenied）
+// 能收到服务端响应说明 MinIO 可达，只有网络层错误才视为不可用
+func ignoreMinIOServerError(err error) error {
+	if err == nil || minio.ToErrorResponse(err).Code != "" {
+		return nil
+	}
+	return err
+}
+
+// connectMinIO 创建 MinIO 客户端并完成 bucket 初始化
+func connectMinIO(ctx context.Context, config configs.MinIOConfig) (*minio.Client, error) {
+	// 1. 创建 MinIO 客户端
 	// - Endpoint: MinIO 服务器地址
 	// - Creds: 使用静态凭证（Access Key + Secret Key）
 	// - Secure: 是否使用 HTTPS（true: https://, false: http://）
@@ -71,54 +110,38 @@ func InitMinIO() {
 		Secure: config.UseSSL,
 	})
 	if err != nil {
-		// 客户端创建失败：打印警告，降级到本地存储
-		log.Printf("⚠️  MinIO初始化失败: %v (将使用本地文件存储)", err)
-		return
+		return nil, err
 	}
 
-	// 3. 测试连接 - 检查 bucket 是否存在（5秒超时）
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// 2. 测试连接 - 检查 bucket 是否存在
 	exists, err := client.BucketExists(ctx, config.BucketName)
-	
 	if err != nil {
+		if ignoreMinIOServerError(err) != nil {
+			// 网络错误：MinIO 不可达
+			return nil, err
+		}
 		// 容错处理：如果是权限错误（Access Denied），可能是没有 ListBucket 权限
 		// 但 bucket 可能存在，我们继续尝试使用它（后续 PutObject 可能有权限）
 		log.Printf("⚠️  无法检查bucket状态: %v (尝试直接使用bucket)", err)
-		// 不要 return，继续执行后续步骤
 	} else if !exists {
 		// bucket 不存在，尝试创建
 		err = client.MakeBucket(ctx, config.BucketName, minio.MakeBucketOptions{})
 		if err != nil {
 			// 创建失败：可能是 bucket 已存在但我们没有创建权限
 			log.Printf("⚠️  创建MinIO bucket失败: %v (bucket可能已存在，尝试继续)", err)
-			// 不要 return，可能 bucket 已存在，后续上传可能成功
 		} else {
 			log.Printf("✅ MinIO bucket '%s' 创建成功", config.BucketName)
 		}
 	}
 
-	// 4. 尝试设置 bucket 为公开读取（可选，方便直接访问图片）
-	// 策略说明：允许任何人（Principal: *）执行 GetObject 操作（下载文件）
-	// 注意：如果没有 SetBucketPolicy 权限，这个操作会失败，但不影响上传功能
-	policy := fmt.Sprintf(`{
-		"Version": "2012-10-17",
-		"Statement": [{
-			"Effect": "Allow",
-			"Principal": {"AWS": ["*"]},
-			"Action": ["s3:GetObject"],
-			"Resource": ["arn:aws:s3:::%s/*"]
-		}]
-	}`, config.BucketName)
-
-	err = client.SetBucketPolicy(ctx, config.BucketName, policy)
-	if err != nil {
-		// 策略设置失败：可能是权限不足，但不影响核心上传功能
-		log.Printf("ℹ️  设置bucket策略失败(可忽略): %v", err)
+	// 3. 确保 bucket 为私有（删除旧版本设置的公开读取策略）
+	// 照片只能通过预签名地址访问；没有 SetBucketPolicy 权限时只打印提示
+	if policy, err := client.GetBucketPolicy(ctx, config.BucketName); err == nil && policy != "" {
+		if err := client.SetBucketPolicy(ctx, config.BucketName, ""); err != nil {
+			log.Printf("⚠️  删除bucket公开策略失败，请手动将 bucket '%s' 设为私有: %v", config.BucketName, err)
+		} else {
+			log.Printf("✅ 已删除 bucket '%s' 的公开读取策略", config.BucketName)
+		}
 	}
-
-	// 5. 初始化成功：设置全局变量
-	MinIOClient = client
-	MinIOBucketName = config.BucketName
-	log.Printf("✅ MinIO初始化成功: %s/%s", config.Endpoint, config.BucketName)
+	return client, nil
 }
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateCheckoutOTP 保存取件验证码（只保存哈希）
+func CreateCheckoutOTP(otp *models.CheckoutOTP) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Create(otp).Error
+}
+
+// GetLatestCheckoutOTP 查询某取件码最近发送的验证码（没有记录时返回 gorm.ErrRecordNotFound）
+func GetLatestCheckoutOTP(code string) (models.CheckoutOTP, error) {
+	var otp models.CheckoutOTP
+	if DB == nil {
+		return otp, errors.New("db not initialized")
+	}
+	err := DB.Where("retrieval_code = ?", code).Order("id DESC").First(&otp).Error
+	return otp, err
+}
+
+// IncrementCheckoutOTPAttempts 记录一次验证失败
+func IncrementCheckoutOTPAttempts(id int64) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Model(&models.CheckoutOTP{}).Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// ErrCheckoutOTPConsumed 取件事务中标记验证码已使用时发现它已被其他请求使用
+var ErrCheckoutOTPConsumed = errors.New("checkout otp already consumed")
+
+// consumeCheckoutOTP 在事务中标记验证码已使用（并发验证同一个验证码时只有一个请求成功）
+func consumeCheckoutOTP(tx *gorm.DB, id int64) error {
+	result := tx.Model(&models.CheckoutOTP{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCheckoutOTPConsumed
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"errors"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// GetHotelPolicy 查询酒店策略（没有记录时返回 gorm.ErrRecordNotFound）
+func GetHotelPolicy(hotelID int64) (models.HotelPolicy, error) {
+	var policy models.HotelPolicy
+	if DB == nil {
+		return policy, errors.New("db not initialized")
+	}
+	err := DB.Where("hotel_id = ?", hotelID).First(&policy).Error
+	return policy, err
+}
+
+// SaveHotelPolicy 新增或更新酒店策略（按 hotel_id 唯一）
+func SaveHotelPolicy(policy *models.HotelPolicy) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "hotel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"checkout_verification",
+			"high_risk_verification",
+			"high_risk_quantity",
+			"high_risk_keywords",
+			"require_signature",
+			"require_checkin_photos",
+			"code_length",
+			"code_alphabet",
+			"code_check_digit",
+			"code_reuse_cooldown_hours",
+			"code_ttl_hours",
+			"assign_strategy",
+			"short_term_hours",
+			"lost_found_retention_days",
+			"updated_by",
+			"updated_at",
+		}),
+	}).Create(policy).Error
+}
@@ -51,3 +51,11 @@ func UpdateStoreroomStatus(id int64, isActive bool) error {
 		Where("id = ?", id).
 		Update("is_active", isActive).Error
 }
+
+// UpdateStoreroom 修改寄存室（容量、尺寸权重等）
+func UpdateStoreroom(id int64, updates map[string]interface{}) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Model(&models.LuggageStoreroom{}).Where("id = ?", id).Updates(updates).Error
+}
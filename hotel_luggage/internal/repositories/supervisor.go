@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hotel_luggage/configs"
+)
+
+// DependencyState 可选依赖（Redis / MinIO）的当前状态
+// 供 /readyz 和 /metrics 使用
+type DependencyState struct {
+	Name                string    `json:"name"`
+	Up                  bool      `json:"up"`                   // 当前是否可用（false 表示处于降级模式）
+	Since               time.Time `json:"since"`                // 进入当前状态的时间
+	LastError           string    `json:"last_error,omitempty"` // 最近一次连接/检查失败的原因
+	ConsecutiveFailures int       `json:"consecutive_failures"` // 连续失败次数（恢复后清零）
+	Reconnects          int64     `json:"reconnects"`           // 降级后成功恢复的次数
+}
+
+// supervised 受监管的依赖：保存当前客户端，失败时后台重连并热替换
+// 说明：
+// - client 为 nil 表示依赖不可用，业务代码应走降级逻辑
+// - 客户端通过 atomic.Pointer 替换，业务代码每次取用时都拿到最新的客户端
+type supervised[T any] struct {
+	name    string
+	connect func(ctx context.Context) (*T, error) // 建立连接（含连通性检查）
+	check   func(ctx context.Context, client *T) error
+	close   func(client *T)
+
+	client atomic.Pointer[T]
+
+	mu    sync.Mutex
+	state DependencyState
+}
+
+func newSupervised[T any](name string, connect func(context.Context) (*T, error), check func(context.Context, *T) error, closeFn func(*T)) *supervised[T] {
+	return &supervised[T]{
+		name:    name,
+		connect: connect,
+		check:   check,
+		close:   closeFn,
+		state:   DependencyState{Name: name, Since: time.Now()},
+	}
+}
+
+// get 返回当前客户端（nil 表示降级中）
+func (s *supervised[T]) get() *T {
+	if s == nil {
+		return nil
+	}
+	return s.client.Load()
+}
+
+// tryConnect 尝试建立连接，成功则替换客户端
+func (s *supervised[T]) tryConnect(ctx context.Context) error {
+	client, err := s.connect(ctx)
+	if err != nil {
+		s.markDown(err)
+		return err
+	}
+	s.client.Store(client)
+
+	s.mu.Lock()
+	recovered := !s.state.Up && s.state.ConsecutiveFailures > 0
+	if recovered {
+		s.state.Reconnects++
+	}
+	s.state.Up = true
+	s.state.Since = time.Now()
+	s.state.LastError = ""
+	s.state.ConsecutiveFailures = 0
+	s.mu.Unlock()
+
+	if recovered {
+		log.Printf("✅ %s 已恢复连接，退出降级模式", s.name)
+	}
+	return nil
+}
+
+// markDown 标记为不可用，并关闭旧客户端
+func (s *supervised[T]) markDown(err error) {
+	if old := s.client.Swap(nil); old != nil && s.close != nil {
+		s.close(old)
+	}
+
+	s.mu.Lock()
+	wasUp := s.state.Up
+	if wasUp {
+		s.state.Since = time.Now()
+	}
+	s.state.Up = false
+	s.state.LastError = err.Error()
+	s.state.ConsecutiveFailures++
+	s.mu.Unlock()
+
+	if wasUp {
+		log.Printf("⚠️  %s 连接异常，进入降级模式: %v", s.name, err)
+	}
+}
+
+// snapshot 返回当前状态的副本
+func (s *supervised[T]) snapshot() DependencyState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// run 后台监管循环，直到 ctx 取消
+// - 已连接：每隔 CheckInterval 检查一次，失败立即降级并开始重连
+// - 未连接：按指数退避重连（InitialBackoff 起，每次翻倍，最长 MaxBackoff）
+func (s *supervised[T]) run(ctx context.Context, cfg configs.RecoveryConfig) {
+	backoff := cfg.InitialBackoff.Std()
+	for {
+		var wait time.Duration
+		if client := s.client.Load(); client != nil {
+			checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			err := s.check(checkCtx, client)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				s.markDown(err)
+				backoff = cfg.InitialBackoff.Std()
+				wait = backoff
+			} else {
+				wait = cfg.CheckInterval.Std()
+			}
+		} else {
+			connectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			err := s.tryConnect(connectCtx)
+			cancel()
+			if err != nil {
+				wait = backoff
+				backoff *= 2
+				if max := cfg.MaxBackoff.Std(); backoff > max {
+					backoff = max
+				}
+			} else {
+				backoff = cfg.InitialBackoff.Std()
+				wait = cfg.CheckInterval.Std()
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// shutdown 停止使用并关闭客户端（优雅退出时调用）
+func (s *supervised[T]) shutdown() bool {
+	if s == nil {
+		return false
+	}
+	old := s.client.Swap(nil)
+	if old == nil {
+		return false
+	}
+	if s.close != nil {
+		s.close(old)
+	}
+	return true
+}
+
+// supervisor 需要后台监管的依赖（InitRedis / InitMinIO 时注册）
+type supervisor interface {
+	run(ctx context.Context, cfg configs.RecoveryConfig)
+	snapshot() DependencyState
+}
+
+var (
+	supervisedMu   sync.Mutex
+	supervisedDeps []supervisor
+)
+
+func registerSupervised(dep supervisor) {
+	supervisedMu.Lock()
+	defer supervisedMu.Unlock()
+	supervisedDeps = append(supervisedDeps, dep)
+}
+
+// StartSupervisor 启动依赖监管（后台自动重连降级的 Redis / MinIO）
+// 需要在 InitRedis / InitMinIO 之后调用；ctx 取消后所有监管协程退出
+// 返回的函数会阻塞到所有监管协程退出为止（优雅退出时在关闭客户端之前调用）
+func StartSupervisor(ctx context.Context, cfg configs.RecoveryConfig) (wait func()) {
+	supervisedMu.Lock()
+	deps := append([]supervisor(nil), supervisedDeps...)
+	supervisedMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, dep := range deps {
+		wg.Add(1)
+		go func(dep supervisor) {
+			defer wg.Done()
+			dep.run(ctx, cfg)
+		}(dep)
+	}
+	return wg.Wait
+}
+
+// DependencyStates 返回所有受监管依赖的当前状态
+func DependencyStates() []DependencyState {
+	supervisedMu.Lock()
+	defer supervisedMu.Unlock()
+	states := make([]DependencyState, 0, len(supervisedDeps))
+	for _, dep := range supervisedDeps {
+		states = append(states, dep.snapshot())
+	}
+	return states
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TransferCompletion 签收或取消转寄时一件行李的处理结果
+type TransferCompletion struct {
+	TransferID int64
+	Move       LuggageMove // 行李放入的寄存室和格位（签收为目的酒店的寄存室，取消为原寄存室）
+}
+
+// ListTransfersByCode 查询取件码的转寄记录（按发出顺序）
+func ListTransfersByCode(code string) ([]models.LuggageTransfer, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var transfers []models.LuggageTransfer
+	err := DB.Where("retrieval_code = ?", code).Order("id ASC").Find(&transfers).Error
+	return transfers, err
+}
+
+// ListTransfersByHotel 查询酒店发出（outgoing）或收到（incoming）的转寄记录，direction 为空表示两者都查
+func ListTransfersByHotel(hotelID int64, direction, status string) ([]models.LuggageTransfer, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	query := DB.Model(&models.LuggageTransfer{})
+	switch direction {
+	case "incoming":
+		query = query.Where("to_hotel_id = ?", hotelID)
+	case "outgoing":
+		query = query.Where("from_hotel_id = ?", hotelID)
+	default:
+		query = query.Where("from_hotel_id = ? OR to_hotel_id = ?", hotelID, hotelID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var transfers []models.LuggageTransfer
+	err := query.Order("id DESC").Find(&transfers).Error
+	return transfers, err
+}
+
+// DispatchLuggageTransfers 在一个事务中把行李标记为转寄途中（离开格位），写入转寄记录和修改记录；
+// 任意一件行李已不在寄存状态时整批回滚，返回 gorm.ErrRecordNotFound
+func DispatchLuggageTransfers(transfers []models.LuggageTransfer, records []models.LuggageUpdate) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, transfer := range transfers {
+			result := tx.Model(&models.LuggageItem{}).
+				Where("id = ? AND status = ?", transfer.LuggageID, "stored").
+				Updates(map[string]interface{}{
+					"status": "in_transit",
+					"bin_id": nil,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		if err := tx.Create(&transfers).Error; err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			return tx.Create(&records).Error
+		}
+		return nil
+	})
+}
+
+// CompleteLuggageTransfers 在一个事务中签收（status=received）或取消（status=cancelled）转寄：
+// 行李放入 hotelID 酒店的寄存室并恢复为 stored，转寄记录更新状态，同时写入修改记录；
+// 任意一件行李或转寄记录已不在途中时整批回滚，返回 gorm.ErrRecordNotFound
+func CompleteLuggageTransfers(status string, hotelID int64, by string, completions []TransferCompletion, records []models.LuggageUpdate) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	now := time.Now()
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, c := range completions {
+			result := tx.Model(&models.LuggageItem{}).
+				Where("id = ? AND status = ?", c.Move.LuggageID, "in_transit").
+				Updates(map[string]interface{}{
+					"status":       "stored",
+					"hotel_id":     hotelID,
+					"storeroom_id": c.Move.StoreroomID,
+					"bin_id":       c.Move.BinID,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			updates := map[string]interface{}{"status": status}
+			if status == models.TransferStatusReceived {
+				updates["to_storeroom_id"] = c.Move.StoreroomID
+				updates["received_by"] = by
+				updates["received_at"] = now
+			} else {
+				updates["cancelled_by"] = by
+				updates["cancelled_at"] = now
+			}
+			result = tx.Model(&models.LuggageTransfer{}).
+				Where("id = ? AND status = ?", c.TransferID, models.TransferStatusInTransit).
+				Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		if len(records) > 0 {
+			return tx.Create(&records).Error
+		}
+		return nil
+	})
+}
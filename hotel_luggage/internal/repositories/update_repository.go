@@ -14,12 +14,36 @@ func CreateLuggageUpdate(record *models.LuggageUpdate) error {
 	return DB.Create(record).Error
 }
 
-// ListUpdatesByHotel 按酒店查询寄存单修改记录
-func ListUpdatesByHotel(hotelID int64) ([]models.LuggageUpdate, error) {
+// LuggageUpdateFilter 寄存单修改记录查询条件（为空的字段不过滤）
+type LuggageUpdateFilter struct {
+	HotelID   int64
+	LuggageID int64
+	Field     string // 修改了该字段（旧记录没有 changed_fields，会一并返回，由 service 补算后过滤）
+}
+
+// ListLuggageUpdates 按条件查询寄存单修改记录（按修改时间倒序）
+func ListLuggageUpdates(filter LuggageUpdateFilter) ([]models.LuggageUpdate, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	query := DB.Where("hotel_id = ?", filter.HotelID)
+	if filter.LuggageID > 0 {
+		query = query.Where("luggage_id = ?", filter.LuggageID)
+	}
+	if filter.Field != "" {
+		query = query.Where("(FIND_IN_SET(?, changed_fields) > 0 OR changes IS NULL OR changes = '')", filter.Field)
+	}
+	var items []models.LuggageUpdate
+	err := query.Order("updated_at DESC").Order("id DESC").Find(&items).Error
+	return items, err
+}
+
+// ListLuggageRevisions 按先后顺序查询单件行李在酒店内的修改记录（用于时间线和回滚）
+func ListLuggageRevisions(hotelID, luggageID int64) ([]models.LuggageUpdate, error) {
 	if DB == nil {
 		return nil, errors.New("db not initialized")
 	}
 	var items []models.LuggageUpdate
-	err := DB.Where("hotel_id = ?", hotelID).Order("updated_at DESC").Find(&items).Error
+	err := DB.Where("hotel_id = ? AND luggage_id = ?", hotelID, luggageID).Order("id ASC").Find(&items).Error
 	return items, err
 }
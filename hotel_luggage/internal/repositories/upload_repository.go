@@ -0,0 +1,324 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreatePendingUpload 记录一个待同步的本地上传（同一个 key 已存在时忽略）
+func CreatePendingUpload(record *models.PendingUpload) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error
+}
+
+// ListPendingUploads 按记录顺序获取待同步的上传
+func ListPendingUploads(limit int) ([]models.PendingUpload, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var items []models.PendingUpload
+	err := DB.Where("status = ?", models.PendingUploadPending).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// MarkPendingUploadSynced 标记为已同步
+func MarkPendingUploadSynced(id int64, remoteURL string) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	now := time.Now()
+	return DB.Model(&models.PendingUpload{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.PendingUploadSynced,
+		"remote_url": remoteURL,
+		"last_error": "",
+		"synced_at":  &now,
+		"attempts":   gorm.Expr("attempts + 1"),
+	}).Error
+}
+
+// MarkPendingUploadFailed 记录一次同步失败
+// permanent 为 true 时标记为 failed，不再重试（例如本地文件已丢失）
+func MarkPendingUploadFailed(id int64, reason string, permanent bool) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	updates := map[string]interface{}{
+		"last_error": reason,
+		"attempts":   gorm.Expr("attempts + 1"),
+	}
+	if permanent {
+		updates["status"] = models.PendingUploadFailed
+	}
+	return DB.Model(&models.PendingUpload{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// RewritePhotoURLs 把行李记录、取件历史、拾获物品和事故报告中指向本地文件的照片地址改写为新地址
+// 匹配规则：与 oldURLs 中任一地址完全相同，或以 suffix 结尾（兼容旧版本按请求 Host 拼接的地址）
+// 返回受影响的取件码（用于清理缓存）
+func RewritePhotoURLs(oldURLs []string, suffix, newURL string) ([]string, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	matches := func(u string) bool {
+		if u == "" {
+			return false
+		}
+		for _, old := range oldURLs {
+			if u == old {
+				return true
+			}
+		}
+		return suffix != "" && strings.HasSuffix(u, suffix)
+	}
+	rewrite := func(photoURL string, photoURLs []string) (string, []string, bool) {
+		changed := false
+		if matches(photoURL) {
+			photoURL = newURL
+			changed = true
+		}
+		for i, u := range photoURLs {
+			if matches(u) {
+				photoURLs[i] = newURL
+				changed = true
+			}
+		}
+		return photoURL, photoURLs, changed
+	}
+
+	// 先用 LIKE 粗筛，再在内存中精确匹配
+	pattern := "%" + suffix + "%"
+	var codes []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var items []models.LuggageItem
+		if err := tx.Where("photo_url LIKE ? OR photo_urls LIKE ?", pattern, pattern).Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			photoURL, photoURLs, changed := rewrite(item.PhotoURL, item.PhotoURLs)
+			if !changed {
+				continue
+			}
+			item.PhotoURL, item.PhotoURLs = photoURL, photoURLs
+			if err := item.BeforeSave(tx); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.LuggageItem{}).Where("id = ?", item.ID).UpdateColumns(map[string]interface{}{
+				"photo_url":  item.PhotoURL,
+				"photo_urls": item.PhotoURLsRaw,
+			}).Error; err != nil {
+				return err
+			}
+			codes = append(codes, item.RetrievalCode)
+		}
+
+		var history []models.LuggageHistory
+		if err := tx.Where("photo_url LIKE ? OR photo_urls LIKE ?", pattern, pattern).Find(&history).Error; err != nil {
+			return err
+		}
+		for _, record := range history {
+			photoURL, photoURLs, changed := rewrite(record.PhotoURL, record.PhotoURLs)
+			if !changed {
+				continue
+			}
+			record.PhotoURL, record.PhotoURLs = photoURL, photoURLs
+			if err := record.BeforeSave(tx); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.LuggageHistory{}).Where("id = ?", record.ID).UpdateColumns(map[string]interface{}{
+				"photo_url":  record.PhotoURL,
+				"photo_urls": record.PhotoURLsRaw,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var found []models.FoundItem
+		if err := tx.Where("photo_url LIKE ? OR photo_urls LIKE ?", pattern, pattern).Find(&found).Error; err != nil {
+			return err
+		}
+		for _, item := range found {
+			photoURL, photoURLs, changed := rewrite(item.PhotoURL, item.PhotoURLs)
+			if !changed {
+				continue
+			}
+			item.PhotoURL, item.PhotoURLs = photoURL, photoURLs
+			if err := item.BeforeSave(tx); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.FoundItem{}).Where("id = ?", item.ID).UpdateColumns(map[string]interface{}{
+				"photo_url":  item.PhotoURL,
+				"photo_urls": item.PhotoURLsRaw,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var incidents []models.LuggageIncident
+		if err := tx.Where("checkin_photo_urls LIKE ? OR checkout_photo_urls LIKE ?", pattern, pattern).Find(&incidents).Error; err != nil {
+			return err
+		}
+		for _, incident := range incidents {
+			_, checkin, checkinChanged := rewrite("", incident.CheckinPhotoURLs)
+			_, checkout, checkoutChanged := rewrite("", incident.CheckoutPhotoURLs)
+			if !checkinChanged && !checkoutChanged {
+				continue
+			}
+			incident.CheckinPhotoURLs, incident.CheckoutPhotoURLs = checkin, checkout
+			if err := incident.BeforeSave(tx); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.LuggageIncident{}).Where("id = ?", incident.ID).UpdateColumns(map[string]interface{}{
+				"checkin_photo_urls":  incident.CheckinPhotoURLsRaw,
+				"checkout_photo_urls": incident.CheckoutPhotoURLsRaw,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return codes, err
+}
+
+// CreateUploadRecord 记录一次上传（同一个 key 已存在时忽略）
+func CreateUploadRecord(record *models.UploadRecord) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	return DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error
+}
+
+// TouchUploadRecords 更新照片最近一次被引用的时间（寄存单挂上或替换照片时调用，替换掉的照片从此刻起计算宽限期）
+func TouchUploadRecords(keys []string) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return DB.Model(&models.UploadRecord{}).
+		Where("object_key IN ? AND status = ?", keys, models.UploadRecordActive).
+		Update("last_referenced_at", time.Now()).Error
+}
+
+// ListUploadRecordsByKeys 按原图 key 批量查询仍在存储中的上传记录（签名照片时确认已生成的缩略图）
+func ListUploadRecordsByKeys(keys []string) ([]models.UploadRecord, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var items []models.UploadRecord
+	if len(keys) == 0 {
+		return items, nil
+	}
+	err := DB.Where("object_key IN ? AND status = ?", keys, models.UploadRecordActive).Find(&items).Error
+	return items, err
+}
+
+// ListUnsyncedUploadKeys 返回 keys 中降级写入本地、尚未同步到主存储的对象 key
+func ListUnsyncedUploadKeys(keys []string) ([]string, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var result []string
+	if len(keys) == 0 {
+		return result, nil
+	}
+	err := DB.Model(&models.PendingUpload{}).
+		Where("object_key IN ? AND status <> ?", keys, models.PendingUploadSynced).
+		Pluck("object_key", &result).Error
+	return result, err
+}
+
+// ListUploadGCCandidates 获取可能需要清理的上传记录（按 ID 分批）
+// 条件：上传时间和最近引用时间都早于 cutoff，且不在待同步队列中（待同步的文件同步完成后再处理）
+func ListUploadGCCandidates(cutoff time.Time, afterID int64, limit int) ([]models.UploadRecord, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	var items []models.UploadRecord
+	err := DB.Where("status = ? AND id > ? AND created_at < ?", models.UploadRecordActive, afterID, cutoff).
+		Where("last_referenced_at IS NULL OR last_referenced_at < ?", cutoff).
+		Where("object_key NOT IN (?)", DB.Model(&models.PendingUpload{}).Select("object_key").Where("status = ?", models.PendingUploadPending)).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// FindPhotoRefsLike 返回 luggage_items、luggage_history、found_items 和 luggage_incidents 中可能引用 keys 的照片地址
+// 只用 LIKE 粗筛（地址中包含 key 即返回），由调用方精确匹配
+func FindPhotoRefsLike(keys []string) ([]string, error) {
+	if DB == nil {
+		return nil, errors.New("db not initialized")
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	conds := make([]string, 0, len(keys))
+	incidentConds := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, len(keys)*2)
+	for _, key := range keys {
+		conds = append(conds, "photo_url LIKE ? OR photo_urls LIKE ?")
+		incidentConds = append(incidentConds, "checkin_photo_urls LIKE ? OR checkout_photo_urls LIKE ?")
+		pattern := "%" + key + "%"
+		args = append(args, pattern, pattern)
+	}
+	where := strings.Join(conds, " OR ")
+	incidentWhere := strings.Join(incidentConds, " OR ")
+	var refs []string
+	collect := func(photoURL string, photoURLs []string) {
+		refs = append(append(refs, photoURL), photoURLs...)
+	}
+
+	var items []models.LuggageItem
+	if err := DB.Select("id", "photo_url", "photo_urls").Where(where, args...).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		collect(item.PhotoURL, item.PhotoURLs)
+	}
+	var history []models.LuggageHistory
+	if err := DB.Select("id", "photo_url", "photo_urls").Where(where, args...).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range history {
+		collect(record.PhotoURL, record.PhotoURLs)
+	}
+	var found []models.FoundItem
+	if err := DB.Select("id", "photo_url", "photo_urls").Where(where, args...).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range found {
+		collect(item.PhotoURL, item.PhotoURLs)
+	}
+	var incidents []models.LuggageIncident
+	if err := DB.Select("id", "checkin_photo_urls", "checkout_photo_urls").Where(incidentWhere, args...).Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		collect("", append(incident.CheckinPhotoURLs, incident.CheckoutPhotoURLs...))
+	}
+	return refs, nil
+}
+
+// MarkUploadRecordDeleted 标记上传记录已删除
+func MarkUploadRecordDeleted(id int64) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	now := time.Now()
+	return DB.Model(&models.UploadRecord{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.UploadRecordDeleted,
+		"deleted_at": &now,
+	}).Error
+}
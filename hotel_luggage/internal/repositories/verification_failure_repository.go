@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"hotel_luggage/internal/models"
+)
+
+// CreateVerificationFailures 记录一次验证失败（每个计数对象一条），并删除 cutoff 之前的旧记录
+func CreateVerificationFailures(scope string, subjects []string, cutoff time.Time) error {
+	if DB == nil {
+		return errors.New("db not initialized")
+	}
+	if len(subjects) == 0 {
+		return nil
+	}
+	records := make([]models.VerificationFailure, len(subjects))
+	for i, subject := range subjects {
+		records[i] = models.VerificationFailure{Scope: scope, Subject: subject}
+	}
+	if err := DB.Create(&records).Error; err != nil {
+		return err
+	}
+	return DB.Where("created_at < ?", cutoff).Delete(&models.VerificationFailure{}).Error
+}
+
+// CountVerificationFailures 统计 since 之后某计数对象的验证失败次数
+func CountVerificationFailures(scope, subject string, since time.Time) (int64, error) {
+	if DB == nil {
+		return 0, errors.New("db not initialized")
+	}
+	var count int64
+	err := DB.Model(&models.VerificationFailure{}).
+		Where("scope = ? AND subject = ? AND created_at >= ?", scope, subject, since).
+		Count(&count).Error
+	return count, err
+}
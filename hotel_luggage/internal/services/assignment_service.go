@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+)
+
+// AssignmentRequest 自动分配寄存室的业务输入
+type AssignmentRequest struct {
+	HotelID          int64
+	Quantity         int
+	SizeClass        string     // 尺寸（为空按 medium 计算容量）
+	Handling         []string   // 特殊保管要求：fragile / valuables / refrigerated
+	ExpectedPickupAt *time.Time // 预计取件时间（为空时不按取件时间区分寄存室）
+}
+
+// StoreroomRecommendation 推荐的寄存室（按推荐顺序排列，第一项即自动分配的结果）
+type StoreroomRecommendation struct {
+	StoreroomID       int64    `json:"storeroom_id"`
+	StoreroomName     string   `json:"storeroom_name"`
+	BinID             *int64   `json:"bin_id"`             // 推荐的格位（寄存室未划分格位时为空）
+	BinPath           string   `json:"bin_path,omitempty"` // 格位完整位置
+	Units             int64    `json:"units"`              // 该行李占用的单位数（按该寄存室的尺寸权重）
+	RemainingCapacity int64    `json:"remaining_capacity"` // 放入前的剩余单位数（不限制容量时为 -1）
+	Priority          int      `json:"priority"`
+	PickupTerm        string   `json:"pickup_term"`
+	Reasons           []string `json:"reasons"` // 推荐理由（展示给员工）
+
+	termRank  int
+	freeRatio float64
+}
+
+// normalizeHandling 校验特殊保管要求并去重排序，返回保存到数据库的逗号分隔字符串
+func normalizeHandling(tags []string) (string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !models.IsHandling(tag) {
+			return "", apperr.InvalidRequest("handling must be one of fragile, valuables, refrigerated")
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return strings.Join(result, ","), nil
+}
+
+// splitHandling 拆分逗号分隔的特殊保管要求
+func splitHandling(handling string) []string {
+	var tags []string
+	for _, tag := range strings.Split(handling, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// missingHandling 返回寄存室不支持的特殊保管要求
+func missingHandling(room models.LuggageStoreroom, tags []string) []string {
+	supported := map[string]bool{}
+	for _, tag := range splitHandling(room.Handling) {
+		supported[tag] = true
+	}
+	var missing []string
+	for _, tag := range tags {
+		if !supported[tag] {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// desiredPickupTerm 按预计取件时间和酒店的短时寄存阈值判断取件时间类型（未填写时不区分）
+func desiredPickupTerm(policy models.HotelPolicy, expectedPickupAt *time.Time) string {
+	if expectedPickupAt == nil {
+		return models.PickupTermAny
+	}
+	if expectedPickupAt.Sub(time.Now()) <= time.Duration(policy.ShortTermHours)*time.Hour {
+		return models.PickupTermShort
+	}
+	return models.PickupTermLong
+}
+
+// RecommendStorerooms 按酒店的分配规则推荐寄存室
+// 只推荐启用、支持全部特殊保管要求、剩余容量放得下（划分了格位时还要有放得下的格位）的寄存室；
+// 排序：取件时间类型匹配的优先（any 次之，不匹配的最后）→ priority 数字小的优先 →
+// 按酒店策略比较剩余容量比例（balance 剩余多的优先，fill 剩余少的优先）→ ID 小的优先
+func RecommendStorerooms(req AssignmentRequest) ([]StoreroomRecommendation, error) {
+	if req.HotelID <= 0 {
+		return nil, apperr.InvalidRequest("invalid hotel id")
+	}
+	if req.Quantity <= 0 {
+		req.Quantity = 1
+	}
+	if !models.IsSizeClass(req.SizeClass) {
+		return nil, apperr.InvalidRequest("size_class must be one of small, medium, large, oversized")
+	}
+	handling, err := normalizeHandling(req.Handling)
+	if err != nil {
+		return nil, err
+	}
+	tags := splitHandling(handling)
+
+	policy, err := GetHotelPolicy(req.HotelID)
+	if err != nil {
+		return nil, err
+	}
+	term := desiredPickupTerm(policy, req.ExpectedPickupAt)
+
+	rooms, err := repositories.ListStorerooms(req.HotelID)
+	if err != nil {
+		return nil, err
+	}
+	recs := []StoreroomRecommendation{}
+	for _, room := range rooms {
+		if !room.IsActive || len(missingHandling(room, tags)) > 0 {
+			continue
+		}
+		units := room.LuggageUnits(req.Quantity, req.SizeClass)
+		usage, err := GetStoreroomUsage(room)
+		if err != nil {
+			return nil, err
+		}
+		if usage.RemainingCapacity >= 0 && usage.RemainingCapacity < units {
+			continue
+		}
+		layout, err := loadStoreroomLayout(room.ID)
+		if err != nil {
+			return nil, err
+		}
+		bin, err := layout.suggestBin(units)
+		if errors.Is(err, apperr.ErrNoFreeBin) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec := StoreroomRecommendation{
+			StoreroomID:       room.ID,
+			StoreroomName:     room.Name,
+			Units:             units,
+			RemainingCapacity: usage.RemainingCapacity,
+			Priority:          room.Priority,
+			PickupTerm:        room.PickupTerm,
+			Reasons:           []string{},
+			freeRatio:         1,
+		}
+		if bin != nil {
+			rec.BinID, rec.BinPath = &bin.ID, bin.Path
+		}
+		if room.Capacity > 0 {
+			rec.freeRatio = float64(usage.RemainingCapacity) / float64(room.Capacity)
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("%d of %d units free", usage.RemainingCapacity, room.Capacity))
+		} else {
+			rec.Reasons = append(rec.Reasons, "unlimited capacity")
+		}
+		if len(tags) > 0 {
+			rec.Reasons = append(rec.Reasons, "supports "+handling)
+		}
+		switch {
+		case term == models.PickupTermAny || room.PickupTerm == term:
+			rec.termRank = 0
+			if term != models.PickupTermAny {
+				rec.Reasons = append(rec.Reasons, term+"-term storeroom")
+			}
+		case room.PickupTerm == models.PickupTermAny || room.PickupTerm == "":
+			rec.termRank = 1
+		default:
+			rec.termRank = 2
+			rec.Reasons = append(rec.Reasons, "intended for "+room.PickupTerm+"-term storage")
+		}
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("priority %d", room.Priority))
+		if bin != nil {
+			rec.Reasons = append(rec.Reasons, "free bin "+bin.Path)
+		}
+		recs = append(recs, rec)
+	}
+
+	fill := policy.AssignStrategy == models.AssignStrategyFill
+	sort.SliceStable(recs, func(i, j int) bool {
+		a, b := recs[i], recs[j]
+		if a.termRank != b.termRank {
+			return a.termRank < b.termRank
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if a.freeRatio != b.freeRatio {
+			if fill {
+				return a.freeRatio < b.freeRatio
+			}
+			return a.freeRatio > b.freeRatio
+		}
+		return a.StoreroomID < b.StoreroomID
+	})
+	return recs, nil
+}
+
+// UpdateStoreroomAssignmentRequest 修改寄存室自动分配规则（只修改传入的字段）
+type UpdateStoreroomAssignmentRequest struct {
+	Priority   *int
+	Handling   *[]string
+	PickupTerm *string
+}
+
+// apply 校验并写入寄存室的自动分配规则，返回需要更新的列
+func (r UpdateStoreroomAssignmentRequest) apply(room *models.LuggageStoreroom) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	if r.Priority != nil {
+		if *r.Priority < 0 {
+			return nil, apperr.InvalidRequest("priority cannot be negative")
+		}
+		room.Priority = *r.Priority
+		updates["priority"] = *r.Priority
+	}
+	if r.Handling != nil {
+		handling, err := normalizeHandling(*r.Handling)
+		if err != nil {
+			return nil, err
+		}
+		room.Handling = handling
+		updates["handling"] = handling
+	}
+	if r.PickupTerm != nil {
+		if !models.IsPickupTerm(*r.PickupTerm) {
+			return nil, apperr.InvalidRequest("pickup_term must be one of any, short, long")
+		}
+		room.PickupTerm = *r.PickupTerm
+		updates["pickup_term"] = *r.PickupTerm
+	}
+	return updates, nil
+}
+
+// UpdateStoreroomAssignment 修改寄存室的优先级、支持的特殊保管要求和适合的取件时间
+func UpdateStoreroomAssignment(ctx context.Context, hotelID, id int64, req UpdateStoreroomAssignmentRequest) (models.LuggageStoreroom, error) {
+	room, err := storeroomOfHotel(hotelID, id)
+	if err != nil {
+		return models.LuggageStoreroom{}, err
+	}
+	before := room
+	updates, err := req.apply(&room)
+	if err != nil {
+		return models.LuggageStoreroom{}, err
+	}
+	if len(updates) > 0 {
+		if err := repositories.UpdateStoreroom(id, updates); err != nil {
+			return models.LuggageStoreroom{}, err
+		}
+		recordAudit(ctx, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityStoreroom,
+			EntityID:   id,
+			Action:     models.AuditUpdate,
+			Before:     before,
+			After:      room,
+		})
+	}
+	return room, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/audit"
+	"hotel_luggage/internal/repositories"
+)
+
+// 验证失败次数限制（启动时由 InitAttemptLimits 设置）
+// lockoutWindow 内同一取件码失败 maxFailedAttempts 次、或同一 IP 失败 maxFailedAttemptsPerIP 次后锁定，直到最早的失败记录移出窗口
+var (
+	maxFailedAttempts      = 5
+	maxFailedAttemptsPerIP = 20
+	lockoutWindow          = 15 * time.Minute
+)
+
+// InitAttemptLimits 设置每个取件码、每个 IP 的失败次数上限和计数窗口
+func InitAttemptLimits(perCode, perIP int, window time.Duration) {
+	maxFailedAttempts = perCode
+	maxFailedAttemptsPerIP = perIP
+	lockoutWindow = window
+}
+
+// attemptSubjects 失败计数对象：取件码和客户端 IP（没有 IP 时只按取件码计数）
+func attemptSubjects(ctx context.Context, code string) []string {
+	subjects := []string{"code:" + code}
+	if ip := audit.ActorFrom(ctx).IP; ip != "" {
+		subjects = append(subjects, "ip:"+ip)
+	}
+	return subjects
+}
+
+// checkAttemptLimit 失败次数已达上限时返回 ErrTooManyAttempts（取件码是否存在都按同样规则计数，不泄露取件码是否有效）
+func checkAttemptLimit(ctx context.Context, scope, code string) error {
+	since := time.Now().Add(-lockoutWindow)
+	for _, subject := range attemptSubjects(ctx, code) {
+		limit := maxFailedAttempts
+		if subject != "code:"+code {
+			limit = maxFailedAttemptsPerIP
+		}
+		count, err := repositories.CountVerificationFailures(scope, subject, since)
+		if err != nil {
+			return err
+		}
+		if count >= int64(limit) {
+			return apperr.ErrTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// recordFailedAttempt 记录一次验证失败（写入失败只记录日志，不改变返回给调用方的错误）
+func recordFailedAttempt(ctx context.Context, scope, code string) {
+	now := time.Now()
+	if err := repositories.CreateVerificationFailures(scope, attemptSubjects(ctx, code), now.Add(-lockoutWindow)); err != nil {
+		log.Printf("⚠️  记录验证失败次数失败 %s: %v", scope, err)
+	}
+}
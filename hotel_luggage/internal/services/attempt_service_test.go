@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"hotel_luggage/internal/audit"
+)
+
+func TestAttemptSubjects(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		// 后台任务、没有客户端 IP 时只按取件码计数
+		{name: "no request", ctx: context.Background(), want: []string{"code:123455"}},
+		{name: "guest request", ctx: audit.WithActor(context.Background(), audit.Actor{IP: "192.0.2.1"}), want: []string{"code:123455", "ip:192.0.2.1"}},
+		{name: "ipv6", ctx: audit.WithActor(context.Background(), audit.Actor{IP: "2001:db8::1"}), want: []string{"code:123455", "ip:2001:db8::1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attemptSubjects(tt.ctx, "123455"); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("attemptSubjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/audit"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+)
+
+// maxAuditLogs 审计日志查询最多返回的条数
+const maxAuditLogs = 1000
+
+// auditFailures 写入失败的审计日志条数（/metrics 中的 hotel_luggage_audit_write_failures_total）
+var auditFailures atomic.Int64
+
+// AuditFailures 返回启动以来写入失败的审计日志条数
+func AuditFailures() int64 {
+	return auditFailures.Load()
+}
+
+// auditEntry 一次写操作的审计内容（操作人、IP、请求ID 从 ctx 中读取）
+type auditEntry struct {
+	HotelID    int64       // 数据所属酒店（0 表示不属于某个酒店）
+	EntityType string      // models.AuditEntity*
+	EntityID   interface{} // 实体ID（照片为对象 key）
+	Action     string      // models.Audit*
+	Before     interface{} // 修改前快照（新建时为 nil）
+	After      interface{} // 修改后快照（删除时为 nil）
+}
+
+// recordAudit 在业务数据写入成功后追加审计日志
+// 此时业务数据已经提交，写入失败不影响请求结果，只记录错误日志并计入失败指标，由运维排查补录；
+// 仓储层提供事务内写入的操作（修改寄存信息、取件、批量迁移）改用 auditBatch，审计日志与业务数据一起提交
+func recordAudit(ctx context.Context, entry auditEntry) {
+	record := auditRecord(ctx, entry)
+	if err := repositories.AppendAuditLog(&record, audit.Hash); err != nil {
+		auditFailures.Add(1)
+		log.Printf("⚠️  审计日志写入失败 %s %s #%s (actor=%s request_id=%s): %v",
+			record.EntityType, record.Action, record.EntityID, record.Actor, record.RequestID, err)
+	}
+}
+
+// auditBatch 生成与业务数据在同一事务中写入的审计日志，写入失败时业务数据一起回滚
+func auditBatch(ctx context.Context, entries ...auditEntry) repositories.AuditBatch {
+	batch := repositories.AuditBatch{Entries: make([]models.AuditLog, 0, len(entries)), Hash: audit.Hash}
+	for _, entry := range entries {
+		batch.Entries = append(batch.Entries, auditRecord(ctx, entry))
+	}
+	return batch
+}
+
+// auditRecord 把审计内容和 ctx 中的操作人组装为审计日志（序号和哈希在写入时填写）
+func auditRecord(ctx context.Context, entry auditEntry) models.AuditLog {
+	actor := audit.ActorFrom(ctx)
+	record := models.AuditLog{
+		Actor:      actor.Username,
+		ActorRole:  actor.Role,
+		EntityType: entry.EntityType,
+		EntityID:   fmt.Sprint(entry.EntityID),
+		Action:     entry.Action,
+		Before:     auditSnapshot(entry.Before),
+		After:      auditSnapshot(entry.After),
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+		CreatedAt:  time.Now().Truncate(time.Second),
+	}
+	if entry.HotelID > 0 {
+		hotelID := entry.HotelID
+		record.HotelID = &hotelID
+	}
+	return record
+}
+
+// auditSnapshot 把快照序列化为 JSON（nil 为空字符串）
+func auditSnapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	}
+	return string(data)
+}
+
+// AuditLogQuery 查询审计日志的条件
+type AuditLogQuery struct {
+	HotelID    int64
+	Actor      string
+	EntityType string
+	EntityID   string
+	Action     string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int // 默认 200，最多 1000
+}
+
+// ListAuditLogs 按条件查询审计日志（按序号倒序）
+func ListAuditLogs(query AuditLogQuery) ([]models.AuditLog, error) {
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, apperr.InvalidRequest("from must be earlier than to")
+	}
+	if query.Limit <= 0 {
+		query.Limit = 200
+	}
+	if query.Limit > maxAuditLogs {
+		query.Limit = maxAuditLogs
+	}
+	return repositories.ListAuditLogs(repositories.AuditLogFilter{
+		HotelID:    query.HotelID,
+		Actor:      query.Actor,
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		Action:     query.Action,
+		RequestID:  query.RequestID,
+		From:       query.From,
+		To:         query.To,
+		Limit:      query.Limit,
+	})
+}
+
+// AuditChainReport 哈希链校验结果
+type AuditChainReport struct {
+	Checked  int64  // 已校验的日志条数
+	LastSeq  int64  // 最后一条通过校验的序号
+	HeadSeq  int64  // 链末尾记录的序号
+	BrokenAt int64  // 第一条校验失败的序号（0 表示全部通过）
+	Problem  string // 校验失败原因
+}
+
+// VerifyAuditChain 从第一条开始按序号重新计算哈希，校验审计日志是否被修改、删除或插入
+func VerifyAuditChain(batchSize int) (AuditChainReport, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	head, err := repositories.GetAuditChain()
+	if err != nil {
+		return AuditChainReport{}, err
+	}
+	report := AuditChainReport{HeadSeq: head.LastSeq}
+	prevHash := ""
+	for {
+		entries, err := repositories.ListAuditLogsAfterSeq(report.LastSeq, batchSize)
+		if err != nil {
+			return report, err
+		}
+		for _, entry := range entries {
+			if err := audit.Verify(report.LastSeq, prevHash, entry); err != nil {
+				report.BrokenAt, report.Problem = report.LastSeq+1, err.Error()
+				return report, nil
+			}
+			report.Checked++
+			report.LastSeq, prevHash = entry.Seq, entry.Hash
+		}
+		if len(entries) < batchSize {
+			break
+		}
+	}
+	// 末尾的日志被删除时链本身仍然连续，需要和链末尾记录比对
+	if report.LastSeq != head.LastSeq || prevHash != head.LastHash {
+		report.BrokenAt = report.LastSeq + 1
+		report.Problem = fmt.Sprintf("chain head is at seq %d but the log ends at seq %d, trailing entries were deleted or the head was modified", head.LastSeq, report.LastSeq)
+	}
+	return report, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 
+	"hotel_luggage/internal/apperr"
 	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/repositories"
 
@@ -48,7 +49,7 @@ import (
 func Login(username, password string) (models.User, error) {
 	// 1. 参数验证：用户名和密码不能为空
 	if username == "" || password == "" {
-		return models.User{}, errors.New("username or password is empty")
+		return models.User{}, apperr.InvalidRequest("username or password is empty")
 	}
 
 	// 2. 查询用户信息
@@ -56,7 +57,7 @@ func Login(username, password string) (models.User, error) {
 	if err != nil {
 		// 用户不存在：返回统一的错误信息（不暴露用户名是否存在）
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.User{}, errors.New("invalid username or password")
+			return models.User{}, apperr.ErrInvalidCredentials
 		}
 		// 数据库错误：返回原始错误
 		return models.User{}, err
@@ -69,7 +70,7 @@ func Login(username, password string) (models.User, error) {
 	// - 比较两个哈希值是否相同
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
 		// 密码错误：返回统一的错误信息（不暴露密码错误）
-		return models.User{}, errors.New("invalid username or password")
+		return models.User{}, apperr.ErrInvalidCredentials
 	}
 
 	// 4. 验证成功：返回用户信息
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/utils"
+
+	"gorm.io/gorm"
+)
+
+// 取件码规则限制：luggage_items 等表的 retrieval_code 列为 VARCHAR(8)
+const (
+	minCodeLength       = 6
+	maxCodeLength       = 8
+	codeGenerateRetries = 10
+)
+
+// codeScheme 酒店策略中的取件码规则
+func codeScheme(policy models.HotelPolicy) utils.CodeScheme {
+	return utils.CodeScheme{
+		Length:     policy.CodeLength,
+		Alphabet:   policy.CodeAlphabet,
+		CheckDigit: policy.CodeCheckDigit,
+	}
+}
+
+// GenerateRetrievalCode 按酒店的取件码规则生成取件码
+// 取件码不能与在存行李重复，也不能是冷却期（code_reuse_cooldown_hours）内刚被取走的取件码；
+// 返回值 expiresAt 为取件码过期时间（code_ttl_hours 为 0 时为空）
+func GenerateRetrievalCode(hotelID int64) (code string, expiresAt *time.Time, err error) {
+	policy, err := GetHotelPolicy(hotelID)
+	if err != nil {
+		return "", nil, err
+	}
+	scheme := codeScheme(policy)
+	now := time.Now()
+	for i := 0; i < codeGenerateRetries; i++ {
+		candidate, err := utils.GenerateSchemeCode(scheme)
+		if err != nil {
+			return "", nil, err
+		}
+		available, err := retrievalCodeAvailable(policy, candidate, now)
+		if err != nil {
+			return "", nil, err
+		}
+		if available {
+			return candidate, codeExpiresAt(policy, now), nil
+		}
+	}
+	return "", nil, apperr.ErrCodeGenerationFailed
+}
+
+// retrievalCodeAvailable 取件码是否可以分配：不能与在存行李重复，也不能是冷却期内刚被取走的取件码
+func retrievalCodeAvailable(policy models.HotelPolicy, code string, now time.Time) (bool, error) {
+	exists, err := repositories.RetrievalCodeExists(code)
+	if err != nil || exists {
+		return false, err
+	}
+	if policy.CodeReuseCooldownHours > 0 {
+		since := now.Add(-time.Duration(policy.CodeReuseCooldownHours) * time.Hour)
+		used, err := repositories.RetrievalCodeUsedSince(code, since)
+		if err != nil || used {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// codeExpiresAt 按酒店策略计算新取件码的过期时间（code_ttl_hours 为 0 时为空）
+func codeExpiresAt(policy models.HotelPolicy, now time.Time) *time.Time {
+	if policy.CodeTTLHours <= 0 {
+		return nil
+	}
+	t := now.Add(time.Duration(policy.CodeTTLHours) * time.Hour)
+	return &t
+}
+
+// NewRetrievalCodeForStoreroom 按寄存室所属酒店的规则生成取件码（多件寄存共用一个取件码时使用）
+func NewRetrievalCodeForStoreroom(storeroomID int64) (string, *time.Time, error) {
+	room, err := repositories.GetStoreroomByID(storeroomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, apperr.ErrStoreroomNotFound
+		}
+		return "", nil, err
+	}
+	return GenerateRetrievalCode(room.HotelID)
+}
+
+// retrievalCodeNotFound 取件码查不到时的错误：酒店启用了校验位且校验失败时提示输错，否则按不存在处理
+func retrievalCodeNotFound(hotelID int64, code string) error {
+	if hotelID > 0 {
+		policy, err := GetHotelPolicy(hotelID)
+		if err == nil && policy.CodeCheckDigit && len(code) == policy.CodeLength && !utils.ValidateSchemeCode(codeScheme(policy), code) {
+			return apperr.ErrRetrievalCodeInvalid
+		}
+	}
+	return apperr.ErrLuggageNotFound
+}
+
+// checkCodeExpiry 取件码已过期时返回 ErrRetrievalCodeExpired（同一取件码的行李过期时间相同，取最早的一件）
+func checkCodeExpiry(items []models.LuggageItem) error {
+	now := time.Now()
+	for _, item := range items {
+		if item.CodeExpiresAt != nil && now.After(*item.CodeExpiresAt) {
+			return apperr.ErrRetrievalCodeExpired
+		}
+	}
+	return nil
+}
+
+// ReissueRetrievalCode 为取件码下仍在寄存的行李重新生成取件码（取件码过期或泄露时使用）
+// 旧取件码立即失效，登记在旧取件码上的代取人和验证码也随之失效
+func ReissueRetrievalCode(ctx context.Context, hotelID int64, code, updatedBy string) (string, *time.Time, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return "", nil, apperr.InvalidRequest("code is empty")
+	}
+	items, err := repositories.FindLuggageByCode(code)
+	if err != nil {
+		return "", nil, err
+	}
+	var stored []models.LuggageItem
+	for _, item := range items {
+		if item.Status == "stored" && item.HotelID == hotelID {
+			stored = append(stored, item)
+		}
+	}
+	if len(stored) == 0 {
+		return "", nil, retrievalCodeNotFound(hotelID, code)
+	}
+
+	newCode, expiresAt, err := GenerateRetrievalCode(hotelID)
+	if err != nil {
+		return "", nil, err
+	}
+	changes := make([]repositories.LuggageCodeChange, 0, len(stored))
+	records := make([]models.LuggageUpdate, 0, len(stored))
+	entries := make([]auditEntry, 0, len(stored))
+	for _, item := range stored {
+		updates := map[string]interface{}{
+			"retrieval_code":  newCode,
+			"code_expires_at": expiresAt,
+		}
+		updated := item
+		updated.RetrievalCode, updated.CodeExpiresAt = newCode, expiresAt
+		if item.QRCodeURL == fmt.Sprintf("/qr/%s", code) {
+			updates["qr_code_url"] = fmt.Sprintf("/qr/%s", newCode)
+			updated.QRCodeURL = updates["qr_code_url"].(string)
+		}
+		changes = append(changes, repositories.LuggageCodeChange{LuggageID: item.ID, Updates: updates})
+		records = append(records, luggageUpdateRecord(item, updated, updatedBy, nil))
+		entries = append(entries, auditEntry{
+			HotelID:    item.HotelID,
+			EntityType: models.AuditEntityLuggage,
+			EntityID:   item.ID,
+			Action:     models.AuditReissueCode,
+			Before:     item,
+			After:      updated,
+		})
+	}
+	// 所有行李在一个事务中换码：不会出现同一批行李一部分用新码、一部分仍用旧码
+	if err := repositories.ReissueLuggageCodes(changes, records, auditBatch(ctx, entries...)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, apperr.ErrLuggageNotStored.WithMessage("some luggage was retrieved or moved, please retry")
+		}
+		return "", nil, err
+	}
+	_ = repositories.DeleteLuggageByCodeCache(code)
+	return newCode, expiresAt, nil
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/utils"
+
+	"gorm.io/gorm"
+)
+
+// maxDelegatesPerCode 每个取件码最多同时有效的代取人数量
+const maxDelegatesPerCode = 5
+
+// delegateCodeTTL 代取码默认有效期（启动时由 InitPickupDelegates 设置）
+var delegateCodeTTL = 72 * time.Hour
+
+// InitPickupDelegates 设置代取码默认有效期
+func InitPickupDelegates(codeTTL time.Duration) {
+	delegateCodeTTL = codeTTL
+}
+
+// CreatePickupDelegateRequest 登记代取人请求
+type CreatePickupDelegateRequest struct {
+	Name      string     // 代取人姓名
+	Phone     string     // 代取人电话（可选，用于 phone_last4 核验）
+	IssueCode bool       // 是否签发代取码
+	ExpiresAt *time.Time // 授权过期时间（可选；签发代取码时默认 checkout.delegate_code_ttl）
+	CreatedBy string     // 登记的工作人员（客人自助登记时为空）
+}
+
+// DelegateCredential 取件时出示的代取凭证
+// 凭代取码取件视为已核验身份；只给 delegate_id 时由工作人员核对代取人身份（按酒店策略核验）
+type DelegateCredential struct {
+	DelegateID int64  `json:"delegate_id"` // 登记的代取人ID
+	Code       string `json:"code"`        // 代取码（签发时返回给客人的 6 位数字）
+}
+
+// delegatePickup 本次取件的代取人
+type delegatePickup struct {
+	Delegate models.PickupDelegate
+	ByCode   bool // 是否凭代取码
+}
+
+// storedBatch 返回取件码下仍在寄存的行李，以及这批行李最早的存放时间
+// 取件码在全部取走后可以被重新分配，代取人只对本批行李有效
+func storedBatch(code string) ([]models.LuggageItem, time.Time, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return nil, time.Time{}, apperr.InvalidRequest("code is empty")
+	}
+	items, err := repositories.FindLuggageByCode(code)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(items) == 0 {
+		return nil, time.Time{}, apperr.ErrLuggageNotFound
+	}
+	var stored []models.LuggageItem
+	var since time.Time
+	for _, item := range items {
+		if item.Status != "stored" {
+			continue
+		}
+		stored = append(stored, item)
+		if since.IsZero() || item.StoredAt.Before(since) {
+			since = item.StoredAt
+		}
+	}
+	if len(stored) == 0 {
+		return nil, time.Time{}, notStoredError(items)
+	}
+	return stored, since, nil
+}
+
+// CreatePickupDelegate 前台为取件码登记代取人
+// 返回登记记录和代取码明文（只在登记时返回一次，未签发时为空）
+func CreatePickupDelegate(ctx context.Context, hotelID int64, code string, req CreatePickupDelegateRequest) (models.PickupDelegate, string, error) {
+	items, since, err := storedBatch(code)
+	if err != nil {
+		return models.PickupDelegate{}, "", err
+	}
+	if items[0].HotelID != hotelID {
+		return models.PickupDelegate{}, "", apperr.ErrLuggageNotFound
+	}
+	return createPickupDelegate(ctx, items[0], since, models.DelegateSourceStaff, req)
+}
+
+// errGuestDelegateMismatch 客人自助登记代取人时取件码或手机号不匹配
+// 取件码不存在、已取走、已过期、未登记手机号和手机号不匹配都返回这个错误，避免被用来探测有效的取件码
+var errGuestDelegateMismatch = apperr.ErrVerificationFailed.WithMessage("retrieval code or phone number does not match")
+
+// CreateGuestPickupDelegate 客人凭取件码和登记手机号后四位自助登记代取人
+// 同一取件码 / 同一 IP 验证失败次数过多时暂时锁定；自助登记的代取码只有 phone_last4 强度（见 verifyCheckout）
+func CreateGuestPickupDelegate(ctx context.Context, code, phoneLast4 string, req CreatePickupDelegateRequest) (models.PickupDelegate, string, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return models.PickupDelegate{}, "", apperr.InvalidRequest("code is empty")
+	}
+	if err := checkAttemptLimit(ctx, models.AttemptScopeGuestDelegate, code); err != nil {
+		return models.PickupDelegate{}, "", err
+	}
+	items, since, err := storedBatch(code)
+	if err == nil {
+		err = checkCodeExpiry(items)
+	}
+	if err == nil {
+		digits := digitsOf(contactOf(items, func(item models.LuggageItem) string { return item.ContactPhone }))
+		if len(digits) < 4 || subtle.ConstantTimeCompare([]byte(digits[len(digits)-4:]), []byte(strings.TrimSpace(phoneLast4))) != 1 {
+			err = errGuestDelegateMismatch
+		}
+	}
+	if err != nil {
+		var appErr *apperr.Error
+		if !errors.As(err, &appErr) {
+			return models.PickupDelegate{}, "", err
+		}
+		recordFailedAttempt(ctx, models.AttemptScopeGuestDelegate, code)
+		return models.PickupDelegate{}, "", errGuestDelegateMismatch
+	}
+	req.CreatedBy = ""
+	return createPickupDelegate(ctx, items[0], since, models.DelegateSourceGuest, req)
+}
+
+func createPickupDelegate(ctx context.Context, owner models.LuggageItem, since time.Time, source string, req CreatePickupDelegateRequest) (models.PickupDelegate, string, error) {
+	name := strings.TrimSpace(req.Name)
+	phone := strings.TrimSpace(req.Phone)
+	if name == "" || len([]rune(name)) > 100 {
+		return models.PickupDelegate{}, "", apperr.InvalidRequest("name is required (max 100 chars)")
+	}
+	if len(phone) > 20 {
+		return models.PickupDelegate{}, "", apperr.InvalidRequest("phone must be at most 20 characters")
+	}
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if expiresAt != nil && !expiresAt.After(now) {
+		return models.PickupDelegate{}, "", apperr.InvalidRequest("expires_at must be in the future")
+	}
+	if req.IssueCode && expiresAt == nil {
+		t := now.Add(delegateCodeTTL)
+		expiresAt = &t
+	}
+
+	existing, err := repositories.ListPickupDelegates(owner.RetrievalCode, since)
+	if err != nil {
+		return models.PickupDelegate{}, "", err
+	}
+	active := 0
+	for _, d := range existing {
+		if d.Usable(now) {
+			active++
+		}
+	}
+	if active >= maxDelegatesPerCode {
+		return models.PickupDelegate{}, "", apperr.ErrDelegateLimitReached
+	}
+
+	delegate := models.PickupDelegate{
+		HotelID:       owner.HotelID,
+		RetrievalCode: owner.RetrievalCode,
+		Name:          name,
+		Phone:         phone,
+		ExpiresAt:     expiresAt,
+		Status:        models.DelegateStatusActive,
+		Source:        source,
+		CreatedBy:     req.CreatedBy,
+	}
+	var plain string
+	if req.IssueCode {
+		plain, err = utils.GenerateCode(6)
+		if err != nil {
+			return models.PickupDelegate{}, "", err
+		}
+		delegate.CodeHash = hashDelegateCode(owner.RetrievalCode, plain)
+		delegate.HasCode = true
+	}
+	if err := repositories.CreatePickupDelegate(&delegate); err != nil {
+		return models.PickupDelegate{}, "", err
+	}
+	// 代取码只记录哈希（json 中不输出），明文不进入审计日志
+	recordAudit(ctx, auditEntry{
+		HotelID:    delegate.HotelID,
+		EntityType: models.AuditEntityDelegate,
+		EntityID:   delegate.ID,
+		Action:     models.AuditCreate,
+		After:      delegate,
+	})
+	return delegate, plain, nil
+}
+
+// ListPickupDelegates 查询取件码下本批行李的代取人（含已撤销、已过期）
+func ListPickupDelegates(hotelID int64, code string) ([]models.PickupDelegate, error) {
+	items, since, err := storedBatch(code)
+	if err != nil {
+		return nil, err
+	}
+	if items[0].HotelID != hotelID {
+		return nil, apperr.ErrLuggageNotFound
+	}
+	delegates, err := repositories.ListPickupDelegates(items[0].RetrievalCode, since)
+	if err != nil {
+		return nil, err
+	}
+	if delegates == nil {
+		delegates = []models.PickupDelegate{}
+	}
+	return delegates, nil
+}
+
+// RevokePickupDelegate 撤销代取授权
+func RevokePickupDelegate(ctx context.Context, hotelID int64, code string, delegateID int64, revokedBy string) error {
+	if delegateID <= 0 {
+		return apperr.InvalidRequest("invalid delegate id")
+	}
+	delegate, err := repositories.GetPickupDelegateByID(delegateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperr.ErrDelegateNotFound
+		}
+		return err
+	}
+	if delegate.RetrievalCode != utils.NormalizeCode(code) || delegate.HotelID != hotelID {
+		return apperr.ErrDelegateNotFound
+	}
+	if err := repositories.RevokePickupDelegate(delegateID, revokedBy); err != nil {
+		return err
+	}
+	revoked := delegate
+	now := time.Now()
+	revoked.Status, revoked.RevokedBy, revoked.RevokedAt = models.DelegateStatusRevoked, revokedBy, &now
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityDelegate,
+		EntityID:   delegateID,
+		Action:     models.AuditRevoke,
+		Before:     delegate,
+		After:      revoked,
+	})
+	return nil
+}
+
+// resolveDelegate 校验取件时出示的代取凭证（cred 为空表示客人本人取件）
+// 代取码错误按取件码和客户端 IP 计数，失败次数过多时暂时锁定
+func resolveDelegate(ctx context.Context, code string, storedItems []models.LuggageItem, cred *DelegateCredential) (*delegatePickup, error) {
+	if cred == nil || (cred.DelegateID == 0 && strings.TrimSpace(cred.Code) == "") {
+		return nil, nil
+	}
+	plain := strings.TrimSpace(cred.Code)
+	if plain != "" {
+		if err := checkAttemptLimit(ctx, models.AttemptScopeDelegateCode, code); err != nil {
+			return nil, err
+		}
+	}
+	var since time.Time
+	for _, item := range storedItems {
+		if since.IsZero() || item.StoredAt.Before(since) {
+			since = item.StoredAt
+		}
+	}
+	delegates, err := repositories.ListPickupDelegates(code, since)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	if plain != "" {
+		hash := hashDelegateCode(code, plain)
+		for _, d := range delegates {
+			if d.CodeHash == "" || (cred.DelegateID != 0 && d.ID != cred.DelegateID) || !d.Usable(now) {
+				continue
+			}
+			if subtle.ConstantTimeCompare([]byte(hash), []byte(d.CodeHash)) == 1 {
+				return &delegatePickup{Delegate: d, ByCode: true}, nil
+			}
+		}
+		recordFailedAttempt(ctx, models.AttemptScopeDelegateCode, code)
+		return nil, apperr.ErrVerificationFailed.WithMessage("invalid or expired delegate code")
+	}
+
+	for _, d := range delegates {
+		if d.ID != cred.DelegateID {
+			continue
+		}
+		if !d.Usable(now) {
+			return nil, apperr.ErrVerificationFailed.WithMessage("delegate authorization has been revoked or expired")
+		}
+		return &delegatePickup{Delegate: d}, nil
+	}
+	return nil, apperr.ErrDelegateNotFound
+}
+
+// hashDelegateCode 代取码哈希（与取件码绑定）
+func hashDelegateCode(code, delegateCode string) string {
+	sum := sha256.Sum256([]byte("delegate:" + code + ":" + delegateCode))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// EvacuateStoreroomRequest 批量迁移寄存室行李的业务输入
+type EvacuateStoreroomRequest struct {
+	LuggageIDs []int64 // 要迁移的行李（为空表示源寄存室内所有在存行李）
+	Targets    []int64 // 目标寄存室（按顺序依次放满；为空时使用本酒店其他启用的寄存室，按 priority、ID 排序）
+	Deactivate bool    // 迁移完成后停用源寄存室（如装修关闭）
+	DryRun     bool    // 只生成迁移清单，不实际迁移
+	MovedBy    string
+}
+
+// MoveManifest 迁移清单
+type MoveManifest struct {
+	SourceStoreroomID   int64                `json:"source_storeroom_id"`
+	SourceStoreroomName string               `json:"source_storeroom_name"`
+	DryRun              bool                 `json:"dry_run"`
+	SourceDeactivated   bool                 `json:"source_deactivated"`
+	MovedBy             string               `json:"moved_by"`
+	MovedAt             time.Time            `json:"moved_at"`
+	TotalItems          int                  `json:"total_items"`
+	Targets             []MoveManifestTarget `json:"targets"` // 各目标寄存室的接收情况
+	Items               []MoveManifestItem   `json:"items"`   // 每件行李的迁移明细
+}
+
+// MoveManifestTarget 迁移清单中的目标寄存室汇总
+type MoveManifestTarget struct {
+	StoreroomID   int64  `json:"storeroom_id"`
+	StoreroomName string `json:"storeroom_name"`
+	Items         int    `json:"items"` // 迁入的行李记录数
+	Units         int64  `json:"units"` // 迁入占用的单位数（按目标寄存室的尺寸权重）
+}
+
+// MoveManifestItem 迁移清单中的一件行李
+type MoveManifestItem struct {
+	LuggageID       int64  `json:"luggage_id"`
+	RetrievalCode   string `json:"retrieval_code"`
+	GuestName       string `json:"guest_name"`
+	Quantity        int    `json:"quantity"`
+	SizeClass       string `json:"size_class,omitempty"`
+	FromBinPath     string `json:"from_bin_path,omitempty"`
+	ToStoreroomID   int64  `json:"to_storeroom_id"`
+	ToStoreroomName string `json:"to_storeroom_name"`
+	ToBinID         *int64 `json:"to_bin_id"`
+	ToBinPath       string `json:"to_bin_path,omitempty"`
+	Units           int64  `json:"units"`
+}
+
+// placementTarget 批量放入行李（迁移、转寄签收）时的目标寄存室（在内存中累计已分配的占用）
+type placementTarget struct {
+	room    models.LuggageStoreroom
+	used    int64
+	layout  *storeroomLayout
+	summary MoveManifestTarget
+}
+
+// EvacuateStoreroom 把寄存室内全部或指定的行李批量迁移到一个或多个目标寄存室
+// 先按目标顺序为每件行李规划位置（校验容量、特殊保管要求和格位），任意一件放不下时整批拒绝；
+// 规划成功后在一个事务中迁移所有行李并为每件写入修改记录，返回迁移清单
+func EvacuateStoreroom(ctx context.Context, hotelID, sourceID int64, req EvacuateStoreroomRequest) (MoveManifest, error) {
+	source, err := storeroomOfHotel(hotelID, sourceID)
+	if err != nil {
+		return MoveManifest{}, err
+	}
+	if req.MovedBy == "" {
+		return MoveManifest{}, apperr.InvalidRequest("moved_by is empty")
+	}
+
+	items, err := evacuationItems(sourceID, req.LuggageIDs)
+	if err != nil {
+		return MoveManifest{}, err
+	}
+	targets, err := loadPlacementTargets(hotelID, sourceID, req.Targets)
+	if err != nil {
+		return MoveManifest{}, err
+	}
+	sourceLayout, err := loadStoreroomLayout(sourceID)
+	if err != nil {
+		return MoveManifest{}, err
+	}
+
+	manifest := MoveManifest{
+		SourceStoreroomID:   source.ID,
+		SourceStoreroomName: source.Name,
+		DryRun:              req.DryRun,
+		MovedBy:             req.MovedBy,
+		MovedAt:             time.Now(),
+		TotalItems:          len(items),
+		Targets:             []MoveManifestTarget{},
+		Items:               []MoveManifestItem{},
+	}
+	moves := make([]repositories.LuggageMove, 0, len(items))
+	records := make([]models.LuggageUpdate, 0, len(items))
+	audits := make([]auditEntry, 0, len(items)+1)
+	names := newNameCache()
+	for _, item := range items {
+		target, bin, units, err := placeItem(targets, item)
+		if err != nil {
+			return MoveManifest{}, err
+		}
+		entry := MoveManifestItem{
+			LuggageID:       item.ID,
+			RetrievalCode:   item.RetrievalCode,
+			GuestName:       item.GuestName,
+			Quantity:        item.Quantity,
+			SizeClass:       item.SizeClass,
+			ToStoreroomID:   target.room.ID,
+			ToStoreroomName: target.room.Name,
+			Units:           units,
+		}
+		if item.BinID != nil {
+			entry.FromBinPath = sourceLayout.path(*item.BinID)
+		}
+		updated := item
+		updated.StoreroomID, updated.BinID = target.room.ID, nil
+		if bin != nil {
+			entry.ToBinID, entry.ToBinPath = &bin.ID, bin.Path
+			updated.BinID = &bin.ID
+		}
+		manifest.Items = append(manifest.Items, entry)
+		moves = append(moves, repositories.LuggageMove{LuggageID: item.ID, StoreroomID: target.room.ID, BinID: updated.BinID})
+		records = append(records, luggageUpdateRecord(item, updated, req.MovedBy, names))
+		audits = append(audits, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityLuggage,
+			EntityID:   item.ID,
+			Action:     models.AuditEvacuate,
+			Before:     item,
+			After:      updated,
+		})
+	}
+	for _, target := range targets {
+		if target.summary.Items > 0 {
+			manifest.Targets = append(manifest.Targets, target.summary)
+		}
+	}
+	if req.DryRun {
+		return manifest, nil
+	}
+	if req.Deactivate && source.IsActive {
+		deactivated := source
+		deactivated.IsActive = false
+		audits = append(audits, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityStoreroom,
+			EntityID:   source.ID,
+			Action:     models.AuditStatus,
+			Before:     source,
+			After:      deactivated,
+		})
+	}
+
+	if err := repositories.MoveLuggageBatch(sourceID, moves, records, req.Deactivate, auditBatch(ctx, audits...)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return MoveManifest{}, apperr.ErrLuggageNotStored.WithMessage("some luggage was retrieved or moved during evacuation, please retry")
+		}
+		if errors.Is(err, repositories.ErrStoreroomOverCapacity) {
+			return MoveManifest{}, apperr.ErrStoreroomFull.WithMessage("a target storeroom filled up during evacuation, please retry")
+		}
+		if errors.Is(err, repositories.ErrLocationOverCapacity) {
+			return MoveManifest{}, apperr.ErrLocationFull.WithMessage("a target bin filled up during evacuation, please retry")
+		}
+		return MoveManifest{}, err
+	}
+	manifest.SourceDeactivated = req.Deactivate
+	for _, item := range items {
+		_ = repositories.DeleteLuggageByCodeCache(item.RetrievalCode)
+	}
+	return manifest, nil
+}
+
+// evacuationItems 返回源寄存室内要迁移的在存行李（按寄存先后顺序）
+func evacuationItems(sourceID int64, luggageIDs []int64) ([]models.LuggageItem, error) {
+	stored, err := repositories.ListLuggageByStoreroom(sourceID, "stored")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+	if len(luggageIDs) == 0 {
+		return stored, nil
+	}
+	byID := make(map[int64]models.LuggageItem, len(stored))
+	for _, item := range stored {
+		byID[item.ID] = item
+	}
+	seen := map[int64]bool{}
+	var items []models.LuggageItem
+	for _, id := range luggageIDs {
+		if seen[id] {
+			continue
+		}
+		item, ok := byID[id]
+		if !ok {
+			return nil, apperr.ErrLuggageNotFound.WithMessage(fmt.Sprintf("luggage %d is not stored in this storeroom", id))
+		}
+		seen[id] = true
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+// loadPlacementTargets 校验并加载目标寄存室（同一酒店、启用、不是源寄存室 excludeID）
+// ids 为空时使用酒店内其他启用的寄存室，按 priority、ID 排序
+func loadPlacementTargets(hotelID, excludeID int64, ids []int64) ([]*placementTarget, error) {
+	var rooms []models.LuggageStoreroom
+	if len(ids) == 0 {
+		all, err := repositories.ListStorerooms(hotelID)
+		if err != nil {
+			return nil, err
+		}
+		for _, room := range all {
+			if room.ID != excludeID && room.IsActive {
+				rooms = append(rooms, room)
+			}
+		}
+		sort.SliceStable(rooms, func(i, j int) bool { return rooms[i].Priority < rooms[j].Priority })
+	} else {
+		seen := map[int64]bool{}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			if id == excludeID {
+				return nil, apperr.InvalidRequest("target storeroom cannot be the source storeroom")
+			}
+			room, err := storeroomOfHotel(hotelID, id)
+			if err != nil {
+				return nil, err
+			}
+			if !room.IsActive {
+				return nil, apperr.ErrStoreroomInactive.WithMessage(fmt.Sprintf("target storeroom %d is inactive", id))
+			}
+			seen[id] = true
+			rooms = append(rooms, room)
+		}
+	}
+	if len(rooms) == 0 {
+		return nil, apperr.ErrNoStoreroomAvailable.WithMessage("no active target storeroom")
+	}
+
+	targets := make([]*placementTarget, 0, len(rooms))
+	for _, room := range rooms {
+		used, err := repositories.SumStoredUnitsByStoreroom(room)
+		if err != nil {
+			return nil, err
+		}
+		layout, err := loadStoreroomLayout(room.ID)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, &placementTarget{
+			room:    room,
+			used:    used,
+			layout:  layout,
+			summary: MoveManifestTarget{StoreroomID: room.ID, StoreroomName: room.Name},
+		})
+	}
+	return targets, nil
+}
+
+// placeItem 按目标顺序找第一个放得下的寄存室（容量、特殊保管要求、格位），并在内存中计入占用
+func placeItem(targets []*placementTarget, item models.LuggageItem) (*placementTarget, *models.StoreroomLocation, int64, error) {
+	tags := splitHandling(item.Handling)
+	for _, target := range targets {
+		units := target.room.LuggageUnits(item.Quantity, item.SizeClass)
+		if target.room.Capacity > 0 && target.used+units > int64(target.room.Capacity) {
+			continue
+		}
+		if len(missingHandling(target.room, tags)) > 0 {
+			continue
+		}
+		bin, err := target.layout.suggestBin(units)
+		if errors.Is(err, apperr.ErrNoFreeBin) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if bin != nil {
+			target.layout.place(bin.ID, units)
+		}
+		target.used += units
+		target.summary.Items++
+		target.summary.Units += units
+		return target, bin, units, nil
+	}
+	return nil, nil, 0, apperr.ErrStoreroomFull.WithMessage(fmt.Sprintf("no target storeroom can take luggage %d", item.ID))
+}
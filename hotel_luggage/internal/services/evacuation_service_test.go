@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+)
+
+// testTarget 生成迁移目标（layout 为空表示未划分格位）
+func testTarget(id int64, capacity int, used int64, handling string, layout *storeroomLayout) *placementTarget {
+	if layout == nil {
+		layout = newStoreroomLayout(nil, nil)
+	}
+	return &placementTarget{
+		room:    models.LuggageStoreroom{ID: id, Capacity: capacity, WeightMedium: 2, WeightLarge: 4, Handling: handling},
+		used:    used,
+		layout:  layout,
+		summary: MoveManifestTarget{StoreroomID: id},
+	}
+}
+
+func TestPlaceItem(t *testing.T) {
+	type placement struct {
+		room int64 // 0 表示放不下
+		bin  int64 // 0 表示不放格位
+	}
+	tests := []struct {
+		name    string
+		targets []*placementTarget
+		items   []models.LuggageItem
+		want    []placement
+	}{
+		{name: "first target", targets: []*placementTarget{testTarget(1, 10, 0, "", nil), testTarget(2, 10, 0, "", nil)},
+			items: []models.LuggageItem{{ID: 1, Quantity: 1}}, want: []placement{{room: 1}}},
+		// 按目标寄存室的尺寸权重计算：2 件大件占 8 个单位
+		{name: "capacity skip", targets: []*placementTarget{testTarget(1, 10, 3, "", nil), testTarget(2, 0, 0, "", nil)},
+			items: []models.LuggageItem{{ID: 1, Quantity: 2, SizeClass: models.SizeLarge}}, want: []placement{{room: 2}}},
+		{name: "handling skip", targets: []*placementTarget{testTarget(1, 0, 0, "fragile", nil), testTarget(2, 0, 0, "fragile,valuables", nil)},
+			items: []models.LuggageItem{{ID: 1, Quantity: 1, Handling: "valuables, fragile"}}, want: []placement{{room: 2}}},
+		// 寄存室有余量但格位都满时换下一个目标
+		{name: "no free bin", targets: []*placementTarget{
+			testTarget(1, 0, 0, "", testLayout(map[int64]int64{3: 4, 4: 2})),
+			testTarget(2, 0, 0, "", testLayout(nil)),
+		}, items: []models.LuggageItem{{ID: 1, Quantity: 1}}, want: []placement{{room: 2, bin: 3}}},
+		// 同一批次内已分配的行李在内存中计入占用
+		{name: "accumulate", targets: []*placementTarget{testTarget(1, 4, 0, "", testLayout(nil)), testTarget(2, 0, 0, "", nil)},
+			items: []models.LuggageItem{{ID: 1, Quantity: 1}, {ID: 2, Quantity: 1}, {ID: 3, Quantity: 1}},
+			want:  []placement{{room: 1, bin: 3}, {room: 1, bin: 3}, {room: 2}}},
+		{name: "bins accumulate", targets: []*placementTarget{testTarget(1, 0, 0, "", testLayout(nil))},
+			items: []models.LuggageItem{{ID: 1, Quantity: 1}, {ID: 2, Quantity: 1}, {ID: 3, Quantity: 1}, {ID: 4, Quantity: 1}},
+			want:  []placement{{room: 1, bin: 3}, {room: 1, bin: 3}, {room: 1, bin: 4}, {}}},
+		{name: "all full", targets: []*placementTarget{testTarget(1, 2, 2, "", nil)},
+			items: []models.LuggageItem{{ID: 1, Quantity: 1}}, want: []placement{{}}},
+		{name: "no targets", items: []models.LuggageItem{{ID: 1, Quantity: 1}}, want: []placement{{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, item := range tt.items {
+				target, bin, units, err := placeItem(tt.targets, item)
+				if tt.want[i].room == 0 {
+					if !errors.Is(err, apperr.ErrStoreroomFull) {
+						t.Fatalf("placeItem(%d) error = %v, want %v", item.ID, err, apperr.ErrStoreroomFull)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("placeItem(%d) error = %v", item.ID, err)
+				}
+				got := placement{room: target.room.ID}
+				if bin != nil {
+					got.bin = bin.ID
+				}
+				if got != tt.want[i] {
+					t.Fatalf("placeItem(%d) = %+v, want %+v", item.ID, got, tt.want[i])
+				}
+				if want := target.room.LuggageUnits(item.Quantity, item.SizeClass); units != want {
+					t.Fatalf("placeItem(%d) units = %d, want %d", item.ID, units, want)
+				}
+			}
+		})
+	}
+}
+
+// 迁移清单汇总各目标迁入的记录数和单位数
+func TestPlaceItemSummary(t *testing.T) {
+	targets := []*placementTarget{testTarget(1, 0, 0, "", nil)}
+	for _, item := range []models.LuggageItem{{ID: 1, Quantity: 2}, {ID: 2, Quantity: 1, SizeClass: models.SizeLarge}} {
+		if _, _, _, err := placeItem(targets, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := targets[0]; got.used != 8 || got.summary.Items != 2 || got.summary.Units != 8 {
+		t.Fatalf("target used = %d summary = %+v, want 8 units in 2 items", got.used, got.summary)
+	}
+}
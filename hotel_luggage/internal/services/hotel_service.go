@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
 
+	"hotel_luggage/internal/apperr"
 	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/repositories"
 
@@ -15,9 +17,9 @@ func ListHotels() ([]models.Hotel, error) {
 }
 
 // CreateHotel 创建酒店
-func CreateHotel(name, address, phone string, isActive bool) (models.Hotel, error) {
+func CreateHotel(ctx context.Context, name, address, phone string, isActive bool) (models.Hotel, error) {
 	if name == "" {
-		return models.Hotel{}, errors.New("name is empty")
+		return models.Hotel{}, apperr.InvalidRequest("name is empty")
 	}
 	hotel := models.Hotel{
 		Name:     name,
@@ -28,54 +30,87 @@ func CreateHotel(name, address, phone string, isActive bool) (models.Hotel, erro
 	if err := repositories.CreateHotel(&hotel); err != nil {
 		return models.Hotel{}, err
 	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotel.ID,
+		EntityType: models.AuditEntityHotel,
+		EntityID:   hotel.ID,
+		Action:     models.AuditCreate,
+		After:      hotel,
+	})
 	return hotel, nil
 }
 
 // UpdateHotel 更新酒店信息
-func UpdateHotel(id int64, name, address, phone *string, isActive *bool) error {
+func UpdateHotel(ctx context.Context, id int64, name, address, phone *string, isActive *bool) error {
 	if id <= 0 {
-		return errors.New("invalid hotel id")
+		return apperr.InvalidRequest("invalid hotel id")
 	}
 
-	_, err := repositories.GetHotelByID(id)
+	hotel, err := repositories.GetHotelByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("hotel not found")
+			return apperr.ErrHotelNotFound
 		}
 		return err
 	}
 
+	updated := hotel
 	updates := map[string]interface{}{}
 	if name != nil {
 		updates["name"] = *name
+		updated.Name = *name
 	}
 	if address != nil {
 		updates["address"] = *address
+		updated.Address = *address
 	}
 	if phone != nil {
 		updates["phone"] = *phone
+		updated.Phone = *phone
 	}
 	if isActive != nil {
 		updates["is_active"] = *isActive
+		updated.IsActive = *isActive
 	}
 	if len(updates) == 0 {
-		return errors.New("no fields to update")
+		return apperr.InvalidRequest("no fields to update")
 	}
 
-	return repositories.UpdateHotel(id, updates)
+	if err := repositories.UpdateHotel(id, updates); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    id,
+		EntityType: models.AuditEntityHotel,
+		EntityID:   id,
+		Action:     models.AuditUpdate,
+		Before:     hotel,
+		After:      updated,
+	})
+	return nil
 }
 
 // DeleteHotel 删除酒店
-func DeleteHotel(id int64) error {
+func DeleteHotel(ctx context.Context, id int64) error {
 	if id <= 0 {
-		return errors.New("invalid hotel id")
+		return apperr.InvalidRequest("invalid hotel id")
 	}
-	_, err := repositories.GetHotelByID(id)
+	hotel, err := repositories.GetHotelByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("hotel not found")
+			return apperr.ErrHotelNotFound
 		}
 		return err
 	}
-	return repositories.DeleteHotel(id)
+	if err := repositories.DeleteHotel(id); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    id,
+		EntityType: models.AuditEntityHotel,
+		EntityID:   id,
+		Action:     models.AuditDelete,
+		Before:     hotel,
+	})
+	return nil
 }
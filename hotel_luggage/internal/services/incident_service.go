@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/utils"
+
+	"gorm.io/gorm"
+)
+
+// CreateIncidentRequest 登记事故报告的业务输入（LuggageID 与 HistoryID 二选一）
+type CreateIncidentRequest struct {
+	HotelID           int64
+	LuggageID         int64 // 在存的寄存单
+	HistoryID         int64 // 已取件的取件历史
+	Type              string
+	Description       string
+	CheckinPhotoURLs  []string // 寄存时的状态照片（为空时使用寄存单上的照片）
+	CheckoutPhotoURLs []string // 取件 / 发现问题时拍的照片
+	StaffInvolved     []string // 其他经手员工（存放和取件操作员会自动加入）
+	ReportedBy        string
+}
+
+// UpdateIncidentRequest 修改事故报告（只修改传入的字段，结案后不能再修改）
+type UpdateIncidentRequest struct {
+	Type              *string
+	Description       *string
+	CheckinPhotoURLs  *[]string
+	CheckoutPhotoURLs *[]string
+	StaffInvolved     *[]string // 替换经手员工列表
+	Status            *string   // investigating / resolved / rejected（结案时必须填写处理结果）
+	Resolution        *string
+	UpdatedBy         string
+}
+
+// IncidentQuery 查询事故报告的条件
+type IncidentQuery struct {
+	HotelID       int64 // 0 表示全部酒店（仅管理员）
+	Status        string
+	Type          string
+	LuggageID     int64
+	HistoryID     int64
+	RetrievalCode string
+	Staff         string
+	From          *time.Time
+	To            *time.Time
+}
+
+// CreateIncident 登记行李损坏 / 事故报告
+// 关联在存的寄存单或已取件的取件历史，客人姓名、取件码、寄存室和寄存时的照片从关联记录中复制
+func CreateIncident(ctx context.Context, req CreateIncidentRequest) (models.LuggageIncident, error) {
+	if (req.LuggageID > 0) == (req.HistoryID > 0) {
+		return models.LuggageIncident{}, apperr.InvalidRequest("exactly one of luggage_id and history_id is required")
+	}
+	if !models.IsIncidentType(req.Type) {
+		return models.LuggageIncident{}, apperr.InvalidRequest("type must be one of damage, missing, wrong_pickup, other")
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Description == "" {
+		return models.LuggageIncident{}, apperr.InvalidRequest("description is empty")
+	}
+
+	incident := models.LuggageIncident{
+		HotelID:     req.HotelID,
+		Type:        req.Type,
+		Description: req.Description,
+		Status:      models.IncidentOpen,
+		ReportedBy:  req.ReportedBy,
+	}
+	var recordPhotos, staff []string
+	if req.LuggageID > 0 {
+		item, err := repositories.GetLuggageByID(req.LuggageID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.LuggageIncident{}, apperr.ErrLuggageNotFound
+			}
+			return models.LuggageIncident{}, err
+		}
+		if item.HotelID != req.HotelID {
+			return models.LuggageIncident{}, apperr.ErrLuggageNotFound
+		}
+		incident.LuggageID = &item.ID
+		incident.RetrievalCode, incident.GuestName, incident.StoreroomID = item.RetrievalCode, item.GuestName, item.StoreroomID
+		recordPhotos = append([]string{item.PhotoURL}, item.PhotoURLs...)
+		staff = []string{item.StoredBy}
+	} else {
+		record, err := repositories.GetHistoryByID(req.HistoryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.LuggageIncident{}, apperr.ErrLuggageNotFound.WithMessage("luggage history not found")
+			}
+			return models.LuggageIncident{}, err
+		}
+		if record.HotelID != req.HotelID {
+			return models.LuggageIncident{}, apperr.ErrLuggageNotFound.WithMessage("luggage history not found")
+		}
+		incident.HistoryID = &record.ID
+		incident.RetrievalCode, incident.GuestName, incident.StoreroomID = record.RetrievalCode, record.GuestName, record.StoreroomID
+		recordPhotos = append([]string{record.PhotoURL}, record.PhotoURLs...)
+		staff = []string{record.StoredBy, record.RetrievedBy}
+	}
+
+	// 寄存时的状态照片：未单独上传时使用寄存单上的照片（照片与寄存单一样保存对象 key）
+	incident.CheckinPhotoURLs = NormalizePhotoRefs(req.CheckinPhotoURLs)
+	if len(incident.CheckinPhotoURLs) == 0 {
+		incident.CheckinPhotoURLs = uniqueRefs(NormalizePhotoRefs(recordPhotos))
+	}
+	incident.CheckoutPhotoURLs = NormalizePhotoRefs(req.CheckoutPhotoURLs)
+	if incident.CheckoutPhotoURLs == nil {
+		incident.CheckoutPhotoURLs = []string{}
+	}
+
+	if err := validateIncidentStaff(req.StaffInvolved); err != nil {
+		return models.LuggageIncident{}, err
+	}
+	joined, err := joinIncidentStaff(append(staff, req.StaffInvolved...))
+	if err != nil {
+		return models.LuggageIncident{}, err
+	}
+	incident.StaffInvolved = joined
+
+	if err := repositories.CreateIncident(&incident); err != nil {
+		return models.LuggageIncident{}, err
+	}
+	touchPhotoRefs(append(incident.CheckinPhotoURLs, incident.CheckoutPhotoURLs...)...)
+	recordAudit(ctx, auditEntry{
+		HotelID:    incident.HotelID,
+		EntityType: models.AuditEntityIncident,
+		EntityID:   incident.ID,
+		Action:     models.AuditCreate,
+		After:      incident,
+	})
+	return incident, nil
+}
+
+// GetIncident 获取本酒店的事故报告
+func GetIncident(hotelID, id int64) (models.LuggageIncident, error) {
+	return incidentOfHotel(hotelID, id)
+}
+
+// ListIncidents 按条件查询事故报告（按登记时间倒序）
+func ListIncidents(query IncidentQuery) ([]models.LuggageIncident, error) {
+	if query.Status != "" && !models.IsIncidentStatus(query.Status) {
+		return nil, apperr.InvalidRequest("status must be one of open, investigating, resolved, rejected")
+	}
+	if query.Type != "" && !models.IsIncidentType(query.Type) {
+		return nil, apperr.InvalidRequest("type must be one of damage, missing, wrong_pickup, other")
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, apperr.InvalidRequest("from must be earlier than to")
+	}
+	return repositories.ListIncidents(repositories.IncidentFilter{
+		HotelID:       query.HotelID,
+		Status:        query.Status,
+		Type:          query.Type,
+		LuggageID:     query.LuggageID,
+		HistoryID:     query.HistoryID,
+		RetrievalCode: utils.NormalizeCode(query.RetrievalCode),
+		Staff:         strings.TrimSpace(query.Staff),
+		From:          query.From,
+		To:            query.To,
+	})
+}
+
+// UpdateIncident 修改事故报告：补充照片、经手员工，更新处理状态；结案（resolved / rejected）时必须填写处理结果
+func UpdateIncident(ctx context.Context, hotelID, id int64, req UpdateIncidentRequest) (models.LuggageIncident, error) {
+	incident, err := incidentOfHotel(hotelID, id)
+	if err != nil {
+		return models.LuggageIncident{}, err
+	}
+	if models.IncidentClosed(incident.Status) {
+		return models.LuggageIncident{}, apperr.ErrIncidentClosed
+	}
+
+	updates := map[string]interface{}{}
+	if req.Type != nil {
+		if !models.IsIncidentType(*req.Type) {
+			return models.LuggageIncident{}, apperr.InvalidRequest("type must be one of damage, missing, wrong_pickup, other")
+		}
+		updates["type"] = *req.Type
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if description == "" {
+			return models.LuggageIncident{}, apperr.InvalidRequest("description is empty")
+		}
+		updates["description"] = description
+	}
+	var newPhotos []string
+	if req.CheckinPhotoURLs != nil {
+		refs := NormalizePhotoRefs(*req.CheckinPhotoURLs)
+		if refs == nil {
+			refs = []string{}
+		}
+		data, err := json.Marshal(refs)
+		if err != nil {
+			return models.LuggageIncident{}, err
+		}
+		updates["checkin_photo_urls"] = string(data)
+		newPhotos = append(newPhotos, refs...)
+	}
+	if req.CheckoutPhotoURLs != nil {
+		refs := NormalizePhotoRefs(*req.CheckoutPhotoURLs)
+		if refs == nil {
+			refs = []string{}
+		}
+		data, err := json.Marshal(refs)
+		if err != nil {
+			return models.LuggageIncident{}, err
+		}
+		updates["checkout_photo_urls"] = string(data)
+		newPhotos = append(newPhotos, refs...)
+	}
+	if req.StaffInvolved != nil {
+		if err := validateIncidentStaff(*req.StaffInvolved); err != nil {
+			return models.LuggageIncident{}, err
+		}
+		joined, err := joinIncidentStaff(*req.StaffInvolved)
+		if err != nil {
+			return models.LuggageIncident{}, err
+		}
+		updates["staff_involved"] = joined
+	}
+	resolution := incident.Resolution
+	if req.Resolution != nil {
+		resolution = strings.TrimSpace(*req.Resolution)
+		updates["resolution"] = resolution
+	}
+	if req.Status != nil {
+		status := *req.Status
+		if !models.IsIncidentStatus(status) {
+			return models.LuggageIncident{}, apperr.InvalidRequest("status must be one of open, investigating, resolved, rejected")
+		}
+		if models.IncidentClosed(status) {
+			if resolution == "" {
+				return models.LuggageIncident{}, apperr.InvalidRequest("resolution is required to resolve or reject an incident")
+			}
+			now := time.Now()
+			updates["resolved_by"] = req.UpdatedBy
+			updates["resolved_at"] = &now
+		}
+		updates["status"] = status
+	}
+	if len(updates) == 0 {
+		return incident, nil
+	}
+
+	if err := repositories.UpdateIncident(incident.ID, updates); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LuggageIncident{}, apperr.ErrIncidentClosed
+		}
+		return models.LuggageIncident{}, err
+	}
+	// 替换照片时新旧照片都刷新引用时间：被替换掉的照片在宽限期后才会被清理任务删除
+	if req.CheckinPhotoURLs != nil || req.CheckoutPhotoURLs != nil {
+		refs := append(append(newPhotos, incident.CheckinPhotoURLs...), incident.CheckoutPhotoURLs...)
+		touchPhotoRefs(refs...)
+	}
+	updated, err := incidentOfHotel(hotelID, id)
+	if err != nil {
+		return models.LuggageIncident{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityIncident,
+		EntityID:   id,
+		Action:     models.AuditUpdate,
+		Before:     incident,
+		After:      updated,
+	})
+	return updated, nil
+}
+
+// incidentOfHotel 查询事故报告并校验属于当前酒店
+func incidentOfHotel(hotelID, id int64) (models.LuggageIncident, error) {
+	if id <= 0 {
+		return models.LuggageIncident{}, apperr.InvalidRequest("invalid incident id")
+	}
+	incident, err := repositories.GetIncidentByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LuggageIncident{}, apperr.ErrIncidentNotFound
+		}
+		return models.LuggageIncident{}, err
+	}
+	if incident.HotelID != hotelID {
+		return models.LuggageIncident{}, apperr.ErrIncidentNotFound
+	}
+	return incident, nil
+}
+
+// validateIncidentStaff 校验手动填写的经手员工都是系统中的用户
+func validateIncidentStaff(names []string) error {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, err := repositories.GetUserByUsername(name); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.ErrUserNotFound.WithMessage("staff not found: " + name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// joinIncidentStaff 去重后用逗号拼接经手员工用户名（保持原顺序）
+func joinIncidentStaff(names []string) (string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	joined := strings.Join(result, ",")
+	if len(joined) > 255 {
+		return "", apperr.InvalidRequest("staff_involved is too long")
+	}
+	return joined, nil
+}
+
+// uniqueRefs 去掉重复的照片地址（寄存单的主照片通常也在多图数组中）
+func uniqueRefs(refs []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, ref := range refs {
+		if !seen[ref] {
+			seen[ref] = true
+			result = append(result, ref)
+		}
+	}
+	return result
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// locationKindRank 位置层级（数字越大越靠下，上级位置的层级必须更小）
+var locationKindRank = map[string]int{
+	models.LocationKindZone:  1,
+	models.LocationKindShelf: 2,
+	models.LocationKindBin:   3,
+}
+
+// locationPathSeparator 完整位置的分隔符
+const locationPathSeparator = " / "
+
+// CreateStoreroomLocationRequest 创建位置的业务输入
+type CreateStoreroomLocationRequest struct {
+	ParentID *int64 // 上级位置（为空表示直接挂在寄存室下）
+	Kind     string // zone / shelf / bin
+	Name     string
+	Capacity int   // 0 表示不限制
+	IsActive *bool // 为空时默认启用
+}
+
+// UpdateStoreroomLocationRequest 修改位置的业务输入（只修改传入的字段）
+type UpdateStoreroomLocationRequest struct {
+	Name     *string
+	Capacity *int
+	IsActive *bool
+}
+
+// storeroomLayout 寄存室内的位置树和在存件数
+type storeroomLayout struct {
+	locations []models.StoreroomLocation          // 按创建顺序
+	byID      map[int64]*models.StoreroomLocation // 指向 locations 中的元素
+	children  map[int64]int                       // 位置ID → 下级位置数量
+}
+
+// loadStoreroomLayout 读取寄存室内的所有位置，并计算完整路径和各层在存行李占用的单位数
+func loadStoreroomLayout(storeroomID int64) (*storeroomLayout, error) {
+	room, err := repositories.GetStoreroomByID(storeroomID)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := repositories.ListStoreroomLocations(storeroomID)
+	if err != nil {
+		return nil, err
+	}
+	units, err := repositories.SumStoredUnitsByBins(room)
+	if err != nil {
+		return nil, err
+	}
+	return newStoreroomLayout(locations, units), nil
+}
+
+// newStoreroomLayout 由位置列表（按创建顺序）和各格位占用的单位数生成位置树
+func newStoreroomLayout(locations []models.StoreroomLocation, units map[int64]int64) *storeroomLayout {
+	layout := &storeroomLayout{
+		locations: locations,
+		byID:      make(map[int64]*models.StoreroomLocation, len(locations)),
+		children:  map[int64]int{},
+	}
+	for i := range layout.locations {
+		layout.byID[layout.locations[i].ID] = &layout.locations[i]
+	}
+	for i := range layout.locations {
+		loc := &layout.locations[i]
+		if loc.ParentID != nil {
+			layout.children[*loc.ParentID]++
+		}
+		loc.Path = layout.path(loc.ID)
+		// 格位占用的单位数计入自身和所有上级
+		if used := units[loc.ID]; used > 0 {
+			for _, a := range layout.chain(loc.ID) {
+				a.StoredCount += used
+			}
+		}
+	}
+	return layout
+}
+
+// chain 返回位置自身及其所有上级（自下而上）
+func (l *storeroomLayout) chain(id int64) []*models.StoreroomLocation {
+	var result []*models.StoreroomLocation
+	for loc := l.byID[id]; loc != nil && len(result) < len(locationKindRank); {
+		result = append(result, loc)
+		if loc.ParentID == nil {
+			break
+		}
+		loc = l.byID[*loc.ParentID]
+	}
+	return result
+}
+
+// place 把占用 units 个单位的行李计入格位及所有上级（批量分配时在内存中累计占用）
+func (l *storeroomLayout) place(binID, units int64) {
+	for _, loc := range l.chain(binID) {
+		loc.StoredCount += units
+	}
+}
+
+// path 拼接完整位置（自上而下）
+func (l *storeroomLayout) path(id int64) string {
+	chain := l.chain(id)
+	names := make([]string, len(chain))
+	for i, loc := range chain {
+		names[len(chain)-1-i] = loc.Name
+	}
+	return strings.Join(names, locationPathSeparator)
+}
+
+// hasBins 寄存室是否划分了格位
+func (l *storeroomLayout) hasBins() bool {
+	for _, loc := range l.locations {
+		if loc.Kind == models.LocationKindBin {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBin 校验占用 units 个单位的行李能否放入格位：格位及所有上级都启用且放得下
+func (l *storeroomLayout) checkBin(binID, units int64) error {
+	bin, ok := l.byID[binID]
+	if !ok || bin.Kind != models.LocationKindBin {
+		return apperr.ErrLocationNotFound.WithMessage("bin not found in storeroom")
+	}
+	for _, loc := range l.chain(binID) {
+		if !loc.IsActive {
+			return apperr.ErrLocationInactive.WithMessage(loc.Path + " is inactive")
+		}
+		if loc.Capacity > 0 && loc.StoredCount+units > int64(loc.Capacity) {
+			return apperr.ErrLocationFull.WithMessage(loc.Path + " is full")
+		}
+	}
+	return nil
+}
+
+// suggestBin 按创建顺序返回第一个放得下 units 个单位的格位（寄存室未划分格位时返回 nil）
+func (l *storeroomLayout) suggestBin(units int64) (*models.StoreroomLocation, error) {
+	if !l.hasBins() {
+		return nil, nil
+	}
+	for i := range l.locations {
+		loc := &l.locations[i]
+		if loc.Kind == models.LocationKindBin && l.checkBin(loc.ID, units) == nil {
+			return loc, nil
+		}
+	}
+	return nil, apperr.ErrNoFreeBin
+}
+
+// assignBin 为放入寄存室、占用 units 个单位的行李确定格位
+// binID 为空时自动分配第一个放得下的格位（寄存室未划分格位时返回 nil）；binID 为 0 表示不放格位；
+// currentBin 为行李当前所在格位，保持不变时不重复校验容量
+func assignBin(storeroomID int64, binID, currentBin *int64, units int64) (*models.StoreroomLocation, error) {
+	if binID != nil && *binID == 0 {
+		return nil, nil
+	}
+	layout, err := loadStoreroomLayout(storeroomID)
+	if err != nil {
+		return nil, err
+	}
+	if binID == nil {
+		return layout.suggestBin(units)
+	}
+	if currentBin == nil || *binID != *currentBin {
+		if err := layout.checkBin(*binID, units); err != nil {
+			return nil, err
+		}
+	}
+	bin, ok := layout.byID[*binID]
+	if !ok {
+		return nil, apperr.ErrLocationNotFound.WithMessage("bin not found in storeroom")
+	}
+	return bin, nil
+}
+
+// storeroomOfHotel 查询寄存室并校验属于当前酒店
+func storeroomOfHotel(hotelID, storeroomID int64) (models.LuggageStoreroom, error) {
+	room, err := repositories.GetStoreroomByID(storeroomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LuggageStoreroom{}, apperr.ErrStoreroomNotFound
+		}
+		return models.LuggageStoreroom{}, err
+	}
+	if room.HotelID != hotelID {
+		return models.LuggageStoreroom{}, apperr.ErrStoreroomNotFound
+	}
+	return room, nil
+}
+
+// locationOfHotel 查询位置并校验属于当前酒店
+func locationOfHotel(hotelID, id int64) (models.StoreroomLocation, error) {
+	loc, err := repositories.GetStoreroomLocationByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.StoreroomLocation{}, apperr.ErrLocationNotFound
+		}
+		return models.StoreroomLocation{}, err
+	}
+	if loc.HotelID != hotelID {
+		return models.StoreroomLocation{}, apperr.ErrLocationNotFound
+	}
+	return loc, nil
+}
+
+// ListStoreroomLocations 获取寄存室内的所有位置（含完整路径和在存件数，前端按 parent_id 组装成树）
+func ListStoreroomLocations(hotelID, storeroomID int64) ([]models.StoreroomLocation, error) {
+	if _, err := storeroomOfHotel(hotelID, storeroomID); err != nil {
+		return nil, err
+	}
+	layout, err := loadStoreroomLayout(storeroomID)
+	if err != nil {
+		return nil, err
+	}
+	if layout.locations == nil {
+		return []models.StoreroomLocation{}, nil
+	}
+	return layout.locations, nil
+}
+
+// CreateStoreroomLocation 在寄存室内创建区域 / 货架 / 格位
+func CreateStoreroomLocation(ctx context.Context, hotelID, storeroomID int64, req CreateStoreroomLocationRequest) (models.StoreroomLocation, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > 50 {
+		return models.StoreroomLocation{}, apperr.InvalidRequest("name is required (max 50 chars)")
+	}
+	rank, ok := locationKindRank[req.Kind]
+	if !ok {
+		return models.StoreroomLocation{}, apperr.InvalidRequest("kind must be one of zone, shelf, bin")
+	}
+	if req.Capacity < 0 {
+		return models.StoreroomLocation{}, apperr.InvalidRequest("capacity cannot be negative")
+	}
+	if _, err := storeroomOfHotel(hotelID, storeroomID); err != nil {
+		return models.StoreroomLocation{}, err
+	}
+	if req.ParentID != nil {
+		parent, err := locationOfHotel(hotelID, *req.ParentID)
+		if err != nil {
+			return models.StoreroomLocation{}, err
+		}
+		if parent.StoreroomID != storeroomID {
+			return models.StoreroomLocation{}, apperr.ErrLocationNotFound.WithMessage("parent location not found in storeroom")
+		}
+		if locationKindRank[parent.Kind] >= rank {
+			return models.StoreroomLocation{}, apperr.InvalidRequest("a " + req.Kind + " cannot be placed under a " + parent.Kind)
+		}
+	}
+
+	loc := models.StoreroomLocation{
+		HotelID:     hotelID,
+		StoreroomID: storeroomID,
+		ParentID:    req.ParentID,
+		Kind:        req.Kind,
+		Name:        name,
+		Capacity:    req.Capacity,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+	if err := repositories.CreateStoreroomLocation(&loc); err != nil {
+		return models.StoreroomLocation{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityLocation,
+		EntityID:   loc.ID,
+		Action:     models.AuditCreate,
+		After:      loc,
+	})
+	layout, err := loadStoreroomLayout(storeroomID)
+	if err != nil {
+		return models.StoreroomLocation{}, err
+	}
+	if created, ok := layout.byID[loc.ID]; ok {
+		return *created, nil
+	}
+	return loc, nil
+}
+
+// UpdateStoreroomLocation 修改位置名称、容量、启用状态
+// 容量调小到低于在存件数时不影响已放的行李，只是不再分配新行李
+func UpdateStoreroomLocation(ctx context.Context, hotelID, id int64, req UpdateStoreroomLocationRequest) (models.StoreroomLocation, error) {
+	loc, err := locationOfHotel(hotelID, id)
+	if err != nil {
+		return models.StoreroomLocation{}, err
+	}
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len([]rune(name)) > 50 {
+			return models.StoreroomLocation{}, apperr.InvalidRequest("name is required (max 50 chars)")
+		}
+		updates["name"] = name
+	}
+	if req.Capacity != nil {
+		if *req.Capacity < 0 {
+			return models.StoreroomLocation{}, apperr.InvalidRequest("capacity cannot be negative")
+		}
+		updates["capacity"] = *req.Capacity
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if len(updates) > 0 {
+		if err := repositories.UpdateStoreroomLocation(id, updates); err != nil {
+			return models.StoreroomLocation{}, err
+		}
+	}
+	layout, err := loadStoreroomLayout(loc.StoreroomID)
+	if err != nil {
+		return models.StoreroomLocation{}, err
+	}
+	updated, ok := layout.byID[id]
+	if !ok {
+		return models.StoreroomLocation{}, apperr.ErrLocationNotFound
+	}
+	if len(updates) > 0 {
+		recordAudit(ctx, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityLocation,
+			EntityID:   id,
+			Action:     models.AuditUpdate,
+			Before:     loc,
+			After:      *updated,
+		})
+	}
+	return *updated, nil
+}
+
+// DeleteStoreroomLocation 删除位置（有下级位置或在存行李时禁止删除）
+func DeleteStoreroomLocation(ctx context.Context, hotelID, id int64) error {
+	loc, err := locationOfHotel(hotelID, id)
+	if err != nil {
+		return err
+	}
+	layout, err := loadStoreroomLayout(loc.StoreroomID)
+	if err != nil {
+		return err
+	}
+	if layout.children[id] > 0 || (layout.byID[id] != nil && layout.byID[id].StoredCount > 0) {
+		return apperr.ErrLocationNotEmpty
+	}
+	if err := repositories.DeleteStoreroomLocation(id); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityLocation,
+		EntityID:   id,
+		Action:     models.AuditDelete,
+		Before:     loc,
+	})
+	return nil
+}
+
+// SuggestBin 推荐寄存室内放得下该行李（件数 × 尺寸权重）的空闲格位（寄存室未划分格位时返回 nil）
+func SuggestBin(hotelID, storeroomID int64, quantity int, sizeClass string) (*models.StoreroomLocation, error) {
+	if !models.IsSizeClass(sizeClass) {
+		return nil, apperr.InvalidRequest("size_class must be one of small, medium, large, oversized")
+	}
+	room, err := storeroomOfHotel(hotelID, storeroomID)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := loadStoreroomLayout(storeroomID)
+	if err != nil {
+		return nil, err
+	}
+	return layout.suggestBin(room.LuggageUnits(quantity, sizeClass))
+}
+
+// fillBinPaths 填充行李所在格位的完整位置（只用于响应）
+func fillBinPaths(items []models.LuggageItem) error {
+	paths := map[int64]string{}
+	for _, item := range items {
+		if item.BinID == nil {
+			continue
+		}
+		if _, ok := paths[*item.BinID]; ok {
+			continue
+		}
+		layout, err := loadStoreroomLayout(item.StoreroomID)
+		if err != nil {
+			return err
+		}
+		paths[*item.BinID] = ""
+		for _, loc := range layout.locations {
+			paths[loc.ID] = loc.Path
+		}
+	}
+	for i := range items {
+		if items[i].BinID != nil {
+			items[i].BinPath = paths[*items[i].BinID]
+		}
+	}
+	return nil
+}
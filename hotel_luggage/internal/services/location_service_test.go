@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+)
+
+func TestLuggageUnits(t *testing.T) {
+	room := models.LuggageStoreroom{WeightSmall: 1, WeightMedium: 2, WeightLarge: 4, WeightOversized: 8}
+	tests := []struct {
+		name     string
+		room     models.LuggageStoreroom
+		quantity int
+		size     string
+		want     int64
+	}{
+		{name: "small", room: room, quantity: 3, size: models.SizeSmall, want: 3},
+		{name: "large", room: room, quantity: 2, size: models.SizeLarge, want: 8},
+		{name: "oversized", room: room, quantity: 1, size: models.SizeOversized, want: 8},
+		// 未填写尺寸按 medium 计算
+		{name: "no size", room: room, quantity: 2, want: 4},
+		{name: "unknown size", room: room, quantity: 1, size: "huge", want: 2},
+		// 件数为 0 按 1 件计算
+		{name: "zero quantity", room: room, quantity: 0, size: models.SizeLarge, want: 4},
+		// 权重未配置时每件占 1 个单位
+		{name: "weight not set", quantity: 3, size: models.SizeLarge, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.room.LuggageUnits(tt.quantity, tt.size); got != tt.want {
+				t.Fatalf("LuggageUnits() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// testLocation 生成位置（parent 为 0 表示直接挂在寄存室下）
+func testLocation(id, parent int64, kind, name string, capacity int, active bool) models.StoreroomLocation {
+	loc := models.StoreroomLocation{ID: id, StoreroomID: 1, Kind: kind, Name: name, Capacity: capacity, IsActive: active}
+	if parent > 0 {
+		loc.ParentID = &parent
+	}
+	return loc
+}
+
+// testLayout A区（10）/ 1号架（6）/ 1格（4）、2格（不限）；B区停用 / 3格
+func testLayout(units map[int64]int64) *storeroomLayout {
+	return newStoreroomLayout([]models.StoreroomLocation{
+		testLocation(1, 0, models.LocationKindZone, "A区", 10, true),
+		testLocation(2, 1, models.LocationKindShelf, "1号架", 6, true),
+		testLocation(3, 2, models.LocationKindBin, "1格", 4, true),
+		testLocation(4, 2, models.LocationKindBin, "2格", 0, true),
+		testLocation(5, 0, models.LocationKindZone, "B区", 0, false),
+		testLocation(6, 5, models.LocationKindBin, "3格", 0, true),
+	}, units)
+}
+
+func TestNewStoreroomLayout(t *testing.T) {
+	layout := testLayout(map[int64]int64{3: 2, 4: 3, 6: 5})
+	tests := []struct {
+		id         int64
+		wantPath   string
+		wantStored int64
+	}{
+		{id: 1, wantPath: "A区", wantStored: 5},
+		{id: 2, wantPath: "A区 / 1号架", wantStored: 5},
+		{id: 3, wantPath: "A区 / 1号架 / 1格", wantStored: 2},
+		{id: 6, wantPath: "B区 / 3格", wantStored: 5},
+	}
+	for _, tt := range tests {
+		loc := layout.byID[tt.id]
+		if loc.Path != tt.wantPath || loc.StoredCount != tt.wantStored {
+			t.Fatalf("location %d = %q/%d, want %q/%d", tt.id, loc.Path, loc.StoredCount, tt.wantPath, tt.wantStored)
+		}
+	}
+	if layout.children[2] != 2 || layout.children[3] != 0 {
+		t.Fatalf("children = %v, want shelf 2 with 2 bins", layout.children)
+	}
+}
+
+func TestCheckBin(t *testing.T) {
+	tests := []struct {
+		name    string
+		units   map[int64]int64
+		binID   int64
+		want    int64 // 行李占用的单位数
+		wantErr error
+	}{
+		{name: "fits", binID: 3, want: 4},
+		{name: "bin full", units: map[int64]int64{3: 1}, binID: 3, want: 4, wantErr: apperr.ErrLocationFull},
+		// 格位不限容量，但所在货架已满
+		{name: "shelf full", units: map[int64]int64{3: 3}, binID: 4, want: 4, wantErr: apperr.ErrLocationFull},
+		{name: "shelf has room", units: map[int64]int64{3: 3}, binID: 4, want: 3},
+		// 上级停用时格位也不能放
+		{name: "parent inactive", binID: 6, want: 1, wantErr: apperr.ErrLocationInactive},
+		{name: "not a bin", binID: 2, want: 1, wantErr: apperr.ErrLocationNotFound},
+		{name: "unknown bin", binID: 99, want: 1, wantErr: apperr.ErrLocationNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testLayout(tt.units).checkBin(tt.binID, tt.want)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkBin() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSuggestBin(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  *storeroomLayout
+		units   int64
+		wantBin int64 // 0 表示不放格位
+		wantErr error
+	}{
+		{name: "first bin", layout: testLayout(nil), units: 2, wantBin: 3},
+		// 第一个格位放不下时按创建顺序找下一个
+		{name: "next bin", layout: testLayout(map[int64]int64{3: 3}), units: 2, wantBin: 4},
+		// 1号架已满，B区停用，其下的格位不参与分配
+		{name: "all full", layout: testLayout(map[int64]int64{3: 4, 4: 2}), units: 1, wantErr: apperr.ErrNoFreeBin},
+		{name: "no bins", layout: newStoreroomLayout([]models.StoreroomLocation{
+			testLocation(1, 0, models.LocationKindZone, "A区", 1, true),
+		}, nil), units: 5},
+		{name: "no locations", layout: newStoreroomLayout(nil, nil), units: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin, err := tt.layout.suggestBin(tt.units)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("suggestBin() error = %v, want %v", err, tt.wantErr)
+			}
+			var got int64
+			if bin != nil {
+				got = bin.ID
+			}
+			if got != tt.wantBin {
+				t.Fatalf("suggestBin() = bin %d, want %d", got, tt.wantBin)
+			}
+		})
+	}
+}
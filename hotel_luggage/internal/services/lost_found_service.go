@@ -0,0 +1,845 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// lostAtSlack 认领匹配时允许拾获时间早于客人报的丢失时间多久（客人记错时间的余量）
+const lostAtSlack = 24 * time.Hour
+
+// maxClaimMatches 认领匹配最多返回的拾获物品数
+const maxClaimMatches = 20
+
+// CreateFoundItemRequest 登记拾获物品的业务输入
+type CreateFoundItemRequest struct {
+	HotelID       int64
+	StoreroomID   int64
+	Category      string
+	Description   string
+	FoundLocation string
+	FoundAt       *time.Time // 拾获时间（为空表示现在）
+	FoundBy       string
+	Quantity      int
+	SizeClass     string
+	PhotoURL      string
+	PhotoURLs     []string
+	Notes         string
+	CreatedBy     string
+}
+
+// UpdateFoundItemRequest 修改拾获物品（只修改传入的字段）
+type UpdateFoundItemRequest struct {
+	StoreroomID   *int64
+	Category      *string
+	Description   *string
+	FoundLocation *string
+	FoundBy       *string
+	Quantity      *int
+	SizeClass     *string
+	PhotoURL      *string
+	PhotoURLs     *[]string
+	Notes         *string
+	UpdatedBy     string
+}
+
+// FoundItemQuery 搜索拾获物品的条件
+type FoundItemQuery struct {
+	Q           string // 关键字（空格分隔，每个都要出现在描述、类别、拾获地点或备注中）
+	Status      string
+	Category    string
+	StoreroomID int64
+	Overdue     bool // 只查超过保管期限、等待处置的物品
+}
+
+// CreateLostItemClaimRequest 登记失物认领的业务输入
+type CreateLostItemClaimRequest struct {
+	HotelID      int64
+	GuestName    string
+	ContactPhone string
+	ContactEmail string
+	Category     string
+	Description  string
+	LostLocation string
+	LostAt       *time.Time
+	CreatedBy    string
+}
+
+// ClaimVerification 核验失主身份：核对证件，并由失主说出未公开的物品特征
+type ClaimVerification struct {
+	FoundItemID    int64
+	DocumentType   string // 证件类型（例如 id_card / passport）
+	DocumentLast4  string // 证件号后四位（可选，仅用于留档）
+	OwnershipProof string // 失主说出的物品特征（如 锁屏壁纸、包内物品）
+	VerifiedBy     string
+}
+
+// FoundItemMatch 认领匹配到的拾获物品
+type FoundItemMatch struct {
+	Item         models.FoundItem `json:"item"`
+	Score        int              `json:"score"`         // 匹配得分（越高越可能是失主的物品）
+	MatchedTerms []string         `json:"matched_terms"` // 命中的描述关键词
+}
+
+// CreateFoundItem 登记拾获物品：放入本酒店启用的寄存室（计入寄存室容量），按酒店策略计算保管期限
+func CreateFoundItem(ctx context.Context, req CreateFoundItemRequest) (models.FoundItem, error) {
+	if req.HotelID <= 0 {
+		return models.FoundItem{}, apperr.InvalidRequest("invalid hotel id")
+	}
+	if req.CreatedBy == "" {
+		return models.FoundItem{}, apperr.InvalidRequest("created_by is empty")
+	}
+	item := models.FoundItem{
+		HotelID:       req.HotelID,
+		StoreroomID:   req.StoreroomID,
+		Category:      strings.TrimSpace(req.Category),
+		Description:   strings.TrimSpace(req.Description),
+		FoundLocation: strings.TrimSpace(req.FoundLocation),
+		FoundBy:       strings.TrimSpace(req.FoundBy),
+		Quantity:      req.Quantity,
+		SizeClass:     req.SizeClass,
+		Notes:         req.Notes,
+		Status:        models.FoundItemHeld,
+		CreatedBy:     req.CreatedBy,
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if err := validateFoundItem(item); err != nil {
+		return models.FoundItem{}, err
+	}
+	now := time.Now()
+	item.FoundAt = now
+	if req.FoundAt != nil {
+		if req.FoundAt.After(now) {
+			return models.FoundItem{}, apperr.InvalidRequest("found_at cannot be in the future")
+		}
+		item.FoundAt = *req.FoundAt
+	}
+	// 照片统一保存对象 key（前端可能回传上传接口返回的签名地址）
+	item.PhotoURL = NormalizePhotoRef(req.PhotoURL)
+	item.PhotoURLs = NormalizePhotoRefs(req.PhotoURLs)
+	if len(item.PhotoURLs) == 0 && item.PhotoURL != "" {
+		item.PhotoURLs = []string{item.PhotoURL}
+	}
+	if len(item.PhotoURLs) > 0 && item.PhotoURL == "" {
+		item.PhotoURL = item.PhotoURLs[0]
+	}
+
+	if _, err := foundItemStoreroom(item); err != nil {
+		return models.FoundItem{}, err
+	}
+	policy, err := GetHotelPolicy(req.HotelID)
+	if err != nil {
+		return models.FoundItem{}, err
+	}
+	item.RetainUntil = item.FoundAt.AddDate(0, 0, policy.LostFoundRetentionDays)
+
+	err = repositories.CreateFoundItem(&item, func(created models.FoundItem) models.FoundItemUpdate {
+		return foundItemRecord(nil, created, models.FoundItemActionCreated, req.CreatedBy)
+	})
+	if err != nil {
+		return models.FoundItem{}, err
+	}
+	touchPhotoRefs(append([]string{item.PhotoURL}, item.PhotoURLs...)...)
+	recordAudit(ctx, auditEntry{
+		HotelID:    item.HotelID,
+		EntityType: models.AuditEntityFoundItem,
+		EntityID:   item.ID,
+		Action:     models.AuditCreate,
+		After:      item,
+	})
+	return item, nil
+}
+
+// GetFoundItem 获取本酒店的拾获物品
+func GetFoundItem(hotelID, id int64) (models.FoundItem, error) {
+	return foundItemOfHotel(hotelID, id)
+}
+
+// SearchFoundItems 按描述关键字、状态、类别搜索本酒店的拾获物品
+func SearchFoundItems(hotelID int64, query FoundItemQuery) ([]models.FoundItem, error) {
+	switch query.Status {
+	case "", models.FoundItemHeld, models.FoundItemReserved, models.FoundItemHandedOver, models.FoundItemDisposed:
+	default:
+		return nil, apperr.InvalidRequest("status must be one of held, reserved, handed_over, disposed")
+	}
+	filter := repositories.FoundItemFilter{
+		Status:      query.Status,
+		Category:    strings.TrimSpace(query.Category),
+		StoreroomID: query.StoreroomID,
+		Keywords:    strings.Fields(query.Q),
+	}
+	if query.Overdue {
+		now := time.Now()
+		filter.OverdueAt = &now
+	}
+	items, err := repositories.SearchFoundItems(hotelID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.FoundItem{}
+	}
+	return items, nil
+}
+
+// UpdateFoundItem 修改保管中（含等待交还）的拾获物品，更换寄存室时校验目标寄存室的容量
+func UpdateFoundItem(ctx context.Context, hotelID, id int64, req UpdateFoundItemRequest) (models.FoundItem, error) {
+	if req.UpdatedBy == "" {
+		return models.FoundItem{}, apperr.InvalidRequest("updated_by is empty")
+	}
+	item, err := foundItemOfHotel(hotelID, id)
+	if err != nil {
+		return models.FoundItem{}, err
+	}
+	if item.Status != models.FoundItemHeld && item.Status != models.FoundItemReserved {
+		return models.FoundItem{}, apperr.ErrFoundItemUnavailable
+	}
+
+	updated := item
+	updates := map[string]interface{}{}
+	for _, f := range []struct {
+		column string
+		value  *string
+		target *string
+	}{
+		{"category", req.Category, &updated.Category},
+		{"description", req.Description, &updated.Description},
+		{"found_location", req.FoundLocation, &updated.FoundLocation},
+		{"found_by", req.FoundBy, &updated.FoundBy},
+		{"size_class", req.SizeClass, &updated.SizeClass},
+		{"notes", req.Notes, &updated.Notes},
+	} {
+		if f.value == nil {
+			continue
+		}
+		value := *f.value
+		if f.column != "notes" {
+			value = strings.TrimSpace(value)
+		}
+		*f.target = value
+		updates[f.column] = value
+	}
+	if req.Quantity != nil {
+		updated.Quantity = *req.Quantity
+		updates["quantity"] = *req.Quantity
+	}
+	if req.StoreroomID != nil {
+		updated.StoreroomID = *req.StoreroomID
+		updates["storeroom_id"] = *req.StoreroomID
+	}
+	// 照片统一保存对象 key，只传 photo_urls 时主照片为第一张
+	if req.PhotoURL != nil {
+		updated.PhotoURL = NormalizePhotoRef(*req.PhotoURL)
+		updates["photo_url"] = updated.PhotoURL
+	}
+	if req.PhotoURLs != nil {
+		updated.PhotoURLs = NormalizePhotoRefs(*req.PhotoURLs)
+		if updated.PhotoURLs == nil {
+			updated.PhotoURLs = []string{}
+		}
+		data, err := json.Marshal(updated.PhotoURLs)
+		if err != nil {
+			return models.FoundItem{}, err
+		}
+		updates["photo_urls"] = string(data)
+		if req.PhotoURL == nil && len(updated.PhotoURLs) > 0 {
+			updated.PhotoURL = updated.PhotoURLs[0]
+			updates["photo_url"] = updated.PhotoURL
+		}
+	}
+	if len(updates) == 0 {
+		return item, nil
+	}
+	if err := validateFoundItem(updated); err != nil {
+		return models.FoundItem{}, err
+	}
+	if updated.StoreroomID != item.StoreroomID {
+		if _, err := foundItemStoreroom(updated); err != nil {
+			return models.FoundItem{}, err
+		}
+	}
+
+	err = repositories.UpdateFoundItem(repositories.FoundItemChange{
+		ID:      item.ID,
+		From:    []string{item.Status},
+		Updates: updates,
+		Record:  foundItemRecord(&item, updated, models.FoundItemActionUpdated, req.UpdatedBy),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.FoundItem{}, apperr.ErrFoundItemUnavailable.WithMessage("found item status changed, please retry")
+		}
+		return models.FoundItem{}, err
+	}
+	// 替换照片时新旧照片都刷新引用时间：被替换掉的照片在宽限期后才会被清理任务删除
+	if req.PhotoURL != nil || req.PhotoURLs != nil {
+		refs := append([]string{item.PhotoURL}, item.PhotoURLs...)
+		touchPhotoRefs(append(append(refs, updated.PhotoURL), updated.PhotoURLs...)...)
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityFoundItem,
+		EntityID:   item.ID,
+		Action:     models.AuditUpdate,
+		Before:     item,
+		After:      updated,
+	})
+	return updated, nil
+}
+
+// DisposeFoundItem 处置超过保管期限仍无人认领的拾获物品（丢弃、捐赠、移交警方、销毁）
+func DisposeFoundItem(ctx context.Context, hotelID, id int64, method, disposedBy string) (models.FoundItem, error) {
+	if !models.IsDisposalMethod(method) {
+		return models.FoundItem{}, apperr.InvalidRequest("method must be one of discarded, donated, police, destroyed")
+	}
+	if disposedBy == "" {
+		return models.FoundItem{}, apperr.InvalidRequest("disposed_by is empty")
+	}
+	item, err := foundItemOfHotel(hotelID, id)
+	if err != nil {
+		return models.FoundItem{}, err
+	}
+	if item.Status != models.FoundItemHeld {
+		return models.FoundItem{}, apperr.ErrFoundItemUnavailable
+	}
+	now := time.Now()
+	if now.Before(item.RetainUntil) {
+		return models.FoundItem{}, apperr.ErrRetentionNotExpired.WithMessage(
+			fmt.Sprintf("found item must be kept until %s", item.RetainUntil.Format(time.RFC3339)))
+	}
+
+	updated := item
+	updated.Status, updated.DisposalMethod, updated.DisposedBy, updated.DisposedAt = models.FoundItemDisposed, method, disposedBy, &now
+	err = repositories.UpdateFoundItem(repositories.FoundItemChange{
+		ID:   item.ID,
+		From: []string{models.FoundItemHeld},
+		Updates: map[string]interface{}{
+			"status":          models.FoundItemDisposed,
+			"disposal_method": method,
+			"disposed_by":     disposedBy,
+			"disposed_at":     now,
+		},
+		Record: foundItemRecord(&item, updated, models.FoundItemActionDisposed, disposedBy),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.FoundItem{}, apperr.ErrFoundItemUnavailable.WithMessage("found item status changed, please retry")
+		}
+		return models.FoundItem{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityFoundItem,
+		EntityID:   item.ID,
+		Action:     models.AuditDispose,
+		Before:     item,
+		After:      updated,
+	})
+	return updated, nil
+}
+
+// ListFoundItemHistory 获取拾获物品的修改记录（登记、修改、核验、交还、处置）
+func ListFoundItemHistory(hotelID, id int64) ([]models.FoundItemUpdate, error) {
+	if _, err := foundItemOfHotel(hotelID, id); err != nil {
+		return nil, err
+	}
+	records, err := repositories.ListFoundItemUpdates(id)
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []models.FoundItemUpdate{}
+	}
+	return records, nil
+}
+
+// CreateLostItemClaim 登记客人报失 / 认领
+func CreateLostItemClaim(ctx context.Context, req CreateLostItemClaimRequest) (models.LostItemClaim, error) {
+	if req.HotelID <= 0 {
+		return models.LostItemClaim{}, apperr.InvalidRequest("invalid hotel id")
+	}
+	if req.CreatedBy == "" {
+		return models.LostItemClaim{}, apperr.InvalidRequest("created_by is empty")
+	}
+	claim := models.LostItemClaim{
+		HotelID:      req.HotelID,
+		GuestName:    strings.TrimSpace(req.GuestName),
+		ContactPhone: strings.TrimSpace(req.ContactPhone),
+		ContactEmail: strings.TrimSpace(req.ContactEmail),
+		Category:     strings.TrimSpace(req.Category),
+		Description:  strings.TrimSpace(req.Description),
+		LostLocation: strings.TrimSpace(req.LostLocation),
+		LostAt:       req.LostAt,
+		Status:       models.ClaimOpen,
+		CreatedBy:    req.CreatedBy,
+	}
+	switch {
+	case claim.GuestName == "" || len([]rune(claim.GuestName)) > 100:
+		return models.LostItemClaim{}, apperr.InvalidRequest("guest_name is required (max 100 chars)")
+	case claim.Description == "":
+		return models.LostItemClaim{}, apperr.InvalidRequest("description is required")
+	case len([]rune(claim.Category)) > 50:
+		return models.LostItemClaim{}, apperr.InvalidRequest("category must be at most 50 characters")
+	case len([]rune(claim.LostLocation)) > 255:
+		return models.LostItemClaim{}, apperr.InvalidRequest("lost_location must be at most 255 characters")
+	case len(claim.ContactPhone) > 20 || len(claim.ContactEmail) > 100:
+		return models.LostItemClaim{}, apperr.InvalidRequest("contact_phone or contact_email is too long")
+	}
+	if err := repositories.CreateLostItemClaim(&claim); err != nil {
+		return models.LostItemClaim{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    claim.HotelID,
+		EntityType: models.AuditEntityClaim,
+		EntityID:   claim.ID,
+		Action:     models.AuditCreate,
+		After:      claim,
+	})
+	return claim, nil
+}
+
+// GetLostItemClaim 获取本酒店的失物认领
+func GetLostItemClaim(hotelID, id int64) (models.LostItemClaim, error) {
+	return claimOfHotel(hotelID, id)
+}
+
+// ListLostItemClaims 查询本酒店的失物认领
+func ListLostItemClaims(hotelID int64, status string) ([]models.LostItemClaim, error) {
+	switch status {
+	case "", models.ClaimOpen, models.ClaimVerified, models.ClaimHandedOver, models.ClaimClosed:
+	default:
+		return nil, apperr.InvalidRequest("status must be one of open, verified, handed_over, closed")
+	}
+	claims, err := repositories.ListLostItemClaims(hotelID, status)
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		claims = []models.LostItemClaim{}
+	}
+	return claims, nil
+}
+
+// MatchLostItemClaim 为认领查找可能匹配的拾获物品（只在保管中的物品里找）
+// 按描述关键词（英文按单词，中文按相邻两个字）命中数打分，类别相同额外加分；
+// 客人填写了丢失时间时，排除在此之前（超过一天）就已拾获的物品
+func MatchLostItemClaim(hotelID, claimID int64) ([]FoundItemMatch, error) {
+	claim, err := claimOfHotel(hotelID, claimID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := repositories.SearchFoundItems(hotelID, repositories.FoundItemFilter{Status: models.FoundItemHeld})
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := matchTerms(strings.Join([]string{claim.Description, claim.Category, claim.LostLocation}, " "))
+	matches := []FoundItemMatch{}
+	for _, item := range items {
+		if claim.LostAt != nil && item.FoundAt.Before(claim.LostAt.Add(-lostAtSlack)) {
+			continue
+		}
+		have := map[string]bool{}
+		for _, term := range matchTerms(strings.Join([]string{item.Description, item.Category, item.FoundLocation, item.Notes}, " ")) {
+			have[term] = true
+		}
+		match := FoundItemMatch{Item: item, MatchedTerms: []string{}}
+		for _, term := range wanted {
+			if have[term] {
+				match.Score++
+				match.MatchedTerms = append(match.MatchedTerms, term)
+			}
+		}
+		if claim.Category != "" && strings.EqualFold(claim.Category, item.Category) {
+			match.Score += 3
+		}
+		if match.Score > 0 {
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Item.FoundAt.After(matches[j].Item.FoundAt)
+	})
+	if len(matches) > maxClaimMatches {
+		matches = matches[:maxClaimMatches]
+	}
+	return matches, nil
+}
+
+// VerifyLostItemClaim 核验失主身份并把拾获物品预留给该认领（物品变为 reserved，等待交还）
+func VerifyLostItemClaim(ctx context.Context, hotelID, claimID int64, v ClaimVerification) (models.LostItemClaim, error) {
+	if v.VerifiedBy == "" {
+		return models.LostItemClaim{}, apperr.InvalidRequest("verified_by is empty")
+	}
+	docType, proof := strings.TrimSpace(v.DocumentType), strings.TrimSpace(v.OwnershipProof)
+	if docType == "" || len(docType) > 30 {
+		return models.LostItemClaim{}, apperr.InvalidRequest("document_type is required (max 30 chars)")
+	}
+	if proof == "" || len([]rune(proof)) > 150 {
+		return models.LostItemClaim{}, apperr.InvalidRequest("ownership_proof is required (max 150 chars)")
+	}
+	detail := docType
+	if last4 := strings.TrimSpace(v.DocumentLast4); last4 != "" {
+		if len([]rune(last4)) > 4 {
+			return models.LostItemClaim{}, apperr.InvalidRequest("document_last4 must be at most 4 characters")
+		}
+		detail += " ****" + last4
+	}
+	detail += "; proof: " + proof
+
+	claim, err := claimOfHotel(hotelID, claimID)
+	if err != nil {
+		return models.LostItemClaim{}, err
+	}
+	if claim.Status != models.ClaimOpen {
+		return models.LostItemClaim{}, apperr.ErrClaimStatusConflict.WithMessage("only open claims can be verified")
+	}
+	item, err := foundItemOfHotel(hotelID, v.FoundItemID)
+	if err != nil {
+		return models.LostItemClaim{}, err
+	}
+	if item.Status != models.FoundItemHeld {
+		return models.LostItemClaim{}, apperr.ErrFoundItemUnavailable
+	}
+
+	now := time.Now()
+	before := claim
+	claim.Status, claim.FoundItemID = models.ClaimVerified, &item.ID
+	claim.VerificationDetail, claim.VerifiedBy, claim.VerifiedAt = detail, v.VerifiedBy, &now
+	reserved := item
+	reserved.Status, reserved.ClaimID = models.FoundItemReserved, &claim.ID
+	err = repositories.UpdateLostItemClaim(claim.ID, models.ClaimOpen, map[string]interface{}{
+		"status":              models.ClaimVerified,
+		"found_item_id":       item.ID,
+		"verification_detail": detail,
+		"verified_by":         v.VerifiedBy,
+		"verified_at":         now,
+	}, &repositories.FoundItemChange{
+		ID:      item.ID,
+		From:    []string{models.FoundItemHeld},
+		Updates: map[string]interface{}{"status": models.FoundItemReserved, "claim_id": claim.ID},
+		Record:  foundItemRecord(&item, reserved, models.FoundItemActionReserved, v.VerifiedBy),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LostItemClaim{}, apperr.ErrClaimStatusConflict.WithMessage("claim or found item status changed, please retry")
+		}
+		return models.LostItemClaim{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityClaim,
+		EntityID:   claim.ID,
+		Action:     models.AuditVerify,
+		Before:     before,
+		After:      claim,
+	})
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityFoundItem,
+		EntityID:   item.ID,
+		Action:     models.AuditVerify,
+		Before:     item,
+		After:      reserved,
+	})
+	return claim, nil
+}
+
+// HandOverLostItem 把核验通过的拾获物品交还失主（collectedBy 为实际领取人，为空表示失主本人）
+func HandOverLostItem(ctx context.Context, hotelID, claimID int64, collectedBy, handedOverBy string) (models.LostItemClaim, error) {
+	if handedOverBy == "" {
+		return models.LostItemClaim{}, apperr.InvalidRequest("handed_over_by is empty")
+	}
+	claim, err := claimOfHotel(hotelID, claimID)
+	if err != nil {
+		return models.LostItemClaim{}, err
+	}
+	if claim.Status != models.ClaimVerified || claim.FoundItemID == nil {
+		return models.LostItemClaim{}, apperr.ErrClaimStatusConflict.WithMessage("claim must be verified before hand-over")
+	}
+	collectedBy = strings.TrimSpace(collectedBy)
+	if collectedBy == "" {
+		collectedBy = claim.GuestName
+	}
+	if len([]rune(collectedBy)) > 100 {
+		return models.LostItemClaim{}, apperr.InvalidRequest("collected_by must be at most 100 characters")
+	}
+	item, err := foundItemOfHotel(hotelID, *claim.FoundItemID)
+	if err != nil {
+		return models.LostItemClaim{}, err
+	}
+
+	now := time.Now()
+	before := claim
+	claim.Status, claim.CollectedBy, claim.HandedOverBy, claim.HandedOverAt = models.ClaimHandedOver, collectedBy, handedOverBy, &now
+	handed := item
+	handed.Status, handed.HandedOverTo, handed.HandedOverBy, handed.HandedOverAt = models.FoundItemHandedOver, collectedBy, handedOverBy, &now
+	err = repositories.UpdateLostItemClaim(claim.ID, models.ClaimVerified, map[string]interface{}{
+		"status":         models.ClaimHandedOver,
+		"collected_by":   collectedBy,
+		"handed_over_by": handedOverBy,
+		"handed_over_at": now,
+	}, &repositories.FoundItemChange{
+		ID:   item.ID,
+		From: []string{models.FoundItemReserved},
+		Updates: map[string]interface{}{
+			"status":         models.FoundItemHandedOver,
+			"handed_over_to": collectedBy,
+			"handed_over_by": handedOverBy,
+			"handed_over_at": now,
+		},
+		Record: foundItemRecord(&item, handed, models.FoundItemActionHandedOver, handedOverBy),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LostItemClaim{}, apperr.ErrClaimStatusConflict.WithMessage("claim or found item status changed, please retry")
+		}
+		return models.LostItemClaim{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityClaim,
+		EntityID:   claim.ID,
+		Action:     models.AuditHandOver,
+		Before:     before,
+		After:      claim,
+	})
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityFoundItem,
+		EntityID:   item.ID,
+		Action:     models.AuditHandOver,
+		Before:     item,
+		After:      handed,
+	})
+	return claim, nil
+}
+
+// CloseLostItemClaim 关闭认领（未找到、客人撤销或核验后发现不是失主）；已核验的认领关闭后物品恢复为保管中
+func CloseLostItemClaim(ctx context.Context, hotelID, claimID int64, reason, closedBy string) (models.LostItemClaim, error) {
+	if closedBy == "" {
+		return models.LostItemClaim{}, apperr.InvalidRequest("closed_by is empty")
+	}
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > 255 {
+		return models.LostItemClaim{}, apperr.InvalidRequest("reason must be at most 255 characters")
+	}
+	claim, err := claimOfHotel(hotelID, claimID)
+	if err != nil {
+		return models.LostItemClaim{}, err
+	}
+	if claim.Status != models.ClaimOpen && claim.Status != models.ClaimVerified {
+		return models.LostItemClaim{}, apperr.ErrClaimStatusConflict.WithMessage("claim is already handed over or closed")
+	}
+
+	var release *repositories.FoundItemChange
+	var releaseAudit *auditEntry
+	if claim.Status == models.ClaimVerified && claim.FoundItemID != nil {
+		item, err := foundItemOfHotel(hotelID, *claim.FoundItemID)
+		if err != nil {
+			return models.LostItemClaim{}, err
+		}
+		released := item
+		released.Status, released.ClaimID = models.FoundItemHeld, nil
+		release = &repositories.FoundItemChange{
+			ID:      item.ID,
+			From:    []string{models.FoundItemReserved},
+			Updates: map[string]interface{}{"status": models.FoundItemHeld, "claim_id": nil},
+			Record:  foundItemRecord(&item, released, models.FoundItemActionReleased, closedBy),
+		}
+		releaseAudit = &auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityFoundItem,
+			EntityID:   item.ID,
+			Action:     models.AuditClose,
+			Before:     item,
+			After:      released,
+		}
+	}
+
+	now := time.Now()
+	before := claim
+	from := claim.Status
+	claim.Status, claim.ClosedReason, claim.ClosedBy, claim.ClosedAt = models.ClaimClosed, reason, closedBy, &now
+	err = repositories.UpdateLostItemClaim(claim.ID, from, map[string]interface{}{
+		"status":        models.ClaimClosed,
+		"closed_reason": reason,
+		"closed_by":     closedBy,
+		"closed_at":     now,
+	}, release)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LostItemClaim{}, apperr.ErrClaimStatusConflict.WithMessage("claim status changed, please retry")
+		}
+		return models.LostItemClaim{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityClaim,
+		EntityID:   claim.ID,
+		Action:     models.AuditClose,
+		Before:     before,
+		After:      claim,
+	})
+	if releaseAudit != nil {
+		recordAudit(ctx, *releaseAudit)
+	}
+	return claim, nil
+}
+
+// validateFoundItem 校验拾获物品的基本信息
+func validateFoundItem(item models.FoundItem) error {
+	switch {
+	case item.StoreroomID <= 0:
+		return apperr.InvalidRequest("storeroom_id is required")
+	case item.Description == "":
+		return apperr.InvalidRequest("description is required")
+	case item.FoundLocation == "" || len([]rune(item.FoundLocation)) > 255:
+		return apperr.InvalidRequest("found_location is required (max 255 chars)")
+	case len([]rune(item.Category)) > 50:
+		return apperr.InvalidRequest("category must be at most 50 characters")
+	case len([]rune(item.FoundBy)) > 100:
+		return apperr.InvalidRequest("found_by must be at most 100 characters")
+	case item.Quantity <= 0:
+		return apperr.InvalidRequest("quantity must be positive")
+	case !models.IsSizeClass(item.SizeClass):
+		return apperr.InvalidRequest("size_class must be one of small, medium, large, oversized")
+	}
+	return nil
+}
+
+// foundItemStoreroom 校验拾获物品要放入的寄存室：属于本酒店、启用、剩余容量放得下
+func foundItemStoreroom(item models.FoundItem) (models.LuggageStoreroom, error) {
+	room, err := storeroomOfHotel(item.HotelID, item.StoreroomID)
+	if err != nil {
+		return models.LuggageStoreroom{}, err
+	}
+	if !room.IsActive {
+		return models.LuggageStoreroom{}, apperr.ErrStoreroomInactive
+	}
+	if room.Capacity > 0 {
+		used, err := repositories.SumStoredUnitsByStoreroom(room)
+		if err != nil {
+			return models.LuggageStoreroom{}, err
+		}
+		if used+room.LuggageUnits(item.Quantity, item.SizeClass) > int64(room.Capacity) {
+			return models.LuggageStoreroom{}, apperr.ErrStoreroomFull
+		}
+	}
+	return room, nil
+}
+
+// foundItemOfHotel 查询拾获物品并校验属于当前酒店
+func foundItemOfHotel(hotelID, id int64) (models.FoundItem, error) {
+	if id <= 0 {
+		return models.FoundItem{}, apperr.InvalidRequest("invalid found item id")
+	}
+	item, err := repositories.GetFoundItemByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.FoundItem{}, apperr.ErrFoundItemNotFound
+		}
+		return models.FoundItem{}, err
+	}
+	if item.HotelID != hotelID {
+		return models.FoundItem{}, apperr.ErrFoundItemNotFound
+	}
+	return item, nil
+}
+
+// claimOfHotel 查询失物认领并校验属于当前酒店
+func claimOfHotel(hotelID, id int64) (models.LostItemClaim, error) {
+	if id <= 0 {
+		return models.LostItemClaim{}, apperr.InvalidRequest("invalid claim id")
+	}
+	claim, err := repositories.GetLostItemClaimByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LostItemClaim{}, apperr.ErrClaimNotFound
+		}
+		return models.LostItemClaim{}, err
+	}
+	if claim.HotelID != hotelID {
+		return models.LostItemClaim{}, apperr.ErrClaimNotFound
+	}
+	return claim, nil
+}
+
+// foundItemRecord 生成拾获物品的修改记录（修改前后快照，登记时 before 为空）
+func foundItemRecord(before *models.FoundItem, after models.FoundItem, action, by string) models.FoundItemUpdate {
+	record := models.FoundItemUpdate{
+		HotelID:     after.HotelID,
+		FoundItemID: after.ID,
+		Action:      action,
+		UpdatedBy:   by,
+	}
+	if before != nil {
+		oldData, _ := json.Marshal(before)
+		record.OldData = string(oldData)
+	}
+	newData, _ := json.Marshal(after)
+	record.NewData = string(newData)
+	return record
+}
+
+// matchTerms 把描述拆成用于匹配的关键词（转小写、去重）：
+// 英文和数字按单词（至少两个字符），中文按相邻两个字（单独的一个字原样保留）
+func matchTerms(text string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	var word, han []rune
+	flushWord := func() {
+		if len(word) >= 2 {
+			add(string(word))
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
@@ -1,11 +1,16 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/audit"
 	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/repositories"
 	"hotel_luggage/utils"
@@ -20,29 +25,49 @@ type CreateLuggageRequest struct {
 	ContactEmail  string
 	Description   string
 	Quantity      int
+	SizeClass     string // 尺寸：small / medium / large / oversized（可选，为空按 medium 计算容量）
 	SpecialNotes  string
 	PhotoURL      string
 	PhotoURLs     []string
-	RetrievalCode string
+	RetrievalCode string     // 多件寄存共用的取件码（为空时按酒店规则生成）
+	CodeExpiresAt *time.Time // 共用取件码的过期时间（与 RetrievalCode 一起由 NewRetrievalCodeForStoreroom 生成）
 	StoreroomID   int64
+	BinID         *int64 // 格位ID（为空时自动分配第一个可用格位，寄存室未划分格位时不分配）
 	StaffName     string
 	QRCodeURL     string
+	// 自动分配寄存室（AutoAssign 为 true 时忽略 StoreroomID，按 RecommendStorerooms 的第一项分配）
+	AutoAssign       bool
+	Handling         []string   // 特殊保管要求：fragile / valuables / refrigerated
+	ExpectedPickupAt *time.Time // 预计取件时间
 }
 
 // CreateLuggage 生成寄存记录并自动生成取件码
-func CreateLuggage(req CreateLuggageRequest) (models.LuggageItem, error) {
+func CreateLuggage(ctx context.Context, req CreateLuggageRequest) (models.LuggageItem, error) {
 	if req.GuestName == "" {
-		return models.LuggageItem{}, errors.New("guest name is empty")
+		return models.LuggageItem{}, apperr.InvalidRequest("guest name is empty")
 	}
-	if req.StoreroomID <= 0 {
-		return models.LuggageItem{}, errors.New("invalid storeroom id")
+	if req.StoreroomID <= 0 && !req.AutoAssign {
+		return models.LuggageItem{}, apperr.InvalidRequest("invalid storeroom id")
 	}
 	if req.StaffName == "" {
-		return models.LuggageItem{}, errors.New("staff_name is empty")
+		return models.LuggageItem{}, apperr.InvalidRequest("staff_name is empty")
 	}
 	if req.Quantity <= 0 {
 		req.Quantity = 1
 	}
+	if !models.IsSizeClass(req.SizeClass) {
+		return models.LuggageItem{}, apperr.InvalidRequest("size_class must be one of small, medium, large, oversized")
+	}
+	handling, err := normalizeHandling(req.Handling)
+	if err != nil {
+		return models.LuggageItem{}, err
+	}
+	if req.ExpectedPickupAt != nil && !req.ExpectedPickupAt.After(time.Now()) {
+		return models.LuggageItem{}, apperr.InvalidRequest("expected_pickup_at must be in the future")
+	}
+	// 照片统一保存对象 key（前端可能回传上传接口返回的签名地址）
+	req.PhotoURL = NormalizePhotoRef(req.PhotoURL)
+	req.PhotoURLs = NormalizePhotoRefs(req.PhotoURLs)
 	if len(req.PhotoURLs) == 0 && req.PhotoURL != "" {
 		req.PhotoURLs = []string{req.PhotoURL}
 	}
@@ -53,77 +78,114 @@ func CreateLuggage(req CreateLuggageRequest) (models.LuggageItem, error) {
 	staff, err := repositories.GetUserByUsername(req.StaffName)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.LuggageItem{}, errors.New("staff not found")
+			return models.LuggageItem{}, apperr.ErrUserNotFound.WithMessage("staff not found")
 		}
 		return models.LuggageItem{}, err
 	}
 	if staff.Role != "staff" {
-		return models.LuggageItem{}, errors.New("staff_name is not staff")
+		return models.LuggageItem{}, apperr.ErrNotStaff.WithMessage("staff_name is not staff")
+	}
+
+	// 自动分配：在操作员所属酒店内按分配规则选第一个推荐的寄存室（未指定格位时使用推荐的格位）
+	if req.AutoAssign {
+		if staff.HotelID == nil || *staff.HotelID <= 0 {
+			return models.LuggageItem{}, apperr.ErrHotelNotAssigned
+		}
+		recs, err := RecommendStorerooms(AssignmentRequest{
+			HotelID:          *staff.HotelID,
+			Quantity:         req.Quantity,
+			SizeClass:        req.SizeClass,
+			Handling:         req.Handling,
+			ExpectedPickupAt: req.ExpectedPickupAt,
+		})
+		if err != nil {
+			return models.LuggageItem{}, err
+		}
+		if len(recs) == 0 {
+			return models.LuggageItem{}, apperr.ErrNoStoreroomAvailable
+		}
+		req.StoreroomID = recs[0].StoreroomID
+		if req.BinID == nil {
+			req.BinID = recs[0].BinID
+		}
 	}
 
 	// 校验寄存室是否存在且启用
 	room, err := repositories.GetStoreroomByID(req.StoreroomID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.LuggageItem{}, errors.New("storeroom not found")
+			return models.LuggageItem{}, apperr.ErrStoreroomNotFound
 		}
 		return models.LuggageItem{}, err
 	}
 	if !room.IsActive {
-		return models.LuggageItem{}, errors.New("storeroom is inactive")
+		return models.LuggageItem{}, apperr.ErrStoreroomInactive
 	}
 	if room.HotelID <= 0 {
-		return models.LuggageItem{}, errors.New("storeroom hotel_id is missing")
+		return models.LuggageItem{}, apperr.Internal(errors.New("storeroom hotel_id is missing"))
+	}
+	if missing := missingHandling(room, splitHandling(handling)); len(missing) > 0 {
+		return models.LuggageItem{}, apperr.ErrStoreroomUnsuitable.WithMessage("storeroom does not support " + strings.Join(missing, ", "))
+	}
+	// 酒店要求寄存时拍摄行李状态照片（发生损坏纠纷时作为依据）
+	policy, err := GetHotelPolicy(room.HotelID)
+	if err != nil {
+		return models.LuggageItem{}, err
+	}
+	if policy.RequireCheckinPhotos && len(req.PhotoURLs) == 0 {
+		return models.LuggageItem{}, apperr.ErrCheckinPhotoRequired
 	}
 
-	// 容量校验（当 capacity > 0 才判断）
+	// 容量校验（当 capacity > 0 才判断）：按 件数 × 尺寸权重 计算占用的单位数
+	units := room.LuggageUnits(req.Quantity, req.SizeClass)
 	if room.Capacity > 0 {
-		count, err := repositories.CountStoredByStoreroom(req.StoreroomID)
+		used, err := repositories.SumStoredUnitsByStoreroom(room)
 		if err != nil {
 			return models.LuggageItem{}, err
 		}
-		if int(count) >= room.Capacity {
-			return models.LuggageItem{}, errors.New("storeroom is full")
+		if used+units > int64(room.Capacity) {
+			return models.LuggageItem{}, apperr.ErrStoreroomFull
 		}
 	}
 
-	// 生成或使用取件码（6 位数字，最多尝试 5 次）
-	code := req.RetrievalCode
+	// 分配格位（寄存室划分了格位时必须放在放得下的格位上）
+	bin, err := assignBin(req.StoreroomID, req.BinID, nil, units)
+	if err != nil {
+		return models.LuggageItem{}, err
+	}
+
+	// 生成或使用取件码（按酒店的取件码规则：长度、字符集、校验位、复用冷却期、有效期）
+	code, codeExpiresAt := utils.NormalizeCode(req.RetrievalCode), req.CodeExpiresAt
 	if code == "" {
-		for i := 0; i < 5; i++ {
-			c, err := utils.GenerateCode(6)
-			if err != nil {
-				return models.LuggageItem{}, err
-			}
-			exists, err := repositories.RetrievalCodeExists(c)
-			if err != nil {
-				return models.LuggageItem{}, err
-			}
-			if !exists {
-				code = c
-				break
-			}
-		}
-		if code == "" {
-			return models.LuggageItem{}, fmt.Errorf("failed to generate unique retrieval code")
+		code, codeExpiresAt, err = GenerateRetrievalCode(room.HotelID)
+		if err != nil {
+			return models.LuggageItem{}, err
 		}
 	}
 
 	item := models.LuggageItem{
-		GuestName:     req.GuestName,
-		ContactPhone:  req.ContactPhone,
-		ContactEmail:  req.ContactEmail,
-		Description:   req.Description,
-		Quantity:      req.Quantity,
-		SpecialNotes:  req.SpecialNotes,
-		PhotoURL:      req.PhotoURL,
-		PhotoURLs:     req.PhotoURLs,
-		HotelID:       room.HotelID,
-		StoreroomID:   req.StoreroomID,
-		RetrievalCode: code,
-		QRCodeURL:     req.QRCodeURL,
-		Status:        "stored",
-		StoredBy:      req.StaffName,
+		GuestName:        req.GuestName,
+		ContactPhone:     req.ContactPhone,
+		ContactEmail:     req.ContactEmail,
+		Description:      req.Description,
+		Quantity:         req.Quantity,
+		SizeClass:        req.SizeClass,
+		SpecialNotes:     req.SpecialNotes,
+		Handling:         handling,
+		ExpectedPickupAt: req.ExpectedPickupAt,
+		PhotoURL:         req.PhotoURL,
+		PhotoURLs:        req.PhotoURLs,
+		HotelID:          room.HotelID,
+		StoreroomID:      req.StoreroomID,
+		RetrievalCode:    code,
+		CodeExpiresAt:    codeExpiresAt,
+		QRCodeURL:        req.QRCodeURL,
+		Status:           "stored",
+		StoredBy:         req.StaffName,
+	}
+
+	if bin != nil {
+		item.BinID, item.BinPath = &bin.ID, bin.Path
 	}
 
 	// 如果未传入二维码URL，则默认指向二维码展示接口
@@ -134,21 +196,30 @@ func CreateLuggage(req CreateLuggageRequest) (models.LuggageItem, error) {
 	if err := repositories.CreateLuggage(&item); err != nil {
 		return models.LuggageItem{}, err
 	}
+	touchPhotoRefs(append([]string{item.PhotoURL}, item.PhotoURLs...)...)
+	recordAudit(ctx, auditEntry{
+		HotelID:    item.HotelID,
+		EntityType: models.AuditEntityLuggage,
+		EntityID:   item.ID,
+		Action:     models.AuditCreate,
+		After:      item,
+	})
 	return item, nil
 }
 
 // FindLuggageByUserInfo 按客人姓名/电话查询寄存记录
 func FindLuggageByUserInfo(guestName, contactPhone string) ([]models.LuggageItem, error) {
 	if guestName == "" && contactPhone == "" {
-		return nil, errors.New("guest_name and contact_phone cannot both be empty")
+		return nil, apperr.InvalidRequest("guest_name and contact_phone cannot both be empty")
 	}
 	return repositories.FindLuggageByUserInfo(guestName, contactPhone)
 }
 
 // FindLuggageByCode 按取件码查询寄存记录
 func FindLuggageByCode(code string) ([]models.LuggageItem, error) {
+	code = utils.NormalizeCode(code)
 	if code == "" {
-		return nil, errors.New("code is empty")
+		return nil, apperr.InvalidRequest("code is empty")
 	}
 	if items, ok, err := repositories.GetLuggageByCodeCache(code); err == nil && ok {
 		return items, nil
@@ -156,46 +227,76 @@ func FindLuggageByCode(code string) ([]models.LuggageItem, error) {
 	items, err := repositories.FindLuggageByCode(code)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("luggage not found")
+			return nil, apperr.ErrLuggageNotFound
 		}
 		return nil, err
 	}
 	if len(items) == 0 {
-		return nil, errors.New("luggage not found")
+		return nil, apperr.ErrLuggageNotFound
 	}
 	_ = repositories.SetLuggageByCodeCache(code, items)
 	return items, nil
 }
 
-// RetrieveLuggage 取件：根据取件码更新状态与取件人/时间
-func RetrieveLuggage(code string, retrievedByUsername string) ([]models.LuggageItem, error) {
+// RetrieveLuggageRequest 取件请求
+type RetrieveLuggageRequest struct {
+	Code         string               // 取件码
+	RetrievedBy  string               // 取件操作员用户名（同时作为身份核验人）
+	LuggageIDs   []int64              // 本次取走的行李ID（为空表示全部）
+	Verification CheckoutVerification // 取件人身份核验信息（按酒店策略要求）
+	Delegate     *DelegateCredential  // 代取凭证（为空表示客人本人取件）
+	Signature    *CheckoutSignature   // 取件人签名（PNG 或笔迹，酒店策略要求时必填）
+}
+
+// RetrieveLuggageResult 取件结果
+type RetrieveLuggageResult struct {
+	Retrieved []models.LuggageItem   // 本次取走的行李
+	Remaining []models.LuggageItem   // 仍在寄存的行李
+	Delegate  *models.PickupDelegate // 代取人（客人本人取件时为空）
+	Signature string                 // 取件签名的对象 key（未签名时为空）
+}
+
+// RetrieveLuggage 取件：核验取件人身份后更新状态与取件人/时间
+// LuggageIDs 为空时取走该取件码下所有在存行李；否则只取走指定的行李（部分取件），
+// 取件码对剩余行李继续有效。Delegate 不为空时为代取，取件历史同时记录实际取件人和登记的客人；
+// 签名保存到照片存储，取件历史中记录其 key
+func RetrieveLuggage(ctx context.Context, req RetrieveLuggageRequest) (RetrieveLuggageResult, error) {
+	code, retrievedByUsername, luggageIDs := utils.NormalizeCode(req.Code), req.RetrievedBy, req.LuggageIDs
 	if code == "" {
-		return nil, errors.New("code is empty")
+		return RetrieveLuggageResult{}, apperr.InvalidRequest("code is empty")
 	}
 	if retrievedByUsername == "" {
-		return nil, errors.New("retrieved_by is empty")
+		return RetrieveLuggageResult{}, apperr.InvalidRequest("retrieved_by is empty")
+	}
+	signature, err := prepareSignature(req.Signature)
+	if err != nil {
+		return RetrieveLuggageResult{}, err
 	}
 
 	user, err := repositories.GetUserByUsername(retrievedByUsername)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return RetrieveLuggageResult{}, apperr.ErrUserNotFound
 		}
-		return nil, err
+		return RetrieveLuggageResult{}, err
 	}
 	if user.Role != "staff" {
-		return nil, errors.New("retrieved_by is not staff")
+		return RetrieveLuggageResult{}, apperr.ErrNotStaff.WithMessage("retrieved_by is not staff")
 	}
 
 	items, err := repositories.FindLuggageByCode(code)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("luggage not found")
+			return RetrieveLuggageResult{}, apperr.ErrLuggageNotFound
 		}
-		return nil, err
+		return RetrieveLuggageResult{}, err
 	}
 	if len(items) == 0 {
-		return nil, errors.New("luggage not found")
+		var hotelID int64
+		if user.HotelID != nil {
+			hotelID = *user.HotelID
+		}
+		return RetrieveLuggageResult{}, retrievalCodeNotFound(hotelID, code)
 	}
 	storedItems := make([]models.LuggageItem, 0, len(items))
 	for _, item := range items {
@@ -204,51 +305,259 @@ func RetrieveLuggage(code string, retrievedByUsername string) ([]models.LuggageI
 		}
 	}
 	if len(storedItems) == 0 {
-		return nil, errors.New("luggage is not in stored status")
+		return RetrieveLuggageResult{}, notStoredError(items)
 	}
 
-	for _, item := range storedItems {
-		if err := repositories.UpdateLuggageRetrieved(item.ID, user.Username); err != nil {
-			return nil, err
+	if err := checkCodeExpiry(storedItems); err != nil {
+		return RetrieveLuggageResult{}, err
+	}
+
+	retrieveItems, remainingItems, err := splitByLuggageIDs(items, storedItems, luggageIDs)
+	if err != nil {
+		return RetrieveLuggageResult{}, err
+	}
+
+	// 代取：校验代取凭证（代取码或登记的代取人）
+	pickup, err := resolveDelegate(ctx, code, storedItems, req.Delegate)
+	if err != nil {
+		return RetrieveLuggageResult{}, err
+	}
+	// 按酒店策略核验取件人身份（高风险行李要求更严格的核验方式）
+	policy, err := GetHotelPolicy(retrieveItems[0].HotelID)
+	if err != nil {
+		return RetrieveLuggageResult{}, err
+	}
+	// 保险要求的取件凭证：酒店策略要求时必须有签名
+	// 在核验身份之前检查，避免缺少签名的请求消耗掉客人的一次性验证码
+	if signature == nil && policy.RequireSignature {
+		return RetrieveLuggageResult{}, apperr.ErrSignatureRequired
+	}
+	verification, err := verifyCheckout(code, policy, retrieveItems, req.Verification, pickup)
+	if err != nil {
+		return RetrieveLuggageResult{}, err
+	}
+	// 签名在核验通过后再保存
+	var signatureKey, signatureType string
+	if signature != nil {
+		if signatureKey, err = storeSignature(ctx, signature); err != nil {
+			return RetrieveLuggageResult{}, err
 		}
-		// 写入取件历史
+		signatureType = signature.Type
+	}
+	result := RetrieveLuggageResult{Retrieved: retrieveItems, Remaining: remainingItems, Signature: signatureKey}
+	if pickup != nil {
+		result.Delegate = &pickup.Delegate
+	}
+
+	// 写入取件历史（RemainingCount > 0 表示部分取件）
+	histories := make([]models.LuggageHistory, 0, len(retrieveItems))
+	for _, item := range retrieveItems {
 		history := models.LuggageHistory{
-			LuggageID:     item.ID,
-			GuestName:     item.GuestName,
-			ContactPhone:  item.ContactPhone,
-			ContactEmail:  item.ContactEmail,
-			Description:   item.Description,
-			Quantity:      item.Quantity,
-			SpecialNotes:  item.SpecialNotes,
-			PhotoURL:      item.PhotoURL,
-			PhotoURLs:     item.PhotoURLs,
-			HotelID:       item.HotelID,
-			StoreroomID:   item.StoreroomID,
-			RetrievalCode: item.RetrievalCode,
-			QRCodeURL:     item.QRCodeURL,
-			Status:        "retrieved",
-			StoredBy:      item.StoredBy,
-			RetrievedBy:   user.Username,
-			StoredAt:      item.StoredAt,
-			RetrievedAt:   time.Now(),
-		}
-		if err := repositories.CreateLuggageHistory(&history); err != nil {
-			return nil, err
-		}
-		// 已取件的行李从数据库中删除（历史已保留）
-		if err := repositories.DeleteLuggageByID(item.ID); err != nil {
-			return nil, err
+			LuggageID:          item.ID,
+			GuestName:          item.GuestName,
+			ContactPhone:       item.ContactPhone,
+			ContactEmail:       item.ContactEmail,
+			Description:        item.Description,
+			Quantity:           item.Quantity,
+			SpecialNotes:       item.SpecialNotes,
+			PhotoURL:           item.PhotoURL,
+			PhotoURLs:          item.PhotoURLs,
+			HotelID:            item.HotelID,
+			StoreroomID:        item.StoreroomID,
+			RetrievalCode:      item.RetrievalCode,
+			QRCodeURL:          item.QRCodeURL,
+			Status:             "retrieved",
+			RemainingCount:     len(remainingItems),
+			StoredBy:           item.StoredBy,
+			RetrievedBy:        user.Username,
+			VerificationMethod: verification.Method,
+			VerifiedBy:         user.Username,
+			VerificationDetail: verification.Detail,
+			CollectedBy:        item.GuestName,
+			CollectedByPhone:   item.ContactPhone,
+			SignatureURL:       signatureKey,
+			SignatureType:      signatureType,
+			StoredAt:           item.StoredAt,
+			RetrievedAt:        time.Now(),
+		}
+		if pickup != nil {
+			history.CollectedBy = pickup.Delegate.Name
+			history.CollectedByPhone = pickup.Delegate.Phone
+			history.DelegateID = &pickup.Delegate.ID
 		}
+		histories = append(histories, history)
+	}
+	// 所有行李在一个事务中取走：任意一件失败时整批回滚，不会出现部分行李已删除而其余仍在存的情况
+	// 验证码在同一事务中标记为已使用：取件失败时客人不需要重新获取验证码
+	// 取件审计日志与取件一起提交
+	retrieveAudits := func(histories []models.LuggageHistory) repositories.AuditBatch {
+		entries := make([]auditEntry, 0, len(histories))
+		for i, item := range retrieveItems {
+			entries = append(entries, auditEntry{
+				HotelID:    item.HotelID,
+				EntityType: models.AuditEntityLuggage,
+				EntityID:   item.ID,
+				Action:     models.AuditRetrieve,
+				Before:     item,
+				After:      histories[i],
+			})
+		}
+		return auditBatch(ctx, entries...)
+	}
+	if err := repositories.RetrieveLuggageBatch(histories, user.Username, verification.OTPID, retrieveAudits); err != nil {
+		discardSignature(ctx, signatureKey)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return RetrieveLuggageResult{}, apperr.ErrLuggageNotStored.WithMessage("some luggage was retrieved or moved, please retry")
+		case errors.Is(err, repositories.ErrCheckoutOTPConsumed):
+			return RetrieveLuggageResult{}, apperr.ErrVerificationFailed.WithMessage("verification code already used, request a new one")
+		}
+		return RetrieveLuggageResult{}, err
 	}
 	_ = repositories.DeleteLuggageByCodeCache(code)
+	if pickup != nil {
+		if err := repositories.TouchPickupDelegate(pickup.Delegate.ID); err != nil {
+			log.Printf("⚠️  记录代取时间失败 delegate=%d: %v", pickup.Delegate.ID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// splitByLuggageIDs 把在存行李分为本次取走的和剩余的
+// luggageIDs 为空表示全部取走；ID 不属于该取件码返回 404，已不在寄存状态返回 409
+func splitByLuggageIDs(items, storedItems []models.LuggageItem, luggageIDs []int64) ([]models.LuggageItem, []models.LuggageItem, error) {
+	if len(luggageIDs) == 0 {
+		return storedItems, []models.LuggageItem{}, nil
+	}
+	selected := make(map[int64]bool, len(luggageIDs))
+	for _, id := range luggageIDs {
+		selected[id] = true
+	}
+	for id := range selected {
+		found := false
+		for _, item := range items {
+			if item.ID != id {
+				continue
+			}
+			found = true
+			if item.Status != "stored" {
+				return nil, nil, apperr.ErrLuggageNotStored.WithMessage(fmt.Sprintf("luggage %d is not in stored status", id))
+			}
+		}
+		if !found {
+			return nil, nil, apperr.ErrLuggageNotFound.WithMessage(fmt.Sprintf("luggage %d does not belong to this retrieval code", id))
+		}
+	}
+
+	retrieveItems := make([]models.LuggageItem, 0, len(selected))
+	remainingItems := make([]models.LuggageItem, 0, len(storedItems)-len(selected))
+	for _, item := range storedItems {
+		if selected[item.ID] {
+			retrieveItems = append(retrieveItems, item)
+		} else {
+			remainingItems = append(remainingItems, item)
+		}
+	}
+	return retrieveItems, remainingItems, nil
+}
+
+// CheckoutInfo 某取件码的取件情况
+type CheckoutInfo struct {
+	RetrievalCode string                  `json:"retrieval_code"`
+	GuestName     string                  `json:"guest_name"`
+	ContactPhone  string                  `json:"contact_phone"`
+	Remaining     []models.LuggageItem    `json:"remaining"` // 仍在寄存的行李
+	Retrieved     []models.LuggageHistory `json:"retrieved"` // 已取走的行李（取件历史）
+	// 取走全部剩余行李需要的身份核验方式（按酒店策略，高风险行李要求更严格）
+	RequiredVerification string     `json:"required_verification"`
+	HighRisk             bool       `json:"high_risk"`
+	RequireSignature     bool       `json:"require_signature"` // 取件时必须有客人签名
+	CodeExpiresAt        *time.Time `json:"code_expires_at"`   // 取件码过期时间（为空表示不过期，过期后需重新生成取件码）
+	// 本批行李登记的代取人（含已撤销、已过期，前端按 status / expires_at 展示）
+	Delegates []models.PickupDelegate `json:"delegates"`
+}
+
+// GetCheckoutInfo 获取取件码下仍在寄存和已取走的行李
+// hotelID 为当前用户所属酒店，取件码属于其他酒店时按不存在处理
+func GetCheckoutInfo(hotelID int64, code string) (CheckoutInfo, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return CheckoutInfo{}, apperr.InvalidRequest("code is empty")
+	}
+	items, err := repositories.FindLuggageByCode(code)
+	if err != nil {
+		return CheckoutInfo{}, err
+	}
+
+	info := CheckoutInfo{RetrievalCode: code, Remaining: []models.LuggageItem{}, Retrieved: []models.LuggageHistory{}, Delegates: []models.PickupDelegate{}}
+	// 取件码在全部取走后可以被重新分配：只统计本批行李（存放之后）的取件记录
+	var since time.Time
+	var owner models.LuggageItem
+	for _, item := range items {
+		if item.Status != "stored" {
+			continue
+		}
+		info.Remaining = append(info.Remaining, item)
+		if since.IsZero() || item.StoredAt.Before(since) {
+			since = item.StoredAt
+			owner = item
+		}
+	}
+	if len(info.Remaining) == 0 {
+		if err := notStoredError(items); errors.Is(err, apperr.ErrLuggageInTransit) {
+			return CheckoutInfo{}, err
+		}
+		// 已全部取走：以最近一次取件记录所在的一批为准
+		latest, err := repositories.GetLatestHistoryByCode(code)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return CheckoutInfo{}, retrievalCodeNotFound(hotelID, code)
+			}
+			return CheckoutInfo{}, err
+		}
+		if latest.HotelID != hotelID {
+			return CheckoutInfo{}, apperr.ErrLuggageNotFound
+		}
+		since = latest.StoredAt
+		info.GuestName, info.ContactPhone = latest.GuestName, latest.ContactPhone
+	} else {
+		if owner.HotelID != hotelID {
+			return CheckoutInfo{}, apperr.ErrLuggageNotFound
+		}
+		info.GuestName, info.ContactPhone = owner.GuestName, owner.ContactPhone
+		policy, err := GetHotelPolicy(hotelID)
+		if err != nil {
+			return CheckoutInfo{}, err
+		}
+		info.RequiredVerification, info.HighRisk = RequiredVerification(policy, info.Remaining)
+		info.RequireSignature = policy.RequireSignature
+		info.CodeExpiresAt = owner.CodeExpiresAt
+		delegates, err := repositories.ListPickupDelegates(code, since)
+		if err != nil {
+			return CheckoutInfo{}, err
+		}
+		if delegates != nil {
+			info.Delegates = delegates
+		}
+	}
 
-	return storedItems, nil
+	// 仍在寄存的行李带上格位完整位置，方便直接去取
+	if err := fillBinPaths(info.Remaining); err != nil {
+		return CheckoutInfo{}, err
+	}
+	retrieved, err := repositories.ListHistoryByCode(code, since)
+	if err != nil {
+		return CheckoutInfo{}, err
+	}
+	info.Retrieved = retrieved
+	return info, nil
 }
 
 // ListLuggageByUser 获取用户寄存单列表
 func ListLuggageByUser(username string, status string) ([]models.LuggageItem, error) {
 	if username == "" {
-		return nil, errors.New("username is empty")
+		return nil, apperr.InvalidRequest("username is empty")
 	}
 	return repositories.ListLuggageByUser(username, status)
 }
@@ -256,7 +565,7 @@ func ListLuggageByUser(username string, status string) ([]models.LuggageItem, er
 // ListLuggageByGuest 按客人姓名/手机号查询寄存单列表
 func ListLuggageByGuest(guestName, contactPhone, status string) ([]models.LuggageItem, error) {
 	if guestName == "" && contactPhone == "" {
-		return nil, errors.New("guest_name and contact_phone cannot both be empty")
+		return nil, apperr.InvalidRequest("guest_name and contact_phone cannot both be empty")
 	}
 	return repositories.ListLuggageByGuest(guestName, contactPhone, status)
 }
@@ -269,7 +578,7 @@ func ListGuestNames() ([]string, error) {
 // ListLuggageByStoreroom 按寄存室查询寄存单列表
 func ListLuggageByStoreroom(storeroomID int64, status string) ([]models.LuggageItem, error) {
 	if storeroomID <= 0 {
-		return nil, errors.New("invalid storeroom id")
+		return nil, apperr.InvalidRequest("invalid storeroom id")
 	}
 	return repositories.ListLuggageByStoreroom(storeroomID, status)
 }
@@ -277,7 +586,7 @@ func ListLuggageByStoreroom(storeroomID int64, status string) ([]models.LuggageI
 // ListLuggageByHotelAndStatus 按酒店与状态查询寄存单列表
 func ListLuggageByHotelAndStatus(hotelID int64, status string) ([]models.LuggageItem, error) {
 	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
+		return nil, apperr.InvalidRequest("invalid hotel id")
 	}
 	return repositories.ListLuggageByHotelAndStatus(hotelID, status)
 }
@@ -285,26 +594,18 @@ func ListLuggageByHotelAndStatus(hotelID int64, status string) ([]models.Luggage
 // ListGuestNamesByHotelAndStatus 查询某酒店下指定状态的客人姓名（去重）
 func ListGuestNamesByHotelAndStatus(hotelID int64, status string) ([]string, error) {
 	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
+		return nil, apperr.InvalidRequest("invalid hotel id")
 	}
 	return repositories.ListGuestNamesByHotelAndStatus(hotelID, status)
 }
 
-// ListLuggageUpdatesByHotel 查询某酒店寄存单修改记录
-func ListLuggageUpdatesByHotel(hotelID int64) ([]models.LuggageUpdate, error) {
-	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
-	}
-	return repositories.ListUpdatesByHotel(hotelID)
-}
-
 // ListStoredLuggageByGuestName 获取某客人正在寄存的行李列表
 func ListStoredLuggageByGuestName(hotelID int64, guestName string) ([]models.LuggageItem, error) {
 	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
+		return nil, apperr.InvalidRequest("invalid hotel id")
 	}
 	if guestName == "" {
-		return nil, errors.New("guest_name is empty")
+		return nil, apperr.InvalidRequest("guest_name is empty")
 	}
 	return repositories.ListLuggageByHotelGuestAndStatus(hotelID, guestName, "stored")
 }
@@ -312,12 +613,12 @@ func ListStoredLuggageByGuestName(hotelID int64, guestName string) ([]models.Lug
 // GetLuggageDetail 获取寄存单详情
 func GetLuggageDetail(id int64) (models.LuggageItem, error) {
 	if id <= 0 {
-		return models.LuggageItem{}, errors.New("invalid luggage id")
+		return models.LuggageItem{}, apperr.InvalidRequest("invalid luggage id")
 	}
 	item, err := repositories.GetLuggageByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.LuggageItem{}, errors.New("luggage not found")
+			return models.LuggageItem{}, apperr.ErrLuggageNotFound
 		}
 		return models.LuggageItem{}, err
 	}
@@ -327,17 +628,17 @@ func GetLuggageDetail(id int64) (models.LuggageItem, error) {
 // GetLuggageDetailByCode 按取件码查询寄存单详情
 func GetLuggageDetailByCode(code string) (models.LuggageItem, error) {
 	if code == "" {
-		return models.LuggageItem{}, errors.New("code is empty")
+		return models.LuggageItem{}, apperr.InvalidRequest("code is empty")
 	}
 	items, err := repositories.FindLuggageByCode(code)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.LuggageItem{}, errors.New("luggage not found")
+			return models.LuggageItem{}, apperr.ErrLuggageNotFound
 		}
 		return models.LuggageItem{}, err
 	}
 	if len(items) == 0 {
-		return models.LuggageItem{}, errors.New("luggage not found")
+		return models.LuggageItem{}, apperr.ErrLuggageNotFound
 	}
 	return items[0], nil
 }
@@ -345,7 +646,7 @@ func GetLuggageDetailByCode(code string) (models.LuggageItem, error) {
 // ListLuggageDetailByPhone 按客人手机号查询寄存单详情列表
 func ListLuggageDetailByPhone(contactPhone string) ([]models.LuggageItem, error) {
 	if contactPhone == "" {
-		return nil, errors.New("contact_phone is empty")
+		return nil, apperr.InvalidRequest("contact_phone is empty")
 	}
 	return repositories.ListLuggageByGuest("", contactPhone, "")
 }
@@ -353,7 +654,7 @@ func ListLuggageDetailByPhone(contactPhone string) ([]models.LuggageItem, error)
 // ListPickupCodesByUser 获取用户取件码列表
 func ListPickupCodesByUser(username string, status string) ([]models.LuggageItem, error) {
 	if username == "" {
-		return nil, errors.New("username is empty")
+		return nil, apperr.InvalidRequest("username is empty")
 	}
 	return repositories.ListPickupCodesByUser(username, status)
 }
@@ -361,7 +662,7 @@ func ListPickupCodesByUser(username string, status string) ([]models.LuggageItem
 // ListPickupCodesByPhone 按手机号查询取件码列表
 func ListPickupCodesByPhone(contactPhone, status string) ([]models.LuggageItem, error) {
 	if contactPhone == "" {
-		return nil, errors.New("contact_phone is empty")
+		return nil, apperr.InvalidRequest("contact_phone is empty")
 	}
 	return repositories.ListPickupCodesByPhone(contactPhone, status)
 }
@@ -373,59 +674,138 @@ type UpdateLuggageInfoRequest struct {
 	ContactEmail *string
 	Description  *string
 	Quantity     *int
+	SizeClass    *string // 尺寸：small / medium / large / oversized（空字符串表示未填写）
 	SpecialNotes *string
 	PhotoURL     *string
 	PhotoURLs    *[]string
 	StoreroomID  *int64 // 新增：支持修改寄存室（迁移）
+	BinID        *int64 // 格位ID（0 表示移出格位；迁移寄存室且未指定时自动分配目标寄存室的可用格位）
 	UpdatedBy    string
 }
 
 // UpdateLuggageInfo 修改寄存信息（包含寄存室迁移）
-func UpdateLuggageInfo(id int64, req UpdateLuggageInfoRequest) error {
+func UpdateLuggageInfo(ctx context.Context, id int64, req UpdateLuggageInfoRequest) error {
 	if id <= 0 {
-		return errors.New("invalid luggage id")
+		return apperr.InvalidRequest("invalid luggage id")
+	}
+	// 照片统一保存对象 key
+	if req.PhotoURL != nil {
+		ref := NormalizePhotoRef(*req.PhotoURL)
+		req.PhotoURL = &ref
+	}
+	if req.PhotoURLs != nil {
+		refs := NormalizePhotoRefs(*req.PhotoURLs)
+		if refs == nil {
+			refs = []string{}
+		}
+		req.PhotoURLs = &refs
 	}
 
 	item, err := repositories.GetLuggageByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("luggage not found")
+			return apperr.ErrLuggageNotFound
 		}
 		return err
 	}
+	if item.Status == "in_transit" {
+		return apperr.ErrLuggageInTransit
+	}
+	// 酒店要求保留寄存时的状态照片：不能删掉全部照片
+	if req.PhotoURLs != nil && len(*req.PhotoURLs) == 0 ||
+		req.PhotoURLs == nil && req.PhotoURL != nil && *req.PhotoURL == "" && len(item.PhotoURLs) == 0 {
+		policy, err := GetHotelPolicy(item.HotelID)
+		if err != nil {
+			return err
+		}
+		if policy.RequireCheckinPhotos {
+			return apperr.ErrCheckinPhotoRequired.WithMessage("luggage condition photos cannot be removed")
+		}
+	}
+
+	// 修改后的件数和尺寸（用于容量校验）
+	newQuantity, newSize := item.Quantity, item.SizeClass
+	if req.Quantity != nil {
+		if *req.Quantity <= 0 {
+			return apperr.InvalidRequest("quantity must be greater than 0")
+		}
+		newQuantity = *req.Quantity
+	}
+	if req.SizeClass != nil {
+		if !models.IsSizeClass(*req.SizeClass) {
+			return apperr.InvalidRequest("size_class must be one of small, medium, large, oversized")
+		}
+		newSize = *req.SizeClass
+	}
+	targetStoreroom := item.StoreroomID
+	if req.StoreroomID != nil {
+		targetStoreroom = *req.StoreroomID
+	}
+	moving := targetStoreroom != item.StoreroomID
+	resized := newQuantity != item.Quantity || newSize != item.SizeClass
+	moveBin := req.BinID != nil || moving
 
-	// 如果要修改寄存室，需要验证目标寄存室
-	if req.StoreroomID != nil && *req.StoreroomID != item.StoreroomID {
-		// 验证目标寄存室是否存在
-		targetRoom, err := repositories.GetStoreroomByID(*req.StoreroomID)
+	var units int64
+	if moving || resized || moveBin {
+		targetRoom, err := repositories.GetStoreroomByID(targetStoreroom)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("target storeroom not found")
+				return apperr.ErrStoreroomNotFound.WithMessage("target storeroom not found")
 			}
 			return err
 		}
-
-		// 验证寄存室是否属于同一酒店
-		if targetRoom.HotelID != item.HotelID {
-			return errors.New("storeroom hotel mismatch")
+		if moving {
+			// 验证寄存室是否属于同一酒店
+			if targetRoom.HotelID != item.HotelID {
+				return apperr.ErrStoreroomHotelMismatch
+			}
+			// 验证寄存室是否可用
+			if !targetRoom.IsActive {
+				return apperr.ErrStoreroomInactive.WithMessage("target storeroom is inactive")
+			}
 		}
 
-		// 验证寄存室是否可用
-		if !targetRoom.IsActive {
-			return errors.New("target storeroom is inactive")
+		// 容量校验（capacity > 0 才判断）：迁移时整件计入目标寄存室，原寄存室内改件数 / 尺寸时只校验增加的部分
+		units = targetRoom.LuggageUnits(newQuantity, newSize)
+		extra := units
+		if !moving {
+			extra -= targetRoom.LuggageUnits(item.Quantity, item.SizeClass)
 		}
+		if targetRoom.Capacity > 0 && extra > 0 {
+			used, err := repositories.SumStoredUnitsByStoreroom(targetRoom)
+			if err != nil {
+				return err
+			}
+			if used+extra > int64(targetRoom.Capacity) {
+				return apperr.ErrStoreroomFull.WithMessage("target storeroom is full")
+			}
+		}
+	}
 
-		// 验证寄存室是否已满
-		count, err := repositories.CountStoredByStoreroom(*req.StoreroomID)
+	// 迁移寄存室或指定格位时重新分配格位
+	var newBinID *int64
+	if moveBin {
+		currentBin := item.BinID
+		if moving {
+			currentBin = nil
+		}
+		bin, err := assignBin(targetStoreroom, req.BinID, currentBin, units)
 		if err != nil {
 			return err
 		}
-		if count >= int64(targetRoom.Capacity) {
-			return errors.New("target storeroom is full")
+		if bin != nil {
+			newBinID = &bin.ID
 		}
 	}
 
 	updates := map[string]interface{}{}
+	if moveBin {
+		if newBinID != nil {
+			updates["bin_id"] = *newBinID
+		} else {
+			updates["bin_id"] = nil
+		}
+	}
 	if req.GuestName != nil {
 		updates["guest_name"] = *req.GuestName
 	}
@@ -439,11 +819,11 @@ func UpdateLuggageInfo(id int64, req UpdateLuggageInfoRequest) error {
 		updates["description"] = *req.Description
 	}
 	if req.Quantity != nil {
-		if *req.Quantity <= 0 {
-			return errors.New("quantity must be greater than 0")
-		}
 		updates["quantity"] = *req.Quantity
 	}
+	if req.SizeClass != nil {
+		updates["size_class"] = *req.SizeClass
+	}
 	if req.SpecialNotes != nil {
 		updates["special_notes"] = *req.SpecialNotes
 	}
@@ -466,11 +846,7 @@ func UpdateLuggageInfo(id int64, req UpdateLuggageInfoRequest) error {
 		updates["storeroom_id"] = *req.StoreroomID
 	}
 
-	if err := repositories.UpdateLuggageInfo(id, updates); err != nil {
-		return err
-	}
-
-	// 记录修改历史
+	// 修改后快照（与修改一起写入修改记录）
 	updated := item
 	if req.GuestName != nil {
 		updated.GuestName = *req.GuestName
@@ -487,6 +863,9 @@ func UpdateLuggageInfo(id int64, req UpdateLuggageInfoRequest) error {
 	if req.Quantity != nil {
 		updated.Quantity = *req.Quantity
 	}
+	if req.SizeClass != nil {
+		updated.SizeClass = *req.SizeClass
+	}
 	if req.SpecialNotes != nil {
 		updated.SpecialNotes = *req.SpecialNotes
 	}
@@ -504,66 +883,159 @@ func UpdateLuggageInfo(id int64, req UpdateLuggageInfoRequest) error {
 	if req.StoreroomID != nil {
 		updated.StoreroomID = *req.StoreroomID
 	}
+	if moveBin {
+		updated.BinID = newBinID
+	}
+
+	updatedBy := req.UpdatedBy
+	if updatedBy == "" {
+		updatedBy = audit.ActorFrom(ctx).Username
+	}
+	audits := auditBatch(ctx, auditEntry{
+		HotelID:    item.HotelID,
+		EntityType: models.AuditEntityLuggage,
+		EntityID:   item.ID,
+		Action:     models.AuditUpdate,
+		Before:     item,
+		After:      updated,
+	})
+	if err := repositories.UpdateLuggageInfo(id, updates, luggageUpdateRecord(item, updated, updatedBy, nil), audits); err != nil {
+		return err
+	}
+	// 替换照片时新旧照片都刷新引用时间：被替换掉的照片在宽限期后才会被清理任务删除
+	if req.PhotoURL != nil || req.PhotoURLs != nil {
+		refs := append([]string{item.PhotoURL}, item.PhotoURLs...)
+		if req.PhotoURL != nil {
+			refs = append(refs, *req.PhotoURL)
+		}
+		if req.PhotoURLs != nil {
+			refs = append(refs, *req.PhotoURLs...)
+		}
+		touchPhotoRefs(refs...)
+	}
+	return nil
+}
 
+// luggageUpdateRecord 生成修改记录（修改前后快照和字段级变更）
+// names 缓存寄存室、酒店名称，批量生成时传入同一个以减少查询，单条时传 nil
+func luggageUpdateRecord(item, updated models.LuggageItem, updatedBy string, names *nameCache) models.LuggageUpdate {
 	oldData, _ := json.Marshal(item)
 	newData, _ := json.Marshal(updated)
-	if req.UpdatedBy != "" {
-		_ = repositories.CreateLuggageUpdate(&models.LuggageUpdate{
-			HotelID:   item.HotelID,
-			LuggageID: item.ID,
-			UpdatedBy: req.UpdatedBy,
-			OldData:   string(oldData),
-			NewData:   string(newData),
-		})
+	changes := luggageChanges(item, updated, names)
+	return models.LuggageUpdate{
+		HotelID:       item.HotelID,
+		LuggageID:     item.ID,
+		UpdatedBy:     updatedBy,
+		OldData:       string(oldData),
+		NewData:       string(newData),
+		Changes:       changes,
+		ChangedFields: changedFieldNames(changes),
 	}
-
-	return nil
 }
 
-// UpdateLuggageCode 修改取件码
-func UpdateLuggageCode(id int64, code string) error {
+// UpdateLuggageCode 手动修改取件码（写入修改记录）
+// 新取件码须符合酒店的取件码规则（长度、字符集、校验位），不能与在存行李重复或处于复用冷却期；
+// 过期时间按酒店策略重新计算，旧取件码的查询缓存随之清除
+func UpdateLuggageCode(ctx context.Context, id int64, code string) error {
 	if id <= 0 {
-		return errors.New("invalid luggage id")
+		return apperr.InvalidRequest("invalid luggage id")
 	}
+	code = utils.NormalizeCode(code)
 	if code == "" {
-		return errors.New("code is empty")
+		return apperr.InvalidRequest("code is empty")
 	}
 
 	item, err := repositories.GetLuggageByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("luggage not found")
+			return apperr.ErrLuggageNotFound
 		}
 		return err
 	}
+	if item.Status != "stored" {
+		return notStoredError([]models.LuggageItem{item})
+	}
+	if code == item.RetrievalCode {
+		return apperr.InvalidRequest("new code is the same as the current code")
+	}
+	policy, err := GetHotelPolicy(item.HotelID)
+	if err != nil {
+		return err
+	}
+	if !utils.ValidateSchemeCode(codeScheme(policy), code) {
+		return apperr.ErrRetrievalCodeInvalid.WithMessage("code does not match the hotel's retrieval code scheme (length, alphabet or check digit)")
+	}
+	now := time.Now()
+	available, err := retrievalCodeAvailable(policy, code, now)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return apperr.ErrRetrievalCodeInUse
+	}
 
-	return repositories.UpdateLuggageCode(item.ID, code)
+	expiresAt := codeExpiresAt(policy, now)
+	updates := map[string]interface{}{
+		"retrieval_code":  code,
+		"code_expires_at": expiresAt,
+	}
+	updated := item
+	updated.RetrievalCode, updated.CodeExpiresAt = code, expiresAt
+	if item.QRCodeURL == fmt.Sprintf("/qr/%s", item.RetrievalCode) {
+		updates["qr_code_url"] = fmt.Sprintf("/qr/%s", code)
+		updated.QRCodeURL = updates["qr_code_url"].(string)
+	}
+	record := luggageUpdateRecord(item, updated, audit.ActorFrom(ctx).Username, nil)
+	audits := auditBatch(ctx, auditEntry{
+		HotelID:    item.HotelID,
+		EntityType: models.AuditEntityLuggage,
+		EntityID:   item.ID,
+		Action:     models.AuditChangeCode,
+		Before:     item,
+		After:      updated,
+	})
+	if err := repositories.UpdateLuggageInfo(item.ID, updates, record, audits); err != nil {
+		return err
+	}
+	_ = repositories.DeleteLuggageByCodeCache(item.RetrievalCode)
+	return nil
 }
 
-// BindLuggageToUser 绑定行李到用户（按行李ID）
-func BindLuggageToUser(luggageID int64, username string) error {
+// BindLuggageToUser 绑定行李到用户（按行李ID，写入修改记录）
+func BindLuggageToUser(ctx context.Context, luggageID int64, username string) error {
 	if luggageID <= 0 || username == "" {
-		return errors.New("invalid luggage_id or user_name")
+		return apperr.InvalidRequest("invalid luggage_id or user_name")
 	}
 
 	item, err := repositories.GetLuggageByID(luggageID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("luggage not found")
+			return apperr.ErrLuggageNotFound
 		}
 		return err
 	}
 	if item.Status != "stored" {
-		return errors.New("luggage is not in stored status")
+		return apperr.ErrLuggageNotStored
 	}
 
-	return repositories.BindLuggageToUser(item.ID, username)
+	updated := item
+	updated.StoredBy = username
+	record := luggageUpdateRecord(item, updated, audit.ActorFrom(ctx).Username, nil)
+	audits := auditBatch(ctx, auditEntry{
+		HotelID:    item.HotelID,
+		EntityType: models.AuditEntityLuggage,
+		EntityID:   item.ID,
+		Action:     models.AuditBind,
+		Before:     item,
+		After:      updated,
+	})
+	return repositories.UpdateLuggageInfo(item.ID, map[string]interface{}{"stored_by": username}, record, audits)
 }
 
 // ListHistoryByGuest 按客人姓名/手机号查询取件历史
 func ListHistoryByGuest(guestName, contactPhone string) ([]models.LuggageHistory, error) {
 	if guestName == "" && contactPhone == "" {
-		return nil, errors.New("guest_name and contact_phone cannot both be empty")
+		return nil, apperr.InvalidRequest("guest_name and contact_phone cannot both be empty")
 	}
 	return repositories.ListHistoryByGuest(guestName, contactPhone)
 }
@@ -571,7 +1043,7 @@ func ListHistoryByGuest(guestName, contactPhone string) ([]models.LuggageHistory
 // ListHistoryByHotel 按酒店查询取件历史
 func ListHistoryByHotel(hotelID int64, guestName, contactPhone string) ([]models.LuggageHistory, error) {
 	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
+		return nil, apperr.InvalidRequest("invalid hotel id")
 	}
 	return repositories.ListHistoryByHotel(hotelID, guestName, contactPhone)
 }
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"hotel_luggage/internal/imaging"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/storage"
+)
+
+// photoStore 照片所在的存储（启动时由 InitPhotoStore 设置）
+// 数据库中的 photo_url / photo_urls 保存对象 key，返回给前端前统一换成短期有效的签名地址
+var photoStore storage.BlobStore
+
+// photoURLExpiry 签名地址有效期
+var photoURLExpiry = 15 * time.Minute
+
+// photoThumbnail 列表中使用的缩略图规格（为空时不返回缩略图）
+var photoThumbnail string
+
+// InitPhotoStore 设置照片存储、签名地址有效期和列表使用的缩略图规格
+func InitPhotoStore(store storage.BlobStore, expiry time.Duration, thumbnail string) {
+	photoStore = store
+	if expiry > 0 {
+		photoURLExpiry = expiry
+	}
+	photoThumbnail = thumbnail
+}
+
+// NormalizePhotoRef 把前端传入的照片地址规范化为对象 key
+// 兼容前端回传签名地址、旧版本的 /uploads/... 地址；外部地址原样保存
+func NormalizePhotoRef(ref string) string {
+	key, ok := storage.KeyFromURL(ref)
+	if !ok {
+		return ref
+	}
+	return key
+}
+
+// NormalizePhotoRefs 批量规范化照片地址
+func NormalizePhotoRefs(refs []string) []string {
+	if refs == nil {
+		return nil
+	}
+	result := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref = NormalizePhotoRef(ref); ref != "" {
+			result = append(result, ref)
+		}
+	}
+	return result
+}
+
+// photoLocations 一批照片的所在存储和已生成的缩略图
+// 来自 upload_records（缩略图）和 pending_uploads（降级写入本地、尚未同步的对象），签名时不再逐个查询存储
+// 没有上传记录的旧照片按在主存储、没有缩略图处理
+type photoLocations struct {
+	local    map[string]bool // 仍在本地等待同步的对象 key
+	variants map[string]bool // 已生成的缩略图 key
+}
+
+// loadPhotoLocations 批量读取照片的所在存储和缩略图（查询失败时只记录日志，按主存储、无缩略图签名）
+func loadPhotoLocations(refs []string) photoLocations {
+	locations := photoLocations{local: map[string]bool{}, variants: map[string]bool{}}
+	if photoStore == nil {
+		return locations
+	}
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if key, ok := storage.KeyFromURL(ref); ok && ref != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return locations
+	}
+	if photoThumbnail != "" {
+		records, err := repositories.ListUploadRecordsByKeys(keys)
+		if err != nil {
+			log.Printf("⚠️  查询照片缩略图失败: %v", err)
+		}
+		for _, record := range records {
+			for _, variant := range record.VariantKeys {
+				locations.variants[variant] = true
+				keys = append(keys, variant)
+			}
+		}
+	}
+	local, err := repositories.ListUnsyncedUploadKeys(keys)
+	if err != nil {
+		log.Printf("⚠️  查询待同步照片失败: %v", err)
+	}
+	for _, key := range local {
+		locations.local[key] = true
+	}
+	return locations
+}
+
+// sign 把照片 key 转换为签名地址（外部地址、未配置存储时原样返回）
+func (l photoLocations) sign(ctx context.Context, ref string) string {
+	if photoStore == nil || ref == "" {
+		return ref
+	}
+	key, ok := storage.KeyFromURL(ref)
+	if !ok {
+		return ref
+	}
+	backend := ""
+	if l.local[key] {
+		backend = "local"
+	}
+	signed, err := storage.SignedURLIn(ctx, photoStore, key, backend, photoURLExpiry)
+	if err != nil {
+		log.Printf("⚠️  生成照片签名地址失败 %s: %v", key, err)
+		return ref
+	}
+	return signed
+}
+
+// signAll 批量签名
+func (l photoLocations) signAll(ctx context.Context, refs []string) []string {
+	if refs == nil {
+		return nil
+	}
+	result := make([]string, len(refs))
+	for i, ref := range refs {
+		result[i] = l.sign(ctx, ref)
+	}
+	return result
+}
+
+// thumbnail 返回缩略图的签名地址，没有生成缩略图（例如图片处理上线前上传的旧照片）时返回原图签名地址
+func (l photoLocations) thumbnail(ctx context.Context, ref string) string {
+	if photoStore == nil || ref == "" || photoThumbnail == "" {
+		return l.sign(ctx, ref)
+	}
+	key, ok := storage.KeyFromURL(ref)
+	if !ok {
+		return ref
+	}
+	if thumbKey := imaging.VariantKey(key, photoThumbnail); l.variants[thumbKey] {
+		return l.sign(ctx, thumbKey)
+	}
+	return l.sign(ctx, key)
+}
+
+// thumbnails 批量生成缩略图签名地址
+func (l photoLocations) thumbnails(ctx context.Context, refs []string) []string {
+	if refs == nil {
+		return nil
+	}
+	result := make([]string, len(refs))
+	for i, ref := range refs {
+		result[i] = l.thumbnail(ctx, ref)
+	}
+	return result
+}
+
+// SignPhotoURL 把照片 key 转换为签名地址（外部地址、未配置存储时原样返回）
+func SignPhotoURL(ctx context.Context, ref string) string {
+	return loadPhotoLocations([]string{ref}).sign(ctx, ref)
+}
+
+// SignPhotoURLs 批量签名
+func SignPhotoURLs(ctx context.Context, refs []string) []string {
+	return loadPhotoLocations(refs).signAll(ctx, refs)
+}
+
+// SignThumbnailURL 返回照片缩略图的签名地址
+// 缩略图不存在（例如图片处理上线前上传的旧照片）时返回原图签名地址，外部地址原样返回
+func SignThumbnailURL(ctx context.Context, ref string) string {
+	return loadPhotoLocations([]string{ref}).thumbnail(ctx, ref)
+}
+
+// SignThumbnailURLs 批量生成缩略图签名地址
+func SignThumbnailURLs(ctx context.Context, refs []string) []string {
+	return loadPhotoLocations(refs).thumbnails(ctx, refs)
+}
+
+// SignLuggagePhotos 把寄存记录中的照片 key 替换为签名地址，并填充缩略图地址（只用于响应，不要再写回数据库）
+func SignLuggagePhotos(ctx context.Context, items []models.LuggageItem) {
+	var refs []string
+	for _, item := range items {
+		refs = append(append(refs, item.PhotoURL), item.PhotoURLs...)
+	}
+	locations := loadPhotoLocations(refs)
+	for i := range items {
+		items[i].ThumbnailURL = locations.thumbnail(ctx, items[i].PhotoURL)
+		items[i].ThumbnailURLs = locations.thumbnails(ctx, items[i].PhotoURLs)
+		items[i].PhotoURL = locations.sign(ctx, items[i].PhotoURL)
+		items[i].PhotoURLs = locations.signAll(ctx, items[i].PhotoURLs)
+	}
+}
+
+// SignHistoryPhotos 把取件历史中的照片、取件签名 key 替换为签名地址，并填充缩略图地址（只用于响应）
+func SignHistoryPhotos(ctx context.Context, items []models.LuggageHistory) {
+	var refs []string
+	for _, item := range items {
+		refs = append(append(refs, item.PhotoURL, item.SignatureURL), item.PhotoURLs...)
+	}
+	locations := loadPhotoLocations(refs)
+	for i := range items {
+		items[i].ThumbnailURL = locations.thumbnail(ctx, items[i].PhotoURL)
+		items[i].ThumbnailURLs = locations.thumbnails(ctx, items[i].PhotoURLs)
+		items[i].PhotoURL = locations.sign(ctx, items[i].PhotoURL)
+		items[i].PhotoURLs = locations.signAll(ctx, items[i].PhotoURLs)
+		items[i].SignatureURL = locations.sign(ctx, items[i].SignatureURL)
+	}
+}
+
+// SignFoundItemPhotos 把拾获物品中的照片 key 替换为签名地址，并填充缩略图地址（只用于响应）
+func SignFoundItemPhotos(ctx context.Context, items []models.FoundItem) {
+	var refs []string
+	for _, item := range items {
+		refs = append(append(refs, item.PhotoURL), item.PhotoURLs...)
+	}
+	locations := loadPhotoLocations(refs)
+	for i := range items {
+		items[i].ThumbnailURL = locations.thumbnail(ctx, items[i].PhotoURL)
+		items[i].ThumbnailURLs = locations.thumbnails(ctx, items[i].PhotoURLs)
+		items[i].PhotoURL = locations.sign(ctx, items[i].PhotoURL)
+		items[i].PhotoURLs = locations.signAll(ctx, items[i].PhotoURLs)
+	}
+}
+
+// SignIncidentPhotos 把事故报告中寄存时、取件时的照片 key 替换为签名地址（只用于响应）
+func SignIncidentPhotos(ctx context.Context, incidents []models.LuggageIncident) {
+	var refs []string
+	for _, incident := range incidents {
+		refs = append(append(refs, incident.CheckinPhotoURLs...), incident.CheckoutPhotoURLs...)
+	}
+	locations := loadPhotoLocations(refs)
+	for i := range incidents {
+		incidents[i].CheckinPhotoURLs = locations.signAll(ctx, incidents[i].CheckinPhotoURLs)
+		incidents[i].CheckoutPhotoURLs = locations.signAll(ctx, incidents[i].CheckoutPhotoURLs)
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/utils"
+
+	"gorm.io/gorm"
+)
+
+// defaultHighRiskKeywords 默认的高风险备注关键字
+const defaultHighRiskKeywords = "贵重,高价值,valuable"
+
+// maxCodeHours 取件码冷却期 / 有效期上限（一年）
+const maxCodeHours = 365 * 24
+
+// maxRetentionDays 拾获物品保管期限上限（十年）
+const maxRetentionDays = 3650
+
+// DefaultHotelPolicy 未单独配置的酒店使用的策略
+// 普通行李仅凭取件码取件（与旧版本一致），高风险行李需要一次性验证码；
+// 取件码为 6 位数字（与旧版本一致），取走后 30 天内不再分配；
+// 自动分配寄存室时均匀分布，24 小时内取件视为短时寄存；拾获物品保管 90 天
+func DefaultHotelPolicy(hotelID int64) models.HotelPolicy {
+	return models.HotelPolicy{
+		HotelID:                hotelID,
+		CheckoutVerification:   models.VerificationNone,
+		HighRiskVerification:   models.VerificationOTP,
+		HighRiskKeywords:       defaultHighRiskKeywords,
+		CodeLength:             6,
+		CodeAlphabet:           utils.CodeAlphabetNumeric,
+		CodeReuseCooldownHours: 30 * 24,
+		AssignStrategy:         models.AssignStrategyBalance,
+		ShortTermHours:         24,
+		LostFoundRetentionDays: 90,
+	}
+}
+
+// GetHotelPolicy 获取酒店策略（未配置时返回默认策略）
+func GetHotelPolicy(hotelID int64) (models.HotelPolicy, error) {
+	if hotelID <= 0 {
+		return models.HotelPolicy{}, apperr.InvalidRequest("invalid hotel id")
+	}
+	policy, err := repositories.GetHotelPolicy(hotelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultHotelPolicy(hotelID), nil
+		}
+		return models.HotelPolicy{}, err
+	}
+	return policy, nil
+}
+
+// UpdateHotelPolicyRequest 修改酒店策略请求（只修改传入的字段）
+type UpdateHotelPolicyRequest struct {
+	CheckoutVerification *string
+	HighRiskVerification *string
+	HighRiskQuantity     *int
+	HighRiskKeywords     *string
+	RequireSignature     *bool
+	RequireCheckinPhotos *bool
+	// 取件码规则（只影响之后生成的取件码）
+	CodeLength             *int
+	CodeAlphabet           *string
+	CodeCheckDigit         *bool
+	CodeReuseCooldownHours *int
+	CodeTTLHours           *int
+	// 自动分配寄存室
+	AssignStrategy *string
+	ShortTermHours *int
+	// 失物招领
+	LostFoundRetentionDays *int
+	UpdatedBy              string
+}
+
+// UpdateHotelPolicy 修改酒店策略
+func UpdateHotelPolicy(ctx context.Context, hotelID int64, req UpdateHotelPolicyRequest) (models.HotelPolicy, error) {
+	if _, err := repositories.GetHotelByID(hotelID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.HotelPolicy{}, apperr.ErrHotelNotFound
+		}
+		return models.HotelPolicy{}, err
+	}
+	policy, err := GetHotelPolicy(hotelID)
+	if err != nil {
+		return models.HotelPolicy{}, err
+	}
+	before := policy
+
+	if req.CheckoutVerification != nil {
+		if !isVerificationMethod(*req.CheckoutVerification) {
+			return models.HotelPolicy{}, apperr.InvalidRequest("invalid checkout_verification")
+		}
+		policy.CheckoutVerification = *req.CheckoutVerification
+	}
+	if req.HighRiskVerification != nil {
+		if !isVerificationMethod(*req.HighRiskVerification) {
+			return models.HotelPolicy{}, apperr.InvalidRequest("invalid high_risk_verification")
+		}
+		policy.HighRiskVerification = *req.HighRiskVerification
+	}
+	if req.HighRiskQuantity != nil {
+		if *req.HighRiskQuantity < 0 {
+			return models.HotelPolicy{}, apperr.InvalidRequest("high_risk_quantity cannot be negative")
+		}
+		policy.HighRiskQuantity = *req.HighRiskQuantity
+	}
+	if req.HighRiskKeywords != nil {
+		keywords := splitKeywords(*req.HighRiskKeywords)
+		joined := strings.Join(keywords, ",")
+		if len(joined) > 255 {
+			return models.HotelPolicy{}, apperr.InvalidRequest("high_risk_keywords is too long")
+		}
+		policy.HighRiskKeywords = joined
+	}
+	if req.RequireSignature != nil {
+		policy.RequireSignature = *req.RequireSignature
+	}
+	if req.RequireCheckinPhotos != nil {
+		policy.RequireCheckinPhotos = *req.RequireCheckinPhotos
+	}
+	if req.CodeLength != nil {
+		if *req.CodeLength < minCodeLength || *req.CodeLength > maxCodeLength {
+			return models.HotelPolicy{}, apperr.InvalidRequest(fmt.Sprintf("code_length must be between %d and %d", minCodeLength, maxCodeLength))
+		}
+		policy.CodeLength = *req.CodeLength
+	}
+	if req.CodeAlphabet != nil {
+		if !utils.IsCodeAlphabet(*req.CodeAlphabet) {
+			return models.HotelPolicy{}, apperr.InvalidRequest("code_alphabet must be numeric or crockford")
+		}
+		policy.CodeAlphabet = *req.CodeAlphabet
+	}
+	if req.CodeCheckDigit != nil {
+		policy.CodeCheckDigit = *req.CodeCheckDigit
+	}
+	if req.CodeReuseCooldownHours != nil {
+		if *req.CodeReuseCooldownHours < 0 || *req.CodeReuseCooldownHours > maxCodeHours {
+			return models.HotelPolicy{}, apperr.InvalidRequest(fmt.Sprintf("code_reuse_cooldown_hours must be between 0 and %d", maxCodeHours))
+		}
+		policy.CodeReuseCooldownHours = *req.CodeReuseCooldownHours
+	}
+	if req.CodeTTLHours != nil {
+		if *req.CodeTTLHours < 0 || *req.CodeTTLHours > maxCodeHours {
+			return models.HotelPolicy{}, apperr.InvalidRequest(fmt.Sprintf("code_ttl_hours must be between 0 and %d", maxCodeHours))
+		}
+		policy.CodeTTLHours = *req.CodeTTLHours
+	}
+	if req.AssignStrategy != nil {
+		if *req.AssignStrategy != models.AssignStrategyBalance && *req.AssignStrategy != models.AssignStrategyFill {
+			return models.HotelPolicy{}, apperr.InvalidRequest("assign_strategy must be balance or fill")
+		}
+		policy.AssignStrategy = *req.AssignStrategy
+	}
+	if req.ShortTermHours != nil {
+		if *req.ShortTermHours < 0 || *req.ShortTermHours > maxCodeHours {
+			return models.HotelPolicy{}, apperr.InvalidRequest(fmt.Sprintf("short_term_hours must be between 0 and %d", maxCodeHours))
+		}
+		policy.ShortTermHours = *req.ShortTermHours
+	}
+	if req.LostFoundRetentionDays != nil {
+		if *req.LostFoundRetentionDays < 1 || *req.LostFoundRetentionDays > maxRetentionDays {
+			return models.HotelPolicy{}, apperr.InvalidRequest(fmt.Sprintf("lost_found_retention_days must be between 1 and %d", maxRetentionDays))
+		}
+		policy.LostFoundRetentionDays = *req.LostFoundRetentionDays
+	}
+	policy.UpdatedBy = req.UpdatedBy
+
+	if err := repositories.SaveHotelPolicy(&policy); err != nil {
+		return models.HotelPolicy{}, err
+	}
+	updated, err := GetHotelPolicy(hotelID)
+	if err != nil {
+		return models.HotelPolicy{}, err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    hotelID,
+		EntityType: models.AuditEntityPolicy,
+		EntityID:   hotelID,
+		Action:     models.AuditUpdate,
+		Before:     before,
+		After:      updated,
+	})
+	return updated, nil
+}
+
+// splitKeywords 拆分逗号分隔的关键字（支持中文逗号），去掉空白和空项
+func splitKeywords(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' })
+	result := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			result = append(result, f)
+		}
+	}
+	return result
+}
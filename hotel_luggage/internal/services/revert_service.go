@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/audit"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// LuggageRevert 寄存单回退到历史版本的预览（Applied 为 false）或结果
+type LuggageRevert struct {
+	LuggageID int64                `json:"luggage_id"`
+	Revision  int                  `json:"revision"` // 回退到的版本号（0 为第一次修改前的寄存信息）
+	Current   models.LuggageItem   `json:"current"`  // 回退前的寄存单
+	Reverted  models.LuggageItem   `json:"reverted"` // 回退后的寄存单（格位按当前布局重新校验）
+	Changes   []models.FieldChange `json:"changes"`  // 回退会修改的字段
+	// MissingPhotos 版本中已被清理任务删除的照片（不会恢复）
+	MissingPhotos []string `json:"missing_photos,omitempty"`
+	Applied       bool     `json:"applied"`
+}
+
+// luggageRevertPlan 回退计划：预览和执行共用同一套校验
+type luggageRevertPlan struct {
+	item     models.LuggageItem
+	reverted models.LuggageItem
+	changes  []models.FieldChange
+	updates  map[string]interface{}
+	missing  []string
+	// checkCapacity 回退会增加寄存室的占用，执行时在事务中锁定寄存室重新校验容量
+	checkCapacity bool
+}
+
+// PreviewLuggageRevert 预览把寄存单回退到版本 revision 的结果（不写入）
+// 版本号与 GET /api/luggage/:id/timeline 一致，只在行李当前所在酒店的修改记录中查找
+func PreviewLuggageRevert(ctx context.Context, luggageID int64, revision int) (LuggageRevert, error) {
+	plan, err := planLuggageRevert(ctx, luggageID, revision)
+	if err != nil {
+		return LuggageRevert{}, err
+	}
+	return plan.result(revision, false), nil
+}
+
+// RevertLuggage 把寄存单回退到版本 revision：恢复客人信息、件数尺寸、照片和寄存室 / 格位
+// 取件码、状态等不随修改接口变化的字段不回退；回退本身写入一条新的修改记录
+func RevertLuggage(ctx context.Context, luggageID int64, revision int, updatedBy string) (LuggageRevert, error) {
+	plan, err := planLuggageRevert(ctx, luggageID, revision)
+	if err != nil {
+		return LuggageRevert{}, err
+	}
+	if len(plan.changes) == 0 {
+		return LuggageRevert{}, apperr.ErrRevertNoChanges
+	}
+	if updatedBy == "" {
+		updatedBy = audit.ActorFrom(ctx).Username
+	}
+	item, reverted := plan.item, plan.reverted
+	audits := auditBatch(ctx, auditEntry{
+		HotelID:    item.HotelID,
+		EntityType: models.AuditEntityLuggage,
+		EntityID:   item.ID,
+		Action:     models.AuditRevert,
+		Before:     item,
+		After:      map[string]interface{}{"revision": revision, "luggage": reverted},
+	})
+	record := luggageUpdateRecord(item, reverted, updatedBy, nil)
+	if plan.checkCapacity {
+		err = repositories.UpdateLuggageInfoWithinCapacity(item.ID, reverted.StoreroomID, plan.updates, record, audits)
+	} else {
+		err = repositories.UpdateLuggageInfo(item.ID, plan.updates, record, audits)
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrStoreroomOverCapacity) {
+			return LuggageRevert{}, apperr.ErrStoreroomFull.WithMessage("storeroom of this revision is full")
+		}
+		return LuggageRevert{}, err
+	}
+	// 取件码查询缓存中保存了客人信息和寄存室，回退后清除
+	_ = repositories.DeleteLuggageByCodeCache(item.RetrievalCode)
+	// 恢复的旧照片刷新引用时间，避免被清理任务当作未被引用的照片删除
+	_, photoChanged := plan.updates["photo_url"]
+	_, photosChanged := plan.updates["photo_urls"]
+	if photoChanged || photosChanged {
+		refs := append([]string{item.PhotoURL, reverted.PhotoURL}, item.PhotoURLs...)
+		touchPhotoRefs(append(refs, reverted.PhotoURLs...)...)
+	}
+	return plan.result(revision, true), nil
+}
+
+func (plan luggageRevertPlan) result(revision int, applied bool) LuggageRevert {
+	return LuggageRevert{
+		LuggageID:     plan.item.ID,
+		Revision:      revision,
+		Current:       plan.item,
+		Reverted:      plan.reverted,
+		Changes:       plan.changes,
+		MissingPhotos: plan.missing,
+		Applied:       applied,
+	}
+}
+
+// planLuggageRevert 读取版本快照，按当前的寄存室、容量和格位重新校验，生成回退后的寄存单和要更新的字段
+func planLuggageRevert(ctx context.Context, luggageID int64, revision int) (luggageRevertPlan, error) {
+	if luggageID <= 0 {
+		return luggageRevertPlan{}, apperr.InvalidRequest("invalid luggage id")
+	}
+	if revision < 0 {
+		return luggageRevertPlan{}, apperr.InvalidRequest("revision must be 0 or greater")
+	}
+	item, err := repositories.GetLuggageByID(luggageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return luggageRevertPlan{}, apperr.ErrLuggageNotFound
+		}
+		return luggageRevertPlan{}, err
+	}
+	// 只有在存的行李可以回退（已取件、转寄途中的行李不在寄存室中）
+	if item.Status != "stored" {
+		return luggageRevertPlan{}, notStoredError([]models.LuggageItem{item})
+	}
+
+	snapshot, err := luggageRevisionSnapshot(item, revision)
+	if err != nil {
+		return luggageRevertPlan{}, err
+	}
+
+	// 只回退修改接口可以修改的字段，其他字段保持当前值
+	reverted := item
+	reverted.GuestName = snapshot.GuestName
+	reverted.ContactPhone = snapshot.ContactPhone
+	reverted.ContactEmail = snapshot.ContactEmail
+	reverted.Description = snapshot.Description
+	reverted.Quantity = snapshot.Quantity
+	reverted.SizeClass = snapshot.SizeClass
+	reverted.SpecialNotes = snapshot.SpecialNotes
+	reverted.PhotoURL = NormalizePhotoRef(snapshot.PhotoURL)
+	reverted.PhotoURLs = NormalizePhotoRefs(snapshot.PhotoURLs)
+	missing, err := dropMissingPhotos(ctx, item, &reverted)
+	if err != nil {
+		return luggageRevertPlan{}, err
+	}
+	if reverted.PhotoURLs == nil {
+		reverted.PhotoURLs = []string{}
+	}
+	reverted.StoreroomID = snapshot.StoreroomID
+	reverted.BinID = snapshot.BinID
+	reverted.BinPath = ""
+
+	if reverted.Quantity <= 0 {
+		return luggageRevertPlan{}, apperr.InvalidRequest("quantity of this revision is invalid")
+	}
+	if !models.IsSizeClass(reverted.SizeClass) {
+		return luggageRevertPlan{}, apperr.InvalidRequest("size_class of this revision is invalid")
+	}
+	// 酒店要求保留寄存时的状态照片：不能回退到没有照片的版本
+	if reverted.PhotoURL == "" && len(reverted.PhotoURLs) == 0 && (item.PhotoURL != "" || len(item.PhotoURLs) > 0) {
+		policy, err := GetHotelPolicy(item.HotelID)
+		if err != nil {
+			return luggageRevertPlan{}, err
+		}
+		if policy.RequireCheckinPhotos {
+			return luggageRevertPlan{}, apperr.ErrCheckinPhotoRequired.WithMessage("luggage condition photos cannot be removed")
+		}
+	}
+
+	checkCapacity := false
+	moving := reverted.StoreroomID != item.StoreroomID
+	resized := reverted.Quantity != item.Quantity || reverted.SizeClass != item.SizeClass
+	moveBin := moving || !sameBin(reverted.BinID, item.BinID)
+	if moving || resized || moveBin {
+		room, err := repositories.GetStoreroomByID(reverted.StoreroomID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return luggageRevertPlan{}, apperr.ErrStoreroomNotFound.WithMessage("storeroom of this revision no longer exists")
+			}
+			return luggageRevertPlan{}, err
+		}
+		if moving {
+			// 行李转寄后，其他酒店的寄存室不能回退
+			if room.HotelID != item.HotelID {
+				return luggageRevertPlan{}, apperr.ErrStoreroomHotelMismatch
+			}
+			if !room.IsActive {
+				return luggageRevertPlan{}, apperr.ErrStoreroomInactive.WithMessage("storeroom of this revision is inactive")
+			}
+		}
+
+		// 容量校验与修改寄存信息一致：迁移时整件计入目标寄存室，原寄存室内只校验增加的部分
+		units := room.LuggageUnits(reverted.Quantity, reverted.SizeClass)
+		extra := units
+		if !moving {
+			extra -= room.LuggageUnits(item.Quantity, item.SizeClass)
+		}
+		// 这里的校验用于预览和尽早拒绝，执行时在事务中重新校验
+		if room.Capacity > 0 && extra > 0 {
+			checkCapacity = true
+			used, err := repositories.SumStoredUnitsByStoreroom(room)
+			if err != nil {
+				return luggageRevertPlan{}, err
+			}
+			if used+extra > int64(room.Capacity) {
+				return luggageRevertPlan{}, apperr.ErrStoreroomFull.WithMessage("storeroom of this revision is full")
+			}
+		}
+
+		// 版本中的格位按当前布局重新校验（可能已停用或放满）；版本中没有格位时移出格位
+		if moveBin {
+			binID := reverted.BinID
+			if binID == nil {
+				binID = new(int64)
+			}
+			currentBin := item.BinID
+			if moving {
+				currentBin = nil
+			}
+			bin, err := assignBin(reverted.StoreroomID, binID, currentBin, units)
+			if err != nil {
+				return luggageRevertPlan{}, err
+			}
+			reverted.BinID = nil
+			if bin != nil {
+				reverted.BinID = &bin.ID
+			}
+		}
+	}
+
+	changes := luggageChanges(item, reverted, nil)
+	photoURLs, err := json.Marshal(reverted.PhotoURLs)
+	if err != nil {
+		return luggageRevertPlan{}, err
+	}
+	var binID interface{}
+	if reverted.BinID != nil {
+		binID = *reverted.BinID
+	}
+	values := map[string]interface{}{
+		"guest_name":    reverted.GuestName,
+		"contact_phone": reverted.ContactPhone,
+		"contact_email": reverted.ContactEmail,
+		"description":   reverted.Description,
+		"quantity":      reverted.Quantity,
+		"size_class":    reverted.SizeClass,
+		"special_notes": reverted.SpecialNotes,
+		"photo_url":     reverted.PhotoURL,
+		"photo_urls":    string(photoURLs),
+		"storeroom_id":  reverted.StoreroomID,
+		"bin_id":        binID,
+	}
+	updates := map[string]interface{}{}
+	for _, change := range changes {
+		updates[change.Field] = values[change.Field]
+	}
+	return luggageRevertPlan{item: item, reverted: reverted, changes: changes, updates: updates, missing: missing, checkCapacity: checkCapacity}, nil
+}
+
+// dropMissingPhotos 去掉版本中已不存在的照片：修改记录中的照片不算引用，替换掉的旧照片过了宽限期会被清理任务删除
+// 当前寄存单仍在使用的照片不会被清理，不再查询；返回被去掉的照片
+func dropMissingPhotos(ctx context.Context, item models.LuggageItem, reverted *models.LuggageItem) ([]string, error) {
+	if photoStore == nil {
+		return nil, nil
+	}
+	current := map[string]bool{NormalizePhotoRef(item.PhotoURL): true}
+	for _, ref := range NormalizePhotoRefs(item.PhotoURLs) {
+		current[ref] = true
+	}
+	var missing []string
+	exists := func(ref string) (bool, error) {
+		key, ok := storage.KeyFromURL(ref)
+		if !ok || ref == "" || current[ref] {
+			return true, nil
+		}
+		if _, err := photoStore.Stat(ctx, key); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				missing = append(missing, ref)
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	ok, err := exists(reverted.PhotoURL)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		reverted.PhotoURL = ""
+	}
+	kept := make([]string, 0, len(reverted.PhotoURLs))
+	for _, ref := range reverted.PhotoURLs {
+		ok, err := exists(ref)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, ref)
+		}
+	}
+	reverted.PhotoURLs = kept
+	return missing, nil
+}
+
+// luggageRevisionSnapshot 取出寄存单在版本 revision 时的寄存信息（版本号规则与修改时间线一致）
+func luggageRevisionSnapshot(item models.LuggageItem, revision int) (models.LuggageItem, error) {
+	records, err := repositories.ListLuggageRevisions(item.HotelID, item.ID)
+	if err != nil {
+		return models.LuggageItem{}, err
+	}
+	if revision > len(records) {
+		return models.LuggageItem{}, apperr.ErrRevisionNotFound
+	}
+	// 没有修改记录时只有版本 0，即当前寄存单
+	if len(records) == 0 {
+		return item, nil
+	}
+	data := records[0].OldData
+	if revision > 0 {
+		data = records[revision-1].NewData
+	}
+	var snapshot models.LuggageItem
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return models.LuggageItem{}, err
+	}
+	return snapshot, nil
+}
+
+// sameBin 两个格位是否相同（都为空也算相同）
+func sameBin(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/storage"
+)
+
+func TestSameBin(t *testing.T) {
+	one, otherOne, two := int64(1), int64(1), int64(2)
+	tests := []struct {
+		name string
+		a, b *int64
+		want bool
+	}{
+		{name: "both empty", want: true},
+		{name: "same pointer", a: &one, b: &one, want: true},
+		{name: "same id", a: &one, b: &otherOne, want: true},
+		{name: "different id", a: &one, b: &two},
+		{name: "left empty", b: &one},
+		{name: "right empty", a: &one},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameBin(tt.a, tt.b); got != tt.want {
+				t.Fatalf("sameBin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropMissingPhotos(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory("http://host/uploads")
+	for _, key := range []string{"2026/01/kept.jpg", "2026/01/kept2.jpg"} {
+		if _, err := store.Put(ctx, key, strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := photoStore
+	photoStore = store
+	defer func() { photoStore = saved }()
+
+	// 当前寄存单在用的照片（current.jpg）不会被清理，即使存储中查不到也保留
+	item := models.LuggageItem{PhotoURL: "2026/01/current.jpg", PhotoURLs: []string{"2026/01/current.jpg"}}
+	tests := []struct {
+		name        string
+		photoURL    string
+		photoURLs   []string
+		wantURL     string
+		wantURLs    []string
+		wantMissing []string
+	}{
+		{name: "all present", photoURL: "2026/01/kept.jpg", photoURLs: []string{"2026/01/kept.jpg", "2026/01/kept2.jpg"},
+			wantURL: "2026/01/kept.jpg", wantURLs: []string{"2026/01/kept.jpg", "2026/01/kept2.jpg"}},
+		{name: "main photo deleted", photoURL: "2026/01/gone.jpg", photoURLs: []string{"2026/01/kept.jpg"},
+			wantURL: "", wantURLs: []string{"2026/01/kept.jpg"}, wantMissing: []string{"2026/01/gone.jpg"}},
+		{name: "some of the list deleted", photoURLs: []string{"2026/01/gone.jpg", "2026/01/kept2.jpg", "2026/01/gone2.jpg"},
+			wantURLs: []string{"2026/01/kept2.jpg"}, wantMissing: []string{"2026/01/gone.jpg", "2026/01/gone2.jpg"}},
+		{name: "still in use", photoURL: "2026/01/current.jpg", photoURLs: []string{"2026/01/current.jpg"},
+			wantURL: "2026/01/current.jpg", wantURLs: []string{"2026/01/current.jpg"}},
+		// 外部地址不在本系统存储中，不查询
+		{name: "external url", photoURL: "https://cdn.example.com/a.jpg", photoURLs: []string{},
+			wantURL: "https://cdn.example.com/a.jpg", wantURLs: []string{}},
+		{name: "no photos", photoURLs: nil, wantURLs: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reverted := models.LuggageItem{PhotoURL: tt.photoURL, PhotoURLs: tt.photoURLs}
+			missing, err := dropMissingPhotos(ctx, item, &reverted)
+			if err != nil {
+				t.Fatalf("dropMissingPhotos() error = %v", err)
+			}
+			if reverted.PhotoURL != tt.wantURL || !reflect.DeepEqual(reverted.PhotoURLs, tt.wantURLs) {
+				t.Fatalf("photos = %q %v, want %q %v", reverted.PhotoURL, reverted.PhotoURLs, tt.wantURL, tt.wantURLs)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Fatalf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
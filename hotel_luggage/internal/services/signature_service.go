@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/storage"
+)
+
+// 取件签名类型
+const (
+	SignatureTypePNG     = "png"     // 手写板导出的 PNG 图片
+	SignatureTypeStrokes = "strokes" // 笔迹坐标（矢量）
+)
+
+// 签名大小限制
+const (
+	maxSignatureBytes     = 512 << 10 // PNG 最大 512KB
+	maxSignatureDimension = 4000      // 画布 / 图片最大边长
+	maxSignaturePoints    = 20000     // 笔迹最多点数
+	signatureKeyPrefix    = "signatures/"
+)
+
+// SignaturePoint 笔迹上的一个点（画布坐标，t 为相对落笔的毫秒数，可选）
+type SignaturePoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	T int64   `json:"t,omitempty"`
+}
+
+// CheckoutSignature 取件时客人的签名，image_base64 与 strokes 二选一
+type CheckoutSignature struct {
+	ImageBase64 string             `json:"image_base64"` // PNG（base64，可带 data:image/png;base64, 前缀）
+	Strokes     [][]SignaturePoint `json:"strokes"`      // 笔迹：每一笔是一组点
+	Width       int                `json:"width"`        // 画布宽度（strokes 必填）
+	Height      int                `json:"height"`       // 画布高度（strokes 必填）
+}
+
+// signatureBlob 校验后待保存的签名文件
+type signatureBlob struct {
+	Type        string
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// prepareSignature 校验签名内容并转换为待保存的文件（sig 为空时返回 nil）
+func prepareSignature(sig *CheckoutSignature) (*signatureBlob, error) {
+	if sig == nil || (sig.ImageBase64 == "" && len(sig.Strokes) == 0) {
+		return nil, nil
+	}
+	if sig.ImageBase64 != "" && len(sig.Strokes) > 0 {
+		return nil, apperr.InvalidRequest("signature: image_base64 and strokes are mutually exclusive")
+	}
+
+	if sig.ImageBase64 != "" {
+		raw := sig.ImageBase64
+		if i := strings.Index(raw, ","); strings.HasPrefix(raw, "data:") && i > 0 {
+			raw = raw[i+1:]
+		}
+		if base64.StdEncoding.DecodedLen(len(raw)) > maxSignatureBytes+3 {
+			return nil, apperr.ErrFileTooLarge.WithMessage("signature image too large")
+		}
+		data, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return nil, apperr.InvalidRequest("signature: image_base64 is not valid base64")
+		}
+		if len(data) > maxSignatureBytes {
+			return nil, apperr.ErrFileTooLarge.WithMessage("signature image too large")
+		}
+		if http.DetectContentType(data) != "image/png" {
+			return nil, apperr.ErrUnsupportedFileType.WithMessage("signature image must be PNG")
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, apperr.ErrUnsupportedFileType.WithMessage("signature image must be PNG")
+		}
+		if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxSignatureDimension || cfg.Height > maxSignatureDimension {
+			return nil, apperr.InvalidRequest("signature image dimensions out of range")
+		}
+		return &signatureBlob{Type: SignatureTypePNG, Data: data, ContentType: "image/png", Ext: ".png"}, nil
+	}
+
+	if sig.Width <= 0 || sig.Height <= 0 || sig.Width > maxSignatureDimension || sig.Height > maxSignatureDimension {
+		return nil, apperr.InvalidRequest(fmt.Sprintf("signature: width and height must be between 1 and %d", maxSignatureDimension))
+	}
+	points := 0
+	for _, stroke := range sig.Strokes {
+		points += len(stroke)
+		for _, p := range stroke {
+			if p.X < 0 || p.Y < 0 || p.X > float64(sig.Width) || p.Y > float64(sig.Height) {
+				return nil, apperr.InvalidRequest("signature: stroke point outside the canvas")
+			}
+		}
+	}
+	if points < 2 {
+		return nil, apperr.InvalidRequest("signature: strokes are empty")
+	}
+	if points > maxSignaturePoints {
+		return nil, apperr.InvalidRequest(fmt.Sprintf("signature: at most %d points", maxSignaturePoints))
+	}
+	data, err := json.Marshal(struct {
+		Width   int                `json:"width"`
+		Height  int                `json:"height"`
+		Strokes [][]SignaturePoint `json:"strokes"`
+	}{sig.Width, sig.Height, sig.Strokes})
+	if err != nil {
+		return nil, err
+	}
+	return &signatureBlob{Type: SignatureTypeStrokes, Data: data, ContentType: "application/json", Ext: ".json"}, nil
+}
+
+// storeSignature 把签名写入照片存储（MinIO 不可用时降级到本地，恢复后由后台任务同步），返回对象 key
+// 签名不登记到 upload_records，不会被照片清理任务删除
+func storeSignature(ctx context.Context, blob *signatureBlob) (string, error) {
+	if photoStore == nil {
+		return "", apperr.Internal(fmt.Errorf("signature store not initialized"))
+	}
+	nameBytes := make([]byte, 16)
+	if _, err := rand.Read(nameBytes); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	key := fmt.Sprintf("%s%s/%s/%s%s", signatureKeyPrefix, now.Format("2006"), now.Format("01"), hex.EncodeToString(nameBytes), blob.Ext)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	info, err := photoStore.Put(ctx, key, bytes.NewReader(blob.Data), int64(len(blob.Data)), blob.ContentType)
+	if err != nil {
+		return "", err
+	}
+	RecordFallbackPut(photoStore, info)
+	return info.Key, nil
+}
+
+// discardSignature 取件失败时删除已保存的签名（没有取件历史引用它，删除失败只记录日志）
+// 降级写入本地时登记的待同步记录由同步任务按本地文件丢失处理
+func discardSignature(ctx context.Context, key string) {
+	if key == "" || photoStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if err := photoStore.Delete(ctx, key); err != nil {
+		log.Printf("⚠️  删除未使用的取件签名失败 %s: %v", key, err)
+	}
+}
+
+// RecordFallbackPut MinIO 不可用时降级写入了本地：登记到待同步上传表，MinIO 恢复后由后台任务同步
+func RecordFallbackPut(store storage.BlobStore, info storage.ObjectInfo) {
+	if _, ok := store.(*storage.FallbackStore); !ok || info.Store != "local" {
+		return
+	}
+	if err := RecordFallbackUpload(info, LocalUploadPath+info.Key); err != nil {
+		log.Printf("⚠️  记录待同步上传失败 %s: %v", info.Key, err)
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/storage"
+)
+
+// testPNG 生成 w×h 的 PNG 图片
+func testPNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPrepareSignature(t *testing.T) {
+	small := base64.StdEncoding.EncodeToString(testPNG(t, 300, 100))
+	tooWide := base64.StdEncoding.EncodeToString(testPNG(t, maxSignatureDimension+1, 1))
+	jpegLike := base64.StdEncoding.EncodeToString([]byte("\xff\xd8\xff\xe0 not a png"))
+	line := [][]SignaturePoint{{{X: 10, Y: 10}, {X: 20, Y: 15, T: 16}}}
+	tooManyPoints := [][]SignaturePoint{make([]SignaturePoint, maxSignaturePoints+1)}
+
+	tests := []struct {
+		name     string
+		sig      *CheckoutSignature
+		wantType string // 为空表示没有签名
+		wantErr  error
+	}{
+		{name: "nil"},
+		{name: "empty", sig: &CheckoutSignature{Width: 300, Height: 100}},
+		{name: "png", sig: &CheckoutSignature{ImageBase64: small}, wantType: SignatureTypePNG},
+		{name: "png data url", sig: &CheckoutSignature{ImageBase64: "data:image/png;base64," + small}, wantType: SignatureTypePNG},
+		{name: "both png and strokes", sig: &CheckoutSignature{ImageBase64: small, Strokes: line, Width: 300, Height: 100}, wantErr: apperr.ErrInvalidRequest},
+		{name: "invalid base64", sig: &CheckoutSignature{ImageBase64: "not base64!"}, wantErr: apperr.ErrInvalidRequest},
+		// 按内容识别类型，不能用其他格式冒充
+		{name: "not png", sig: &CheckoutSignature{ImageBase64: jpegLike}, wantErr: apperr.ErrUnsupportedFileType},
+		{name: "png too large", sig: &CheckoutSignature{ImageBase64: strings.Repeat("A", (maxSignatureBytes+8)*4/3)}, wantErr: apperr.ErrFileTooLarge},
+		{name: "png dimensions", sig: &CheckoutSignature{ImageBase64: tooWide}, wantErr: apperr.ErrInvalidRequest},
+
+		{name: "strokes", sig: &CheckoutSignature{Strokes: line, Width: 300, Height: 100}, wantType: SignatureTypeStrokes},
+		{name: "strokes without canvas", sig: &CheckoutSignature{Strokes: line}, wantErr: apperr.ErrInvalidRequest},
+		{name: "canvas too large", sig: &CheckoutSignature{Strokes: line, Width: maxSignatureDimension + 1, Height: 100}, wantErr: apperr.ErrInvalidRequest},
+		{name: "point outside canvas", sig: &CheckoutSignature{Strokes: [][]SignaturePoint{{{X: 10, Y: 10}, {X: 301, Y: 10}}}, Width: 300, Height: 100}, wantErr: apperr.ErrInvalidRequest},
+		{name: "negative point", sig: &CheckoutSignature{Strokes: [][]SignaturePoint{{{X: -1, Y: 10}, {X: 20, Y: 10}}}, Width: 300, Height: 100}, wantErr: apperr.ErrInvalidRequest},
+		// 只有一个点不算签名
+		{name: "single point", sig: &CheckoutSignature{Strokes: [][]SignaturePoint{{{X: 10, Y: 10}}}, Width: 300, Height: 100}, wantErr: apperr.ErrInvalidRequest},
+		{name: "too many points", sig: &CheckoutSignature{Strokes: tooManyPoints, Width: 300, Height: 100}, wantErr: apperr.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := prepareSignature(tt.sig)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("prepareSignature() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareSignature() error = %v", err)
+			}
+			if tt.wantType == "" {
+				if blob != nil {
+					t.Fatalf("prepareSignature() = %+v, want nil", blob)
+				}
+				return
+			}
+			if blob == nil || blob.Type != tt.wantType {
+				t.Fatalf("prepareSignature() = %+v, want type %s", blob, tt.wantType)
+			}
+		})
+	}
+}
+
+// 笔迹保存为带画布尺寸的 JSON，回放时按原画布比例还原
+func TestPrepareSignatureStrokesPayload(t *testing.T) {
+	sig := &CheckoutSignature{Strokes: [][]SignaturePoint{{{X: 1, Y: 2}, {X: 3, Y: 4, T: 20}}}, Width: 300, Height: 100}
+	blob, err := prepareSignature(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.ContentType != "application/json" || blob.Ext != ".json" {
+		t.Fatalf("content type = %s ext = %s, want application/json .json", blob.ContentType, blob.Ext)
+	}
+	var payload CheckoutSignature
+	if err := json.Unmarshal(blob.Data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Width != 300 || payload.Height != 100 || len(payload.Strokes) != 1 || payload.Strokes[0][1] != (SignaturePoint{X: 3, Y: 4, T: 20}) {
+		t.Fatalf("payload = %+v", payload)
+	}
+}
+
+// 取件失败时删除已保存的签名
+func TestDiscardSignature(t *testing.T) {
+	store := storage.NewMemory("")
+	saved := photoStore
+	photoStore = store
+	defer func() { photoStore = saved }()
+
+	ctx := context.Background()
+	blob, err := prepareSignature(&CheckoutSignature{Strokes: [][]SignaturePoint{{{X: 1, Y: 2}, {X: 3, Y: 4}}}, Width: 10, Height: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := storeSignature(ctx, blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, signatureKeyPrefix) {
+		t.Fatalf("signature key = %q, want prefix %q", key, signatureKeyPrefix)
+	}
+	if _, err := store.Stat(ctx, key); err != nil {
+		t.Fatalf("stored signature not found: %v", err)
+	}
+	discardSignature(ctx, key)
+	if _, err := store.Stat(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Stat() after discard error = %v, want ErrNotFound", err)
+	}
+	// 没有签名时什么也不做
+	discardSignature(ctx, "")
+}
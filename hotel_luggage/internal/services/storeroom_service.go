@@ -1,75 +1,143 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
+	"hotel_luggage/internal/apperr"
 	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/repositories"
 
 	"gorm.io/gorm"
 )
 
+// 默认尺寸权重（每件行李占用的容量单位数）
+const (
+	defaultWeightSmall     = 1
+	defaultWeightMedium    = 1
+	defaultWeightLarge     = 2
+	defaultWeightOversized = 3
+)
+
+// maxSizeWeight 尺寸权重上限
+const maxSizeWeight = 100
+
+// SizeWeights 寄存室的尺寸权重（为空的字段使用默认值 / 不修改）
+type SizeWeights struct {
+	Small     *int
+	Medium    *int
+	Large     *int
+	Oversized *int
+}
+
+// apply 校验并写入寄存室的尺寸权重，返回需要更新的列
+func (w SizeWeights) apply(room *models.LuggageStoreroom) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	for _, f := range []struct {
+		column string
+		value  *int
+		target *int
+	}{
+		{"weight_small", w.Small, &room.WeightSmall},
+		{"weight_medium", w.Medium, &room.WeightMedium},
+		{"weight_large", w.Large, &room.WeightLarge},
+		{"weight_oversized", w.Oversized, &room.WeightOversized},
+	} {
+		if f.value == nil {
+			continue
+		}
+		if *f.value < 1 || *f.value > maxSizeWeight {
+			return nil, apperr.InvalidRequest(fmt.Sprintf("%s must be between 1 and %d", f.column, maxSizeWeight))
+		}
+		*f.target = *f.value
+		updates[f.column] = *f.value
+	}
+	return updates, nil
+}
+
 // CreateStoreroomRequest 创建寄存室的业务输入
 type CreateStoreroomRequest struct {
 	HotelID  int64
 	Name     string
 	Location string
-	Capacity int
+	Capacity int // 容量（单位数，0 表示不限制）
 	IsActive bool
+	Weights  SizeWeights
+	// 自动分配规则（可选，默认优先级 0、不支持特殊保管、不区分取件时间）
+	Assignment UpdateStoreroomAssignmentRequest
 }
 
 // ListStorerooms 获取寄存室列表（按酒店）
 func ListStorerooms(hotelID int64) ([]models.LuggageStoreroom, error) {
 	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
+		return nil, apperr.InvalidRequest("invalid hotel id")
 	}
 	return repositories.ListStorerooms(hotelID)
 }
 
 // CreateStoreroom 创建寄存室
-func CreateStoreroom(req CreateStoreroomRequest) (models.LuggageStoreroom, error) {
+func CreateStoreroom(ctx context.Context, req CreateStoreroomRequest) (models.LuggageStoreroom, error) {
 	if req.HotelID <= 0 {
-		return models.LuggageStoreroom{}, errors.New("invalid hotel id")
+		return models.LuggageStoreroom{}, apperr.InvalidRequest("invalid hotel id")
 	}
 	if req.Name == "" {
-		return models.LuggageStoreroom{}, errors.New("name is empty")
+		return models.LuggageStoreroom{}, apperr.InvalidRequest("name is empty")
 	}
 	if req.Capacity < 0 {
-		return models.LuggageStoreroom{}, errors.New("capacity cannot be negative")
+		return models.LuggageStoreroom{}, apperr.InvalidRequest("capacity cannot be negative")
 	}
 
 	// 校验酒店是否存在
 	if _, err := repositories.GetHotelByID(req.HotelID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.LuggageStoreroom{}, errors.New("hotel not found")
+			return models.LuggageStoreroom{}, apperr.ErrHotelNotFound
 		}
 		return models.LuggageStoreroom{}, err
 	}
 
 	room := models.LuggageStoreroom{
-		HotelID:  req.HotelID,
-		Name:     req.Name,
-		Location: req.Location,
-		Capacity: req.Capacity,
-		IsActive: req.IsActive,
+		HotelID:         req.HotelID,
+		Name:            req.Name,
+		Location:        req.Location,
+		Capacity:        req.Capacity,
+		WeightSmall:     defaultWeightSmall,
+		WeightMedium:    defaultWeightMedium,
+		WeightLarge:     defaultWeightLarge,
+		WeightOversized: defaultWeightOversized,
+		PickupTerm:      models.PickupTermAny,
+		IsActive:        req.IsActive,
+	}
+	if _, err := req.Weights.apply(&room); err != nil {
+		return models.LuggageStoreroom{}, err
+	}
+	if _, err := req.Assignment.apply(&room); err != nil {
+		return models.LuggageStoreroom{}, err
 	}
 	if err := repositories.CreateStoreroom(&room); err != nil {
 		return models.LuggageStoreroom{}, err
 	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    room.HotelID,
+		EntityType: models.AuditEntityStoreroom,
+		EntityID:   room.ID,
+		Action:     models.AuditCreate,
+		After:      room,
+	})
 	return room, nil
 }
 
-// DeleteStoreroom 删除寄存室（有行李则禁止删除）
-func DeleteStoreroom(id int64) error {
+// DeleteStoreroom 删除寄存室（有行李或拾获物品则禁止删除）
+func DeleteStoreroom(ctx context.Context, id int64) error {
 	if id <= 0 {
-		return errors.New("invalid storeroom id")
+		return apperr.InvalidRequest("invalid storeroom id")
 	}
 
 	// 判断是否存在
-	_, err := repositories.GetStoreroomByID(id)
+	room, err := repositories.GetStoreroomByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("storeroom not found")
+			return apperr.ErrStoreroomNotFound
 		}
 		return err
 	}
@@ -80,25 +148,119 @@ func DeleteStoreroom(id int64) error {
 		return err
 	}
 	if count > 0 {
-		return errors.New("storeroom has luggage, cannot delete")
+		return apperr.ErrStoreroomNotEmpty.WithMessage("storeroom has luggage, evacuate it first")
+	}
+	// 仍在保管的拾获物品也要先移走
+	found, err := repositories.CountFoundItemsByStoreroom(id)
+	if err != nil {
+		return err
+	}
+	if found > 0 {
+		return apperr.ErrStoreroomNotEmpty.WithMessage("storeroom holds lost-and-found items, move them first")
 	}
 
-	return repositories.DeleteStoreroom(id)
+	if err := repositories.DeleteStoreroom(id); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    room.HotelID,
+		EntityType: models.AuditEntityStoreroom,
+		EntityID:   id,
+		Action:     models.AuditDelete,
+		Before:     room,
+	})
+	return nil
 }
 
 // UpdateStoreroomStatus 更新寄存室状态（启用/停用）
-func UpdateStoreroomStatus(id int64, isActive bool) error {
+func UpdateStoreroomStatus(ctx context.Context, id int64, isActive bool) error {
 	if id <= 0 {
-		return errors.New("invalid storeroom id")
+		return apperr.InvalidRequest("invalid storeroom id")
 	}
 
-	_, err := repositories.GetStoreroomByID(id)
+	room, err := repositories.GetStoreroomByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("storeroom not found")
+			return apperr.ErrStoreroomNotFound
 		}
 		return err
 	}
 
-	return repositories.UpdateStoreroomStatus(id, isActive)
+	if err := repositories.UpdateStoreroomStatus(id, isActive); err != nil {
+		return err
+	}
+	updated := room
+	updated.IsActive = isActive
+	recordAudit(ctx, auditEntry{
+		HotelID:    room.HotelID,
+		EntityType: models.AuditEntityStoreroom,
+		EntityID:   id,
+		Action:     models.AuditStatus,
+		Before:     room,
+		After:      updated,
+	})
+	return nil
+}
+
+// UpdateStoreroomCapacityRequest 修改寄存室容量和尺寸权重（只修改传入的字段）
+type UpdateStoreroomCapacityRequest struct {
+	Capacity *int // 容量（单位数，0 表示不限制）
+	Weights  SizeWeights
+}
+
+// UpdateStoreroomCapacity 修改寄存室容量和尺寸权重
+// 调小容量或调大权重不影响已存放的行李，只是之后寄存时按新规则校验
+func UpdateStoreroomCapacity(ctx context.Context, hotelID, id int64, req UpdateStoreroomCapacityRequest) (models.LuggageStoreroom, error) {
+	room, err := storeroomOfHotel(hotelID, id)
+	if err != nil {
+		return models.LuggageStoreroom{}, err
+	}
+	before := room
+	updates, err := req.Weights.apply(&room)
+	if err != nil {
+		return models.LuggageStoreroom{}, err
+	}
+	if req.Capacity != nil {
+		if *req.Capacity < 0 {
+			return models.LuggageStoreroom{}, apperr.InvalidRequest("capacity cannot be negative")
+		}
+		room.Capacity = *req.Capacity
+		updates["capacity"] = *req.Capacity
+	}
+	if len(updates) > 0 {
+		if err := repositories.UpdateStoreroom(id, updates); err != nil {
+			return models.LuggageStoreroom{}, err
+		}
+		recordAudit(ctx, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityStoreroom,
+			EntityID:   id,
+			Action:     models.AuditUpdate,
+			Before:     before,
+			After:      room,
+		})
+	}
+	return room, nil
+}
+
+// StoreroomUsage 寄存室占用情况
+type StoreroomUsage struct {
+	StoredUnits       int64 // 在存行李和保管中的拾获物品占用的单位数
+	RemainingCapacity int64 // 剩余单位数（不限制容量时为 -1）
+}
+
+// GetStoreroomUsage 按 件数 × 尺寸权重 统计寄存室占用情况
+func GetStoreroomUsage(room models.LuggageStoreroom) (StoreroomUsage, error) {
+	used, err := repositories.SumStoredUnitsByStoreroom(room)
+	if err != nil {
+		return StoreroomUsage{}, err
+	}
+	usage := StoreroomUsage{StoredUnits: used, RemainingCapacity: -1}
+	if room.Capacity > 0 {
+		usage.RemainingCapacity = int64(room.Capacity) - used
+		if usage.RemainingCapacity < 0 {
+			usage.RemainingCapacity = 0
+		}
+	}
+	return usage, nil
 }
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/utils"
+
+	"gorm.io/gorm"
+)
+
+// DispatchTransferRequest 发出转寄的业务输入
+type DispatchTransferRequest struct {
+	ToHotelID    int64  // 目的酒店
+	Courier      string // 承运方
+	TrackingNo   string // 运单号（可选）
+	Notes        string // 备注（可选）
+	DispatchedBy string
+}
+
+// ReceiveTransferRequest 签收转寄的业务输入
+type ReceiveTransferRequest struct {
+	StoreroomID int64 // 放入的寄存室
+	AutoAssign  bool  // 为 true 时忽略 StoreroomID，按 priority 依次放入本酒店放得下的寄存室
+	ReceivedBy  string
+}
+
+// DispatchTransfer 把取件码下所有在存行李转寄到连锁内的另一家酒店
+// 行李离开寄存室和格位，状态变为 in_transit；取件码保持不变，签收后在目的酒店继续有效
+func DispatchTransfer(ctx context.Context, hotelID int64, code string, req DispatchTransferRequest) ([]models.LuggageTransfer, error) {
+	items, _, err := storedBatch(code)
+	if err != nil {
+		return nil, err
+	}
+	if items[0].HotelID != hotelID {
+		return nil, apperr.ErrLuggageNotFound
+	}
+	courier, trackingNo := strings.TrimSpace(req.Courier), strings.TrimSpace(req.TrackingNo)
+	if courier == "" || len([]rune(courier)) > 100 {
+		return nil, apperr.InvalidRequest("courier is required (max 100 chars)")
+	}
+	if len([]rune(trackingNo)) > 100 {
+		return nil, apperr.InvalidRequest("tracking_no must be at most 100 characters")
+	}
+	if req.DispatchedBy == "" {
+		return nil, apperr.InvalidRequest("dispatched_by is empty")
+	}
+	if req.ToHotelID == hotelID {
+		return nil, apperr.InvalidRequest("cannot forward luggage to the same hotel")
+	}
+	hotel, err := repositories.GetHotelByID(req.ToHotelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrHotelNotFound.WithMessage("destination hotel not found")
+		}
+		return nil, err
+	}
+	if !hotel.IsActive {
+		return nil, apperr.ErrHotelInactive.WithMessage("destination hotel is inactive")
+	}
+
+	now := time.Now()
+	transfers := make([]models.LuggageTransfer, 0, len(items))
+	records := make([]models.LuggageUpdate, 0, 2*len(items))
+	audits := make([]auditEntry, 0, len(items))
+	names := newNameCache()
+	for _, item := range items {
+		transfers = append(transfers, models.LuggageTransfer{
+			LuggageID:       item.ID,
+			RetrievalCode:   item.RetrievalCode,
+			FromHotelID:     hotelID,
+			FromStoreroomID: item.StoreroomID,
+			ToHotelID:       req.ToHotelID,
+			Courier:         courier,
+			TrackingNo:      trackingNo,
+			Notes:           req.Notes,
+			Status:          models.TransferStatusInTransit,
+			DispatchedBy:    req.DispatchedBy,
+			DispatchedAt:    now,
+		})
+		updated := item
+		updated.Status, updated.BinID = "in_transit", nil
+		records = append(records, transferRecords(item, updated, req.DispatchedBy, req.ToHotelID, names)...)
+		audits = append(audits, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityLuggage,
+			EntityID:   item.ID,
+			Action:     models.AuditDispatch,
+			Before:     item,
+			After:      updated,
+		})
+	}
+	if err := repositories.DispatchLuggageTransfers(transfers, records); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrLuggageNotStored.WithMessage("some luggage was retrieved or moved, please retry")
+		}
+		return nil, err
+	}
+	_ = repositories.DeleteLuggageByCodeCache(items[0].RetrievalCode)
+	for _, entry := range audits {
+		recordAudit(ctx, entry)
+	}
+	return transfers, nil
+}
+
+// ReceiveTransfer 目的酒店签收转寄到本酒店的行李，放入本酒店的寄存室（校验容量、特殊保管要求并分配格位）
+func ReceiveTransfer(ctx context.Context, hotelID int64, code string, req ReceiveTransferRequest) ([]models.LuggageItem, error) {
+	if req.ReceivedBy == "" {
+		return nil, apperr.InvalidRequest("received_by is empty")
+	}
+	if !req.AutoAssign && req.StoreroomID <= 0 {
+		return nil, apperr.InvalidRequest("storeroom_id is required")
+	}
+	items, transfers, err := inTransitBatch(code, func(t models.LuggageTransfer) bool { return t.ToHotelID == hotelID })
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	if !req.AutoAssign {
+		ids = []int64{req.StoreroomID}
+	}
+	targets, err := loadPlacementTargets(hotelID, 0, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	completions := make([]repositories.TransferCompletion, 0, len(items))
+	records := make([]models.LuggageUpdate, 0, 2*len(items))
+	received := make([]models.LuggageItem, 0, len(items))
+	audits := make([]auditEntry, 0, len(items))
+	names := newNameCache()
+	for _, item := range items {
+		target, bin, _, err := placeItem(targets, item)
+		if err != nil {
+			return nil, err
+		}
+		updated := item
+		updated.Status, updated.HotelID, updated.StoreroomID, updated.BinID = "stored", hotelID, target.room.ID, nil
+		if bin != nil {
+			updated.BinID, updated.BinPath = &bin.ID, bin.Path
+		}
+		completions = append(completions, repositories.TransferCompletion{
+			TransferID: transfers[item.ID].ID,
+			Move:       repositories.LuggageMove{LuggageID: item.ID, StoreroomID: target.room.ID, BinID: updated.BinID},
+		})
+		records = append(records, transferRecords(item, updated, req.ReceivedBy, hotelID, names)...)
+		received = append(received, updated)
+		audits = append(audits, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityLuggage,
+			EntityID:   item.ID,
+			Action:     models.AuditReceive,
+			Before:     item,
+			After:      updated,
+		})
+	}
+	if err := repositories.CompleteLuggageTransfers(models.TransferStatusReceived, hotelID, req.ReceivedBy, completions, records); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrTransferNotFound.WithMessage("transfer was already received or cancelled")
+		}
+		return nil, err
+	}
+	_ = repositories.DeleteLuggageByCodeCache(items[0].RetrievalCode)
+	for _, entry := range audits {
+		recordAudit(ctx, entry)
+	}
+	return received, nil
+}
+
+// CancelTransfer 发出酒店取消转寄（如承运失败），行李退回原寄存室并恢复为 stored
+// 退回时不校验容量（行李本来就在该寄存室），有空闲格位时分配格位
+func CancelTransfer(ctx context.Context, hotelID int64, code, cancelledBy string) ([]models.LuggageItem, error) {
+	if cancelledBy == "" {
+		return nil, apperr.InvalidRequest("cancelled_by is empty")
+	}
+	items, transfers, err := inTransitBatch(code, func(t models.LuggageTransfer) bool { return t.FromHotelID == hotelID })
+	if err != nil {
+		return nil, err
+	}
+
+	layouts := map[int64]*storeroomLayout{}
+	completions := make([]repositories.TransferCompletion, 0, len(items))
+	records := make([]models.LuggageUpdate, 0, 2*len(items))
+	restored := make([]models.LuggageItem, 0, len(items))
+	audits := make([]auditEntry, 0, len(items))
+	names := newNameCache()
+	for _, item := range items {
+		transfer := transfers[item.ID]
+		room, err := repositories.GetStoreroomByID(transfer.FromStoreroomID)
+		if err != nil {
+			return nil, err
+		}
+		layout, ok := layouts[room.ID]
+		if !ok {
+			if layout, err = loadStoreroomLayout(room.ID); err != nil {
+				return nil, err
+			}
+			layouts[room.ID] = layout
+		}
+		units := room.LuggageUnits(item.Quantity, item.SizeClass)
+		bin, err := layout.suggestBin(units)
+		if err != nil && !errors.Is(err, apperr.ErrNoFreeBin) {
+			return nil, err
+		}
+		updated := item
+		updated.Status, updated.StoreroomID, updated.BinID = "stored", room.ID, nil
+		if bin != nil {
+			layout.place(bin.ID, units)
+			updated.BinID, updated.BinPath = &bin.ID, bin.Path
+		}
+		completions = append(completions, repositories.TransferCompletion{
+			TransferID: transfer.ID,
+			Move:       repositories.LuggageMove{LuggageID: item.ID, StoreroomID: room.ID, BinID: updated.BinID},
+		})
+		records = append(records, transferRecords(item, updated, cancelledBy, transfer.ToHotelID, names)...)
+		restored = append(restored, updated)
+		audits = append(audits, auditEntry{
+			HotelID:    hotelID,
+			EntityType: models.AuditEntityLuggage,
+			EntityID:   item.ID,
+			Action:     models.AuditCancel,
+			Before:     item,
+			After:      updated,
+		})
+	}
+	if err := repositories.CompleteLuggageTransfers(models.TransferStatusCancelled, hotelID, cancelledBy, completions, records); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrTransferNotFound.WithMessage("transfer was already received or cancelled")
+		}
+		return nil, err
+	}
+	_ = repositories.DeleteLuggageByCodeCache(items[0].RetrievalCode)
+	for _, entry := range audits {
+		recordAudit(ctx, entry)
+	}
+	return restored, nil
+}
+
+// ListTransfers 查询本酒店发出（outgoing）或收到（incoming）的转寄记录
+func ListTransfers(hotelID int64, direction, status string) ([]models.LuggageTransfer, error) {
+	if direction != "" && direction != "incoming" && direction != "outgoing" {
+		return nil, apperr.InvalidRequest("direction must be incoming or outgoing")
+	}
+	switch status {
+	case "", models.TransferStatusInTransit, models.TransferStatusReceived, models.TransferStatusCancelled:
+	default:
+		return nil, apperr.InvalidRequest("status must be one of in_transit, received, cancelled")
+	}
+	transfers, err := repositories.ListTransfersByHotel(hotelID, direction, status)
+	if err != nil {
+		return nil, err
+	}
+	if transfers == nil {
+		transfers = []models.LuggageTransfer{}
+	}
+	return transfers, nil
+}
+
+// ListTransfersByCode 查询取件码的转寄记录（只返回本酒店发出或收到的）
+func ListTransfersByCode(hotelID int64, code string) ([]models.LuggageTransfer, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return nil, apperr.InvalidRequest("code is empty")
+	}
+	all, err := repositories.ListTransfersByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	transfers := []models.LuggageTransfer{}
+	for _, t := range all {
+		if t.FromHotelID == hotelID || t.ToHotelID == hotelID {
+			transfers = append(transfers, t)
+		}
+	}
+	if len(transfers) == 0 {
+		return nil, apperr.ErrTransferNotFound.WithMessage("no transfer for this retrieval code")
+	}
+	return transfers, nil
+}
+
+// inTransitBatch 返回取件码下转寄途中的行李，以及每件行李对应的在途转寄记录（按 match 过滤酒店）
+func inTransitBatch(code string, match func(models.LuggageTransfer) bool) ([]models.LuggageItem, map[int64]models.LuggageTransfer, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return nil, nil, apperr.InvalidRequest("code is empty")
+	}
+	all, err := repositories.ListTransfersByCode(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := repositories.FindLuggageByCode(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	return matchInTransit(all, items, match)
+}
+
+// matchInTransit 只保留状态为 in_transit 且有匹配的在途转寄记录的行李
+func matchInTransit(all []models.LuggageTransfer, items []models.LuggageItem, match func(models.LuggageTransfer) bool) ([]models.LuggageItem, map[int64]models.LuggageTransfer, error) {
+	transfers := map[int64]models.LuggageTransfer{}
+	for _, t := range all {
+		if t.Status == models.TransferStatusInTransit && match(t) {
+			transfers[t.LuggageID] = t
+		}
+	}
+	var inTransit []models.LuggageItem
+	for _, item := range items {
+		if _, ok := transfers[item.ID]; ok && item.Status == "in_transit" {
+			inTransit = append(inTransit, item)
+		}
+	}
+	if len(inTransit) == 0 {
+		return nil, nil, apperr.ErrTransferNotFound
+	}
+	return inTransit, transfers, nil
+}
+
+// transferRecords 转寄的修改记录：发出酒店和目的酒店各写一条，两边都能在修改记录中看到完整经过
+func transferRecords(item, updated models.LuggageItem, by string, otherHotelID int64, names *nameCache) []models.LuggageUpdate {
+	record := luggageUpdateRecord(item, updated, by, names)
+	other := record
+	other.HotelID = otherHotelID
+	return []models.LuggageUpdate{record, other}
+}
+
+// notStoredError 取件码下没有在存行李时的错误：有行李正在转寄时提示转寄中
+func notStoredError(items []models.LuggageItem) error {
+	for _, item := range items {
+		if item.Status == "in_transit" {
+			return apperr.ErrLuggageInTransit
+		}
+	}
+	return apperr.ErrLuggageNotStored
+}
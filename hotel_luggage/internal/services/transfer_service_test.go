@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+)
+
+func TestMatchInTransit(t *testing.T) {
+	transfer := func(id, luggageID int64, status string) models.LuggageTransfer {
+		return models.LuggageTransfer{ID: id, LuggageID: luggageID, FromHotelID: 1, ToHotelID: 2, Status: status}
+	}
+	toHotel2 := func(t models.LuggageTransfer) bool { return t.ToHotelID == 2 }
+	fromHotel1 := func(t models.LuggageTransfer) bool { return t.FromHotelID == 1 }
+	otherHotel := func(t models.LuggageTransfer) bool { return t.ToHotelID == 3 }
+
+	tests := []struct {
+		name      string
+		transfers []models.LuggageTransfer
+		items     []models.LuggageItem
+		match     func(models.LuggageTransfer) bool
+		want      map[int64]int64 // 行李ID → 转寄记录ID
+		wantErr   error
+	}{
+		{name: "receive", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusInTransit), transfer(11, 2, models.TransferStatusInTransit)},
+			items: []models.LuggageItem{{ID: 1, Status: "in_transit"}, {ID: 2, Status: "in_transit"}}, match: toHotel2, want: map[int64]int64{1: 10, 2: 11}},
+		{name: "cancel", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusInTransit)},
+			items: []models.LuggageItem{{ID: 1, Status: "in_transit"}}, match: fromHotel1, want: map[int64]int64{1: 10}},
+		// 同一件行李转寄过多次，只取在途的那条记录
+		{name: "earlier transfers", transfers: []models.LuggageTransfer{
+			transfer(5, 1, models.TransferStatusReceived), transfer(7, 1, models.TransferStatusCancelled), transfer(10, 1, models.TransferStatusInTransit),
+		}, items: []models.LuggageItem{{ID: 1, Status: "in_transit"}}, match: toHotel2, want: map[int64]int64{1: 10}},
+		// 同一取件码下没有转寄的行李不受影响
+		{name: "mixed batch", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusInTransit)},
+			items: []models.LuggageItem{{ID: 1, Status: "in_transit"}, {ID: 2, Status: "stored"}}, match: toHotel2, want: map[int64]int64{1: 10}},
+		{name: "already received", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusReceived)},
+			items: []models.LuggageItem{{ID: 1, Status: "stored"}}, match: toHotel2, wantErr: apperr.ErrTransferNotFound},
+		{name: "already cancelled", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusCancelled)},
+			items: []models.LuggageItem{{ID: 1, Status: "stored"}}, match: fromHotel1, wantErr: apperr.ErrTransferNotFound},
+		// 转寄记录在途但行李状态已变化（如并发签收）时不处理
+		{name: "luggage not in transit", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusInTransit)},
+			items: []models.LuggageItem{{ID: 1, Status: "stored"}}, match: toHotel2, wantErr: apperr.ErrTransferNotFound},
+		// 其他酒店的转寄不能签收或取消
+		{name: "other hotel", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusInTransit)},
+			items: []models.LuggageItem{{ID: 1, Status: "in_transit"}}, match: otherHotel, wantErr: apperr.ErrTransferNotFound},
+		{name: "no luggage", transfers: []models.LuggageTransfer{transfer(10, 1, models.TransferStatusInTransit)}, match: toHotel2, wantErr: apperr.ErrTransferNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, transfers, err := matchInTransit(tt.transfers, tt.items, tt.match)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("matchInTransit() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchInTransit() error = %v", err)
+			}
+			got := map[int64]int64{}
+			for _, item := range items {
+				got[item.ID] = transfers[item.ID].ID
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("matchInTransit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotStoredError(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []models.LuggageItem
+		want  error
+	}{
+		{name: "in transit", items: []models.LuggageItem{{ID: 1, Status: "retrieved"}, {ID: 2, Status: "in_transit"}}, want: apperr.ErrLuggageInTransit},
+		{name: "not stored", items: []models.LuggageItem{{ID: 1, Status: "retrieved"}}, want: apperr.ErrLuggageNotStored},
+		{name: "no luggage", want: apperr.ErrLuggageNotStored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := notStoredError(tt.items); !errors.Is(err, tt.want) {
+				t.Fatalf("notStoredError() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
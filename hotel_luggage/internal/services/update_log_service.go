@@ -0,0 +1,268 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// luggageField 寄存单中记录修改的字段（name 为数据库列名，value 取出用于比较和展示的值）
+type luggageField struct {
+	name  string
+	value func(item models.LuggageItem) interface{}
+}
+
+// luggageFields 按展示顺序列出会记录修改的字段（ID、时间戳、只用于响应的字段不参与比较）
+var luggageFields = []luggageField{
+	{"guest_name", func(i models.LuggageItem) interface{} { return i.GuestName }},
+	{"contact_phone", func(i models.LuggageItem) interface{} { return i.ContactPhone }},
+	{"contact_email", func(i models.LuggageItem) interface{} { return i.ContactEmail }},
+	{"description", func(i models.LuggageItem) interface{} { return i.Description }},
+	{"quantity", func(i models.LuggageItem) interface{} { return i.Quantity }},
+	{"size_class", func(i models.LuggageItem) interface{} { return i.SizeClass }},
+	{"special_notes", func(i models.LuggageItem) interface{} { return i.SpecialNotes }},
+	{"handling", func(i models.LuggageItem) interface{} { return i.Handling }},
+	{"expected_pickup_at", func(i models.LuggageItem) interface{} { return i.ExpectedPickupAt }},
+	{"photo_url", func(i models.LuggageItem) interface{} { return i.PhotoURL }},
+	{"photo_urls", func(i models.LuggageItem) interface{} {
+		if i.PhotoURLs == nil {
+			return []string{}
+		}
+		return i.PhotoURLs
+	}},
+	{"hotel_id", func(i models.LuggageItem) interface{} { return i.HotelID }},
+	{"storeroom_id", func(i models.LuggageItem) interface{} { return i.StoreroomID }},
+	{"bin_id", func(i models.LuggageItem) interface{} { return i.BinID }},
+	{"retrieval_code", func(i models.LuggageItem) interface{} { return i.RetrievalCode }},
+	{"code_expires_at", func(i models.LuggageItem) interface{} { return i.CodeExpiresAt }},
+	{"qr_code_url", func(i models.LuggageItem) interface{} { return i.QRCodeURL }},
+	{"status", func(i models.LuggageItem) interface{} { return i.Status }},
+	{"stored_by", func(i models.LuggageItem) interface{} { return i.StoredBy }},
+	{"retrieved_by", func(i models.LuggageItem) interface{} { return i.RetrievedBy }},
+	{"retrieved_at", func(i models.LuggageItem) interface{} { return i.RetrievedAt }},
+}
+
+// IsLuggageField 是否为修改记录中的字段名
+func IsLuggageField(name string) bool {
+	for _, f := range luggageFields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateLogQuery 查询寄存单修改记录的条件
+type UpdateLogQuery struct {
+	LuggageID int64  // 只看某件行李（0 表示全部）
+	Field     string // 只看修改了该字段的记录（数据库列名，如 storeroom_id）
+}
+
+// LuggageTimeline 单件行李的修改时间线
+type LuggageTimeline struct {
+	LuggageID int64                  `json:"luggage_id"`
+	Current   *models.LuggageItem    `json:"current,omitempty"` // 当前寄存单（已取件的行李为空）
+	Original  *models.LuggageItem    `json:"original"`          // 版本 0：第一次修改前的寄存信息（没有修改记录时为当前寄存单）
+	Revisions []models.LuggageUpdate `json:"revisions"`         // 按时间顺序的修改记录（revision 从 1 开始）
+}
+
+// luggageChanges 比较修改前后的寄存单，返回字段级变更（寄存室、酒店、格位附带可读名称）
+// names 用于在一次请求内缓存寄存室和酒店名称，可为 nil
+func luggageChanges(before, after models.LuggageItem, names *nameCache) []models.FieldChange {
+	if names == nil {
+		names = newNameCache()
+	}
+	changes := []models.FieldChange{}
+	for _, f := range luggageFields {
+		oldValue, newValue := f.value(before), f.value(after)
+		oldJSON, _ := json.Marshal(oldValue)
+		newJSON, _ := json.Marshal(newValue)
+		if string(oldJSON) == string(newJSON) {
+			continue
+		}
+		change := models.FieldChange{Field: f.name, Old: oldValue, New: newValue}
+		switch f.name {
+		case "storeroom_id":
+			change.OldLabel, change.NewLabel = names.storeroom(before.StoreroomID), names.storeroom(after.StoreroomID)
+		case "hotel_id":
+			change.OldLabel, change.NewLabel = names.hotel(before.HotelID), names.hotel(after.HotelID)
+		case "bin_id":
+			change.OldLabel, change.NewLabel = before.BinPath, after.BinPath
+			if change.OldLabel == "" {
+				change.OldLabel = names.bin(before.StoreroomID, before.BinID)
+			}
+			if change.NewLabel == "" {
+				change.NewLabel = names.bin(after.StoreroomID, after.BinID)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// changedFieldNames 把变更的字段名拼成逗号分隔的字符串
+func changedFieldNames(changes []models.FieldChange) string {
+	names := make([]string, len(changes))
+	for i, change := range changes {
+		names[i] = change.Field
+	}
+	return strings.Join(names, ",")
+}
+
+// nameCache 缓存寄存室、酒店名称和格位位置（查询失败时名称为空，不影响修改记录）
+type nameCache struct {
+	storerooms map[int64]string
+	hotels     map[int64]string
+	layouts    map[int64]*storeroomLayout
+}
+
+func newNameCache() *nameCache {
+	return &nameCache{storerooms: map[int64]string{}, hotels: map[int64]string{}, layouts: map[int64]*storeroomLayout{}}
+}
+
+func (n *nameCache) storeroom(id int64) string {
+	if id <= 0 {
+		return ""
+	}
+	if name, ok := n.storerooms[id]; ok {
+		return name
+	}
+	room, err := repositories.GetStoreroomByID(id)
+	if err == nil {
+		n.storerooms[id] = room.Name
+	}
+	return room.Name
+}
+
+func (n *nameCache) hotel(id int64) string {
+	if id <= 0 {
+		return ""
+	}
+	if name, ok := n.hotels[id]; ok {
+		return name
+	}
+	hotel, err := repositories.GetHotelByID(id)
+	if err == nil {
+		n.hotels[id] = hotel.Name
+	}
+	return hotel.Name
+}
+
+func (n *nameCache) bin(storeroomID int64, binID *int64) string {
+	if binID == nil || storeroomID <= 0 {
+		return ""
+	}
+	layout, ok := n.layouts[storeroomID]
+	if !ok {
+		layout, _ = loadStoreroomLayout(storeroomID)
+		n.layouts[storeroomID] = layout
+	}
+	if layout == nil {
+		return ""
+	}
+	return layout.path(*binID)
+}
+
+// fillLegacyChanges 为功能上线前写入的修改记录（没有 changes 字段）根据前后快照补算字段级变更
+func fillLegacyChanges(records []models.LuggageUpdate, names *nameCache) {
+	for i := range records {
+		if records[i].ChangesRaw != "" {
+			continue
+		}
+		var before, after models.LuggageItem
+		if json.Unmarshal([]byte(records[i].OldData), &before) != nil || json.Unmarshal([]byte(records[i].NewData), &after) != nil {
+			records[i].Changes = []models.FieldChange{}
+			continue
+		}
+		records[i].Changes = luggageChanges(before, after, names)
+		records[i].ChangedFields = changedFieldNames(records[i].Changes)
+	}
+}
+
+// ListLuggageUpdates 查询本酒店的寄存单修改记录（按修改时间倒序），可按行李和修改的字段筛选
+func ListLuggageUpdates(hotelID int64, query UpdateLogQuery) ([]models.LuggageUpdate, error) {
+	if hotelID <= 0 {
+		return nil, apperr.InvalidRequest("invalid hotel id")
+	}
+	if query.Field != "" && !IsLuggageField(query.Field) {
+		return nil, apperr.InvalidRequest("unknown field " + query.Field)
+	}
+	records, err := repositories.ListLuggageUpdates(repositories.LuggageUpdateFilter{
+		HotelID:   hotelID,
+		LuggageID: query.LuggageID,
+		Field:     query.Field,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fillLegacyChanges(records, newNameCache())
+	// 旧记录没有 changed_fields，数据库按字段筛选时会一并返回，这里按补算结果再过滤一次
+	if query.Field != "" {
+		filtered := records[:0]
+		for _, record := range records {
+			if hasChangedField(record, query.Field) {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+	if records == nil {
+		records = []models.LuggageUpdate{}
+	}
+	return records, nil
+}
+
+// hasChangedField 修改记录是否修改了字段 field
+func hasChangedField(record models.LuggageUpdate, field string) bool {
+	for _, change := range record.Changes {
+		if change.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLuggageTimeline 获取单件行李在本酒店的修改时间线：版本 0 为原始寄存信息，之后每条修改记录为一个版本
+// 已取件的行李只要本酒店有修改记录仍可查看
+func GetLuggageTimeline(hotelID, luggageID int64) (LuggageTimeline, error) {
+	if luggageID <= 0 {
+		return LuggageTimeline{}, apperr.InvalidRequest("invalid luggage id")
+	}
+	timeline := LuggageTimeline{LuggageID: luggageID, Revisions: []models.LuggageUpdate{}}
+	item, err := repositories.GetLuggageByID(luggageID)
+	switch {
+	case err == nil && item.HotelID == hotelID:
+		timeline.Current = &item
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return LuggageTimeline{}, err
+	}
+
+	records, err := repositories.ListLuggageRevisions(hotelID, luggageID)
+	if err != nil {
+		return LuggageTimeline{}, err
+	}
+	if len(records) == 0 {
+		if timeline.Current == nil {
+			return LuggageTimeline{}, apperr.ErrLuggageNotFound
+		}
+		timeline.Original = timeline.Current
+		return timeline, nil
+	}
+	fillLegacyChanges(records, newNameCache())
+	for i := range records {
+		records[i].Revision = i + 1
+	}
+	var original models.LuggageItem
+	if err := json.Unmarshal([]byte(records[0].OldData), &original); err != nil {
+		return LuggageTimeline{}, err
+	}
+	timeline.Original = &original
+	timeline.Revisions = records
+	return timeline, nil
+}
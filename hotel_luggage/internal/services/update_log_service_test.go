@@ -0,0 +1,121 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"hotel_luggage/internal/models"
+)
+
+// testNameCache 预先填好名称的缓存，测试时不查询数据库
+func testNameCache() *nameCache {
+	names := newNameCache()
+	names.storerooms[1], names.storerooms[2] = "A 区", "B 区"
+	names.hotels[10], names.hotels[20] = "总店", "分店"
+	names.layouts[1], names.layouts[2] = nil, nil
+	return names
+}
+
+func TestLuggageChanges(t *testing.T) {
+	pickup := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	bin3, bin4 := int64(3), int64(4)
+	base := models.LuggageItem{
+		ID:            42,
+		HotelID:       10,
+		StoreroomID:   1,
+		GuestName:     "张三",
+		ContactPhone:  "13800000000",
+		Quantity:      1,
+		SizeClass:     models.SizeMedium,
+		RetrievalCode: "123455",
+		Status:        "stored",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*models.LuggageItem)
+		want   []models.FieldChange
+	}{
+		{name: "no change", want: []models.FieldChange{}},
+		// 不参与比较的字段（ID、更新时间）不记录
+		{name: "untracked fields", modify: func(i *models.LuggageItem) { i.ID = 43; i.UpdatedAt = pickup }, want: []models.FieldChange{}},
+		{name: "guest info in display order", modify: func(i *models.LuggageItem) {
+			i.ContactPhone = "13900000000"
+			i.GuestName = "李四"
+		}, want: []models.FieldChange{
+			{Field: "guest_name", Old: "张三", New: "李四"},
+			{Field: "contact_phone", Old: "13800000000", New: "13900000000"},
+		}},
+		{name: "quantity and size", modify: func(i *models.LuggageItem) {
+			i.Quantity = 2
+			i.SizeClass = models.SizeLarge
+		}, want: []models.FieldChange{
+			{Field: "quantity", Old: 1, New: 2},
+			{Field: "size_class", Old: models.SizeMedium, New: models.SizeLarge},
+		}},
+		{name: "expected pickup set", modify: func(i *models.LuggageItem) { i.ExpectedPickupAt = &pickup }, want: []models.FieldChange{
+			{Field: "expected_pickup_at", Old: (*time.Time)(nil), New: &pickup},
+		}},
+		// 照片列表 nil 与空列表相同
+		{name: "nil photo list", modify: func(i *models.LuggageItem) { i.PhotoURLs = []string{} }, want: []models.FieldChange{}},
+		{name: "photo list", modify: func(i *models.LuggageItem) { i.PhotoURLs = []string{"2026/01/a.jpg"} }, want: []models.FieldChange{
+			{Field: "photo_urls", Old: []string{}, New: []string{"2026/01/a.jpg"}},
+		}},
+		{name: "storeroom with labels", modify: func(i *models.LuggageItem) { i.StoreroomID = 2 }, want: []models.FieldChange{
+			{Field: "storeroom_id", Old: int64(1), New: int64(2), OldLabel: "A 区", NewLabel: "B 区"},
+		}},
+		{name: "transfer to another hotel", modify: func(i *models.LuggageItem) { i.HotelID = 20 }, want: []models.FieldChange{
+			{Field: "hotel_id", Old: int64(10), New: int64(20), OldLabel: "总店", NewLabel: "分店"},
+		}},
+		// 格位标签优先使用快照中的位置
+		{name: "bin path from snapshot", modify: func(i *models.LuggageItem) {
+			i.BinID, i.BinPath = &bin4, "1-2-4"
+		}, want: []models.FieldChange{
+			{Field: "bin_id", Old: (*int64)(nil), New: &bin4, NewLabel: "1-2-4"},
+		}},
+		{name: "code reissued", modify: func(i *models.LuggageItem) { i.RetrievalCode = "654323" }, want: []models.FieldChange{
+			{Field: "retrieval_code", Old: "123455", New: "654323"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			if tt.modify != nil {
+				tt.modify(&after)
+			}
+			got := luggageChanges(base, after, testNameCache())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("luggageChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("same bin", func(t *testing.T) {
+		before, after := base, base
+		before.BinID, after.BinID = &bin3, &bin3
+		before.BinPath = "1-1-3"
+		if got := luggageChanges(before, after, testNameCache()); len(got) != 0 {
+			t.Fatalf("luggageChanges() = %+v, want no changes", got)
+		}
+	})
+}
+
+func TestChangedFieldNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []models.FieldChange
+		want    string
+	}{
+		{name: "none", changes: []models.FieldChange{}, want: ""},
+		{name: "one", changes: []models.FieldChange{{Field: "guest_name"}}, want: "guest_name"},
+		{name: "several", changes: []models.FieldChange{{Field: "quantity"}, {Field: "storeroom_id"}, {Field: "bin_id"}}, want: "quantity,storeroom_id,bin_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedFieldNames(tt.changes); got != tt.want {
+				t.Fatalf("changedFieldNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/storage"
+)
+
+// uploadGCBatchSize 每批检查的上传记录数
+const uploadGCBatchSize = 100
+
+// OrphanUpload 未被任何寄存单 / 取件历史 / 拾获物品 / 事故报告引用的上传
+type OrphanUpload struct {
+	Key              string     `json:"key"`
+	VariantKeys      []string   `json:"variant_keys,omitempty"`
+	Size             int64      `json:"size"`
+	UploadedBy       string     `json:"uploaded_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastReferencedAt *time.Time `json:"last_referenced_at,omitempty"`
+	Deleted          bool       `json:"deleted"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// UploadGCReport 一轮清理的结果
+type UploadGCReport struct {
+	DryRun     bool           `json:"dry_run"`     // 只统计，不删除
+	Cutoff     time.Time      `json:"cutoff"`      // 早于该时间上传且此后未被引用的照片才会被清理
+	Scanned    int            `json:"scanned"`     // 检查的上传记录数
+	Referenced int            `json:"referenced"`  // 仍被引用（保留）
+	Deleted    int            `json:"deleted"`     // 已删除
+	Failed     int            `json:"failed"`      // 删除失败（下一轮重试）
+	FreedBytes int64          `json:"freed_bytes"` // 删除（dry-run 时为可删除）的字节数
+	Orphans    []OrphanUpload `json:"orphans"`     // 未被引用的上传
+}
+
+// RecordUpload 记录一次上传（原图 + 缩略图），供清理任务判断是否被引用
+func RecordUpload(ctx context.Context, info storage.ObjectInfo, variantKeys []string, size int64, uploadedBy string) error {
+	record := models.UploadRecord{
+		ObjectKey:   info.Key,
+		VariantKeys: variantKeys,
+		ContentType: info.ContentType,
+		Size:        size,
+		UploadedBy:  uploadedBy,
+	}
+	if err := repositories.CreateUploadRecord(&record); err != nil {
+		return err
+	}
+	recordAudit(ctx, auditEntry{
+		HotelID:    0,
+		EntityType: models.AuditEntityUpload,
+		EntityID:   record.ObjectKey,
+		Action:     models.AuditCreate,
+		After:      record,
+	})
+	return nil
+}
+
+// touchPhotoRefs 刷新照片的最近引用时间（外部地址会被忽略，失败只记录日志）
+func touchPhotoRefs(refs ...string) {
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if key, ok := storage.KeyFromURL(ref); ok {
+			keys = append(keys, key)
+		}
+	}
+	if err := repositories.TouchUploadRecords(keys); err != nil {
+		log.Printf("⚠️  更新照片引用时间失败: %v", err)
+	}
+}
+
+// referencedPhotoKeys 从粗筛出的照片地址中精确匹配仍被引用的 key
+// 数据库中可能保存旧版本的完整地址、相对地址或带签名参数的地址，统一转换为对象 key 后比较；外部地址忽略
+func referencedPhotoKeys(keys, refs []string) map[string]bool {
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+	referenced := make(map[string]bool, len(keys))
+	for _, ref := range refs {
+		if key, ok := storage.KeyFromURL(ref); ok && wanted[key] {
+			referenced[key] = true
+		}
+	}
+	return referenced
+}
+
+// CollectOrphanUploads 清理未被引用的上传
+// 上传时间和最近引用时间都早于 now-grace、且不再被 luggage_items / luggage_history / found_items / luggage_incidents 引用的照片会连同缩略图一起删除
+// dryRun 为 true 时只生成报告，不删除任何文件
+func CollectOrphanUploads(ctx context.Context, store storage.BlobStore, grace time.Duration, dryRun bool) (UploadGCReport, error) {
+	report := UploadGCReport{DryRun: dryRun, Cutoff: time.Now().Add(-grace), Orphans: []OrphanUpload{}}
+	var afterID int64
+	for {
+		records, err := repositories.ListUploadGCCandidates(report.Cutoff, afterID, uploadGCBatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(records) == 0 {
+			return report, nil
+		}
+		afterID = records[len(records)-1].ID
+
+		keys := make([]string, len(records))
+		for i, record := range records {
+			keys[i] = record.ObjectKey
+		}
+		refs, err := repositories.FindPhotoRefsLike(keys)
+		if err != nil {
+			return report, err
+		}
+		referenced := referencedPhotoKeys(keys, refs)
+
+		var stillReferenced []string
+		for _, record := range records {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			report.Scanned++
+			if referenced[record.ObjectKey] {
+				report.Referenced++
+				stillReferenced = append(stillReferenced, record.ObjectKey)
+				continue
+			}
+
+			orphan := OrphanUpload{
+				Key:              record.ObjectKey,
+				VariantKeys:      record.VariantKeys,
+				Size:             record.Size,
+				UploadedBy:       record.UploadedBy,
+				CreatedAt:        record.CreatedAt,
+				LastReferencedAt: record.LastReferencedAt,
+			}
+			report.FreedBytes += record.Size
+			if dryRun {
+				report.Orphans = append(report.Orphans, orphan)
+				continue
+			}
+
+			err := deleteUploadObjects(ctx, store, append([]string{record.ObjectKey}, record.VariantKeys...))
+			if err == nil {
+				err = repositories.MarkUploadRecordDeleted(record.ID)
+			}
+			if errors.Is(err, storage.ErrUnavailable) {
+				report.FreedBytes -= record.Size
+				return report, err
+			}
+			if err != nil {
+				report.Failed++
+				report.FreedBytes -= record.Size
+				orphan.Error = err.Error()
+				log.Printf("⚠️  清理上传文件失败 %s: %v", record.ObjectKey, err)
+			} else {
+				report.Deleted++
+				orphan.Deleted = true
+				recordAudit(ctx, auditEntry{
+					HotelID:    0,
+					EntityType: models.AuditEntityUpload,
+					EntityID:   record.ObjectKey,
+					Action:     models.AuditCollect,
+					Before:     record,
+				})
+			}
+			report.Orphans = append(report.Orphans, orphan)
+		}
+
+		// 仍被引用的照片刷新引用时间，宽限期内不再重复检查
+		if !dryRun && len(stillReferenced) > 0 {
+			if err := repositories.TouchUploadRecords(stillReferenced); err != nil {
+				return report, err
+			}
+		}
+		if len(records) < uploadGCBatchSize {
+			return report, nil
+		}
+	}
+}
+
+// deleteUploadObjects 删除对象（不存在时忽略）
+// 主备存储需要两边都删除：MinIO 不可用时返回 storage.ErrUnavailable，避免只删掉本地副本后把记录标记为已删除
+func deleteUploadObjects(ctx context.Context, store storage.BlobStore, keys []string) error {
+	stores := []storage.BlobStore{store}
+	if fallback, ok := store.(*storage.FallbackStore); ok {
+		stores = []storage.BlobStore{fallback.Primary(), fallback.Secondary()}
+	}
+	for _, s := range stores {
+		for _, key := range keys {
+			if err := s.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StartUploadGC 启动后台清理任务：每隔 interval 删除超过宽限期仍未被引用的上传
+// ctx 取消后退出；返回的函数会阻塞到清理协程退出为止
+func StartUploadGC(ctx context.Context, store storage.BlobStore, interval, grace time.Duration) (wait func()) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			report, err := CollectOrphanUploads(ctx, store, grace, false)
+			if err != nil && !errors.Is(err, storage.ErrUnavailable) && !errors.Is(err, context.Canceled) {
+				log.Printf("⚠️  上传清理任务出错: %v", err)
+			}
+			if report.Deleted > 0 || report.Failed > 0 {
+				log.Printf("✅ 已清理 %d 个未被引用的上传（%d 字节，失败 %d 个）", report.Deleted, report.FreedBytes, report.Failed)
+			}
+		}
+	}()
+	return wg.Wait
+}
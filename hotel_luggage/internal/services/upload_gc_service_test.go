@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"hotel_luggage/internal/storage"
+)
+
+func TestReferencedPhotoKeys(t *testing.T) {
+	keys := []string{"2026/01/a.jpg", "2026/01/b.jpg", "2026/01/c.png"}
+	tests := []struct {
+		name string
+		refs []string
+		want []string
+	}{
+		{name: "object key", refs: []string{"2026/01/a.jpg"}, want: []string{"2026/01/a.jpg"}},
+		// 旧版本保存的完整地址、相对地址和带签名参数的地址
+		{name: "legacy urls", refs: []string{
+			"http://host/uploads/2026/01/a.jpg",
+			"/uploads/2026/01/b.jpg?expires=1&sig=x",
+			"http://minio:9000/luggage/uploads/2026/01/c.png",
+		}, want: keys},
+		// LIKE 粗筛会把包含 key 的其他照片也查出来，精确匹配时排除
+		{name: "longer key containing the key", refs: []string{"2026/01/a.jpg.bak", "x2026/01/a.jpg", "2026/01/a.jpg_small.jpg"}},
+		{name: "external url", refs: []string{"https://cdn.example.com/2026/01/a.jpg"}},
+		{name: "empty refs", refs: []string{"", " "}},
+		{name: "key not in batch", refs: []string{"2026/02/a.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			referenced := referencedPhotoKeys(keys, tt.refs)
+			got := make([]string, 0, len(referenced))
+			for key := range referenced {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("referencedPhotoKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// unavailableStore 模拟 MinIO 不可用
+type unavailableStore struct {
+	*storage.MemoryStore
+}
+
+func (unavailableStore) Delete(ctx context.Context, key string) error {
+	return storage.ErrUnavailable
+}
+
+func TestDeleteUploadObjects(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"2026/01/a.jpg", "2026/01/a_small.jpg"}
+	put := func(t *testing.T, store *storage.MemoryStore, keys ...string) {
+		for _, key := range keys {
+			if _, err := store.Put(ctx, key, strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tests := []struct {
+		name        string
+		primaryDown bool
+		wantErr     error
+		wantLocal   bool // 本地副本是否保留
+	}{
+		// 主备存储中的原图和缩略图都删除
+		{name: "both stores"},
+		// MinIO 不可用时不能只删本地副本，记录保持未删除，下一轮重试
+		{name: "primary unavailable", primaryDown: true, wantErr: storage.ErrUnavailable, wantLocal: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, local := storage.NewMemory(""), storage.NewMemory("")
+			put(t, primary, keys...)
+			put(t, local, keys...)
+			var store storage.BlobStore = storage.NewFallback(primary, local)
+			if tt.primaryDown {
+				store = storage.NewFallback(unavailableStore{primary}, local)
+			}
+			err := deleteUploadObjects(ctx, store, keys)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("deleteUploadObjects() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, key := range keys {
+				if _, err := local.Stat(ctx, key); (err == nil) != tt.wantLocal {
+					t.Fatalf("local %s exists = %v, want %v", key, err == nil, tt.wantLocal)
+				}
+				if !tt.primaryDown {
+					if _, err := primary.Stat(ctx, key); err == nil {
+						t.Fatalf("primary %s was not deleted", key)
+					}
+				}
+			}
+		})
+	}
+	// 已不存在的对象视为删除成功
+	if err := deleteUploadObjects(ctx, storage.NewMemory(""), keys); err != nil {
+		t.Fatalf("deleteUploadObjects() on missing objects error = %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/internal/storage"
+)
+
+// LocalUploadPath 本地存储文件的相对访问路径前缀（与 router 中的 /uploads 静态目录一致）
+const LocalUploadPath = "/uploads/"
+
+// uploadSyncBatchSize 每轮同步处理的记录数
+const uploadSyncBatchSize = 50
+
+// UploadSyncResult 一轮同步的结果
+type UploadSyncResult struct {
+	Synced  int `json:"synced"`  // 同步成功
+	Failed  int `json:"failed"`  // 同步失败（会重试）
+	Missing int `json:"missing"` // 本地文件已丢失（标记为 failed，不再重试）
+}
+
+// RecordFallbackUpload 记录一个降级写入本地的上传，等待 MinIO 恢复后同步
+// localURL: 上传接口返回给前端的地址（前端可能把它写进 photo_url / photo_urls）
+func RecordFallbackUpload(info storage.ObjectInfo, localURL string) error {
+	return repositories.CreatePendingUpload(&models.PendingUpload{
+		ObjectKey:   info.Key,
+		LocalURL:    localURL,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+	})
+}
+
+// EnqueueLocalUploads 把本地目录中已有的文件全部登记为待同步（用于迁移历史 uploads 目录）
+// 已登记的文件会被忽略，返回扫描到的文件数
+func EnqueueLocalUploads(ctx context.Context, local *storage.LocalStore) (int, error) {
+	count := 0
+	err := local.Walk(ctx, func(info storage.ObjectInfo) error {
+		count++
+		return RecordFallbackUpload(info, local.URL(info.Key))
+	})
+	return count, err
+}
+
+// SyncPendingUploads 把待同步的本地文件推送到主存储（MinIO）
+// 流程（每个文件）：
+// 1. 从本地读取文件并写入 MinIO
+// 2. 把 luggage_items / luggage_history / found_items / luggage_incidents 中指向本地的旧地址改写为对象 key，并清理取件码缓存
+// 3. 标记为已同步，删除本地文件
+// MinIO 仍不可用时立即返回 storage.ErrUnavailable，等待下一轮
+func SyncPendingUploads(ctx context.Context, store *storage.FallbackStore) (UploadSyncResult, error) {
+	var result UploadSyncResult
+	for {
+		items, err := repositories.ListPendingUploads(uploadSyncBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(items) == 0 {
+			return result, nil
+		}
+
+		progressed := false
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			err := syncPendingUpload(ctx, store, item)
+			switch {
+			case err == nil:
+				result.Synced++
+				progressed = true
+			case errors.Is(err, storage.ErrUnavailable):
+				return result, err
+			case errors.Is(err, storage.ErrNotFound):
+				result.Missing++
+				progressed = true
+				if markErr := repositories.MarkPendingUploadFailed(item.ID, "local file missing", true); markErr != nil {
+					return result, markErr
+				}
+			default:
+				result.Failed++
+				log.Printf("⚠️  同步上传文件失败 %s: %v", item.ObjectKey, err)
+				if markErr := repositories.MarkPendingUploadFailed(item.ID, err.Error(), false); markErr != nil {
+					return result, markErr
+				}
+			}
+		}
+		// 本批全部失败（会重试）时结束本轮，避免反复处理同一批记录
+		if !progressed || len(items) < uploadSyncBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func syncPendingUpload(ctx context.Context, store *storage.FallbackStore, item models.PendingUpload) error {
+	local, remote := store.Secondary(), store.Primary()
+
+	rc, info, err := local.Get(ctx, item.ObjectKey)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	contentType := item.ContentType
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if _, err := remote.Put(ctx, item.ObjectKey, rc, info.Size, contentType); err != nil {
+		return err
+	}
+
+	// 照片字段统一保存对象 key（key 与存储后端无关），这里把旧版本保存的本地地址改写为 key
+	remoteURL := remote.URL(item.ObjectKey)
+	oldURLs := []string{item.LocalURL, local.URL(item.ObjectKey), LocalUploadPath + item.ObjectKey}
+	codes, err := repositories.RewritePhotoURLs(oldURLs, LocalUploadPath+item.ObjectKey, item.ObjectKey)
+	if err != nil {
+		return err
+	}
+	for _, code := range codes {
+		_ = repositories.DeleteLuggageByCodeCache(code)
+	}
+
+	if err := repositories.MarkPendingUploadSynced(item.ID, remoteURL); err != nil {
+		return err
+	}
+	// 照片地址被改写的取件码一并记录，便于追查
+	synced := item
+	synced.Status, synced.RemoteURL = models.PendingUploadSynced, remoteURL
+	recordAudit(ctx, auditEntry{
+		HotelID:    0,
+		EntityType: models.AuditEntityUpload,
+		EntityID:   item.ObjectKey,
+		Action:     models.AuditSync,
+		Before:     item,
+		After:      synced,
+	})
+	// 地址已改写，本地副本可以删除（删除失败不影响结果）
+	if err := local.Delete(ctx, item.ObjectKey); err != nil {
+		log.Printf("ℹ️  删除已同步的本地文件失败(可忽略) %s: %v", item.ObjectKey, err)
+	}
+	return nil
+}
+
+// StartUploadReplicator 启动后台同步任务：每隔 interval 把降级写入本地的上传推送到 MinIO
+// ctx 取消后退出；返回的函数会阻塞到同步协程退出为止
+func StartUploadReplicator(ctx context.Context, store *storage.FallbackStore, interval time.Duration) (wait func()) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			result, err := SyncPendingUploads(ctx, store)
+			if err != nil && !errors.Is(err, storage.ErrUnavailable) && !errors.Is(err, context.Canceled) {
+				log.Printf("⚠️  上传同步任务出错: %v", err)
+			}
+			if result.Synced > 0 || result.Missing > 0 {
+				log.Printf("✅ 已同步 %d 个本地上传到 MinIO（本地文件丢失 %d 个）", result.Synced, result.Missing)
+			}
+		}
+	}()
+	return wg.Wait
+}
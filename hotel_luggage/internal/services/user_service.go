@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
 
+	"hotel_luggage/internal/apperr"
 	"hotel_luggage/internal/models"
 	"hotel_luggage/internal/repositories"
 
@@ -14,27 +16,27 @@ import (
 // 1. 校验参数
 // 2. 密码哈希
 // 3. 写入数据库
-func CreateUser(username, password, role string, hotelID *int64) (models.User, error) {
+func CreateUser(ctx context.Context, username, password, role string, hotelID *int64) (models.User, error) {
 	if username == "" || password == "" {
-		return models.User{}, errors.New("username or password is empty")
+		return models.User{}, apperr.InvalidRequest("username or password is empty")
 	}
 	role = "staff"
 
 	// staff 必须关联酒店
 	if hotelID == nil || *hotelID <= 0 {
-		return models.User{}, errors.New("hotel_id is required")
+		return models.User{}, apperr.InvalidRequest("hotel_id is required")
 	}
 
 	if _, err := repositories.GetHotelByID(*hotelID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.User{}, errors.New("hotel not found")
+			return models.User{}, apperr.ErrHotelNotFound
 		}
 		return models.User{}, err
 	}
 
 	// 用户名唯一校验
 	if _, err := repositories.GetUserByUsername(username); err == nil {
-		return models.User{}, errors.New("username already exists")
+		return models.User{}, apperr.ErrUsernameExists
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return models.User{}, err
 	}
@@ -54,13 +56,23 @@ func CreateUser(username, password, role string, hotelID *int64) (models.User, e
 	if err := repositories.CreateUser(&user); err != nil {
 		return models.User{}, err
 	}
+	// 审计日志不记录密码哈希
+	snapshot := user
+	snapshot.PasswordHash = ""
+	recordAudit(ctx, auditEntry{
+		HotelID:    *hotelID,
+		EntityType: models.AuditEntityUser,
+		EntityID:   user.ID,
+		Action:     models.AuditCreate,
+		After:      snapshot,
+	})
 	return user, nil
 }
 
 // ListUsersByHotel 查询指定酒店的用户列表
 func ListUsersByHotel(hotelID int64) ([]models.User, error) {
 	if hotelID <= 0 {
-		return nil, errors.New("invalid hotel id")
+		return nil, apperr.InvalidRequest("invalid hotel id")
 	}
 	return repositories.ListUsersByHotel(hotelID)
 }
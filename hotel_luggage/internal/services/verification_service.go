@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+	"hotel_luggage/internal/notify"
+	"hotel_luggage/internal/repositories"
+	"hotel_luggage/utils"
+
+	"gorm.io/gorm"
+)
+
+// verificationStrength 核验方式强度：提供的核验方式不低于要求即可（例如要求 otp 时可以核验证件）
+var verificationStrength = map[string]int{
+	models.VerificationNone:       0,
+	models.VerificationPhoneLast4: 1,
+	models.VerificationOTP:        2,
+	models.VerificationIDDocument: 3,
+}
+
+// 一次性验证码参数（启动时由 InitCheckoutVerification 设置）
+var (
+	otpNotifier       notify.Notifier = notify.LogNotifier{}
+	otpTTL                            = 10 * time.Minute
+	otpResendInterval                 = time.Minute
+	otpMaxAttempts                    = 5
+)
+
+// InitCheckoutVerification 设置验证码发送渠道和有效期、重发间隔、最大尝试次数
+func InitCheckoutVerification(n notify.Notifier, ttl, resendInterval time.Duration, maxAttempts int) {
+	otpNotifier = n
+	otpTTL = ttl
+	otpResendInterval = resendInterval
+	otpMaxAttempts = maxAttempts
+}
+
+func isVerificationMethod(method string) bool {
+	_, ok := verificationStrength[method]
+	return ok
+}
+
+// CheckoutVerification 取件时提交的身份核验信息
+type CheckoutVerification struct {
+	Method        string `json:"method"`         // none / phone_last4 / otp / id_document
+	PhoneLast4    string `json:"phone_last4"`    // phone_last4：客人报出的手机号后四位
+	OTP           string `json:"otp"`            // otp：客人收到的验证码
+	DocumentType  string `json:"document_type"`  // id_document：证件类型（例如 id_card / passport）
+	DocumentLast4 string `json:"document_last4"` // id_document：证件号后四位（可选，仅用于留档）
+}
+
+// verificationResult 核验结果（写入取件历史）
+type verificationResult struct {
+	Method string
+	Detail string
+	OTPID  int64 // 核验通过的验证码（取件事务中标记为已使用）
+}
+
+// IsHighRisk 行李是否为高风险：件数达到 HighRiskQuantity，或特殊备注包含高风险关键字
+func IsHighRisk(policy models.HotelPolicy, item models.LuggageItem) bool {
+	if policy.HighRiskQuantity > 0 && item.Quantity >= policy.HighRiskQuantity {
+		return true
+	}
+	notes := strings.ToLower(item.SpecialNotes)
+	for _, keyword := range splitKeywords(policy.HighRiskKeywords) {
+		if strings.Contains(notes, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredVerification 取走这些行李需要的核验方式（取最严格的一件）
+func RequiredVerification(policy models.HotelPolicy, items []models.LuggageItem) (method string, highRisk bool) {
+	method = policy.CheckoutVerification
+	for _, item := range items {
+		if IsHighRisk(policy, item) {
+			highRisk = true
+			if verificationStrength[policy.HighRiskVerification] > verificationStrength[method] {
+				method = policy.HighRiskVerification
+			}
+		}
+	}
+	return method, highRisk
+}
+
+// verifyCheckout 按酒店策略核验取件人身份
+// pickup 不为空表示代取：凭前台签发的代取码视为 otp 强度的核验，客人自助登记的代取码只核验过手机号后四位，视为 phone_last4 强度；
+// phone_last4 核对代取人登记的电话
+func verifyCheckout(code string, policy models.HotelPolicy, items []models.LuggageItem, v CheckoutVerification, pickup *delegatePickup) (verificationResult, error) {
+	if len(items) == 0 {
+		return verificationResult{Method: models.VerificationNone}, nil
+	}
+	required, highRisk := RequiredVerification(policy, items)
+
+	method := v.Method
+	if method == "" && pickup != nil && pickup.ByCode {
+		method = models.VerificationDelegateCode
+	}
+	if method == "" {
+		method = models.VerificationNone
+	}
+	strength, ok := verificationStrength[method]
+	if method == models.VerificationDelegateCode {
+		if pickup == nil || !pickup.ByCode {
+			return verificationResult{}, apperr.InvalidRequest("delegate_code verification requires a delegate code")
+		}
+		strength, ok = verificationStrength[models.VerificationOTP], true
+		if pickup.Delegate.Source == models.DelegateSourceGuest {
+			strength = verificationStrength[models.VerificationPhoneLast4]
+		}
+	}
+	if !ok {
+		return verificationResult{}, apperr.InvalidRequest("invalid verification method")
+	}
+	if strength < verificationStrength[required] {
+		message := fmt.Sprintf("checkout requires %s verification", required)
+		if highRisk {
+			message = fmt.Sprintf("high-risk luggage requires %s verification", required)
+		}
+		return verificationResult{}, apperr.ErrVerificationRequired.WithMessage(message)
+	}
+
+	switch method {
+	case models.VerificationDelegateCode:
+		return verificationResult{Method: method, Detail: fmt.Sprintf("delegate code #%d", pickup.Delegate.ID)}, nil
+	case models.VerificationPhoneLast4:
+		phone := contactOf(items, func(item models.LuggageItem) string { return item.ContactPhone })
+		if pickup != nil {
+			phone = pickup.Delegate.Phone
+		}
+		digits := digitsOf(phone)
+		if len(digits) < 4 {
+			return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("no contact phone on record, use a stronger verification method")
+		}
+		if subtle.ConstantTimeCompare([]byte(digits[len(digits)-4:]), []byte(strings.TrimSpace(v.PhoneLast4))) != 1 {
+			return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("phone last four digits do not match")
+		}
+		return verificationResult{Method: method, Detail: "phone ****" + digits[len(digits)-4:]}, nil
+	case models.VerificationOTP:
+		return verifyCheckoutOTP(code, strings.TrimSpace(v.OTP))
+	case models.VerificationIDDocument:
+		docType := strings.TrimSpace(v.DocumentType)
+		if docType == "" || len(docType) > 30 {
+			return verificationResult{}, apperr.InvalidRequest("document_type is required (max 30 chars)")
+		}
+		detail := docType
+		if last4 := strings.TrimSpace(v.DocumentLast4); last4 != "" {
+			if len([]rune(last4)) > 4 {
+				return verificationResult{}, apperr.InvalidRequest("document_last4 must be at most 4 characters")
+			}
+			detail += " ****" + last4
+		}
+		return verificationResult{Method: method, Detail: detail}, nil
+	}
+	return verificationResult{Method: models.VerificationNone}, nil
+}
+
+// verifyCheckoutOTP 校验该取件码最近一次发送的验证码（取件成功后失效，见 RetrieveLuggageBatch）
+func verifyCheckoutOTP(code, otp string) (verificationResult, error) {
+	if otp == "" {
+		return verificationResult{}, apperr.InvalidRequest("otp is required")
+	}
+	record, err := repositories.GetLatestCheckoutOTP(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("no verification code has been sent")
+		}
+		return verificationResult{}, err
+	}
+	switch {
+	case record.ConsumedAt != nil:
+		return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("verification code already used, request a new one")
+	case time.Now().After(record.ExpiresAt):
+		return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("verification code expired, request a new one")
+	case record.Attempts >= otpMaxAttempts:
+		return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("too many attempts, request a new verification code")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashOTP(code, otp)), []byte(record.CodeHash)) != 1 {
+		if err := repositories.IncrementCheckoutOTPAttempts(record.ID); err != nil {
+			return verificationResult{}, err
+		}
+		return verificationResult{}, apperr.ErrVerificationFailed.WithMessage("invalid verification code")
+	}
+	return verificationResult{
+		Method: models.VerificationOTP,
+		Detail: fmt.Sprintf("otp via %s to %s", record.Channel, maskDestination(record.Channel, record.Destination)),
+		OTPID:  record.ID,
+	}, nil
+}
+
+// OTPSendResult 发送验证码的结果
+type OTPSendResult struct {
+	Channel     string `json:"channel"`     // sms / email
+	Destination string `json:"destination"` // 脱敏后的手机号 / 邮箱
+	ExpiresIn   int64  `json:"expires_in"`  // 有效期（秒）
+}
+
+// SendCheckoutOTP 向寄存时登记的手机号 / 邮箱发送取件验证码
+// channel 为空时优先短信，没有手机号再用邮件
+func SendCheckoutOTP(ctx context.Context, code, channel, requestedBy string) (OTPSendResult, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return OTPSendResult{}, apperr.InvalidRequest("code is empty")
+	}
+	items, err := repositories.FindLuggageByCode(code)
+	if err != nil {
+		return OTPSendResult{}, err
+	}
+	var stored []models.LuggageItem
+	for _, item := range items {
+		if item.Status == "stored" {
+			stored = append(stored, item)
+		}
+	}
+	if len(items) == 0 {
+		return OTPSendResult{}, apperr.ErrLuggageNotFound
+	}
+	if len(stored) == 0 {
+		return OTPSendResult{}, apperr.ErrLuggageNotStored
+	}
+	if err := checkCodeExpiry(stored); err != nil {
+		return OTPSendResult{}, err
+	}
+
+	phone := contactOf(stored, func(item models.LuggageItem) string { return item.ContactPhone })
+	email := contactOf(stored, func(item models.LuggageItem) string { return item.ContactEmail })
+	if channel == "" {
+		channel = models.OTPChannelSMS
+		if phone == "" {
+			channel = models.OTPChannelEmail
+		}
+	}
+	var destination string
+	switch channel {
+	case models.OTPChannelSMS:
+		destination = phone
+	case models.OTPChannelEmail:
+		destination = email
+	default:
+		return OTPSendResult{}, apperr.InvalidRequest("channel must be sms or email")
+	}
+	if destination == "" {
+		return OTPSendResult{}, apperr.InvalidRequest(fmt.Sprintf("no contact %s on record for this retrieval code", map[string]string{models.OTPChannelSMS: "phone", models.OTPChannelEmail: "email"}[channel]))
+	}
+
+	// 限制发送频率
+	if last, err := repositories.GetLatestCheckoutOTP(code); err == nil {
+		if time.Since(last.CreatedAt) < otpResendInterval {
+			return OTPSendResult{}, apperr.ErrOTPRateLimited
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return OTPSendResult{}, err
+	}
+
+	otp, err := utils.GenerateCode(6)
+	if err != nil {
+		return OTPSendResult{}, err
+	}
+	record := models.CheckoutOTP{
+		RetrievalCode: code,
+		Channel:       channel,
+		Destination:   destination,
+		CodeHash:      hashOTP(code, otp),
+		ExpiresAt:     time.Now().Add(otpTTL),
+		CreatedBy:     requestedBy,
+	}
+	// 先发送再保存：发送失败不计入重发间隔
+	minutes := int(otpTTL.Minutes())
+	err = otpNotifier.Send(ctx, notify.Message{
+		Channel: channel,
+		To:      destination,
+		Subject: "行李取件验证码",
+		Body:    fmt.Sprintf("您的行李取件验证码为 %s，%d 分钟内有效。如非本人操作请忽略。", otp, minutes),
+	})
+	if err != nil {
+		return OTPSendResult{}, apperr.ErrNotifierUnavailable.Wrap(err)
+	}
+	if err := repositories.CreateCheckoutOTP(&record); err != nil {
+		return OTPSendResult{}, err
+	}
+	// 审计日志不记录验证码哈希，接收地址脱敏
+	snapshot := record
+	snapshot.CodeHash, snapshot.Destination = "", maskDestination(channel, destination)
+	recordAudit(ctx, auditEntry{
+		HotelID:    stored[0].HotelID,
+		EntityType: models.AuditEntityCheckout,
+		EntityID:   record.ID,
+		Action:     models.AuditSendOTP,
+		After:      snapshot,
+	})
+	return OTPSendResult{
+		Channel:     channel,
+		Destination: maskDestination(channel, destination),
+		ExpiresIn:   int64(otpTTL.Seconds()),
+	}, nil
+}
+
+// hashOTP 验证码哈希（与取件码绑定）
+func hashOTP(code, otp string) string {
+	sum := sha256.Sum256([]byte(code + ":" + otp))
+	return hex.EncodeToString(sum[:])
+}
+
+// contactOf 返回第一件行李上登记的联系方式（同一取件码下的行李属于同一位客人）
+func contactOf(items []models.LuggageItem, field func(models.LuggageItem) string) string {
+	for _, item := range items {
+		if v := strings.TrimSpace(field(item)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// digitsOf 只保留数字（手机号可能带 +86、空格、横线）
+func digitsOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// maskDestination 脱敏：手机号只保留后四位，邮箱只保留首字母和域名
+func maskDestination(channel, destination string) string {
+	if channel == models.OTPChannelEmail {
+		at := strings.LastIndex(destination, "@")
+		if at <= 0 {
+			return "***"
+		}
+		return destination[:1] + "***" + destination[at:]
+	}
+	digits := digitsOf(destination)
+	if len(digits) < 4 {
+		return "****"
+	}
+	return "****" + digits[len(digits)-4:]
+}
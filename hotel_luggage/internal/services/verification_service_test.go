@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"hotel_luggage/internal/apperr"
+	"hotel_luggage/internal/models"
+)
+
+func TestRequiredVerification(t *testing.T) {
+	policy := models.HotelPolicy{
+		CheckoutVerification: models.VerificationPhoneLast4,
+		HighRiskVerification: models.VerificationOTP,
+		HighRiskQuantity:     5,
+		HighRiskKeywords:     "laptop, 现金",
+	}
+	tests := []struct {
+		name         string
+		policy       models.HotelPolicy
+		items        []models.LuggageItem
+		wantMethod   string
+		wantHighRisk bool
+	}{
+		{name: "normal luggage", policy: policy, items: []models.LuggageItem{{Quantity: 1}}, wantMethod: models.VerificationPhoneLast4},
+		{name: "high quantity", policy: policy, items: []models.LuggageItem{{Quantity: 1}, {Quantity: 5}}, wantMethod: models.VerificationOTP, wantHighRisk: true},
+		// 关键字不区分大小写
+		{name: "keyword", policy: policy, items: []models.LuggageItem{{Quantity: 1, SpecialNotes: "Contains LAPTOP"}}, wantMethod: models.VerificationOTP, wantHighRisk: true},
+		{name: "chinese keyword", policy: policy, items: []models.LuggageItem{{Quantity: 1, SpecialNotes: "内有现金"}}, wantMethod: models.VerificationOTP, wantHighRisk: true},
+		// 高风险要求比普通要求低时仍按普通要求
+		{name: "weaker high-risk method", policy: models.HotelPolicy{
+			CheckoutVerification: models.VerificationIDDocument,
+			HighRiskVerification: models.VerificationOTP,
+			HighRiskQuantity:     2,
+		}, items: []models.LuggageItem{{Quantity: 3}}, wantMethod: models.VerificationIDDocument, wantHighRisk: true},
+		{name: "quantity rule disabled", policy: models.HotelPolicy{
+			CheckoutVerification: models.VerificationNone,
+			HighRiskVerification: models.VerificationOTP,
+		}, items: []models.LuggageItem{{Quantity: 99}}, wantMethod: models.VerificationNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, highRisk := RequiredVerification(tt.policy, tt.items)
+			if method != tt.wantMethod || highRisk != tt.wantHighRisk {
+				t.Fatalf("RequiredVerification() = %s, %v, want %s, %v", method, highRisk, tt.wantMethod, tt.wantHighRisk)
+			}
+		})
+	}
+}
+
+func TestVerifyCheckout(t *testing.T) {
+	items := []models.LuggageItem{{ID: 1, Quantity: 1, ContactPhone: "+86 138-0013-8000"}}
+	noPhone := []models.LuggageItem{{ID: 1, Quantity: 1}}
+	policy := func(method string) models.HotelPolicy {
+		return models.HotelPolicy{CheckoutVerification: method, HighRiskVerification: models.VerificationOTP}
+	}
+	staffDelegate := &delegatePickup{Delegate: models.PickupDelegate{ID: 7, Phone: "13900001234", Source: models.DelegateSourceStaff}, ByCode: true}
+	guestDelegate := &delegatePickup{Delegate: models.PickupDelegate{ID: 8, Phone: "13900001234", Source: models.DelegateSourceGuest}, ByCode: true}
+	listedDelegate := &delegatePickup{Delegate: models.PickupDelegate{ID: 9, Phone: "13900001234", Source: models.DelegateSourceStaff}}
+
+	tests := []struct {
+		name       string
+		policy     models.HotelPolicy
+		items      []models.LuggageItem
+		v          CheckoutVerification
+		pickup     *delegatePickup
+		wantMethod string
+		wantDetail string
+		wantErr    error
+	}{
+		{name: "none", policy: policy(models.VerificationNone), items: items, wantMethod: models.VerificationNone},
+		{name: "no items", policy: policy(models.VerificationIDDocument), wantMethod: models.VerificationNone},
+		{name: "unknown method", policy: policy(models.VerificationNone), items: items, v: CheckoutVerification{Method: "face"}, wantErr: apperr.ErrInvalidRequest},
+		{name: "method too weak", policy: policy(models.VerificationOTP), items: items, v: CheckoutVerification{Method: models.VerificationPhoneLast4, PhoneLast4: "8000"}, wantErr: apperr.ErrVerificationRequired},
+		{name: "missing method", policy: policy(models.VerificationPhoneLast4), items: items, wantErr: apperr.ErrVerificationRequired},
+
+		{name: "phone last4", policy: policy(models.VerificationPhoneLast4), items: items,
+			v: CheckoutVerification{Method: models.VerificationPhoneLast4, PhoneLast4: " 8000 "}, wantMethod: models.VerificationPhoneLast4, wantDetail: "phone ****8000"},
+		{name: "phone last4 mismatch", policy: policy(models.VerificationPhoneLast4), items: items,
+			v: CheckoutVerification{Method: models.VerificationPhoneLast4, PhoneLast4: "0008"}, wantErr: apperr.ErrVerificationFailed},
+		{name: "phone last4 without phone", policy: policy(models.VerificationPhoneLast4), items: noPhone,
+			v: CheckoutVerification{Method: models.VerificationPhoneLast4, PhoneLast4: "0000"}, wantErr: apperr.ErrVerificationFailed},
+		// 更强的核验方式满足较弱的要求
+		{name: "stronger method accepted", policy: policy(models.VerificationPhoneLast4), items: items,
+			v: CheckoutVerification{Method: models.VerificationIDDocument, DocumentType: "passport", DocumentLast4: "123X"}, wantMethod: models.VerificationIDDocument, wantDetail: "passport ****123X"},
+		{name: "otp required", policy: policy(models.VerificationOTP), items: items,
+			v: CheckoutVerification{Method: models.VerificationOTP, OTP: "  "}, wantErr: apperr.ErrInvalidRequest},
+
+		{name: "id document", policy: policy(models.VerificationIDDocument), items: items,
+			v: CheckoutVerification{Method: models.VerificationIDDocument, DocumentType: "id_card"}, wantMethod: models.VerificationIDDocument, wantDetail: "id_card"},
+		{name: "id document without type", policy: policy(models.VerificationIDDocument), items: items,
+			v: CheckoutVerification{Method: models.VerificationIDDocument}, wantErr: apperr.ErrInvalidRequest},
+		{name: "id document last4 too long", policy: policy(models.VerificationIDDocument), items: items,
+			v: CheckoutVerification{Method: models.VerificationIDDocument, DocumentType: "id_card", DocumentLast4: "12345"}, wantErr: apperr.ErrInvalidRequest},
+
+		// 前台签发的代取码视为 otp 强度，不传 method 时自动使用 delegate_code
+		{name: "staff delegate code", policy: policy(models.VerificationOTP), items: items, pickup: staffDelegate,
+			wantMethod: models.VerificationDelegateCode, wantDetail: "delegate code #7"},
+		{name: "delegate code below id document", policy: policy(models.VerificationIDDocument), items: items, pickup: staffDelegate, wantErr: apperr.ErrVerificationRequired},
+		// 客人自助登记的代取码只核验过手机号后四位
+		{name: "guest delegate code", policy: policy(models.VerificationPhoneLast4), items: items, pickup: guestDelegate,
+			wantMethod: models.VerificationDelegateCode, wantDetail: "delegate code #8"},
+		{name: "guest delegate code below otp", policy: policy(models.VerificationOTP), items: items, pickup: guestDelegate, wantErr: apperr.ErrVerificationRequired},
+		{name: "delegate code without code", policy: policy(models.VerificationNone), items: items, pickup: listedDelegate,
+			v: CheckoutVerification{Method: models.VerificationDelegateCode}, wantErr: apperr.ErrInvalidRequest},
+		// 登记的代取人核对代取人的电话，而不是客人的电话
+		{name: "listed delegate phone", policy: policy(models.VerificationPhoneLast4), items: items, pickup: listedDelegate,
+			v: CheckoutVerification{Method: models.VerificationPhoneLast4, PhoneLast4: "1234"}, wantMethod: models.VerificationPhoneLast4, wantDetail: "phone ****1234"},
+		{name: "listed delegate guest phone", policy: policy(models.VerificationPhoneLast4), items: items, pickup: listedDelegate,
+			v: CheckoutVerification{Method: models.VerificationPhoneLast4, PhoneLast4: "8000"}, wantErr: apperr.ErrVerificationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyCheckout("123455", tt.policy, tt.items, tt.v, tt.pickup)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verifyCheckout() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyCheckout() error = %v", err)
+			}
+			if got.Method != tt.wantMethod || got.Detail != tt.wantDetail {
+				t.Fatalf("verifyCheckout() = %+v, want method %s detail %q", got, tt.wantMethod, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"hotel_luggage/configs"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinIOKeyPrefix 上传文件在 MinIO bucket 中的对象名前缀
+const MinIOKeyPrefix = "uploads/"
+
+// New 根据配置创建存储：MinIO 为主，本地目录为备（MinIO 不可用时自动降级）
+// 两者都是私有存储，对外只提供有效期为 upload.url_expiry 的签名地址
+// minioClient: 获取当前 MinIO 客户端的函数（通常为 repositories.MinIO）
+func New(cfg configs.Config, minioClient func() *minio.Client) *FallbackStore {
+	local := NewLocal(cfg.Upload.LocalDir, cfg.Upload.PublicBaseURL, cfg.SigningKey())
+	remote := NewMinIO(minioClient, cfg.MinIO.BucketName, MinIOKeyPrefix, cfg.MinIO.BaseURL())
+	return NewFallback(remote, local)
+}
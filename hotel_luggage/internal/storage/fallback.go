@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+)
+
+// FallbackStore 主备存储：优先写入 primary（MinIO），失败时降级写入 secondary（本地目录）
+// 读取 / 删除 / 签名时先查 primary，找不到或不可用再查 secondary
+type FallbackStore struct {
+	primary   BlobStore
+	secondary BlobStore
+}
+
+// NewFallback 创建主备存储
+func NewFallback(primary, secondary BlobStore) *FallbackStore {
+	return &FallbackStore{primary: primary, secondary: secondary}
+}
+
+// Name 存储后端名称（实际写入的后端见 ObjectInfo.Store）
+func (s *FallbackStore) Name() string {
+	return s.primary.Name() + "+" + s.secondary.Name()
+}
+
+// Primary 主存储
+func (s *FallbackStore) Primary() BlobStore {
+	return s.primary
+}
+
+// Secondary 备用存储
+func (s *FallbackStore) Secondary() BlobStore {
+	return s.secondary
+}
+
+// Put 优先写入主存储，失败时写入备用存储
+// 注意：r 只能读取一次，如果主存储已读取部分数据后失败，需要 r 实现 io.Seeker 才能重试
+func (s *FallbackStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	info, err := s.primary.Put(ctx, key, r, size, contentType)
+	if err == nil {
+		return info, nil
+	}
+	if errors.Is(err, ErrInvalidKey) {
+		return ObjectInfo{}, err
+	}
+	if !errors.Is(err, ErrUnavailable) {
+		seeker, ok := r.(io.Seeker)
+		if !ok {
+			return ObjectInfo{}, err
+		}
+		if _, seekErr := seeker.Seek(0, io.SeekStart); seekErr != nil {
+			return ObjectInfo{}, err
+		}
+		log.Printf("⚠️  %s 写入失败，降级到 %s: %v", s.primary.Name(), s.secondary.Name(), err)
+	}
+	return s.secondary.Put(ctx, key, r, size, contentType)
+}
+
+// Get 先读主存储，找不到再读备用存储
+func (s *FallbackStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	rc, info, err := s.primary.Get(ctx, key)
+	if err == nil || !fallThrough(err) {
+		return rc, info, err
+	}
+	return s.secondary.Get(ctx, key)
+}
+
+// Delete 两个存储中都删除
+func (s *FallbackStore) Delete(ctx context.Context, key string) error {
+	primaryErr := s.primary.Delete(ctx, key)
+	if primaryErr != nil && !fallThrough(primaryErr) {
+		return primaryErr
+	}
+	return s.secondary.Delete(ctx, key)
+}
+
+// Stat 先查主存储，找不到再查备用存储
+func (s *FallbackStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	info, err := s.primary.Stat(ctx, key)
+	if err == nil || !fallThrough(err) {
+		return info, err
+	}
+	return s.secondary.Stat(ctx, key)
+}
+
+// URL 返回对象所在存储的公开地址（默认主存储）
+func (s *FallbackStore) URL(key string) string {
+	return s.primary.URL(key)
+}
+
+// SignedURL 对象在主存储中则签名主存储地址，否则使用备用存储
+// 每次都要查询主存储，批量签名时应使用 SignedURLIn
+func (s *FallbackStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.primary.Stat(ctx, key); err == nil {
+		return s.primary.SignedURL(ctx, key, expiry)
+	} else if !fallThrough(err) {
+		return "", err
+	}
+	return s.secondary.SignedURL(ctx, key, expiry)
+}
+
+// SignedURLIn 已知对象所在后端时直接签名，不查询对象是否存在
+// backend 为 ObjectInfo.Store（与备用存储名称相同时签名备用存储地址，否则签名主存储地址）
+func (s *FallbackStore) SignedURLIn(ctx context.Context, key, backend string, expiry time.Duration) (string, error) {
+	if backend == s.secondary.Name() {
+		return s.secondary.SignedURL(ctx, key, expiry)
+	}
+	return s.primary.SignedURL(ctx, key, expiry)
+}
+
+// VerifySignature 校验备用存储（本地）签发的下载地址
+func (s *FallbackStore) VerifySignature(key, expires, sig string) error {
+	if v, ok := s.secondary.(SignatureVerifier); ok {
+		return v.VerifySignature(key, expires, sig)
+	}
+	return ErrInvalidSignature
+}
+
+// fallThrough 是否应继续尝试备用存储
+func fallThrough(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnavailable)
+}
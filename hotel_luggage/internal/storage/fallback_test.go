@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedURLIn(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemory("http://primary/uploads")
+	if _, err := primary.Put(ctx, "2026/01/a.jpg", strings.NewReader("a"), 1, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	fallback := NewFallback(primary, NewLocal(t.TempDir(), "http://local/uploads", []byte("secret")))
+
+	tests := []struct {
+		name       string
+		store      BlobStore
+		key        string
+		backend    string
+		wantPrefix string
+		wantErr    error
+	}{
+		{name: "primary backend", store: fallback, key: "2026/01/a.jpg", backend: "memory", wantPrefix: "http://primary/uploads/2026/01/a.jpg"},
+		{name: "unknown backend signs primary", store: fallback, key: "2026/01/a.jpg", backend: "", wantPrefix: "http://primary/uploads/2026/01/a.jpg"},
+		// 本地对象不查询主存储，即使主存储中不存在也直接签名本地地址
+		{name: "secondary backend", store: fallback, key: "2026/01/b.jpg", backend: "local", wantPrefix: "http://local/uploads/2026/01/b.jpg?"},
+		{name: "invalid key", store: fallback, key: "../b.jpg", backend: "local", wantErr: ErrInvalidKey},
+		// 不支持按后端签名的存储退回 SignedURL
+		{name: "plain store", store: primary, key: "2026/01/b.jpg", backend: "local", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SignedURLIn(ctx, tt.store, tt.key, tt.backend, time.Hour)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SignedURLIn() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SignedURLIn() error = %v", err)
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Fatalf("SignedURLIn() = %q, want prefix %q", got, tt.wantPrefix)
+			}
+		})
+	}
+}
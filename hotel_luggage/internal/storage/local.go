@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStore 本地文件系统存储
+// 文件保存在 dir 目录下，只能通过带 HMAC 签名的地址（baseURL/key?expires=...&sig=...）下载
+type LocalStore struct {
+	dir     string
+	baseURL string
+	signer  *URLSigner
+}
+
+// NewLocal 创建本地存储
+// dir: 存储根目录（例如 ./uploads）
+// baseURL: 下载地址前缀（为空时使用相对地址 /uploads）
+// secret: 下载地址签名密钥
+func NewLocal(dir, baseURL string, secret []byte) *LocalStore {
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	return &LocalStore{dir: dir, baseURL: baseURL, signer: NewURLSigner(secret)}
+}
+
+// Name 存储后端名称
+func (s *LocalStore) Name() string {
+	return "local"
+}
+
+// Dir 存储根目录
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStore) path(key string) (string, string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put 写入文件（先写临时文件再重命名，避免读到写了一半的文件）
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	key, full, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return ObjectInfo{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), full)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return ObjectInfo{}, err
+	}
+	if contentType == "" {
+		contentType = ContentTypeByKey(key)
+	}
+	return ObjectInfo{Key: key, Size: written, ContentType: contentType, LastModified: time.Now(), Store: s.Name()}, nil
+}
+
+// Get 打开文件
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	_, full, _ := s.path(key)
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, ObjectInfo{}, mapFSError(err)
+	}
+	return f, info, nil
+}
+
+// Delete 删除文件（不存在时忽略）
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	_, full, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Stat 查询文件信息
+func (s *LocalStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	key, full, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	fi, err := os.Stat(full)
+	if err != nil {
+		return ObjectInfo{}, mapFSError(err)
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+	return ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  ContentTypeByKey(key),
+		LastModified: fi.ModTime(),
+		Store:        s.Name(),
+	}, nil
+}
+
+// Walk 遍历存储目录下的所有文件（跳过以 "." 开头的临时文件）
+func (s *LocalStore) Walk(ctx context.Context, fn func(ObjectInfo) error) error {
+	return filepath.WalkDir(s.dir, func(full string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && full != s.dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, full)
+		if err != nil {
+			return err
+		}
+		info, err := s.Stat(ctx, filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		return fn(info)
+	})
+}
+
+// URL 未签名的访问地址（不能直接下载，需要 SignedURL）
+func (s *LocalStore) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
+
+// SignedURL 带 HMAC 签名和过期时间的下载地址
+func (s *LocalStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.signer.Sign(s.URL(key), key, expiry), nil
+}
+
+// VerifySignature 校验下载地址的签名（expires / sig 为查询参数）
+func (s *LocalStore) VerifySignature(key, expires, sig string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.signer.Verify(key, expires, sig)
+}
+
+func mapFSError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
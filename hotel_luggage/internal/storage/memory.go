@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// MemoryStore 内存存储（用于测试和不连接外部依赖的命令行工具）
+type MemoryStore struct {
+	baseURL string
+
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// NewMemory 创建内存存储
+func NewMemory(baseURL string) *MemoryStore {
+	if baseURL == "" {
+		baseURL = "/memory"
+	}
+	return &MemoryStore{baseURL: baseURL, objects: make(map[string]memoryObject)}
+}
+
+// Name 存储后端名称
+func (s *MemoryStore) Name() string {
+	return "memory"
+}
+
+// Put 写入对象
+func (s *MemoryStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if contentType == "" {
+		contentType = ContentTypeByKey(key)
+	}
+	info := ObjectInfo{Key: key, Size: int64(len(data)), ContentType: contentType, LastModified: time.Now(), Store: s.Name()}
+
+	s.mu.Lock()
+	s.objects[key] = memoryObject{data: data, info: info}
+	s.mu.Unlock()
+	return info, nil
+}
+
+// Get 读取对象
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	obj, err := s.lookup(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), obj.info, nil
+}
+
+// Delete 删除对象
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Stat 查询对象信息
+func (s *MemoryStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	obj, err := s.lookup(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return obj.info, nil
+}
+
+// URL 公开访问地址
+func (s *MemoryStore) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
+
+// SignedURL 内存存储不支持签名，返回公开地址
+func (s *MemoryStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.lookup(key); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *MemoryStore) lookup(key string) (memoryObject, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return memoryObject{}, err
+	}
+	s.mu.RLock()
+	obj, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return obj, nil
+}
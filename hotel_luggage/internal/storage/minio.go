@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinIOStore MinIO / S3 对象存储
+// 客户端通过 client 函数按需获取：MinIO 降级期间返回 nil，此时所有操作返回 ErrUnavailable，
+// 重连成功后自动使用新客户端（见 repositories.MinIO）
+type MinIOStore struct {
+	client  func() *minio.Client
+	bucket  string
+	prefix  string
+	baseURL string
+}
+
+// NewMinIO 创建 MinIO 存储
+// client: 获取当前客户端的函数（通常为 repositories.MinIO）
+// bucket: 存储桶名称
+// prefix: 对象名前缀（例如 "uploads/"，对象名 = prefix + key）
+// baseURL: 对外访问前缀（例如 http://localhost:9000/hotel-luggage，或 CDN 地址）
+func NewMinIO(client func() *minio.Client, bucket, prefix, baseURL string) *MinIOStore {
+	return &MinIOStore{client: client, bucket: bucket, prefix: prefix, baseURL: baseURL}
+}
+
+// Name 存储后端名称
+func (s *MinIOStore) Name() string {
+	return "minio"
+}
+
+// ObjectName 返回 key 在 bucket 中的完整对象名
+func (s *MinIOStore) ObjectName(key string) string {
+	return s.prefix + key
+}
+
+func (s *MinIOStore) prepare(key string) (*minio.Client, string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	client := s.client()
+	if client == nil {
+		return nil, "", ErrUnavailable
+	}
+	return client, key, nil
+}
+
+// Put 上传对象
+func (s *MinIOStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error) {
+	client, key, err := s.prepare(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if contentType == "" {
+		contentType = ContentTypeByKey(key)
+	}
+	info, err := client.PutObject(ctx, s.bucket, s.ObjectName(key), r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: key, Size: info.Size, ContentType: contentType, LastModified: time.Now(), Store: s.Name()}, nil
+}
+
+// Get 下载对象
+func (s *MinIOStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	client, key, err := s.prepare(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	obj, err := client.GetObject(ctx, s.bucket, s.ObjectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, mapMinIOError(err)
+	}
+	return obj, info, nil
+}
+
+// Delete 删除对象（不存在时 MinIO 也返回成功）
+func (s *MinIOStore) Delete(ctx context.Context, key string) error {
+	client, key, err := s.prepare(key)
+	if err != nil {
+		return err
+	}
+	return client.RemoveObject(ctx, s.bucket, s.ObjectName(key), minio.RemoveObjectOptions{})
+}
+
+// Stat 查询对象信息
+func (s *MinIOStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	client, key, err := s.prepare(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := client.StatObject(ctx, s.bucket, s.ObjectName(key), minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, mapMinIOError(err)
+	}
+	return ObjectInfo{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+		Store:        s.Name(),
+	}, nil
+}
+
+// URL 未签名的访问地址（baseURL + prefix + key，bucket 为私有时不能直接下载）
+func (s *MinIOStore) URL(key string) string {
+	return joinURL(s.baseURL, s.ObjectName(key))
+}
+
+// SignedURL 预签名下载地址
+func (s *MinIOStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	client, key, err := s.prepare(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := client.PresignedGetObject(ctx, s.bucket, s.ObjectName(key), expiry, nil)
+	if err != nil {
+		return "", mapMinIOError(err)
+	}
+	return u.String(), nil
+}
+
+func mapMinIOError(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchObject":
+		return ErrNotFound
+	}
+	return err
+}
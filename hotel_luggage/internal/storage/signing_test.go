@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestURLSignerVerify(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	signer := &URLSigner{secret: []byte("secret"), now: func() time.Time { return now }}
+	expires := now.Add(time.Minute).Unix()
+	sig := signer.mac("2026/01/a.jpg", expires)
+
+	tests := []struct {
+		name    string
+		key     string
+		expires string
+		sig     string
+		at      time.Time
+		wantErr bool
+	}{
+		{name: "valid", key: "2026/01/a.jpg", expires: strconv.FormatInt(expires, 10), sig: sig, at: now},
+		{name: "valid at expiry", key: "2026/01/a.jpg", expires: strconv.FormatInt(expires, 10), sig: sig, at: now.Add(time.Minute)},
+		{name: "expired", key: "2026/01/a.jpg", expires: strconv.FormatInt(expires, 10), sig: sig, at: now.Add(time.Minute + time.Second), wantErr: true},
+		{name: "other key", key: "2026/01/b.jpg", expires: strconv.FormatInt(expires, 10), sig: sig, at: now, wantErr: true},
+		{name: "extended expiry", key: "2026/01/a.jpg", expires: strconv.FormatInt(expires+3600, 10), sig: sig, at: now, wantErr: true},
+		{name: "bad expires", key: "2026/01/a.jpg", expires: "soon", sig: sig, at: now, wantErr: true},
+		{name: "empty sig", key: "2026/01/a.jpg", expires: strconv.FormatInt(expires, 10), sig: "", at: now, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			signer.now = func() time.Time { return at }
+			err := signer.Verify(tt.key, tt.expires, tt.sig)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("Verify() error = %v, want ErrInvalidSignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestURLSignerSignRoundTrip(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	signer := &URLSigner{secret: []byte("secret"), now: func() time.Time { return now }}
+
+	tests := []struct {
+		name   string
+		rawURL string
+	}{
+		{name: "plain", rawURL: "http://host/uploads/2026/01/a.jpg"},
+		{name: "existing query", rawURL: "http://host/uploads/2026/01/a.jpg?download=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := signer.Sign(tt.rawURL, "2026/01/a.jpg", time.Hour)
+			u, err := url.Parse(signed)
+			if err != nil {
+				t.Fatalf("signed url %q: %v", signed, err)
+			}
+			q := u.Query()
+			if got, want := q.Get("expires"), strconv.FormatInt(now.Add(time.Hour).Unix(), 10); got != want {
+				t.Fatalf("expires = %q, want %q", got, want)
+			}
+			if err := signer.Verify("2026/01/a.jpg", q.Get("expires"), q.Get("sig")); err != nil {
+				t.Fatalf("Verify(Sign()) error = %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyFromURL(t *testing.T) {
+	tests := []struct {
+		ref     string
+		wantKey string
+		wantOK  bool
+	}{
+		{ref: "2026/01/a.jpg", wantKey: "2026/01/a.jpg", wantOK: true},
+		{ref: "  2026/01/a.jpg  ", wantKey: "2026/01/a.jpg", wantOK: true},
+		{ref: "/uploads/2026/01/a.jpg", wantKey: "2026/01/a.jpg", wantOK: true},
+		{ref: "http://host/uploads/2026/01/a.jpg?expires=1&sig=x", wantKey: "2026/01/a.jpg", wantOK: true},
+		{ref: "http://minio:9000/bucket/uploads/2026/01/a.jpg", wantKey: "2026/01/a.jpg", wantOK: true},
+		{ref: "https://cdn.example.com/a.jpg", wantKey: "https://cdn.example.com/a.jpg", wantOK: false},
+		{ref: "../secret", wantKey: "../secret", wantOK: false},
+		{ref: "/uploads/../secret", wantKey: "/uploads/../secret", wantOK: false},
+		{ref: "", wantKey: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			key, ok := KeyFromURL(tt.ref)
+			if key != tt.wantKey || ok != tt.wantOK {
+				t.Fatalf("KeyFromURL(%q) = %q, %v; want %q, %v", tt.ref, key, ok, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
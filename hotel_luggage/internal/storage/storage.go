@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 存储相关错误
+var (
+	ErrNotFound    = errors.New("blob not found")         // 对象不存在
+	ErrUnavailable = errors.New("blob store unavailable") // 存储后端不可用（如 MinIO 处于降级状态）
+	ErrInvalidKey  = errors.New("invalid blob key")       // key 为空或包含 ".." 等非法路径
+)
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Key          string    `json:"key"`           // 对象 key（与存储后端无关，例如 2026/01/xxx.jpg）
+	Size         int64     `json:"size"`          // 字节数
+	ContentType  string    `json:"content_type"`  // MIME 类型
+	LastModified time.Time `json:"last_modified"` // 最后修改时间
+	Store        string    `json:"store"`         // 实际所在的存储后端（minio / local / memory）
+}
+
+// BlobStore 对象存储抽象
+// 照片、签名等文件统一通过该接口读写，业务代码不关心底层是 MinIO 还是本地目录
+//
+// key 约定：
+// - 使用 "/" 分隔的相对路径，例如 2026/01/xxx.jpg
+// - 不能以 "/" 开头，不能包含 ".."
+type BlobStore interface {
+	// Name 存储后端名称（minio / local / memory）
+	Name() string
+	// Put 写入对象（同名覆盖）
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (ObjectInfo, error)
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Delete 删除对象（对象不存在时返回 nil）
+	Delete(ctx context.Context, key string) error
+	// Stat 查询对象元信息，不存在时返回 ErrNotFound
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// URL 返回对象的访问地址（基于配置的 public base URL，不依赖请求头；私有存储需使用 SignedURL）
+	URL(key string) string
+	// SignedURL 返回带有效期的访问地址（MinIO 预签名 / 本地 HMAC 签名；内存存储返回普通地址）
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// LocatedSigner 调用方已知对象所在后端（ObjectInfo.Store）时直接签名，不查询对象是否存在
+type LocatedSigner interface {
+	SignedURLIn(ctx context.Context, key, backend string, expiry time.Duration) (string, error)
+}
+
+// SignedURLIn 按对象所在后端签名：s 实现 LocatedSigner 时不查询对象是否存在，否则等同于 s.SignedURL
+func SignedURLIn(ctx context.Context, s BlobStore, key, backend string, expiry time.Duration) (string, error) {
+	if located, ok := s.(LocatedSigner); ok {
+		return located.SignedURLIn(ctx, key, backend, expiry)
+	}
+	return s.SignedURL(ctx, key, expiry)
+}
+
+// CleanKey 校验并规范化对象 key
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// ContentTypeByKey 根据扩展名推断 MIME 类型
+func ContentTypeByKey(key string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(key)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// joinURL 拼接 base URL 与 key（base 为空时返回以 "/" 开头的相对地址）
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
@@ -0,0 +1,176 @@
+package router
+
+import (
+	"hotel_luggage/internal/apidoc"
+	"hotel_luggage/internal/handlers"
+)
+
+// RouteDocs 所有已注册路由的接口文档
+// 说明：
+// - 新增/修改路由时必须同步修改这里，否则 `go run ./cmd/openapi -check` 会失败（CI 会拦截）
+// - Body 直接引用 handler 中用于 ShouldBindJSON 的请求结构体，保证文档与校验规则一致
+// - /api/openapi.json 由这里生成
+var RouteDocs = []apidoc.Route{
+	// 基础
+	{Method: "GET", Path: "/ping", Tag: "system", Summary: "健康检查"},
+	{Method: "GET", Path: "/healthz", Tag: "system", Summary: "存活探针"},
+	{Method: "GET", Path: "/readyz", Tag: "system", Summary: "就绪探针（各依赖状态及降级模式）"},
+	{Method: "GET", Path: "/metrics", Tag: "system", Summary: "依赖状态指标（Prometheus 文本格式）"},
+	{Method: "GET", Path: "/home", Tag: "system", Summary: "接口清单（实时路由表）"},
+	{Method: "GET", Path: "/api/openapi.json", Tag: "system", Summary: "OpenAPI 3 文档"},
+	{Method: "GET", Path: "/uploads/*filepath", Tag: "system", Summary: "本地上传文件下载（签名地址）",
+		Query: []apidoc.Param{
+			{Name: "expires", Type: "integer", Required: true, Description: "过期时间（Unix 秒）"},
+			{Name: "sig", Type: "string", Required: true, Description: "HMAC-SHA256 签名"},
+		}},
+
+	// 认证
+	{Method: "POST", Path: "/api/login", Tag: "auth", Summary: "登录（返回 JWT token）", Body: handlers.LoginRequest{}},
+
+	// 客人自助
+	{Method: "POST", Path: "/api/guest/delegates", Tag: "guest", Summary: "客人凭取件码和手机号后四位自助登记代取人", Body: handlers.GuestPickupDelegateRequest{}},
+
+	// 行李寄存与查询
+	{Method: "POST", Path: "/api/luggage", Tag: "luggage", Summary: "创建行李寄存记录", Auth: true, Body: handlers.CreateLuggageRequest{}},
+	{Method: "GET", Path: "/api/luggage/by_code", Tag: "luggage", Summary: "按取件码查询行李", Auth: true,
+		Query: []apidoc.Param{{Name: "code", Type: "string", Required: true, Description: "取件码"}}},
+	{Method: "GET", Path: "/api/luggage/list", Tag: "luggage", Summary: "当前酒店有行李在存的客人名单", Auth: true},
+	{Method: "GET", Path: "/api/luggage/list/by_guest_name", Tag: "luggage", Summary: "按客人姓名查询寄存中的行李", Auth: true,
+		Query: []apidoc.Param{{Name: "guest_name", Type: "string", Required: true, Description: "客人姓名"}}},
+
+	// 寄存室管理
+	{Method: "GET", Path: "/api/luggage/storerooms", Tag: "storeroom", Summary: "获取当前酒店所有寄存室", Auth: true},
+	{Method: "GET", Path: "/api/luggage/storerooms/:id/orders", Tag: "storeroom", Summary: "获取指定寄存室的所有行李", Auth: true,
+		Query: []apidoc.Param{{Name: "status", Type: "string", Description: "行李状态（stored/retrieved）"}}},
+	{Method: "POST", Path: "/api/luggage/storerooms", Tag: "storeroom", Summary: "创建新寄存室", Auth: true, Body: handlers.CreateStoreroomRequest{}},
+	{Method: "PUT", Path: "/api/luggage/storerooms/:id", Tag: "storeroom", Summary: "更新寄存室状态（启用/停用）", Auth: true, Body: handlers.UpdateStoreroomStatusRequest{}},
+	{Method: "PUT", Path: "/api/luggage/storerooms/:id/capacity", Tag: "storeroom", Summary: "修改寄存室容量和尺寸权重（容量按 件数 × 尺寸权重 计算）", Auth: true, Body: handlers.UpdateStoreroomCapacityRequest{}},
+	{Method: "GET", Path: "/api/luggage/storerooms/:id/locations", Tag: "storeroom", Summary: "获取寄存室内的区域 / 货架 / 格位（含完整位置和在存件数）", Auth: true},
+	{Method: "POST", Path: "/api/luggage/storerooms/:id/locations", Tag: "storeroom", Summary: "创建区域 / 货架 / 格位", Auth: true, Body: handlers.CreateStoreroomLocationRequest{}},
+	{Method: "GET", Path: "/api/luggage/storerooms/:id/bins/suggest", Tag: "storeroom", Summary: "推荐空闲格位（未划分格位时 item 为 null）", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "quantity", Type: "integer", Description: "行李件数（默认 1）"},
+			{Name: "size_class", Type: "string", Description: "尺寸：small / medium / large / oversized（默认按 medium 计算）"},
+		}},
+	{Method: "PUT", Path: "/api/luggage/storerooms/:id/assignment", Tag: "storeroom", Summary: "修改寄存室自动分配规则（优先级、支持的特殊保管要求、适合的取件时间）", Auth: true, Body: handlers.UpdateStoreroomAssignmentRequest{}},
+	{Method: "GET", Path: "/api/luggage/storerooms/recommend", Tag: "storeroom", Summary: "按酒店分配规则推荐寄存室（寄存时 storeroom_id 传 \"auto\" 即使用第一项）", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "quantity", Type: "integer", Description: "行李件数（默认 1）"},
+			{Name: "size_class", Type: "string", Description: "尺寸：small / medium / large / oversized（默认按 medium 计算）"},
+			{Name: "handling", Type: "string", Description: "特殊保管要求，逗号分隔：fragile / valuables / refrigerated"},
+			{Name: "expected_pickup_at", Type: "string", Description: "预计取件时间（RFC3339）"},
+		}},
+	{Method: "POST", Path: "/api/luggage/storerooms/:id/evacuate", Tag: "storeroom", Summary: "批量迁移寄存室内全部或指定的行李到一个或多个寄存室（单事务，返回迁移清单）", Auth: true, Body: handlers.EvacuateStoreroomRequest{}},
+	{Method: "PUT", Path: "/api/luggage/locations/:id", Tag: "storeroom", Summary: "修改位置名称、容量、启用状态", Auth: true, Body: handlers.UpdateStoreroomLocationRequest{}},
+	{Method: "DELETE", Path: "/api/luggage/locations/:id", Tag: "storeroom", Summary: "删除位置（有下级位置或在存行李时不能删）", Auth: true},
+
+	// 日志查询
+	{Method: "GET", Path: "/api/luggage/logs/stored", Tag: "logs", Summary: "获取寄存记录", Auth: true},
+	{Method: "GET", Path: "/api/luggage/logs/updated", Tag: "logs", Summary: "获取修改记录（含寄存室迁移，changes 为字段级变更）", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "field", Type: "string", Description: "只看修改了该字段的记录（如 guest_name / storeroom_id / bin_id / retrieval_code）"},
+			{Name: "luggage_id", Type: "integer", Description: "只看某件行李"},
+		}},
+	{Method: "GET", Path: "/api/luggage/logs/retrieved", Tag: "logs", Summary: "获取取件记录", Auth: true},
+
+	// 行李操作
+	{Method: "PUT", Path: "/api/luggage/:id", Tag: "luggage", Summary: "修改寄存信息（支持寄存室迁移）", Auth: true, Body: handlers.UpdateLuggageInfoRequest{}},
+	{Method: "GET", Path: "/api/luggage/:id/timeline", Tag: "logs", Summary: "单件行李的修改时间线（版本 0 为原始寄存信息，每条修改记录含字段级变更）", Auth: true},
+	{Method: "POST", Path: "/api/luggage/:id/checkout", Tag: "luggage", Summary: "确认取件（id 为取件码，可只取走部分行李，按酒店策略核验身份，支持代取）", Auth: true, Body: handlers.CheckoutLuggageRequest{}},
+	{Method: "GET", Path: "/api/luggage/:id/checkout", Tag: "luggage", Summary: "获取取件信息（仍在寄存 / 已取走的行李、需要的身份核验方式）", Auth: true},
+	{Method: "POST", Path: "/api/luggage/:id/checkout/otp", Tag: "luggage", Summary: "向客人发送取件验证码（短信 / 邮件）", Auth: true, Body: handlers.SendCheckoutOTPRequest{}},
+	{Method: "GET", Path: "/api/luggage/policy", Tag: "luggage", Summary: "当前酒店的取件核验策略", Auth: true},
+	{Method: "POST", Path: "/api/luggage/:id/code", Tag: "luggage", Summary: "重新生成取件码（取件码过期或泄露时使用，旧取件码立即失效）", Auth: true},
+	{Method: "GET", Path: "/api/luggage/:id/delegates", Tag: "luggage", Summary: "获取取件码下登记的代取人", Auth: true},
+	{Method: "POST", Path: "/api/luggage/:id/delegates", Tag: "luggage", Summary: "登记代取人（可签发代取码，代取码只返回一次）", Auth: true, Body: handlers.CreatePickupDelegateRequest{}},
+	{Method: "DELETE", Path: "/api/luggage/:id/delegates/:delegate_id", Tag: "luggage", Summary: "撤销代取授权", Auth: true},
+
+	// 连锁酒店转寄
+	{Method: "GET", Path: "/api/luggage/transfers", Tag: "transfer", Summary: "获取本酒店发出 / 收到的转寄记录", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "direction", Type: "string", Description: "incoming 收到 / outgoing 发出（不传表示两者）"},
+			{Name: "status", Type: "string", Description: "in_transit / received / cancelled"},
+		}},
+	{Method: "GET", Path: "/api/luggage/:id/transfers", Tag: "transfer", Summary: "获取取件码的转寄记录（本酒店发出或收到的）", Auth: true},
+	{Method: "POST", Path: "/api/luggage/:id/transfer", Tag: "transfer", Summary: "把取件码下的在存行李转寄到连锁内的另一家酒店（行李变为 in_transit）", Auth: true, Body: handlers.DispatchTransferRequest{}},
+	{Method: "POST", Path: "/api/luggage/:id/transfer/receive", Tag: "transfer", Summary: "目的酒店签收转寄的行李并放入寄存室（取件码不变）", Auth: true, Body: handlers.ReceiveTransferRequest{}},
+	{Method: "POST", Path: "/api/luggage/:id/transfer/cancel", Tag: "transfer", Summary: "取消转寄，行李退回原寄存室", Auth: true},
+
+	// 损坏 / 事故报告
+	{Method: "POST", Path: "/api/luggage/incidents", Tag: "incident", Summary: "登记行李损坏 / 事故报告（关联寄存单或取件历史）", Auth: true, Body: handlers.CreateIncidentRequest{}},
+	{Method: "GET", Path: "/api/luggage/incidents", Tag: "incident", Summary: "查询本酒店的事故报告", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "status", Type: "string", Description: "open / investigating / resolved / rejected"},
+			{Name: "type", Type: "string", Description: "damage / missing / wrong_pickup / other"},
+			{Name: "luggage_id", Type: "integer", Description: "寄存单ID"},
+			{Name: "history_id", Type: "integer", Description: "取件历史ID"},
+			{Name: "code", Type: "string", Description: "取件码"},
+			{Name: "staff", Type: "string", Description: "经手或登记的员工用户名"},
+			{Name: "from", Type: "string", Description: "登记时间不早于（RFC3339）"},
+			{Name: "to", Type: "string", Description: "登记时间早于（RFC3339）"},
+		}},
+	{Method: "GET", Path: "/api/luggage/incidents/:id", Tag: "incident", Summary: "获取事故报告详情", Auth: true},
+	{Method: "PUT", Path: "/api/luggage/incidents/:id", Tag: "incident", Summary: "修改事故报告（补充照片、经手员工，更新处理状态或结案）", Auth: true, Body: handlers.UpdateIncidentRequest{}},
+
+	// 失物招领
+	{Method: "POST", Path: "/api/lost_found/items", Tag: "lost_found", Summary: "登记拾获物品（放入寄存室，按酒店策略计算保管期限）", Auth: true, Body: handlers.CreateFoundItemRequest{}},
+	{Method: "GET", Path: "/api/lost_found/items", Tag: "lost_found", Summary: "按描述关键字搜索拾获物品", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "q", Type: "string", Description: "关键字（空格分隔，每个都要出现在描述、类别、拾获地点或备注中）"},
+			{Name: "status", Type: "string", Description: "held / reserved / handed_over / disposed"},
+			{Name: "category", Type: "string", Description: "类别"},
+			{Name: "storeroom_id", Type: "integer", Description: "寄存室ID"},
+			{Name: "overdue", Type: "boolean", Description: "true 时只返回超过保管期限、等待处置的物品"},
+		}},
+	{Method: "GET", Path: "/api/lost_found/items/:id", Tag: "lost_found", Summary: "获取拾获物品详情", Auth: true},
+	{Method: "PUT", Path: "/api/lost_found/items/:id", Tag: "lost_found", Summary: "修改拾获物品（描述、照片、寄存室等）", Auth: true, Body: handlers.UpdateFoundItemRequest{}},
+	{Method: "GET", Path: "/api/lost_found/items/:id/history", Tag: "lost_found", Summary: "获取拾获物品的修改记录", Auth: true},
+	{Method: "POST", Path: "/api/lost_found/items/:id/dispose", Tag: "lost_found", Summary: "处置超过保管期限的拾获物品", Auth: true, Body: handlers.DisposeFoundItemRequest{}},
+	{Method: "POST", Path: "/api/lost_found/claims", Tag: "lost_found", Summary: "登记客人报失 / 认领", Auth: true, Body: handlers.CreateLostItemClaimRequest{}},
+	{Method: "GET", Path: "/api/lost_found/claims", Tag: "lost_found", Summary: "获取本酒店的失物认领", Auth: true,
+		Query: []apidoc.Param{{Name: "status", Type: "string", Description: "open / verified / handed_over / closed"}}},
+	{Method: "GET", Path: "/api/lost_found/claims/:id", Tag: "lost_found", Summary: "获取失物认领详情", Auth: true},
+	{Method: "GET", Path: "/api/lost_found/claims/:id/matches", Tag: "lost_found", Summary: "为认领查找可能匹配的拾获物品（按匹配得分排序）", Auth: true},
+	{Method: "POST", Path: "/api/lost_found/claims/:id/verify", Tag: "lost_found", Summary: "核验失主身份并预留拾获物品", Auth: true, Body: handlers.VerifyLostItemClaimRequest{}},
+	{Method: "POST", Path: "/api/lost_found/claims/:id/handover", Tag: "lost_found", Summary: "把核验通过的拾获物品交还失主", Auth: true, Body: handlers.HandOverLostItemRequest{}},
+	{Method: "POST", Path: "/api/lost_found/claims/:id/close", Tag: "lost_found", Summary: "关闭认领（已核验的认领关闭后物品恢复为保管中）", Auth: true, Body: handlers.CloseLostItemClaimRequest{}},
+
+	// 文件上传
+	{Method: "POST", Path: "/api/upload", Tag: "upload", Summary: "上传行李照片", Auth: true,
+		Form: []apidoc.Param{{Name: "file", Type: "file", Required: true, Description: "图片文件（jpg/png/webp，按内容识别类型，自动旋转、去除 EXIF 并生成缩略图，最大 5MB）"}}},
+
+	// 管理员
+	{Method: "GET", Path: "/api/admin/hotels/:id/policy", Tag: "admin", Summary: "获取酒店策略（未配置时为默认策略）", Auth: true},
+	{Method: "PUT", Path: "/api/admin/hotels/:id/policy", Tag: "admin", Summary: "修改酒店策略（取件核验方式、高风险规则、取件码规则）", Auth: true, Body: handlers.UpdateHotelPolicyRequest{}},
+	{Method: "GET", Path: "/api/admin/uploads/orphans", Tag: "admin", Summary: "未被引用的照片报告（dry-run，不删除）", Auth: true},
+	{Method: "POST", Path: "/api/admin/uploads/gc", Tag: "admin", Summary: "立即清理超过宽限期仍未被引用的照片", Auth: true},
+	{Method: "GET", Path: "/api/admin/incidents", Tag: "admin", Summary: "查询所有酒店的事故报告（按酒店、状态、类型、员工、时间筛选）", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "hotel_id", Type: "integer", Description: "酒店ID（不传表示全部酒店）"},
+			{Name: "status", Type: "string", Description: "open / investigating / resolved / rejected"},
+			{Name: "type", Type: "string", Description: "damage / missing / wrong_pickup / other"},
+			{Name: "luggage_id", Type: "integer", Description: "寄存单ID"},
+			{Name: "history_id", Type: "integer", Description: "取件历史ID"},
+			{Name: "code", Type: "string", Description: "取件码"},
+			{Name: "staff", Type: "string", Description: "经手或登记的员工用户名"},
+			{Name: "from", Type: "string", Description: "登记时间不早于（RFC3339）"},
+			{Name: "to", Type: "string", Description: "登记时间早于（RFC3339）"},
+		}},
+	{Method: "GET", Path: "/api/admin/audit_logs", Tag: "admin", Summary: "查询审计日志（所有写操作的操作人、IP、请求ID 和修改前后快照，按序号倒序）", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "hotel_id", Type: "integer", Description: "酒店ID（不传表示全部）"},
+			{Name: "actor", Type: "string", Description: "操作人用户名（后台任务为 system，客人自助为 anonymous）"},
+			{Name: "entity_type", Type: "string", Description: "luggage_item / storeroom / storeroom_location / hotel / hotel_policy / user / pickup_delegate / found_item / lost_item_claim / luggage_incident / checkout_otp / upload"},
+			{Name: "entity_id", Type: "string", Description: "实体ID（照片为对象 key）"},
+			{Name: "action", Type: "string", Description: "create / update / delete / retrieve / change_code / reissue_code / bind / status / evacuate / dispatch / receive / cancel / revoke / verify / hand_over / close / dispose / send_otp / collect / sync / revert"},
+			{Name: "request_id", Type: "string", Description: "请求ID（响应头 X-Request-ID）"},
+			{Name: "from", Type: "string", Description: "记录时间不早于（RFC3339）"},
+			{Name: "to", Type: "string", Description: "记录时间早于（RFC3339）"},
+			{Name: "limit", Type: "integer", Description: "返回条数（默认 200，最多 1000）"},
+		}},
+	{Method: "GET", Path: "/api/admin/luggage/:id/revert", Tag: "admin", Summary: "预览把寄存单回退到历史版本的结果（重新校验寄存室、容量和格位，不写入）", Auth: true,
+		Query: []apidoc.Param{
+			{Name: "revision", Type: "integer", Required: true, Description: "版本号（与 GET /api/luggage/:id/timeline 一致，0 为第一次修改前的寄存信息）"},
+		}},
+	{Method: "POST", Path: "/api/admin/luggage/:id/revert", Tag: "admin", Summary: "把寄存单回退到历史版本（恢复客人信息、件数尺寸、照片和寄存室 / 格位，写入新的修改记录）", Auth: true, Body: handlers.RevertLuggageRequest{}},
+}
@@ -1,8 +1,10 @@
 package router
 
 import (
+	"hotel_luggage/configs"
 	"hotel_luggage/internal/handlers"
 	"hotel_luggage/internal/middleware"
+	"hotel_luggage/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,20 +12,27 @@ import (
 // SetupRouter 初始化并配置所有路由
 // 功能：
 // 1. 创建 Gin 引擎（包含日志和错误恢复中间件）
-// 2. 配置全局中间件（CORS、文件上传限制）
+// 2. 配置全局中间件（统一错误响应、CORS、文件上传限制）
 // 3. 注册公开接口（无需认证）
 // 4. 注册受保护接口（需要 JWT 认证）
 // 5. 返回配置完成的路由引擎
 //
+// 参数：
+//   cfg: 启动时加载的配置（上传限制、本地目录等）
+//   store: 文件存储（上传的照片等通过它读写，见 storage.New）
+//
 // 路由架构：
-// - 公开接口：/api/login（登录）
-// - 受保护接口：/api/luggage/... （需要 JWT token）
-// - 静态文件：/uploads/... （行李照片）
-// - 健康检查：/ping
+// - 公开接口：/api/login（登录）、/api/openapi.json（接口文档）、/api/guest/delegates（客人自助登记代取人）
+// - 受保护接口：/api/luggage/...、/api/lost_found/...（需要 JWT token）
+// - 文件下载：/uploads/... （行李照片，需要签名参数 expires / sig）
+// - 健康检查：/ping、/healthz（存活）、/readyz（就绪，含依赖状态）、/metrics（依赖指标）
+// - 接口清单：/home（实时路由表）
+//
+// 注意：新增路由后需要同步更新 RouteDocs（docs.go），CI 会检查两者是否一致
 //
 // 返回：
 //   *gin.Engine: 配置完成的路由引擎（可直接调用 Run() 启动服务）
-func SetupRouter() *gin.Engine {
+func SetupRouter(cfg configs.Config, store storage.BlobStore) *gin.Engine {
 	// ========================================
 	// 1. 创建 Gin 引擎
 	// ========================================
@@ -31,9 +40,19 @@ func SetupRouter() *gin.Engine {
 	// - Logger 中间件：记录请求日志
 	// - Recovery 中间件：捕获 panic，避免服务崩溃
 	r := gin.Default()
+
+	// 只信任配置的反向代理转发的 X-Forwarded-For（配置已校验，不会出错）；
+	// 否则客户端可以伪造该请求头，绕过按 IP 的失败次数限制并在审计日志中写入任意 IP
+	_ = r.SetTrustedProxies(cfg.Server.TrustedProxies)
+
+	// 统一错误响应：handler 通过 c.Error 记录错误，由该中间件输出 {message, code, error}
+	r.Use(middleware.ErrorHandler())
+
+	// 请求ID：写入响应头 X-Request-ID，并与客户端 IP 一起记录到审计日志
+	r.Use(middleware.RequestID())
 	
-	// 设置文件上传大小限制：5MB（5 << 20 = 5 * 1024 * 1024）
-	r.MaxMultipartMemory = 5 << 20
+	// 设置文件上传内存限制（来自配置 upload.max_size，默认 5MB）
+	r.MaxMultipartMemory = cfg.Upload.MaxSize
 
 	// ========================================
 	// 2. 配置 CORS 跨域中间件
@@ -48,7 +67,10 @@ func SetupRouter() *gin.Engine {
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		
 		// 允许的请求头（包括 Authorization，用于传递 JWT token）
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		
+		// 允许前端读取的响应头（请求ID，用于反馈问题时定位审计日志）
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		
 		// 允许的 HTTP 方法
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
@@ -64,11 +86,12 @@ func SetupRouter() *gin.Engine {
 	})
 
 	// ========================================
-	// 3. 静态文件服务
+	// 3. 本地文件下载（需要签名）
 	// ========================================
-	// 访问路径：http://host:port/uploads/2026/01/xxx.jpg
-	// 映射到：./uploads/2026/01/xxx.jpg
-	r.Static("/uploads", "./uploads")
+	// 访问路径：http://host:port/uploads/2026/01/xxx.jpg?expires=...&sig=...
+	// 映射到：<upload.local_dir>/2026/01/xxx.jpg（默认 ./uploads）
+	// 照片属于客人隐私，不再公开静态目录；签名地址由接口响应返回，有效期 upload.url_expiry
+	r.GET("/uploads/*filepath", handlers.ServeSignedFile(store))
 
 	// ========================================
 	// 4. 健康检查接口（无需认证）
@@ -82,6 +105,16 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
+	// 存活探针：进程能处理请求即返回 200（Kubernetes livenessProbe）
+	r.GET("/healthz", handlers.Healthz)
+	// 就绪探针：检查 MySQL / Redis / MinIO 状态，MySQL 不可用或正在退出时返回 503（readinessProbe）
+	r.GET("/readyz", handlers.Readyz)
+	// 依赖状态指标（Prometheus 文本格式）：Redis / MinIO 是否降级、重连次数等
+	r.GET("/metrics", handlers.Metrics)
+
+	// 接口清单：直接读取实际注册的路由（r.Routes 在请求时求值，包含所有后续注册的路由）
+	r.GET("/home", handlers.Home(r.Routes, RouteDocs))
+
 	// ========================================
 	// 5. API 路由分组
 	// ========================================
@@ -92,6 +125,8 @@ func SetupRouter() *gin.Engine {
 	// 5.1 公开接口（无需认证）
 	// ========================================
 	api.POST("/login", handlers.Login) // 用户登录（返回 JWT token）
+	api.GET("/openapi.json", handlers.OpenAPISpec(RouteDocs)) // OpenAPI 3 文档（由 RouteDocs 生成）
+	api.POST("/guest/delegates", handlers.CreateGuestPickupDelegate) // 客人凭取件码 + 手机号后四位自助登记代取人
 
 	// ========================================
 	// 5.2 受保护接口（需要 JWT 认证）
@@ -108,13 +143,26 @@ func SetupRouter() *gin.Engine {
 	// --- 行李寄存与查询 ---
 	luggage.POST("", handlers.CreateLuggage)                         // 创建行李寄存记录
 	luggage.GET("/by_code", handlers.QueryLuggageByCode)            // 按取件码查询行李
+	luggage.GET("/list", handlers.ListLuggageByUser)                  // 当前酒店有行李在存的客人名单
 	luggage.GET("/list/by_guest_name", handlers.ListStoredLuggageByGuestName) // 按客人姓名查询寄存中的行李
 
+	// --- 酒店策略 ---
+	luggage.GET("/policy", handlers.GetCurrentHotelPolicy) // 当前酒店的取件核验策略
+
 	// --- 寄存室管理 ---
 	luggage.GET("/storerooms", handlers.ListStorerooms)             // 获取当前酒店所有寄存室
 	luggage.GET("/storerooms/:id/orders", handlers.ListLuggageByStoreroom) // 获取指定寄存室的所有行李
 	luggage.POST("/storerooms", handlers.CreateStoreroom)           // 创建新寄存室
 	luggage.PUT("/storerooms/:id", handlers.UpdateStoreroomStatus) // 更新寄存室状态（启用/停用）
+	luggage.PUT("/storerooms/:id/capacity", handlers.UpdateStoreroomCapacity)     // 修改寄存室容量和尺寸权重
+	luggage.GET("/storerooms/:id/locations", handlers.ListStoreroomLocations)     // 寄存室内的区域 / 货架 / 格位
+	luggage.POST("/storerooms/:id/locations", handlers.CreateStoreroomLocation)   // 创建区域 / 货架 / 格位
+	luggage.GET("/storerooms/:id/bins/suggest", handlers.SuggestBin)              // 推荐空闲格位
+	luggage.PUT("/storerooms/:id/assignment", handlers.UpdateStoreroomAssignment) // 修改寄存室自动分配规则
+	luggage.GET("/storerooms/recommend", handlers.RecommendStorerooms)            // 按分配规则推荐寄存室
+	luggage.POST("/storerooms/:id/evacuate", handlers.EvacuateStoreroom)          // 批量迁移寄存室内的行李
+	luggage.PUT("/locations/:id", handlers.UpdateStoreroomLocation)               // 修改位置名称、容量、启用状态
+	luggage.DELETE("/locations/:id", handlers.DeleteStoreroomLocation)            // 删除位置（无下级位置、无在存行李）
 
 	// --- 日志查询 ---
 	luggage.GET("/logs/stored", handlers.ListStoredLogs)            // 获取寄存记录（status=stored）
@@ -123,15 +171,84 @@ func SetupRouter() *gin.Engine {
 
 	// --- 行李操作 ---
 	luggage.PUT("/:id", handlers.UpdateLuggageInfo)                  // 修改寄存信息（支持寄存室迁移，自动记录历史）
-	luggage.POST("/:id/checkout", handlers.CheckoutLuggageByCode)   // 确认取件（更新状态、取件人、取件时间）
+	luggage.GET("/:id/timeline", handlers.GetLuggageTimeline)        // 单件行李的修改时间线（字段级变更）
+	luggage.POST("/:id/checkout", handlers.CheckoutLuggageByCode)   // 确认取件（按酒店策略核验身份，更新状态、取件人、取件时间）
+	luggage.POST("/:id/checkout/otp", handlers.SendCheckoutOTP)     // 向客人发送取件验证码（短信 / 邮件）
 	luggage.GET("/:id/checkout", handlers.GetCheckoutInfoByCode)    // 获取取件信息（客人姓名、联系方式等）
+	luggage.POST("/:id/code", handlers.ReissueRetrievalCode)         // 重新生成取件码（旧取件码立即失效）
+	luggage.GET("/:id/delegates", handlers.ListPickupDelegates)                    // 获取取件码下登记的代取人
+	luggage.POST("/:id/delegates", handlers.CreatePickupDelegate)                  // 登记代取人（可签发代取码）
+	luggage.DELETE("/:id/delegates/:delegate_id", handlers.RevokePickupDelegate)   // 撤销代取授权
+
+	// --- 连锁酒店转寄 ---
+	luggage.GET("/transfers", handlers.ListTransfers)               // 本酒店发出 / 收到的转寄记录
+	luggage.GET("/:id/transfers", handlers.ListTransfersByCode)     // 取件码的转寄记录
+	luggage.POST("/:id/transfer", handlers.DispatchTransfer)        // 转寄到连锁内的另一家酒店
+	luggage.POST("/:id/transfer/receive", handlers.ReceiveTransfer) // 目的酒店签收并放入寄存室
+	luggage.POST("/:id/transfer/cancel", handlers.CancelTransfer)   // 取消转寄，行李退回原寄存室
+
+	// --- 损坏 / 事故报告 ---
+	luggage.POST("/incidents", handlers.CreateIncident)    // 登记事故报告（关联寄存单或取件历史）
+	luggage.GET("/incidents", handlers.ListIncidents)      // 按状态、类型、员工、时间筛选
+	luggage.GET("/incidents/:id", handlers.GetIncident)    // 事故报告详情
+	luggage.PUT("/incidents/:id", handlers.UpdateIncident) // 补充照片、更新处理状态或结案
 
 	// ========================================
-	// 5.4 文件上传（/api/upload）
+	// 5.4 失物招领（/api/lost_found）
+	// ========================================
+	// 与行李寄存分开管理，物品同样放在本酒店的寄存室内（计入容量）
+	lostFound := auth.Group("/lost_found")
+
+	// --- 拾获物品 ---
+	lostFound.POST("/items", handlers.CreateFoundItem)                 // 登记拾获物品
+	lostFound.GET("/items", handlers.SearchFoundItems)                 // 按描述关键字搜索
+	lostFound.GET("/items/:id", handlers.GetFoundItem)                 // 拾获物品详情
+	lostFound.PUT("/items/:id", handlers.UpdateFoundItem)              // 修改描述、照片、寄存室
+	lostFound.GET("/items/:id/history", handlers.ListFoundItemHistory) // 修改记录
+	lostFound.POST("/items/:id/dispose", handlers.DisposeFoundItem)    // 处置超过保管期限的物品
+
+	// --- 失物认领 ---
+	lostFound.POST("/claims", handlers.CreateLostItemClaim)            // 登记客人报失 / 认领
+	lostFound.GET("/claims", handlers.ListLostItemClaims)              // 认领列表
+	lostFound.GET("/claims/:id", handlers.GetLostItemClaim)            // 认领详情
+	lostFound.GET("/claims/:id/matches", handlers.MatchLostItemClaim)  // 查找可能匹配的拾获物品
+	lostFound.POST("/claims/:id/verify", handlers.VerifyLostItemClaim) // 核验失主身份并预留物品
+	lostFound.POST("/claims/:id/handover", handlers.HandOverLostItem)  // 交还失主
+	lostFound.POST("/claims/:id/close", handlers.CloseLostItemClaim)   // 关闭认领
+
+	// ========================================
+	// 5.5 文件上传（/api/upload）
 	// ========================================
 	// 用途：上传行李照片
 	// 存储策略：优先 MinIO，失败则降级到本地 ./uploads 目录
-	auth.POST("/upload", handlers.Upload)
+	auth.POST("/upload", handlers.Upload(cfg.Upload, store))
+
+	// ========================================
+	// 5.6 管理员接口（/api/admin，仅 admin 角色）
+	// ========================================
+	admin := auth.Group("/admin")
+	admin.Use(middleware.AdminOnly())
+
+	// --- 酒店策略 ---
+	admin.GET("/hotels/:id/policy", handlers.GetHotelPolicy)    // 获取酒店策略（未配置时为默认策略）
+	admin.PUT("/hotels/:id/policy", handlers.UpdateHotelPolicy) // 修改酒店策略（取件核验方式、高风险规则）
+
+	// --- 照片清理 ---
+	admin.GET("/uploads/orphans", handlers.OrphanUploadReport(cfg.Upload, store)) // 未被引用的照片报告（dry-run）
+	admin.POST("/uploads/gc", handlers.CollectOrphanUploads(cfg.Upload, store))   // 立即清理未被引用的照片
+
+	// --- 事故报告 ---
+	admin.GET("/incidents", handlers.AdminListIncidents) // 所有酒店的事故报告（可按酒店筛选）
+
+	// --- 审计日志 ---
+	admin.GET("/audit_logs", handlers.ListAuditLogs) // 查询审计日志（按酒店、操作人、实体、请求ID、时间筛选）
+
+	// --- 寄存单回退 ---
+	admin.GET("/luggage/:id/revert", handlers.PreviewLuggageRevert) // 预览回退到历史版本的结果（不写入）
+	admin.POST("/luggage/:id/revert", handlers.RevertLuggage)       // 回退到历史版本（重新校验寄存室和容量，写入修改记录）
+
+	// 未匹配的路由同样使用统一错误信封
+	r.NoRoute(handlers.NotFound)
 
 	// ========================================
 	// 6. 返回配置完成的路由引擎
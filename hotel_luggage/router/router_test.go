@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hotel_luggage/configs"
+	"hotel_luggage/internal/audit"
+	"hotel_luggage/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestClientIPIgnoresSpoofedForwardedFor 按 IP 的失败次数限制和审计日志使用 RequestID 中间件写入的客户端 IP：
+// 不可信来源伪造的 X-Forwarded-For 不能换出新的 IP（否则每次请求换一个值就能绕过锁定）
+func TestClientIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   []string // 依次发送的 X-Forwarded-For
+		want           string   // 每次请求记录的客户端 IP
+	}{
+		{name: "no trusted proxy", remoteAddr: "203.0.113.9:5000",
+			forwardedFor: []string{"", "198.51.100.1", "198.51.100.2", "10.0.0.1, 198.51.100.3"}, want: "203.0.113.9"},
+		{name: "request not from the trusted proxy", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "203.0.113.9:5000",
+			forwardedFor: []string{"198.51.100.1", "198.51.100.2"}, want: "203.0.113.9"},
+		{name: "trusted proxy forwards the client ip", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:5000",
+			forwardedFor: []string{"198.51.100.7", "198.51.100.7"}, want: "198.51.100.7"},
+		// 客户端自带的 X-Forwarded-For 在代理追加的地址之前，取最右侧不可信的地址
+		{name: "client prepends spoofed hops", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:5000",
+			forwardedFor: []string{"1.1.1.1, 198.51.100.7", "2.2.2.2, 198.51.100.7"}, want: "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := configs.Default()
+			cfg.Server.TrustedProxies = tt.trustedProxies
+			r := SetupRouter(cfg, storage.NewMemory(""))
+			r.GET("/test/client-ip", func(c *gin.Context) {
+				c.String(http.StatusOK, audit.ActorFrom(c.Request.Context()).IP)
+			})
+			for _, xff := range tt.forwardedFor {
+				req := httptest.NewRequest(http.MethodGet, "/test/client-ip", nil)
+				req.RemoteAddr = tt.remoteAddr
+				if xff != "" {
+					req.Header.Set("X-Forwarded-For", xff)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if got := w.Body.String(); got != tt.want {
+					t.Fatalf("X-Forwarded-For %q: client ip = %q, want %q", xff, got, tt.want)
+				}
+			}
+		})
+	}
+}
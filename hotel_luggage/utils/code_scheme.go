@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// 取件码字符集
+const (
+	CodeAlphabetNumeric   = "numeric"   // 纯数字 0-9
+	CodeAlphabetCrockford = "crockford" // Crockford Base32：数字 + 大写字母，去掉易混淆的 I L O U
+)
+
+// codeCharsets 字符集名称 → 字符
+var codeCharsets = map[string]string{
+	CodeAlphabetNumeric:   "0123456789",
+	CodeAlphabetCrockford: "0123456789ABCDEFGHJKMNPQRSTVWXYZ",
+}
+
+// ErrInvalidCodeScheme 取件码规则无效（长度或字符集不合法）
+var ErrInvalidCodeScheme = errors.New("invalid code scheme")
+
+// CodeScheme 取件码生成规则
+// Length 为总长度（包含校验位）；CheckDigit 为 true 时最后一位是 Luhn mod N 校验位，可以发现单个字符输错和相邻字符颠倒
+type CodeScheme struct {
+	Length     int
+	Alphabet   string // numeric / crockford
+	CheckDigit bool
+}
+
+// IsCodeAlphabet 判断字符集名称是否有效
+func IsCodeAlphabet(name string) bool {
+	_, ok := codeCharsets[name]
+	return ok
+}
+
+// GenerateSchemeCode 按规则生成随机取件码
+func GenerateSchemeCode(scheme CodeScheme) (string, error) {
+	charset, ok := codeCharsets[scheme.Alphabet]
+	bodyLen := scheme.Length
+	if scheme.CheckDigit {
+		bodyLen--
+	}
+	if !ok || bodyLen <= 0 {
+		return "", ErrInvalidCodeScheme
+	}
+
+	code := make([]byte, bodyLen, scheme.Length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = charset[n.Int64()]
+	}
+	if scheme.CheckDigit {
+		code = append(code, luhnCheckChar(charset, string(code)))
+	}
+	return string(code), nil
+}
+
+// ValidateSchemeCode 校验取件码是否符合规则（长度、字符集、校验位）
+// code 应先经过 NormalizeCode 规范化
+func ValidateSchemeCode(scheme CodeScheme, code string) bool {
+	charset, ok := codeCharsets[scheme.Alphabet]
+	if !ok || len(code) != scheme.Length {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if strings.IndexByte(charset, code[i]) < 0 {
+			return false
+		}
+	}
+	if !scheme.CheckDigit {
+		return true
+	}
+	return luhnCheckChar(charset, code[:len(code)-1]) == code[len(code)-1]
+}
+
+// NormalizeCode 规范化用户输入的取件码
+// 去掉空格和连字符、转为大写，并按 Crockford 约定把 O 视为 0、I / L 视为 1（生成的取件码不含这些字母）
+func NormalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '\t':
+			return -1
+		case 'o', 'O':
+			return '0'
+		case 'i', 'I', 'l', 'L':
+			return '1'
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, strings.TrimSpace(code))
+}
+
+// luhnCheckChar 计算 Luhn mod N 校验字符（N 为字符集大小）
+func luhnCheckChar(charset, body string) byte {
+	n := len(charset)
+	factor := 2
+	sum := 0
+	for i := len(body) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(charset, body[i])
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+		sum += addend/n + addend%n
+	}
+	return charset[(n-sum%n)%n]
+}
@@ -0,0 +1,114 @@
+package utils
+
+import "testing"
+
+func TestLuhnCheckChar(t *testing.T) {
+	tests := []struct {
+		name    string
+		charset string
+		body    string
+		want    byte
+	}{
+		// 数字字符集与标准 Luhn 算法一致
+		{name: "numeric 7992739871", charset: codeCharsets[CodeAlphabetNumeric], body: "7992739871", want: '3'},
+		{name: "numeric zero", charset: codeCharsets[CodeAlphabetNumeric], body: "0000", want: '0'},
+		{name: "numeric 12345", charset: codeCharsets[CodeAlphabetNumeric], body: "12345", want: '5'},
+		{name: "crockford 1", charset: codeCharsets[CodeAlphabetCrockford], body: "1", want: 'Y'},
+		{name: "crockford ZZ", charset: codeCharsets[CodeAlphabetCrockford], body: "ZZ", want: '2'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := luhnCheckChar(tt.charset, tt.body); got != tt.want {
+				t.Fatalf("luhnCheckChar(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchemeCode(t *testing.T) {
+	numeric := CodeScheme{Length: 6, Alphabet: CodeAlphabetNumeric, CheckDigit: true}
+	crockford := CodeScheme{Length: 8, Alphabet: CodeAlphabetCrockford, CheckDigit: true}
+	valid := "123455"
+	validCrockford := "7K3QXW2" + string(luhnCheckChar(codeCharsets[CodeAlphabetCrockford], "7K3QXW2"))
+
+	tests := []struct {
+		name   string
+		scheme CodeScheme
+		code   string
+		want   bool
+	}{
+		{name: "numeric valid", scheme: numeric, code: valid, want: true},
+		{name: "numeric wrong check digit", scheme: numeric, code: "123456"},
+		{name: "numeric single typo", scheme: numeric, code: "124455"},
+		{name: "numeric adjacent swap", scheme: numeric, code: "213455"},
+		{name: "numeric too short", scheme: numeric, code: "12345"},
+		{name: "numeric letter", scheme: numeric, code: "12A455"},
+		{name: "no check digit", scheme: CodeScheme{Length: 6, Alphabet: CodeAlphabetNumeric}, code: "123456", want: true},
+		{name: "crockford valid", scheme: crockford, code: validCrockford, want: true},
+		{name: "crockford excluded letter", scheme: crockford, code: "7K3QXWU" + validCrockford[7:]},
+		{name: "crockford lowercase", scheme: crockford, code: "7k3qxw2" + validCrockford[7:]},
+		{name: "unknown alphabet", scheme: CodeScheme{Length: 6, Alphabet: "hex"}, code: valid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateSchemeCode(tt.scheme, tt.code); got != tt.want {
+				t.Fatalf("ValidateSchemeCode(%+v, %q) = %v, want %v", tt.scheme, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemeCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  CodeScheme
+		wantErr bool
+	}{
+		{name: "numeric", scheme: CodeScheme{Length: 6, Alphabet: CodeAlphabetNumeric}},
+		{name: "numeric check digit", scheme: CodeScheme{Length: 6, Alphabet: CodeAlphabetNumeric, CheckDigit: true}},
+		{name: "crockford check digit", scheme: CodeScheme{Length: 8, Alphabet: CodeAlphabetCrockford, CheckDigit: true}},
+		{name: "unknown alphabet", scheme: CodeScheme{Length: 6, Alphabet: "hex"}, wantErr: true},
+		{name: "only check digit", scheme: CodeScheme{Length: 1, Alphabet: CodeAlphabetNumeric, CheckDigit: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				code, err := GenerateSchemeCode(tt.scheme)
+				if tt.wantErr {
+					if err != ErrInvalidCodeScheme {
+						t.Fatalf("GenerateSchemeCode() error = %v, want ErrInvalidCodeScheme", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("GenerateSchemeCode() error = %v", err)
+				}
+				if !ValidateSchemeCode(tt.scheme, code) {
+					t.Fatalf("GenerateSchemeCode() = %q does not pass ValidateSchemeCode", code)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "123456", want: "123456"},
+		{in: " 123 456 ", want: "123456"},
+		{in: "123-456", want: "123456"},
+		{in: "7k3q-xw2p", want: "7K3QXW2P"},
+		{in: "o0Oi1IlL", want: "00011111"},
+		{in: "ab\tcd", want: "ABCD"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := NormalizeCode(tt.in); got != tt.want {
+				t.Fatalf("NormalizeCode(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
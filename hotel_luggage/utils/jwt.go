@@ -2,15 +2,25 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// jwtSecret JWT 签名密钥，从环境变量 JWT_SECRET 读取
-// 如果未设置环境变量，使用默认值 "change-me"（生产环境必须修改！）
-var jwtSecret = []byte(getJWTSecret())
+// jwtSecret JWT 签名密钥，启动时由 InitJWT 根据配置（jwt.secret / JWT_SECRET）设置
+// 未初始化时拒绝签发和解析 token，避免静默使用弱密钥
+var jwtSecret []byte
+
+// jwtExpire token 有效期（默认 24 小时，可由配置覆盖）
+var jwtExpire = 24 * time.Hour
+
+// InitJWT 设置 JWT 签名密钥与有效期（在 main 中加载配置后调用）
+func InitJWT(secret string, expire time.Duration) {
+	jwtSecret = []byte(secret)
+	if expire > 0 {
+		jwtExpire = expire
+	}
+}
 
 // Claims JWT 自定义载荷（Payload）
 // 包含业务自定义字段（username, role）和标准字段（过期时间、签发时间等）
@@ -33,15 +43,18 @@ type Claims struct {
 //   - string: JWT token 字符串（用于 Authorization: Bearer <token>）
 //   - error: 生成失败时返回错误
 //
-// Token 有效期：24小时
+// Token 有效期：由配置 jwt.expire 决定（默认 24 小时）
 // 算法：HS256（HMAC-SHA256）
 //
 // 使用示例：
 //   token, err := GenerateToken("user001", "staff")
 //   // 返回示例：eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
 func GenerateToken(username, role string) (string, error) {
-	// 设置过期时间为当前时间 + 24小时
-	expire := time.Now().Add(24 * time.Hour)
+	if len(jwtSecret) == 0 {
+		return "", errors.New("jwt secret not configured")
+	}
+	// 设置过期时间为当前时间 + 有效期
+	expire := time.Now().Add(jwtExpire)
 	
 	// 构造载荷（Payload）
 	claims := Claims{
@@ -79,6 +92,9 @@ func GenerateToken(username, role string) (string, error) {
 //   }
 //   username := claims.Username
 func ParseToken(tokenStr string) (*Claims, error) {
+	if len(jwtSecret) == 0 {
+		return nil, errors.New("jwt secret not configured")
+	}
 	// 解析 token，并验证签名
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		// 返回签名密钥用于验证
@@ -98,21 +114,3 @@ func ParseToken(tokenStr string) (*Claims, error) {
 	
 	return claims, nil
 }
-
-// getJWTSecret 从环境变量获取 JWT 签名密钥
-// 环境变量：JWT_SECRET
-// 默认值：change-me（仅用于开发环境，生产环境必须设置强密钥！）
-//
-// 设置方式（Windows）：
-//   set JWT_SECRET=your-strong-secret-key-here
-//
-// 设置方式（Linux/Mac）：
-//   export JWT_SECRET=your-strong-secret-key-here
-func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// ⚠️ 生产环境必须设置 JWT_SECRET 环境变量！
-		secret = "change-me"
-	}
-	return secret
-}